@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"sync"
+)
+
+const (
+	// accessBufferFlush is the number of buffered recency updates after which
+	// the owner of an access buffer attempts to apply them.
+	accessBufferFlush = 64
+
+	// accessBufferMax is the maximum number of buffered recency updates. Beyond
+	// this, updates are dropped until the buffer can be drained.
+	accessBufferMax = 4 * accessBufferFlush
+)
+
+// accessBuffer buffers the keys of entries read under a shared lock, so that
+// their recency updates can be applied later in a single batch under the
+// exclusive lock. Its own lock is held only long enough to append or swap the
+// slice, so readers do not contend on the cache's lock. The zero value is
+// ready for use.
+type accessBuffer[K comparable] struct {
+	// keys are the buffered keys, in access order. It is guarded by lock.
+	keys []K
+	lock sync.Mutex
+}
+
+// add buffers an access of the given key and reports whether the buffer should
+// be drained. If the buffer is at its maximum size, the access is dropped.
+func (b *accessBuffer[K]) add(key K) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if len(b.keys) < accessBufferMax {
+		b.keys = append(b.keys, key)
+	}
+	return len(b.keys) >= accessBufferFlush
+}
+
+// take removes and returns the buffered keys.
+func (b *accessBuffer[K]) take() []K {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	keys := b.keys
+	b.keys = nil
+	return keys
+}
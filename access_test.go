@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAccessBuffer(t *testing.T) {
+	t.Parallel()
+
+	var b accessBuffer[int]
+
+	for i := 0; i < accessBufferFlush-1; i++ {
+		if b.add(i) {
+			t.Fatalf("expected %d to not fill the buffer", i)
+		}
+	}
+	if !b.add(accessBufferFlush - 1) {
+		t.Errorf("expected buffer to be full")
+	}
+
+	for i := accessBufferFlush; i < 2*accessBufferMax; i++ {
+		b.add(i)
+	}
+
+	keys := b.take()
+	if got, want := len(keys), accessBufferMax; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := keys[:3], []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+	if got := b.take(); got != nil {
+		t.Errorf("expected %v to be nil", got)
+	}
+}
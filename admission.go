@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"time"
+)
+
+// Admission decides whether a new entry is inserted into a capacity-bounded
+// cache, so entries which are unlikely to be read again, or which are too
+// large, do not evict more valuable ones. It is consulted only for keys which
+// are not already in the cache; existing entries are always updated. It is
+// configured with WithAdmission.
+//
+// The cache consults the admission policy while holding its lock, so Admit
+// must not call back into the cache.
+type Admission[K comparable, V any] interface {
+	// Admit returns true if the entry should be inserted. If it returns false,
+	// the value is dropped.
+	Admit(key K, val V) bool
+}
+
+// Ensure implements.
+var (
+	_ Admission[string, string] = AdmissionFunc[string, string](nil)
+	_ Admission[string, string] = (*doorkeeperAdmission[string, string])(nil)
+)
+
+// AdmissionFunc adapts a function to an admission policy, for simple rules
+// such as rejecting values larger than a limit:
+//
+//	cache.NewLRU[string, []byte](1000, cache.WithAdmission(
+//	  cache.AdmissionFunc[string, []byte](func(_ string, v []byte) bool {
+//	    return len(v) <= 1<<20
+//	  })))
+type AdmissionFunc[K comparable, V any] func(key K, val V) bool
+
+// Admit calls the function.
+func (fn AdmissionFunc[K, V]) Admit(key K, val V) bool {
+	return fn(key, val)
+}
+
+// Option is an option for configuring a capacity-bounded cache, such as LRU,
+// FIFO, LIFO, Random, Policied, Weighted, or Fair. Every Option is also an
+// LRUOption.
+type Option[K comparable, V any] func(*options[K, V])
+
+// options are the options shared by capacity-bounded caches.
+type options[K comparable, V any] struct {
+	admission Admission[K, V]
+	ttl       time.Duration
+}
+
+// applyLRU implements LRUOption.
+func (opt Option[K, V]) applyLRU(o *lruOptions[K, V]) {
+	opt(&o.options)
+}
+
+// WithAdmission consults the given admission policy before inserting a new
+// entry, and drops the entry if it is not admitted. Fetch still returns a value
+// which is not admitted.
+func WithAdmission[K comparable, V any](a Admission[K, V]) Option[K, V] {
+	if a == nil {
+		panic("admission cannot be nil")
+	}
+
+	return func(o *options[K, V]) {
+		o.admission = a
+	}
+}
+
+// newOptions applies the given options.
+func newOptions[K comparable, V any](opts []Option[K, V]) options[K, V] {
+	var o options[K, V]
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
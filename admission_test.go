@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdmissionFunc(t *testing.T) {
+	t.Parallel()
+
+	a := AdmissionFunc[string, string](func(_ string, v string) bool {
+		return len(v) <= 3
+	})
+
+	if !a.Admit("foo", "bar") {
+		t.Errorf("expected bar to be admitted")
+	}
+	if a.Admit("foo", "bazz") {
+		t.Errorf("expected bazz not to be admitted")
+	}
+}
+
+func TestNewDoorkeeperAdmission(t *testing.T) {
+	t.Parallel()
+
+	a := NewDoorkeeperAdmission[string, int](100, time.Hour)
+
+	if a.Admit("foo", 1) {
+		t.Errorf("expected foo not to be admitted the first time")
+	}
+	if !a.Admit("foo", 1) {
+		t.Errorf("expected foo to be admitted the second time")
+	}
+}
+
+func TestWithAdmission_caches(t *testing.T) {
+	t.Parallel()
+
+	admission := func() Option[string, int] {
+		return WithAdmission[string, int](AdmissionFunc[string, int](func(_ string, v int) bool {
+			return v < 10
+		}))
+	}
+
+	cases := []struct {
+		name  string
+		cache Cache[string, int]
+	}{
+		{"fifo", NewFIFO[string, int](2, admission())},
+		{"lifo", NewLIFO[string, int](2, admission())},
+		{"random", NewRandom[string, int](2, admission())},
+		{"policied", NewPolicied[string, int](2, NewLRUPolicy[string](), admission())},
+		{"weighted", NewWeighted[string, int](2, func(string, int) int64 { return 1 }, admission())},
+		{"fair", NewFair[string, int](2, func(string) string { return "" }, admission())},
+		{"lru", NewLRU[string, int](2, admission())},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := tc.cache
+			defer c.Stop()
+
+			c.Set("foo", 5)
+			c.Set("bar", 3)
+			c.Set("baz", 50)
+
+			// The rejected entry does not evict anything.
+			if _, ok := c.Get("baz"); ok {
+				t.Errorf("expected baz not to be admitted")
+			}
+			if got, want := c.(lener).Len(), 2; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+
+			// Existing entries are always updated.
+			c.Set("foo", 50)
+			if v, ok := c.Get("foo"); !ok || v != 50 {
+				t.Errorf("expected %d to be %d", v, 50)
+			}
+
+			// Fetch still returns a value which is not admitted.
+			v, err := c.Fetch("qux", func() (int, error) { return 60, nil })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != 60 {
+				t.Errorf("expected %d to be %d", v, 60)
+			}
+			if _, ok := c.Get("qux"); ok {
+				t.Errorf("expected qux not to be admitted")
+			}
+		})
+	}
+}
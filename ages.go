@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+// AgeStats summarizes the ages of the entries resident in a cache. Comparing
+// the ages with the cache's TTL shows whether entries leave the cache by
+// expiring, or are evicted for capacity long before they would expire.
+type AgeStats struct {
+	// Age summarizes the time since each entry's current value was set.
+	Age DurationSummary
+
+	// Idle summarizes the time since each entry was last retrieved, or since it
+	// was set if it has not been retrieved.
+	Idle DurationSummary
+}
+
+// DurationSummary is a percentile summary of a set of durations. All fields
+// are zero for an empty set.
+type DurationSummary struct {
+	// Count is the number of durations.
+	Count int
+
+	// Min and Max are the smallest and largest durations.
+	Min, Max time.Duration
+
+	// Mean is the average duration.
+	Mean time.Duration
+
+	// P50, P90, and P99 are the 50th, 90th, and 99th percentile durations.
+	P50, P90, P99 time.Duration
+}
+
+// Ages returns a summary of the ages of the entries currently in the cache.
+// Entries are read with Entries, so the cost is proportional to the size of
+// the cache.
+func Ages[K comparable, V any](c EntryLister[K, V]) AgeStats {
+	return agesAt(c.Entries(), time.Now())
+}
+
+// agesAt summarizes the ages of the given entries as of now.
+func agesAt[K comparable, V any](entries []Entry[K, V], now time.Time) AgeStats {
+	ages := make([]time.Duration, 0, len(entries))
+	idles := make([]time.Duration, 0, len(entries))
+	for _, entry := range entries {
+		ages = append(ages, now.Sub(entry.InsertedAt))
+
+		last := entry.LastAccessedAt
+		if last.IsZero() {
+			last = entry.InsertedAt
+		}
+		idles = append(idles, now.Sub(last))
+	}
+
+	return AgeStats{
+		Age:  summarizeDurations(ages),
+		Idle: summarizeDurations(idles),
+	}
+}
+
+// summarizeDurations returns the summary of the given durations, sorting them
+// in place.
+func summarizeDurations(d []time.Duration) DurationSummary {
+	if len(d) == 0 {
+		return DurationSummary{}
+	}
+
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+
+	var total time.Duration
+	for _, v := range d {
+		total += v
+	}
+
+	return DurationSummary{
+		Count: len(d),
+		Min:   d[0],
+		Max:   d[len(d)-1],
+		Mean:  total / time.Duration(len(d)),
+		P50:   percentile(d, 50),
+		P90:   percentile(d, 90),
+		P99:   percentile(d, 99),
+	}
+}
+
+// percentile returns the p-th percentile of the sorted, non-empty durations,
+// using the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
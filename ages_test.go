@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+		defer cache.Stop()
+
+		if got, want := Ages[string, int](cache), (AgeStats{}); got != want {
+			t.Errorf("expected %#v to be %#v", got, want)
+		}
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Set("bar", 2)
+
+		stats := Ages[string, int](cache)
+		if got, want := stats.Age.Count, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := stats.Idle.Count, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if stats.Age.Min < 0 || stats.Age.Max < stats.Age.Min {
+			t.Errorf("expected %v to be ordered", stats.Age)
+		}
+	})
+}
+
+func TestAgesAt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	entries := make([]Entry[int, int], 0, 100)
+	for i := 1; i <= 100; i++ {
+		entry := Entry[int, int]{
+			Key:        i,
+			InsertedAt: now.Add(-time.Duration(i) * time.Second),
+		}
+		// Odd entries were retrieved a second ago.
+		if i%2 == 1 {
+			entry.LastAccessedAt = now.Add(-time.Second)
+		}
+		entries = append(entries, entry)
+	}
+
+	stats := agesAt(entries, now)
+
+	if got, want := stats.Age, (DurationSummary{
+		Count: 100,
+		Min:   1 * time.Second,
+		Max:   100 * time.Second,
+		Mean:  50500 * time.Millisecond,
+		P50:   50 * time.Second,
+		P90:   90 * time.Second,
+		P99:   99 * time.Second,
+	}); got != want {
+		t.Errorf("expected %#v to be %#v", got, want)
+	}
+
+	if got, want := stats.Idle, (DurationSummary{
+		Count: 100,
+		Min:   1 * time.Second,
+		Max:   100 * time.Second,
+		Mean:  26 * time.Second,
+		P50:   1 * time.Second,
+		P90:   80 * time.Second,
+		P99:   98 * time.Second,
+	}); got != want {
+		t.Errorf("expected %#v to be %#v", got, want)
+	}
+}
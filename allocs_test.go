@@ -0,0 +1,128 @@
+//go:build !race
+
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-cache"
+)
+
+// TestGet_allocs ensures that a cache hit does not allocate, for every cache
+// whose Get returns a stored value as-is. The race detector allocates, so it is
+// excluded.
+func TestGet_allocs(t *testing.T) {
+	const capacity = 64
+
+	caches := []struct {
+		name string
+		c    cache.Cache[int, int]
+	}{
+		{"fifo", cache.NewFIFO[int, int](capacity)},
+		{"lifo", cache.NewLIFO[int, int](capacity)},
+		{"lru", cache.NewLRU[int, int](capacity)},
+		{"lru_approximate", cache.NewLRU[int, int](capacity, cache.WithApproximateRecency[int, int]())},
+		{"lru_buffered", cache.NewLRU[int, int](capacity, cache.WithBufferedRecency[int, int]())},
+		{"random", cache.NewRandom[int, int](capacity)},
+		{"ttl", cache.NewTTL[int, int](time.Hour)},
+		{"sync_lru", cache.NewSyncLRU[int, int](capacity)},
+		{"sync_ttl", cache.NewSyncTTL[int, int](time.Hour)},
+		{"sharded", cache.NewShardedLRU[int, int](4, capacity)},
+		{"generational", cache.NewGenerational[int, int](2, time.Hour)},
+		{"segmented", cache.NewSegmented[int, int](capacity, capacity, 2)},
+		{"instrumented", cache.NewInstrumented[int, int](cache.NewLRU[int, int](capacity), "bench")},
+		{"hooked", cache.NewHooked[int, int](cache.NewLRU[int, int](capacity), cache.Hooks[int, int]{})},
+		{"audited", cache.NewAudited[int, int](cache.NewLRU[int, int](capacity), 16)},
+		{"hotkeys", cache.NewHotKeys[int, int](cache.NewLRU[int, int](capacity), 16, time.Minute)},
+		{"ghost", cache.NewGhost[int, int](cache.NewLRU[int, int](capacity), capacity)},
+		{"doorkeeper", cache.NewDoorkeeper[int, int](cache.NewLRU[int, int](capacity), capacity, time.Hour)},
+		{"loading", cache.NewLoading[int, int](cache.NewLRU[int, int](capacity))},
+		{"keyspace", cache.NewKeyspace[int, int](cache.NewLRU[int, int](capacity))},
+	}
+
+	for _, tc := range caches {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			defer tc.c.Stop()
+			for i := 0; i < capacity; i++ {
+				tc.c.Set(i, i)
+			}
+
+			i := 0
+			allocs := testing.AllocsPerRun(1000, func() {
+				tc.c.Get(i % capacity)
+				i++
+			})
+			if allocs != 0 {
+				t.Errorf("expected %v allocs to be 0", allocs)
+			}
+		})
+	}
+}
+
+// TestSet_allocs ensures that replacing entries in a full cache does not
+// allocate, for every cache which stores its entries inline in a slice and
+// reuses the slots of evicted entries.
+func TestSet_allocs(t *testing.T) {
+	const capacity = 64
+
+	caches := []struct {
+		name string
+		c    cache.Cache[int, int]
+	}{
+		{"fifo", cache.NewFIFO[int, int](capacity)},
+		{"lifo", cache.NewLIFO[int, int](capacity)},
+		{"lru", cache.NewLRU[int, int](capacity)},
+		{"policied", cache.NewPolicied[int, int](capacity, cache.NewLRUPolicy[int]())},
+	}
+
+	for _, tc := range caches {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			defer tc.c.Stop()
+			for i := 0; i < capacity; i++ {
+				tc.c.Set(i, i)
+			}
+
+			i := capacity
+			allocs := testing.AllocsPerRun(1000, func() {
+				tc.c.Set(i, i)
+				i++
+			})
+			if allocs != 0 {
+				t.Errorf("expected %v allocs to be 0", allocs)
+			}
+		})
+	}
+}
+
+// TestSharded_allocs ensures that choosing the shard for a key does not
+// allocate, for keys of common types and for other comparable types.
+func TestSharded_allocs(t *testing.T) {
+	type compound struct {
+		a string
+		b int
+	}
+
+	strings := cache.NewShardedLRU[string, int](4, 64)
+	defer strings.Stop()
+	strings.Set("foo", 1)
+
+	compounds := cache.NewShardedLRU[compound, int](4, 64)
+	defer compounds.Stop()
+	compounds.Set(compound{"foo", 1}, 1)
+
+	if allocs := testing.AllocsPerRun(1000, func() {
+		strings.Get("foo")
+	}); allocs != 0 {
+		t.Errorf("expected %v allocs to be 0", allocs)
+	}
+	if allocs := testing.AllocsPerRun(1000, func() {
+		compounds.Get(compound{"foo", 1})
+	}); allocs != 0 {
+		t.Errorf("expected %v allocs to be 0", allocs)
+	}
+}
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Audited[string, string])(nil)
+
+// Audited wraps a cache and records the most recent operations in a bounded
+// ring buffer, for answering questions like "what happened to this key in the
+// last minute" after the fact. Once the buffer is full, each new event
+// overwrites the oldest.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Audited[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// events is the ring buffer and next is the index the next event is written
+	// to. full indicates whether the buffer has wrapped.
+	events []AuditEvent[K]
+	next   int
+	full   bool
+
+	// lock guards the ring buffer.
+	lock sync.Mutex
+}
+
+// AuditOp is the kind of operation an AuditEvent records.
+type AuditOp uint8
+
+// The operations which are recorded. Evictions are only recorded for caches
+// which implement EvictNotifier.
+const (
+	AuditGet AuditOp = iota + 1
+	AuditSet
+	AuditFetch
+	AuditEvict
+)
+
+// String returns the name of the operation.
+func (o AuditOp) String() string {
+	switch o {
+	case AuditGet:
+		return "get"
+	case AuditSet:
+		return "set"
+	case AuditFetch:
+		return "fetch"
+	case AuditEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// AuditResult is the outcome of an operation an AuditEvent records.
+type AuditResult uint8
+
+const (
+	// AuditOK is the result of operations which always succeed, such as Set.
+	AuditOK AuditResult = iota + 1
+
+	// AuditHit and AuditMiss are the results of lookups which found or did not
+	// find a cached value. A Fetch which invoked its FetchFunc successfully is a
+	// miss.
+	AuditHit
+	AuditMiss
+
+	// AuditError is the result of a Fetch whose FetchFunc returned an error.
+	AuditError
+)
+
+// String returns the name of the result.
+func (r AuditResult) String() string {
+	switch r {
+	case AuditOK:
+		return "ok"
+	case AuditHit:
+		return "hit"
+	case AuditMiss:
+		return "miss"
+	case AuditError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// AuditEvent is a single recorded cache operation.
+type AuditEvent[K comparable] struct {
+	Op     AuditOp
+	Key    K
+	Result AuditResult
+	Time   time.Time
+}
+
+// NewAudited wraps the given cache, recording the last size operations. If the
+// cache implements EvictNotifier, evictions are recorded too.
+func NewAudited[K comparable, V any](c Cache[K, V], size int) *Audited[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if size <= 0 {
+		panic("size must be greater than 0")
+	}
+
+	a := &Audited[K, V]{
+		cache:  c,
+		events: make([]AuditEvent[K], size),
+	}
+	if n, ok := c.(EvictNotifier[K, V]); ok {
+		n.OnEvict(func(k K, _ V) {
+			a.record(AuditEvict, k, AuditOK)
+		})
+	}
+	return a
+}
+
+// Get fetches the cache item at the given key from the underlying cache and
+// records the lookup.
+func (a *Audited[K, V]) Get(key K) (V, bool) {
+	v, ok := a.cache.Get(key)
+	if ok {
+		a.record(AuditGet, key, AuditHit)
+	} else {
+		a.record(AuditGet, key, AuditMiss)
+	}
+	return v, ok
+}
+
+// Set inserts the value in the underlying cache and records the write.
+func (a *Audited[K, V]) Set(key K, val V) {
+	a.cache.Set(key, val)
+	a.record(AuditSet, key, AuditOK)
+}
+
+// Fetch retrieves the cached value from the underlying cache and records the
+// lookup. A Fetch which shares another caller's in-flight load is recorded as
+// a hit.
+func (a *Audited[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	missed := false
+	v, err := a.cache.Fetch(key, func() (V, error) {
+		missed = true
+		return fn()
+	})
+
+	switch {
+	case err != nil:
+		a.record(AuditFetch, key, AuditError)
+	case missed:
+		a.record(AuditFetch, key, AuditMiss)
+	default:
+		a.record(AuditFetch, key, AuditHit)
+	}
+	return v, err
+}
+
+// Stop stops the underlying cache. The recorded events are retained.
+func (a *Audited[K, V]) Stop() {
+	a.cache.Stop()
+}
+
+// RecentEvents returns a copy of the recorded events, from oldest to newest.
+func (a *Audited[K, V]) RecentEvents() []AuditEvent[K] {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if !a.full {
+		events := make([]AuditEvent[K], a.next)
+		copy(events, a.events[:a.next])
+		return events
+	}
+
+	events := make([]AuditEvent[K], 0, len(a.events))
+	events = append(events, a.events[a.next:]...)
+	return append(events, a.events[:a.next]...)
+}
+
+// record appends an event to the ring buffer.
+func (a *Audited[K, V]) record(op AuditOp, key K, result AuditResult) {
+	now := time.Now()
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.events[a.next] = AuditEvent[K]{
+		Op:     op,
+		Key:    key,
+		Result: result,
+		Time:   now,
+	}
+	a.next++
+	if a.next == len(a.events) {
+		a.next = 0
+		a.full = true
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNewAudited(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "size must be greater than 0"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	NewAudited[string, int](NewLRU[string, int](1), 0)
+	t.Errorf("did not panic")
+}
+
+// auditSummary is a comparable summary of an event, without its timestamp.
+type auditSummary struct {
+	op     string
+	key    string
+	result string
+}
+
+func summarizeAudit(events []AuditEvent[string]) []auditSummary {
+	summaries := make([]auditSummary, 0, len(events))
+	for _, e := range events {
+		summaries = append(summaries, auditSummary{e.Op.String(), e.Key, e.Result.String()})
+	}
+	return summaries
+}
+
+func TestAudited_RecentEvents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewAudited[string, int](NewLRU[string, int](1), 10)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Get("foo")
+		cache.Get("bar")
+		cache.Fetch("bar", func() (int, error) { return 2, nil })
+		cache.Fetch("bar", func() (int, error) { return 2, nil })
+		cache.Fetch("baz", func() (int, error) { return 0, fmt.Errorf("error") })
+
+		if got, want := summarizeAudit(cache.RecentEvents()), []auditSummary{
+			{"set", "foo", "ok"},
+			{"get", "foo", "hit"},
+			{"get", "bar", "miss"},
+			{"evict", "foo", "ok"},
+			{"fetch", "bar", "miss"},
+			{"fetch", "bar", "hit"},
+			{"fetch", "baz", "error"},
+		}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("wraps", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewAudited[string, int](NewLRU[string, int](10), 3)
+		defer cache.Stop()
+
+		for _, k := range []string{"a", "b", "c", "d", "e"} {
+			cache.Set(k, 1)
+		}
+
+		var keys []string
+		for _, e := range cache.RecentEvents() {
+			keys = append(keys, e.Key)
+		}
+		if got, want := keys, []string{"c", "d", "e"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("timestamps", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewAudited[string, int](NewLRU[string, int](10), 3)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Set("bar", 1)
+
+		events := cache.RecentEvents()
+		if events[0].Time.IsZero() || events[1].Time.Before(events[0].Time) {
+			t.Errorf("expected increasing timestamps, got %s and %s", events[0].Time, events[1].Time)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// Resizer is implemented by caches whose capacity can be changed at runtime.
+// All of the capacity-bounded caches in this package implement it.
+type Resizer interface {
+	// Capacity returns the current capacity.
+	Capacity() int64
+
+	// Resize changes the capacity, evicting entries if necessary.
+	Resize(int64)
+}
+
+// Ensure implements.
+var (
+	_ Resizer = (*FIFO[string, string])(nil)
+	_ Resizer = (*LIFO[string, string])(nil)
+	_ Resizer = (*LRU[string, string])(nil)
+	_ Resizer = (*Policied[string, string])(nil)
+	_ Resizer = (*Random[string, string])(nil)
+)
+
+// heapMetric is the runtime metric used to measure memory pressure.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// AutotuneConfig is the configuration for an Autotuner.
+type AutotuneConfig struct {
+	// Min and Max bound the capacity the autotuner will choose. Both are
+	// required.
+	Min, Max int64
+
+	// Interval is how often the capacity is evaluated. The default is 1 minute.
+	Interval time.Duration
+
+	// Step is the fraction of the current capacity by which to grow or shrink.
+	// The default is 0.1.
+	Step float64
+
+	// GrowThreshold is the fraction of lookups during an interval which must
+	// have been ghost hits for the cache to grow. The default is 0.05.
+	GrowThreshold float64
+
+	// ShrinkThreshold is the fraction of lookups during an interval below which
+	// ghost hits are considered insignificant and the cache shrinks. The default
+	// is 0.01.
+	ShrinkThreshold float64
+
+	// MaxHeapBytes is the heap size above which the cache shrinks and will not
+	// grow, regardless of ghost hits. If 0, memory pressure is not considered.
+	MaxHeapBytes uint64
+}
+
+// Autotuner periodically adjusts the capacity of a cache within configured
+// bounds. It grows the cache when a significant fraction of misses would have
+// been hits in a larger cache, as measured by a Ghost, and shrinks it when the
+// extra capacity is not paying off or the process is under memory pressure.
+type Autotuner[K comparable, V any] struct {
+	ghost   *Ghost[K, V]
+	resizer Resizer
+	config  AutotuneConfig
+
+	// last is the ghost statistics at the end of the previous interval.
+	last GhostStats
+
+	// heapBytes returns the current heap size. It is overridden in tests.
+	heapBytes func() uint64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewAutotuner creates and starts an autotuner for the cache wrapped by the
+// given ghost. The wrapped cache must implement Resizer.
+func NewAutotuner[K comparable, V any](g *Ghost[K, V], config AutotuneConfig) *Autotuner[K, V] {
+	if g == nil {
+		panic("ghost cannot be nil")
+	}
+	resizer, ok := g.cache.(Resizer)
+	if !ok {
+		panic("cache must implement Resizer")
+	}
+
+	if config.Min <= 0 || config.Max < config.Min {
+		panic("min must be greater than 0 and no more than max")
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.Step <= 0 {
+		config.Step = 0.1
+	}
+	if config.GrowThreshold <= 0 {
+		config.GrowThreshold = 0.05
+	}
+	if config.ShrinkThreshold <= 0 {
+		config.ShrinkThreshold = 0.01
+	}
+
+	a := &Autotuner[K, V]{
+		ghost:     g,
+		resizer:   resizer,
+		config:    config,
+		last:      g.Stats(),
+		heapBytes: readHeapBytes,
+		stopCh:    make(chan struct{}),
+	}
+	go a.start()
+	return a
+}
+
+// Stop stops the autotuner. It does not stop the cache.
+func (a *Autotuner[K, V]) Stop() {
+	a.stopOnce.Do(func() {
+		close(a.stopCh)
+	})
+}
+
+// start runs the tuning loop until stopped.
+func (a *Autotuner[K, V]) start() {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			a.tune()
+		}
+	}
+}
+
+// tune evaluates the statistics for the last interval and resizes the cache if
+// needed. It returns the new capacity.
+func (a *Autotuner[K, V]) tune() int64 {
+	stats := a.ghost.Stats()
+	lookups := (stats.Hits + stats.Misses) - (a.last.Hits + a.last.Misses)
+	ghostHits := stats.GhostHits - a.last.GhostHits
+	a.last = stats
+
+	capacity := a.resizer.Capacity()
+	step := int64(math.Max(1, math.Round(float64(capacity)*a.config.Step)))
+
+	pressured := a.config.MaxHeapBytes > 0 && a.heapBytes() > a.config.MaxHeapBytes
+
+	next := capacity
+	switch {
+	case pressured:
+		next = capacity - step
+	case lookups == 0:
+		// No traffic, so there is no signal either way.
+	case float64(ghostHits)/float64(lookups) >= a.config.GrowThreshold:
+		next = capacity + step
+	case float64(ghostHits)/float64(lookups) < a.config.ShrinkThreshold:
+		next = capacity - step
+	}
+
+	if next < a.config.Min {
+		next = a.config.Min
+	}
+	if next > a.config.Max {
+		next = a.config.Max
+	}
+
+	if next != capacity {
+		a.resizer.Resize(next)
+	}
+	return next
+}
+
+// readHeapBytes returns the number of bytes occupied by live and unswept heap
+// objects.
+func readHeapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
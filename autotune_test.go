@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNewAutotuner(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "min must be greater than 0 and no more than max"; got != want {
+			t.Errorf("expected %q to contain %q", got, want)
+		}
+	}()
+
+	g := NewGhost[int, int](NewLRU[int, int](10), 10)
+	defer g.Stop()
+
+	NewAutotuner(g, AutotuneConfig{Min: 10, Max: 5})
+	t.Errorf("did not panic")
+}
+
+func TestAutotuner_tune(t *testing.T) {
+	t.Parallel()
+
+	newTuner := func(tb testing.TB, config AutotuneConfig) (*LRU[int, int], *Ghost[int, int], *Autotuner[int, int]) {
+		lru := NewLRU[int, int](10)
+		g := NewGhost[int, int](lru, 10)
+		config.Interval = time.Hour
+		a := NewAutotuner(g, config)
+		a.heapBytes = func() uint64 { return 100 }
+
+		tb.Cleanup(func() {
+			a.Stop()
+			g.Stop()
+		})
+		return lru, g, a
+	}
+
+	t.Run("grows_on_ghost_hits", func(t *testing.T) {
+		t.Parallel()
+
+		lru, g, a := newTuner(t, AutotuneConfig{Min: 5, Max: 100})
+
+		for i := 0; i < 20; i++ {
+			g.Set(i, i)
+		}
+		for i := 0; i < 10; i++ {
+			g.Get(i)
+		}
+
+		if got, want := a.tune(), int64(11); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := lru.Capacity(), int64(11); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("shrinks_without_ghost_hits", func(t *testing.T) {
+		t.Parallel()
+
+		lru, g, a := newTuner(t, AutotuneConfig{Min: 5, Max: 100})
+
+		g.Set(1, 1)
+		for i := 0; i < 100; i++ {
+			g.Get(1)
+		}
+
+		if got, want := a.tune(), int64(9); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := lru.Capacity(), int64(9); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, a := newTuner(t, AutotuneConfig{Min: 5, Max: 100})
+
+		if got, want := a.tune(), int64(10); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("memory_pressure", func(t *testing.T) {
+		t.Parallel()
+
+		_, g, a := newTuner(t, AutotuneConfig{Min: 9, Max: 100, MaxHeapBytes: 50})
+
+		for i := 0; i < 20; i++ {
+			g.Set(i, i)
+		}
+		for i := 0; i < 10; i++ {
+			g.Get(i)
+		}
+
+		if got, want := a.tune(), int64(9); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := a.tune(), int64(9); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestReadHeapBytes(t *testing.T) {
+	t.Parallel()
+
+	if readHeapBytes() == 0 {
+		t.Errorf("expected heap to be non-zero")
+	}
+}
@@ -0,0 +1,111 @@
+// Package bboltcache implements a cache.Persister backed by a bucket in a bbolt
+// database, for persisting and restoring a cache's entries:
+//
+//	db, err := bolt.Open("cache.db", 0o600, nil)
+//	p, err := bboltcache.New(db, []byte("users"))
+//
+//	err = cache.Persist[string, *User](p, users, nil, nil)
+//	err = cache.Restore[string, *User](users, p, nil, nil)
+//
+// The package is a separate module, so the go-cache module does not depend on
+// bbolt.
+package bboltcache
+
+import (
+	"fmt"
+
+	"github.com/sethvargo/go-cache"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Ensure implements.
+var _ cache.BatchPersister = (*Persister)(nil)
+
+// Persister stores entries in a bbolt bucket. Each call runs in its own
+// transaction, so PutAll stores many entries with a single write. It is safe for concurrent use.
+type Persister struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// New creates a persister which stores entries in the bucket with the given
+// name, creating the bucket if it does not exist. The database is not closed by
+// the persister.
+func New(db *bolt.DB, bucket []byte) (*Persister, error) {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+	if len(bucket) == 0 {
+		panic("bucket cannot be empty")
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create bucket %q: %w", bucket, err)
+	}
+
+	return &Persister{
+		db:     db,
+		bucket: append([]byte(nil), bucket...),
+	}, nil
+}
+
+// Put stores the value at the given key, overwriting any existing value.
+func (p *Persister) Put(key, value []byte) error {
+	return p.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(p.bucket).Put(key, value)
+	})
+}
+
+// PutAll stores each value at the key with the same index, in a single
+// transaction.
+func (p *Persister) PutAll(keys, values [][]byte) error {
+	return p.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(p.bucket)
+		for i := range keys {
+			if err := b.Put(keys[i], values[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Get retrieves a copy of the value at the given key. If it does not exist,
+// the second return value is false.
+func (p *Persister) Get(key []byte) ([]byte, bool, error) {
+	var value []byte
+	var ok bool
+	if err := p.db.View(func(tx *bolt.Tx) error {
+		// The value is only valid for the life of the transaction.
+		if v := tx.Bucket(p.bucket).Get(key); v != nil {
+			value, ok = append([]byte{}, v...), true
+		}
+		return nil
+	}); err != nil {
+		return nil, false, err
+	}
+	return value, ok, nil
+}
+
+// Delete removes the given key. It is not an error if the key does not exist.
+func (p *Persister) Delete(key []byte) error {
+	return p.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(p.bucket).Delete(key)
+	})
+}
+
+// Iterate calls fn for each key and value in the bucket, in key order, within a
+// single read transaction. Nested buckets are skipped.
+func (p *Persister) Iterate(fn func(key, value []byte) error) error {
+	return p.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(p.bucket).ForEach(func(k, v []byte) error {
+			if v == nil {
+				return nil
+			}
+			return fn(k, v)
+		})
+	})
+}
@@ -0,0 +1,134 @@
+package bboltcache
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sethvargo/go-cache"
+	bolt "go.etcd.io/bbolt"
+)
+
+func testDB(t *testing.T) *bolt.DB {
+	t.Helper()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "cache.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return db
+}
+
+func TestPersister(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(testDB(t), []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Put([]byte("foo"), []byte("bar")); err != nil {
+		t.Fatal(err)
+	}
+
+	v, ok, err := p.Get([]byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || string(v) != "bar" {
+		t.Errorf("expected %q to be %q", v, "bar")
+	}
+
+	if err := p.Delete([]byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := p.Get([]byte("foo")); err != nil || ok {
+		t.Errorf("expected foo to be deleted (%v)", err)
+	}
+
+	// Deleting a missing key is not an error.
+	if err := p.Delete([]byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersister_buckets(t *testing.T) {
+	t.Parallel()
+
+	db := testDB(t)
+
+	a, err := New(db, []byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(db, []byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Put([]byte("foo"), []byte("bar")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := b.Get([]byte("foo")); err != nil || ok {
+		t.Errorf("expected foo not to be in bucket b (%v)", err)
+	}
+}
+
+func TestPersistRestore(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(testDB(t), []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := cache.NewLRU[string, int](10)
+	defer src.Stop()
+	src.Set("foo", 1)
+	src.Set("bar", 2)
+
+	if err := cache.Persist[string, int](p, src, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := cache.NewLRU[string, int](10)
+	defer dst.Stop()
+
+	if err := cache.Restore[string, int](dst, p, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]int{"foo": 1, "bar": 2} {
+		if got, ok := dst.Get(k); !ok || got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	}
+}
+
+func TestPersister_PutAll(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(testDB(t), []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := [][]byte{[]byte("foo"), []byte("bar")}
+	values := [][]byte{[]byte("1"), []byte("2")}
+	if err := p.PutAll(keys, values); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, k := range keys {
+		v, ok, err := p.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || string(v) != string(values[i]) {
+			t.Errorf("expected %q to be %q", v, values[i])
+		}
+	}
+}
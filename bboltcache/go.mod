@@ -0,0 +1,14 @@
+module github.com/sethvargo/go-cache/bboltcache
+
+go 1.18
+
+require (
+	github.com/sethvargo/go-cache v0.0.0
+	go.etcd.io/bbolt v1.3.9
+)
+
+require golang.org/x/sys v0.4.0 // indirect
+
+// The adapter is developed against the cache in this repository rather than a
+// published release.
+replace github.com/sethvargo/go-cache => ../
@@ -0,0 +1,84 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-cache"
+)
+
+// benchmarkCaches are the caches which are benchmarked, keyed by name.
+var benchmarkCaches = []struct {
+	name  string
+	newFn func(capacity int64) cache.Cache[int, int]
+}{
+	{"fifo", func(capacity int64) cache.Cache[int, int] { return cache.NewFIFO[int, int](capacity) }},
+	{"lifo", func(capacity int64) cache.Cache[int, int] { return cache.NewLIFO[int, int](capacity) }},
+	{"lru", func(capacity int64) cache.Cache[int, int] { return cache.NewLRU[int, int](capacity) }},
+	{"lru_prealloc", func(capacity int64) cache.Cache[int, int] {
+		return cache.NewLRU[int, int](capacity, cache.WithPreallocatedNodes[int, int]())
+	}},
+	{"lru_approximate", func(capacity int64) cache.Cache[int, int] {
+		return cache.NewLRU[int, int](capacity, cache.WithApproximateRecency[int, int]())
+	}},
+	{"random", func(capacity int64) cache.Cache[int, int] { return cache.NewRandom[int, int](capacity) }},
+	{"readmostly", func(capacity int64) cache.Cache[int, int] { return cache.NewReadMostly[int, int](time.Hour) }},
+	{"ttl", func(capacity int64) cache.Cache[int, int] { return cache.NewTTL[int, int](time.Hour) }},
+}
+
+func BenchmarkSet(b *testing.B) {
+	const capacity = 1024
+
+	for _, bc := range benchmarkCaches {
+		bc := bc
+
+		// Every Set inserts a new key, so bounded caches evict on each call once
+		// they are full.
+		b.Run(bc.name+"/insert", func(b *testing.B) {
+			c := bc.newFn(capacity)
+			defer c.Stop()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Set(i%(4*capacity), i)
+			}
+		})
+
+		b.Run(bc.name+"/overwrite", func(b *testing.B) {
+			c := bc.newFn(capacity)
+			defer c.Stop()
+			for i := 0; i < capacity/2; i++ {
+				c.Set(i, i)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Set(i%(capacity/2), i)
+			}
+		})
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	const capacity = 1024
+
+	for _, bc := range benchmarkCaches {
+		bc := bc
+
+		b.Run(bc.name, func(b *testing.B) {
+			c := bc.newFn(capacity)
+			defer c.Stop()
+			for i := 0; i < capacity; i++ {
+				c.Set(i, i)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Get(i % capacity)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"math"
+)
+
+// bloomFilter is a simple bloom filter over 64-bit hashes. It is not safe for
+// concurrent use.
+type bloomFilter struct {
+	bits   []uint64
+	hashes uint64
+	count  int
+}
+
+// newBloomFilter creates a bloom filter sized for the expected number of items
+// at the given false positive rate.
+func newBloomFilter(expected int, fpRate float64) *bloomFilter {
+	if expected < 1 {
+		expected = 1
+	}
+
+	m := math.Ceil(-float64(expected) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/float64(expected)*math.Ln2))
+
+	return &bloomFilter{
+		bits:   make([]uint64, (uint64(m)+63)/64),
+		hashes: uint64(k),
+	}
+}
+
+// add adds the hash to the filter. It returns true if the hash was possibly
+// already present.
+func (b *bloomFilter) add(h uint64) bool {
+	n := uint64(len(b.bits)) * 64
+	h1, h2 := h, mix64(h)|1
+
+	present := true
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % n
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[word]&mask == 0 {
+			present = false
+			b.bits[word] |= mask
+		}
+	}
+
+	if !present {
+		b.count++
+	}
+	return present
+}
+
+// contains returns true if the hash is possibly present in the filter.
+func (b *bloomFilter) contains(h uint64) bool {
+	n := uint64(len(b.bits)) * 64
+	h1, h2 := h, mix64(h)|1
+
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % n
+		if b.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reset clears the filter.
+func (b *bloomFilter) reset() {
+	for i := range b.bits {
+		b.bits[i] = 0
+	}
+	b.count = 0
+}
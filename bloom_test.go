@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	t.Parallel()
+
+	b := newBloomFilter(1000, 0.01)
+
+	for i := uint64(0); i < 1000; i++ {
+		if b.add(mix64(i)) && i < 10 {
+			t.Errorf("expected %d to not be present", i)
+		}
+	}
+	for i := uint64(0); i < 1000; i++ {
+		if !b.contains(mix64(i)) {
+			t.Errorf("expected %d to be present", i)
+		}
+	}
+
+	var falsePositives int
+	for i := uint64(1000); i < 11000; i++ {
+		if b.contains(mix64(i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 300 {
+		t.Errorf("expected fewer than 300 false positives, got %d", falsePositives)
+	}
+
+	b.reset()
+	if b.contains(mix64(1)) {
+		t.Errorf("expected filter to be empty")
+	}
+}
@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"time"
+)
+
+// policy is the eviction policy selected on a builder.
+type policy int
+
+const (
+	policyNone policy = iota
+	policyFIFO
+	policyLIFO
+	policyLRU
+	policyRandom
+)
+
+// Builder composes a cache from an eviction policy, an expiration, sharding,
+// and concurrency options without nesting constructors or repeating the type
+// parameters:
+//
+//	c := cache.New[string, string]().LRU(1000).TTL(5 * time.Minute).Sharded(16).Build()
+//
+// Builder methods return the builder so calls can be chained. Invalid
+// configurations panic, consistent with the individual constructors.
+type Builder[K comparable, V any] struct {
+	// storage configures the underlying storage.
+	storage storageConfig
+
+	// sync indicates whether the cache should be wrapped in a sync cache.
+	sync bool
+
+	// instrumented is the name and options of the instrumented cache wrapping
+	// the built cache, or empty if it is not instrumented.
+	instrumented     string
+	instrumentedOpts []InstrumentedOption
+
+	// copier is the function used to copy values, or nil if values are not
+	// copied.
+	copier func(V) V
+
+	// codec is the codec used to encode values, or nil if values are stored
+	// as-is.
+	codec Codec[V]
+
+	// middleware are applied to the built cache, outermost first.
+	middleware []Middleware[K, V]
+}
+
+// storageConfig is the configuration for the underlying storage of a built
+// cache. It is independent of the value type so that the same configuration
+// can build storage for values or for their encoded bytes.
+type storageConfig struct {
+	// policy is the eviction policy and capacity is its total capacity.
+	policy   policy
+	capacity int64
+
+	// ttl is the global TTL value, or 0 if entries do not expire.
+	ttl time.Duration
+
+	// shards is the number of shards, or 0 if the cache is not sharded.
+	shards int
+}
+
+// New creates a new cache builder.
+func New[K comparable, V any]() *Builder[K, V] {
+	return &Builder[K, V]{}
+}
+
+// FIFO configures the cache to use the FIFO eviction policy with the given
+// capacity.
+func (b *Builder[K, V]) FIFO(capacity int64) *Builder[K, V] {
+	return b.withPolicy(policyFIFO, capacity)
+}
+
+// LIFO configures the cache to use the LIFO eviction policy with the given
+// capacity.
+func (b *Builder[K, V]) LIFO(capacity int64) *Builder[K, V] {
+	return b.withPolicy(policyLIFO, capacity)
+}
+
+// LRU configures the cache to use the LRU eviction policy with the given
+// capacity.
+func (b *Builder[K, V]) LRU(capacity int64) *Builder[K, V] {
+	return b.withPolicy(policyLRU, capacity)
+}
+
+// Random configures the cache to use the random replacement eviction policy
+// with the given capacity.
+func (b *Builder[K, V]) Random(capacity int64) *Builder[K, V] {
+	return b.withPolicy(policyRandom, capacity)
+}
+
+// TTL configures entries to expire after the given duration. If no eviction
+// policy is configured, the result is an unbounded TTL cache. If an eviction
+// policy is configured, entries are evicted by that policy and additionally
+// treated as missing once they expire.
+func (b *Builder[K, V]) TTL(ttl time.Duration) *Builder[K, V] {
+	if ttl <= 0 {
+		panic("ttl must be greater than 0")
+	}
+	b.storage.ttl = ttl
+	return b
+}
+
+// Sharded configures the cache to be split into the given number of shards.
+// The configured capacity is divided evenly across the shards, rounding up.
+func (b *Builder[K, V]) Sharded(shards int) *Builder[K, V] {
+	if shards <= 0 {
+		panic("shards must be greater than 0")
+	}
+	b.storage.shards = shards
+	return b
+}
+
+// Sync configures the resulting cache to be wrapped in a sync cache. If the
+// cache is sharded, each shard is guarded by its own lock, as with
+// NewShardedSync, rather than every shard by one lock.
+func (b *Builder[K, V]) Sync() *Builder[K, V] {
+	b.sync = true
+	return b
+}
+
+// Copier configures the cache to store and return copies of values, using the
+// given function to copy them. See Copying for more information.
+func (b *Builder[K, V]) Copier(fn func(V) V) *Builder[K, V] {
+	if fn == nil {
+		panic("copier cannot be nil")
+	}
+	b.copier = fn
+	return b
+}
+
+// Codec configures the cache to store values encoded as bytes using the given
+// codec. See Encoded for more information.
+func (b *Builder[K, V]) Codec(codec Codec[V]) *Builder[K, V] {
+	if codec == nil {
+		panic("codec cannot be nil")
+	}
+	b.codec = codec
+	return b
+}
+
+// Instrumented configures the cache to be wrapped in an instrumented cache with
+// the given name and options, outside of the other configured wrappers and
+// inside of any middleware. See Instrumented for more information. Evictions
+// and lifetimes are not recorded, since the storage is not exposed to the
+// instrumented cache.
+func (b *Builder[K, V]) Instrumented(name string, opts ...InstrumentedOption) *Builder[K, V] {
+	if name == "" {
+		panic("name cannot be empty")
+	}
+	b.instrumented = name
+	b.instrumentedOpts = opts
+	return b
+}
+
+// Use configures the given middleware to wrap the built cache, after all other
+// configured wrappers. Middleware from earlier calls wraps middleware from later
+// calls, as with Chain.
+func (b *Builder[K, V]) Use(mws ...Middleware[K, V]) *Builder[K, V] {
+	for _, mw := range mws {
+		if mw == nil {
+			panic("middleware cannot be nil")
+		}
+	}
+	b.middleware = append(b.middleware, mws...)
+	return b
+}
+
+// Build constructs the configured cache. It panics if neither an eviction
+// policy nor a TTL was configured.
+func (b *Builder[K, V]) Build() Cache[K, V] {
+	if b.storage.policy == policyNone && b.storage.ttl == 0 {
+		panic("no eviction policy or ttl configured")
+	}
+
+	var c Cache[K, V]
+	if b.sync && b.storage.shards > 0 {
+		shard := b.storage
+		shard.shards = 0
+		shard.capacity = b.storage.shardCapacity()
+		c = NewShardedSync(b.storage.shards, func() Cache[K, V] {
+			return b.buildValues(shard)
+		})
+	} else {
+		c = b.buildValues(b.storage)
+		if b.sync {
+			c = NewSync(c)
+		}
+	}
+
+	if b.copier != nil {
+		c = NewCopying(c, b.copier)
+	}
+	if b.instrumented != "" {
+		c = NewInstrumented(c, b.instrumented, b.instrumentedOpts...)
+	}
+	if len(b.middleware) > 0 {
+		c = Chain(b.middleware...)(c)
+	}
+	return c
+}
+
+// buildValues constructs the storage for the given configuration, encoding the
+// values if a codec is configured.
+func (b *Builder[K, V]) buildValues(cfg storageConfig) Cache[K, V] {
+	if b.codec != nil {
+		return NewEncoded(buildStorage[K, []byte](cfg), b.codec)
+	}
+	return buildStorage[K, V](cfg)
+}
+
+// buildStorage constructs the storage for the given configuration.
+func buildStorage[K comparable, V any](cfg storageConfig) Cache[K, V] {
+	if cfg.shards == 0 {
+		return buildShard[K, V](cfg, cfg.capacity)
+	}
+
+	capacity := cfg.shardCapacity()
+	return NewSharded(cfg.shards, func() Cache[K, V] {
+		return buildShard[K, V](cfg, capacity)
+	})
+}
+
+// buildShard constructs a single, unsharded cache with the given capacity.
+func buildShard[K comparable, V any](cfg storageConfig, capacity int64) Cache[K, V] {
+	if cfg.policy == policyNone {
+		return NewTTL[K, V](cfg.ttl)
+	}
+
+	if cfg.ttl == 0 {
+		return newPolicyCache[K, V](cfg.policy, capacity)
+	}
+	return newExpiring[K, V](newPolicyCache[K, expiringEntry[V]](cfg.policy, capacity), cfg.ttl)
+}
+
+// shardCapacity returns the capacity of each shard, dividing the capacity evenly
+// across the shards and rounding up.
+func (cfg storageConfig) shardCapacity() int64 {
+	return (cfg.capacity + int64(cfg.shards) - 1) / int64(cfg.shards)
+}
+
+// withPolicy sets the eviction policy and capacity.
+func (b *Builder[K, V]) withPolicy(p policy, capacity int64) *Builder[K, V] {
+	if capacity <= 0 {
+		panic("capacity must be greater than 0")
+	}
+	b.storage.policy = p
+	b.storage.capacity = capacity
+	return b
+}
+
+// newPolicyCache constructs a cache for the given eviction policy.
+func newPolicyCache[K comparable, V any](p policy, capacity int64) Cache[K, V] {
+	switch p {
+	case policyFIFO:
+		return NewFIFO[K, V](capacity)
+	case policyLIFO:
+		return NewLIFO[K, V](capacity)
+	case policyLRU:
+		return NewLRU[K, V](capacity)
+	case policyRandom:
+		return NewRandom[K, V](capacity)
+	default:
+		panic("unknown eviction policy")
+	}
+}
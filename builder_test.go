@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	t.Run("policy", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, int]().LRU(10).Build()
+		defer cache.Stop()
+
+		lru, ok := cache.(*LRU[string, int])
+		if !ok {
+			t.Fatalf("expected %T to be *LRU", cache)
+		}
+		if got, want := lru.capacity, int64(10); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, int]().TTL(5 * time.Minute).Build()
+		defer cache.Stop()
+
+		if _, ok := cache.(*TTL[string, int]); !ok {
+			t.Fatalf("expected %T to be *TTL", cache)
+		}
+	})
+
+	t.Run("policy_and_ttl", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, int]().FIFO(10).TTL(50 * time.Millisecond).Build()
+		defer cache.Stop()
+
+		e, ok := cache.(*expiring[string, int])
+		if !ok {
+			t.Fatalf("expected %T to be *expiring", cache)
+		}
+		if _, ok := e.cache.(*FIFO[string, expiringEntry[int]]); !ok {
+			t.Fatalf("expected %T to be *FIFO", e.cache)
+		}
+
+		cache.Set("foo", 5)
+		if v, _ := cache.Get("foo"); v != 5 {
+			t.Errorf("expected %#v, got %#v", 5, v)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		if v, ok := cache.Get("foo"); ok {
+			t.Errorf("expected %#v to be expired", v)
+		}
+	})
+
+	t.Run("sharded", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, int]().Random(10).Sharded(4).Sync().Build()
+		defer cache.Stop()
+
+		// Each shard has its own lock.
+		s, ok := cache.(*Sync[string, int])
+		if !ok {
+			t.Fatalf("expected %T to be *Sync", cache)
+		}
+		if got, want := len(s.shards), 4; got != want {
+			t.Fatalf("expected %d to be %d", got, want)
+		}
+		for _, shard := range s.shards {
+			if got, want := shard.cache.(*Random[string, int]).capacity, int64(3); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+		}
+
+		// Without Sync, the shards are not locked.
+		unsynced := New[string, int]().Random(10).Sharded(4).Build()
+		defer unsynced.Stop()
+
+		if _, ok := unsynced.(*Sharded[string, int]); !ok {
+			t.Fatalf("expected %T to be *Sharded", unsynced)
+		}
+	})
+
+	t.Run("sharded_codec", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, int]().LRU(10).Sharded(2).Sync().Codec(JSONCodec[int]{}).Build()
+		defer cache.Stop()
+
+		s, ok := cache.(*Sync[string, int])
+		if !ok {
+			t.Fatalf("expected %T to be *Sync", cache)
+		}
+		for _, shard := range s.shards {
+			if _, ok := shard.cache.(*Encoded[string, int]); !ok {
+				t.Errorf("expected %T to be *Encoded", shard.cache)
+			}
+		}
+
+		cache.Set("foo", 5)
+		if v, ok := cache.Get("foo"); !ok || v != 5 {
+			t.Errorf("expected %d to be %d", v, 5)
+		}
+	})
+
+	t.Run("instrumented", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, int]().LRU(10).Sync().Instrumented("test").Use(AuditMiddleware[string, int](10)).Build()
+		defer cache.Stop()
+
+		audited, ok := cache.(*Audited[string, int])
+		if !ok {
+			t.Fatalf("expected %T to be *Audited", cache)
+		}
+		instrumented, ok := audited.cache.(*Instrumented[string, int])
+		if !ok {
+			t.Fatalf("expected %T to be *Instrumented", audited.cache)
+		}
+		if _, ok := instrumented.cache.(*Sync[string, int]); !ok {
+			t.Fatalf("expected %T to be *Sync", instrumented.cache)
+		}
+
+		cache.Set("foo", 1)
+		cache.Get("foo")
+		if got, want := instrumented.Stats().Hits, uint64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, int]().LRU(10).Sync().Use(AuditMiddleware[string, int](10)).Build()
+		defer cache.Stop()
+
+		audited, ok := cache.(*Audited[string, int])
+		if !ok {
+			t.Fatalf("expected %T to be *Audited", cache)
+		}
+		if _, ok := audited.cache.(*Sync[string, int]); !ok {
+			t.Fatalf("expected %T to be *Sync", audited.cache)
+		}
+	})
+
+	t.Run("panic_on_empty", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "no eviction policy or ttl configured"; got != want {
+				t.Errorf("expected %q to contain %q", got, want)
+			}
+		}()
+
+		New[string, int]().Build()
+		t.Errorf("did not panic")
+	})
+
+	t.Run("panic_on_negative_capacity", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "capacity must be greater than 0"; got != want {
+				t.Errorf("expected %q to contain %q", got, want)
+			}
+		}()
+
+		New[string, int]().LIFO(0)
+		t.Errorf("did not panic")
+	})
+}
@@ -23,7 +23,20 @@
 //     lruSync := cache.NewSync[string, string](cache.NewLRU[string, string](15))
 //
 // Unfortunately Go does not currently infer the type constraint from the input,
-// so you must declare it twice.
+// so you must declare it twice. To avoid the repetition, each implementation
+// also has a convenience constructor which creates and wraps the cache in one
+// call:
+//
+//     lruSync := cache.NewSyncLRU[string, string](15)
+//
+// Values are stored in each entry as the type parameter V, without boxing them
+// in an interface. The LRU, FIFO, LIFO, Random, and Policied caches keep their
+// entries in a single slice indexed by the map and reuse the slots of evicted
+// entries, so small fixed-size values such as integers or small structs live
+// inline in the slice and reading them does not follow a per-entry pointer.
+// Other caches allocate each entry separately and keep a pointer to it in their
+// map. Large values are copied on every Get and Set, so they are best stored as
+// pointers.
 package cache
 
 // Cache is a generic interface for various cache implementations.
@@ -38,18 +51,16 @@ type Cache[K comparable, V any] interface {
 
 	// Fetch retrieves the cached value. If the value does not exist, the
 	// FetchFunc is called and the result is stored. If the value does exist, the
-	// FetchFunc is not invoked.
+	// FetchFunc is not invoked. Errors returned by the FetchFunc are wrapped in a
+	// LoaderError.
 	Fetch(K, FetchFunc[V]) (V, error)
 
 	// Stop terminates the cache, deleting any cached entries. Once invoked, any
-	// future calls to Get or Set will panic.
+	// future calls to Get or Set will panic with ErrStopped. For the caches in
+	// this package, Stop first waits for in-flight FetchFuncs to finish and
+	// store their results, so a FetchFunc must not call Stop on its own cache.
 	Stop()
 }
 
 // FetchFunc is a function that is invoked when a cached value is not found.
 type FetchFunc[V any] func() (V, error)
-
-// ptrTo is a helper for returning the pointer to a type.
-func ptrTo[V any](v V) *V {
-	return &v
-}
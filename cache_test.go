@@ -51,3 +51,21 @@ func ExampleNewTTL() {
 	v, _ := ttl.Get("foo")
 	fmt.Println(v) // Output: bar
 }
+
+func ExampleNewSyncLRU() {
+	lru := cache.NewSyncLRU[string, string](15)
+	defer lru.Stop()
+
+	lru.Set("foo", "bar")
+	v, _ := lru.Get("foo")
+	fmt.Println(v) // Output: bar
+}
+
+func ExampleNew() {
+	c := cache.New[string, string]().LRU(1000).TTL(5 * time.Minute).Sharded(16).Build()
+	defer c.Stop()
+
+	c.Set("foo", "bar")
+	v, _ := c.Get("foo")
+	fmt.Println(v) // Output: bar
+}
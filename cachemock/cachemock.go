@@ -0,0 +1,301 @@
+// Package cachemock implements a recording cache for testing code which
+// depends on a cache.Cache, without a real cache or a hand-written fake. The
+// mock stores values in an unbounded map, records every call in order, and can
+// be scripted to return specific results:
+//
+//	mock := cachemock.New[string, *User]()
+//	mock.ReturnFetch("42", nil, errUpstream)
+//
+//	svc := NewService(mock)
+//	svc.Lookup("42")
+//
+//	mock.AssertCalls(t,
+//	  cachemock.Call[string, *User]{Op: cachemock.OpGet, Key: "42"},
+//	  cachemock.Call[string, *User]{Op: cachemock.OpFetch, Key: "42", Err: errUpstream},
+//	)
+package cachemock
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sethvargo/go-cache"
+)
+
+// Ensure implements.
+var _ cache.Cache[string, string] = (*Cache[string, string])(nil)
+
+// Op is the name of a cache operation.
+type Op string
+
+// The operations of a cache.
+const (
+	OpGet   Op = "get"
+	OpSet   Op = "set"
+	OpFetch Op = "fetch"
+	OpStop  Op = "stop"
+)
+
+// Call is a single recorded call on the mock.
+type Call[K comparable, V any] struct {
+	// Op is the operation which was called.
+	Op Op
+
+	// Key is the key passed to the operation. It is the zero value for Stop.
+	Key K
+
+	// Value is the value passed to Set, or the value returned by Get or Fetch.
+	Value V
+
+	// Hit indicates Get or Fetch found a value without calling a FetchFunc.
+	Hit bool
+
+	// Err is the error returned by Fetch.
+	Err error
+}
+
+// String returns a description of the call, for test failure messages.
+func (c Call[K, V]) String() string {
+	switch c.Op {
+	case OpStop:
+		return "stop()"
+	case OpSet:
+		return fmt.Sprintf("set(%v, %v)", c.Key, c.Value)
+	default:
+		return fmt.Sprintf("%s(%v) = %v, hit=%t, err=%v", c.Op, c.Key, c.Value, c.Hit, c.Err)
+	}
+}
+
+// Cache is a mock cache which records its calls. The zero value is not ready
+// for use; create one with New. It is safe for concurrent use.
+//
+// K is the cache key and must be a comparable. V can be any type.
+type Cache[K comparable, V any] struct {
+	lock sync.Mutex
+
+	// calls are the recorded calls, in order.
+	calls []Call[K, V]
+
+	// values are the stored values.
+	values map[K]V
+
+	// gets and fetches are the scripted responses, by key.
+	gets    map[K]getResponse[V]
+	fetches map[K]fetchResponse[V]
+
+	// stopped indicates Stop was called.
+	stopped bool
+}
+
+// getResponse is a scripted response for Get.
+type getResponse[V any] struct {
+	value V
+	ok    bool
+}
+
+// fetchResponse is a scripted response for Fetch.
+type fetchResponse[V any] struct {
+	value V
+	err   error
+}
+
+// New creates a new mock cache with no stored values or scripted responses.
+func New[K comparable, V any]() *Cache[K, V] {
+	return &Cache[K, V]{
+		values:  make(map[K]V),
+		gets:    make(map[K]getResponse[V]),
+		fetches: make(map[K]fetchResponse[V]),
+	}
+}
+
+// ReturnGet scripts every Get of the given key to return val and ok, regardless
+// of the stored values. It also applies to Fetch if ok is true.
+func (c *Cache[K, V]) ReturnGet(key K, val V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.gets[key] = getResponse[V]{value: val, ok: ok}
+}
+
+// ReturnFetch scripts every Fetch of the given key to return val and err
+// without calling the FetchFunc or storing the value. The error is returned
+// as-is, rather than as a LoaderError.
+func (c *Cache[K, V]) ReturnFetch(key K, val V, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.fetches[key] = fetchResponse[V]{value: val, err: err}
+}
+
+// Get returns the scripted response for the key, or the stored value if there
+// is none.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	v, ok := c.get(key)
+	c.record(Call[K, V]{Op: OpGet, Key: key, Value: v, Hit: ok})
+	return v, ok
+}
+
+// get looks up the key. The caller must hold the lock.
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	if c.stopped {
+		panic(cache.ErrStopped)
+	}
+
+	if r, ok := c.gets[key]; ok {
+		return r.value, r.ok
+	}
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// Set stores the value.
+func (c *Cache[K, V]) Set(key K, val V) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		panic(cache.ErrStopped)
+	}
+
+	c.values[key] = val
+	c.record(Call[K, V]{Op: OpSet, Key: key, Value: val})
+}
+
+// Fetch returns the scripted response for the key if there is one. Otherwise,
+// it returns the value from Get if it is found, or calls the FetchFunc and
+// stores the result. Errors from the FetchFunc are wrapped in a LoaderError.
+// The FetchFunc is called without holding the mock's lock.
+func (c *Cache[K, V]) Fetch(key K, fn cache.FetchFunc[V]) (V, error) {
+	c.lock.Lock()
+	if r, ok := c.fetches[key]; ok {
+		defer c.lock.Unlock()
+		c.record(Call[K, V]{Op: OpFetch, Key: key, Value: r.value, Err: r.err})
+		return r.value, r.err
+	}
+	if v, ok := c.get(key); ok {
+		defer c.lock.Unlock()
+		c.record(Call[K, V]{Op: OpFetch, Key: key, Value: v, Hit: true})
+		return v, nil
+	}
+	c.lock.Unlock()
+
+	v, err := fn()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err != nil {
+		var zeroV V
+		err = &cache.LoaderError{Key: key, Err: err}
+		c.record(Call[K, V]{Op: OpFetch, Key: key, Value: zeroV, Err: err})
+		return zeroV, err
+	}
+
+	if c.stopped {
+		panic(cache.ErrStopped)
+	}
+	c.values[key] = v
+	c.record(Call[K, V]{Op: OpFetch, Key: key, Value: v})
+	return v, nil
+}
+
+// Stop records the call and causes future operations to panic with
+// cache.ErrStopped. The recorded calls remain available.
+func (c *Cache[K, V]) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.stopped = true
+	c.record(Call[K, V]{Op: OpStop})
+}
+
+// Calls returns a copy of the recorded calls, in order.
+func (c *Cache[K, V]) Calls() []Call[K, V] {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	calls := make([]Call[K, V], len(c.calls))
+	copy(calls, c.calls)
+	return calls
+}
+
+// Reset clears the recorded calls. Stored values and scripted responses are
+// kept.
+func (c *Cache[K, V]) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = nil
+}
+
+// AssertCalls reports an error on t unless the recorded calls are exactly the
+// given calls, in order. Values are compared with reflect.DeepEqual, and errors
+// with errors.Is, so a wrapped error matches the error it wraps.
+func (c *Cache[K, V]) AssertCalls(t testing.TB, want ...Call[K, V]) {
+	t.Helper()
+
+	got := c.Calls()
+	for i := 0; i < len(got) || i < len(want); i++ {
+		switch {
+		case i >= len(want):
+			t.Errorf("unexpected call %d: %s", i, got[i])
+		case i >= len(got):
+			t.Errorf("missing call %d: %s", i, want[i])
+		case !callsEqual(got[i], want[i]):
+			t.Errorf("expected call %d to be %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+// AssertCalled reports an error on t unless the operation was called with the
+// given key at least once.
+func (c *Cache[K, V]) AssertCalled(t testing.TB, op Op, key K) {
+	t.Helper()
+
+	if c.count(op, key) == 0 {
+		t.Errorf("expected %s(%v) to be called", op, key)
+	}
+}
+
+// AssertNotCalled reports an error on t if the operation was called with the
+// given key.
+func (c *Cache[K, V]) AssertNotCalled(t testing.TB, op Op, key K) {
+	t.Helper()
+
+	if n := c.count(op, key); n > 0 {
+		t.Errorf("expected %s(%v) to not be called, called %d times", op, key, n)
+	}
+}
+
+// count returns the number of recorded calls of the operation with the key.
+func (c *Cache[K, V]) count(op Op, key K) int {
+	var n int
+	for _, call := range c.Calls() {
+		if call.Op == op && call.Key == key {
+			n++
+		}
+	}
+	return n
+}
+
+// record appends the call. The caller must hold the lock.
+func (c *Cache[K, V]) record(call Call[K, V]) {
+	c.calls = append(c.calls, call)
+}
+
+// callsEqual reports whether got matches the expected call.
+func callsEqual[K comparable, V any](got, want Call[K, V]) bool {
+	if got.Op != want.Op || got.Key != want.Key || got.Hit != want.Hit {
+		return false
+	}
+	if !reflect.DeepEqual(got.Value, want.Value) {
+		return false
+	}
+	if want.Err == nil {
+		return got.Err == nil
+	}
+	return errors.Is(got.Err, want.Err)
+}
@@ -0,0 +1,166 @@
+package cachemock
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sethvargo/go-cache"
+)
+
+// recordingTB records the errors reported to it.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	mock := New[string, int]()
+	defer mock.Stop()
+
+	mock.Set("foo", 1)
+	if v, ok := mock.Get("foo"); !ok || v != 1 {
+		t.Errorf("expected %d to be %d", v, 1)
+	}
+	if _, ok := mock.Get("bar"); ok {
+		t.Errorf("expected bar to be missing")
+	}
+
+	v, err := mock.Fetch("bar", func() (int, error) { return 2, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 2 {
+		t.Errorf("expected %d to be %d", v, 2)
+	}
+	if v, _ := mock.Fetch("bar", func() (int, error) { return 3, nil }); v != 2 {
+		t.Errorf("expected %d to be %d", v, 2)
+	}
+
+	mock.AssertCalls(t,
+		Call[string, int]{Op: OpSet, Key: "foo", Value: 1},
+		Call[string, int]{Op: OpGet, Key: "foo", Value: 1, Hit: true},
+		Call[string, int]{Op: OpGet, Key: "bar"},
+		Call[string, int]{Op: OpFetch, Key: "bar", Value: 2},
+		Call[string, int]{Op: OpFetch, Key: "bar", Value: 2, Hit: true},
+	)
+	mock.AssertCalled(t, OpFetch, "bar")
+	mock.AssertNotCalled(t, OpSet, "bar")
+}
+
+func TestCache_scripted(t *testing.T) {
+	t.Parallel()
+
+	mock := New[string, int]()
+	defer mock.Stop()
+
+	oops := errors.New("oops")
+	mock.ReturnGet("foo", 5, true)
+	mock.ReturnFetch("bar", 0, oops)
+
+	if v, ok := mock.Get("foo"); !ok || v != 5 {
+		t.Errorf("expected %d to be %d", v, 5)
+	}
+	if v, _ := mock.Fetch("foo", func() (int, error) {
+		t.Errorf("should not have been called")
+		return 0, nil
+	}); v != 5 {
+		t.Errorf("expected %d to be %d", v, 5)
+	}
+	if _, err := mock.Fetch("bar", func() (int, error) {
+		t.Errorf("should not have been called")
+		return 0, nil
+	}); err != oops {
+		t.Errorf("expected %v to be %v", err, oops)
+	}
+}
+
+func TestCache_loaderError(t *testing.T) {
+	t.Parallel()
+
+	mock := New[string, int]()
+	defer mock.Stop()
+
+	oops := errors.New("oops")
+	_, err := mock.Fetch("foo", func() (int, error) { return 0, oops })
+
+	var lerr *cache.LoaderError
+	if !errors.As(err, &lerr) {
+		t.Fatalf("expected %v to be a loader error", err)
+	}
+	mock.AssertCalls(t, Call[string, int]{Op: OpFetch, Key: "foo", Err: oops})
+	if _, ok := mock.Get("foo"); ok {
+		t.Errorf("expected foo to be missing")
+	}
+}
+
+func TestCache_Stop(t *testing.T) {
+	t.Parallel()
+
+	mock := New[string, int]()
+	mock.Stop()
+
+	if got, want := mock.Calls(), []Call[string, int]{{Op: OpStop}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+
+	defer func() {
+		if got, want := recover(), cache.ErrStopped; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	}()
+	mock.Get("foo")
+	t.Errorf("did not panic")
+}
+
+func TestCache_Reset(t *testing.T) {
+	t.Parallel()
+
+	mock := New[string, int]()
+	defer mock.Stop()
+
+	mock.Set("foo", 1)
+	mock.Reset()
+
+	if got := mock.Calls(); len(got) != 0 {
+		t.Errorf("expected %v to be empty", got)
+	}
+	if v, _ := mock.Get("foo"); v != 1 {
+		t.Errorf("expected %d to be %d", v, 1)
+	}
+}
+
+func TestCache_assertions(t *testing.T) {
+	t.Parallel()
+
+	mock := New[string, int]()
+	defer mock.Stop()
+
+	mock.Set("foo", 1)
+	mock.Get("bar")
+
+	var tb recordingTB
+	mock.AssertCalls(&tb,
+		Call[string, int]{Op: OpSet, Key: "foo", Value: 2},
+	)
+	mock.AssertCalled(&tb, OpGet, "foo")
+	mock.AssertNotCalled(&tb, OpGet, "bar")
+
+	if got, want := tb.errors, []string{
+		"expected call 0 to be set(foo, 2), got set(foo, 1)",
+		"unexpected call 1: get(bar) = 0, hit=false, err=<nil>",
+		"expected get(foo) to be called",
+		"expected get(bar) to not be called, called 1 times",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+// Package cacheset implements bounded sets for deduplication, built on the
+// eviction policies in the cache package. A set remembers which keys it has
+// seen recently, forgetting keys according to the underlying policy:
+//
+//	seen := cacheset.NewLRU[string](10_000)
+//
+//	if seen.Add(messageID) {
+//	  // First time seeing this message, process it.
+//	}
+package cacheset
+
+import (
+	"time"
+
+	"github.com/sethvargo/go-cache"
+)
+
+// Set is a bounded set of keys backed by a cache. It is safe for concurrent use
+// if the underlying cache is.
+//
+// K is the key and must be a comparable.
+type Set[K comparable] struct {
+	// cache is the underlying cache. The values are zero-sized, so only the
+	// keys and the policy's bookkeeping consume memory.
+	cache cache.Cache[K, struct{}]
+}
+
+// New creates a new set backed by the given cache.
+func New[K comparable](c cache.Cache[K, struct{}]) *Set[K] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	return &Set[K]{
+		cache: c,
+	}
+}
+
+// NewLRU creates a new set which holds up to capacity keys, forgetting the
+// least recently added or checked key when full.
+func NewLRU[K comparable](capacity int64) *Set[K] {
+	return New[K](cache.NewLRU[K, struct{}](capacity))
+}
+
+// NewTTL creates a new set which forgets keys the given duration after they
+// were added.
+func NewTTL[K comparable](ttl time.Duration) *Set[K] {
+	return New[K](cache.NewTTL[K, struct{}](ttl))
+}
+
+// Add adds the key to the set and reports whether it was newly added. If
+// several goroutines add the same key concurrently, exactly one of them
+// observes true, provided the underlying cache deduplicates concurrent Fetch
+// calls, as the caches in the cache package do.
+func (s *Set[K]) Add(key K) bool {
+	added := false
+	s.cache.Fetch(key, func() (struct{}, error) {
+		added = true
+		return struct{}{}, nil
+	})
+	return added
+}
+
+// Contains reports whether the key is in the set. For policies which track
+// recency, such as LRU, it counts as a use of the key.
+func (s *Set[K]) Contains(key K) bool {
+	_, ok := s.cache.Get(key)
+	return ok
+}
+
+// Len returns the number of keys in the set. If the underlying cache does not
+// report its length, it returns 0.
+func (s *Set[K]) Len() int {
+	if l, ok := s.cache.(interface{ Len() int }); ok {
+		return l.Len()
+	}
+	return 0
+}
+
+// Stop stops the underlying cache.
+func (s *Set[K]) Stop() {
+	s.cache.Stop()
+}
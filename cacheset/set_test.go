@@ -0,0 +1,109 @@
+package cacheset
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-cache"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "cache cannot be nil"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	New[string](nil)
+	t.Errorf("did not panic")
+}
+
+func TestSet_Add(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reports_new", func(t *testing.T) {
+		t.Parallel()
+
+		set := NewLRU[string](10)
+		defer set.Stop()
+
+		if !set.Add("foo") {
+			t.Errorf("expected foo to be new")
+		}
+		if set.Add("foo") {
+			t.Errorf("expected foo to not be new")
+		}
+		if !set.Contains("foo") {
+			t.Errorf("expected foo to be in the set")
+		}
+		if set.Contains("bar") {
+			t.Errorf("expected bar to not be in the set")
+		}
+		if got, want := set.Len(), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("evicts", func(t *testing.T) {
+		t.Parallel()
+
+		set := NewLRU[int](2)
+		defer set.Stop()
+
+		set.Add(1)
+		set.Add(2)
+		set.Add(3)
+
+		if set.Contains(1) {
+			t.Errorf("expected 1 to be evicted")
+		}
+		if !set.Add(1) {
+			t.Errorf("expected 1 to be new again")
+		}
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		t.Parallel()
+
+		set := NewTTL[string](10 * time.Millisecond)
+		defer set.Stop()
+
+		set.Add("foo")
+		time.Sleep(20 * time.Millisecond)
+
+		if !set.Add("foo") {
+			t.Errorf("expected foo to be new again")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		set := New[int](cache.NewLRU[int, struct{}](100))
+		defer set.Stop()
+
+		var added int32
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 50; k++ {
+					if set.Add(k) {
+						atomic.AddInt32(&added, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&added), int32(50); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+// Package cachesize estimates the memory consumed by values, for bounding
+// caches by bytes rather than by number of entries:
+//
+//	size := cachesize.Of(user)
+//
+// Sizes are estimates: they include the memory a value references through
+// pointers, strings, slices, maps, interfaces, and channels, but not allocator
+// overhead, padding in the runtime's internal map structures, or memory shared
+// with values outside the one being measured.
+package cachesize
+
+import (
+	"reflect"
+)
+
+// mapEntryOverhead is the estimated per-entry overhead of a map beyond its keys
+// and values, for the hash table's buckets and metadata.
+const mapEntryOverhead = 8
+
+// mapOverhead is the estimated fixed overhead of a non-nil map.
+const mapOverhead = 48
+
+// Of returns the estimated number of bytes consumed by v, including the memory
+// it references. Memory reachable through more than one path, including cycles,
+// is counted once. Functions and unsafe pointers count only their own size. It
+// returns 0 for nil.
+func Of(v any) int64 {
+	if v == nil {
+		return 0
+	}
+
+	rv := reflect.ValueOf(v)
+	s := &sizer{seen: make(map[visit]struct{})}
+	return int64(rv.Type().Size()) + s.referenced(rv)
+}
+
+// visit identifies memory which has been counted. The type is included since a
+// pointer to a struct and a pointer to its first field share an address.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// sizer walks values, tracking the memory it has already counted.
+type sizer struct {
+	seen map[visit]struct{}
+}
+
+// first reports whether the memory at ptr of the given type has not been
+// counted yet, marking it as counted.
+func (s *sizer) first(ptr uintptr, typ reflect.Type) bool {
+	key := visit{ptr, typ}
+	if _, ok := s.seen[key]; ok {
+		return false
+	}
+	s.seen[key] = struct{}{}
+	return true
+}
+
+// referenced returns the number of bytes referenced by v, excluding the size of
+// v itself, which is accounted for by its container.
+func (s *sizer) referenced(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || !s.first(v.Pointer(), v.Type()) {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + s.referenced(elem)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + s.referenced(elem)
+
+	case reflect.String:
+		return int64(v.Len())
+
+	case reflect.Slice:
+		if v.IsNil() || !s.first(v.Pointer(), v.Type()) {
+			return 0
+		}
+		n := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			n += s.referenced(v.Index(i))
+		}
+		return n
+
+	case reflect.Array:
+		var n int64
+		for i := 0; i < v.Len(); i++ {
+			n += s.referenced(v.Index(i))
+		}
+		return n
+
+	case reflect.Struct:
+		var n int64
+		for i := 0; i < v.NumField(); i++ {
+			n += s.referenced(v.Field(i))
+		}
+		return n
+
+	case reflect.Map:
+		if v.IsNil() || !s.first(v.Pointer(), v.Type()) {
+			return 0
+		}
+		typ := v.Type()
+		entry := int64(typ.Key().Size()) + int64(typ.Elem().Size()) + mapEntryOverhead
+		n := mapOverhead + int64(v.Len())*entry
+
+		iter := v.MapRange()
+		for iter.Next() {
+			n += s.referenced(iter.Key())
+			n += s.referenced(iter.Value())
+		}
+		return n
+
+	case reflect.Chan:
+		if v.IsNil() || !s.first(v.Pointer(), v.Type()) {
+			return 0
+		}
+		return int64(v.Cap()) * int64(v.Type().Elem().Size())
+
+	default:
+		// Scalars are counted by their container, and functions and unsafe
+		// pointers are opaque.
+		return 0
+	}
+}
@@ -0,0 +1,58 @@
+package cachesize
+
+import (
+	"testing"
+)
+
+type sizeTestNode struct {
+	Name string
+	Next *sizeTestNode
+}
+
+type sizeTestPrivate struct {
+	name string
+	tags []string
+}
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+
+	str := "hello"
+	shared := []byte("abcd")
+
+	loop := &sizeTestNode{Name: "a"}
+	loop.Next = loop
+
+	cases := []struct {
+		name string
+		v    any
+		want int64
+	}{
+		{"nil", nil, 0},
+		{"int", 5, 8},
+		{"string", "hello", 16 + 5},
+		{"pointer", &str, 8 + 16 + 5},
+		{"slice", []int64{1, 2, 3}, 24 + 3*8},
+		{"slice_cap", make([]int64, 1, 4), 24 + 4*8},
+		{"string_slice", []string{"ab", "cde"}, 24 + 2*16 + 2 + 3},
+		{"array", [2]string{"ab", "c"}, 2*16 + 2 + 1},
+		{"struct", sizeTestPrivate{name: "ab", tags: []string{"c"}}, 16 + 24 + 2 + 16 + 1},
+		{"map", map[string]int64{"ab": 1}, 8 + mapOverhead + (16 + 8 + mapEntryOverhead) + 2},
+		{"interface_slice", []any{int64(1)}, 24 + 16 + 8},
+		{"cycle", loop, 8 + 16 + 8 + 1},
+		{"shared", [][]byte{shared, shared}, 24 + 2*24 + 4},
+		{"func", func() {}, 8},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, want := Of(tc.v), tc.want; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+		})
+	}
+}
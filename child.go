@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Ensure implements.
+var (
+	_ Cache[string, string] = (*Child[string, string])(nil)
+	_ Deleter[string]       = (*Child[string, string])(nil)
+)
+
+// Child is a small overlay cache layered over a parent cache, for request- or
+// transaction-scoped caching over a shared process cache. Gets fall back to the
+// parent, but Sets and Deletes stay in the child until they are applied to the
+// parent with Promote or dropped with Discard. Child is safe for concurrent use
+// if the parent is.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Child[K comparable, V any] struct {
+	// parent is the cache the child is layered over.
+	parent Cache[K, V]
+
+	// writes are the local writes by key, and order is the order in which keys
+	// were first written, so writes are promoted in the order they were made.
+	writes map[K]txnWrite[V]
+	order  []K
+
+	// stopped indicates whether the child is stopped.
+	stopped uint32
+
+	// lock guards the local writes.
+	lock sync.RWMutex
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// NewChild creates a new child cache layered over the given parent.
+func NewChild[K comparable, V any](parent Cache[K, V]) *Child[K, V] {
+	if parent == nil {
+		panic("parent cannot be nil")
+	}
+
+	return &Child[K, V]{
+		parent: parent,
+	}
+}
+
+// Child creates a new child cache layered over the sync cache. It is
+// equivalent to calling NewChild(s). Promote applies the child's writes in a
+// single transaction.
+func (s *Sync[K, V]) Child() *Child[K, V] {
+	return NewChild[K, V](s)
+}
+
+// Get fetches the cache item at the given key from the child's writes if it has
+// been written in the child, or from the parent otherwise.
+func (c *Child[K, V]) Get(key K) (V, bool) {
+	c.lock.RLock()
+	w, ok := c.writes[key]
+	stopped := c.isStopped()
+	c.lock.RUnlock()
+
+	if stopped {
+		panic(ErrStopped)
+	}
+
+	if ok {
+		if w.deleted {
+			var zeroV V
+			return zeroV, false
+		}
+		return w.value, true
+	}
+	return c.parent.Get(key)
+}
+
+// Set inserts the value in the child. It is not visible in the parent until the
+// child is promoted.
+func (c *Child[K, V]) Set(key K, val V) {
+	c.write(key, txnWrite[V]{value: val})
+}
+
+// Delete removes the entry at the given key from the child, hiding any value in
+// the parent. The parent's value is not removed until the child is promoted.
+// It returns false if there was no entry at the given key. It panics if the
+// parent does not implement Deleter.
+func (c *Child[K, V]) Delete(key K) bool {
+	if _, ok := c.parent.(Deleter[K]); !ok {
+		panic("cache does not support delete")
+	}
+
+	_, ok := c.Get(key)
+	c.write(key, txnWrite[V]{deleted: true})
+	return ok
+}
+
+// Fetch retrieves the cached value from the child or the parent. If the value
+// does not exist in either, the FetchFunc is called and the result is stored in
+// the child. Concurrent calls to Fetch for the same key share a single
+// invocation.
+func (c *Child[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+
+	return c.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := c.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		c.Set(key, v)
+		return v, nil
+	})
+}
+
+// Promote applies the child's writes to the parent, in the order they were
+// made, and clears them from the child. If the parent is a Sync cache, the
+// writes are applied in a single transaction, so no other user of the parent
+// observes a partial set of writes.
+func (c *Child[K, V]) Promote() {
+	writes, order := c.take()
+	if len(order) == 0 {
+		return
+	}
+
+	if s, ok := c.parent.(*Sync[K, V]); ok {
+		_ = s.Txn(func(tx Txn[K, V]) error {
+			for _, key := range order {
+				if w := writes[key]; w.deleted {
+					tx.Delete(key)
+				} else {
+					tx.Set(key, w.value)
+				}
+			}
+			return nil
+		})
+		return
+	}
+
+	for _, key := range order {
+		if w := writes[key]; w.deleted {
+			c.parent.(Deleter[K]).Delete(key)
+		} else {
+			c.parent.Set(key, w.value)
+		}
+	}
+}
+
+// Discard drops the child's writes without applying them to the parent.
+func (c *Child[K, V]) Discard() {
+	c.take()
+}
+
+// Len returns the number of keys written in the child.
+func (c *Child[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.writes)
+}
+
+// Stop discards the child's writes and prevents further use of the child. It
+// does not stop the parent.
+func (c *Child[K, V]) Stop() {
+	c.loads.close()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	atomic.StoreUint32(&c.stopped, 1)
+	c.writes = nil
+	c.order = nil
+}
+
+// write records the given write, replacing any earlier write to the key.
+func (c *Child[K, V]) write(key K, w txnWrite[V]) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	if c.writes == nil {
+		c.writes = make(map[K]txnWrite[V])
+	}
+	if _, ok := c.writes[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.writes[key] = w
+}
+
+// take removes and returns the child's writes.
+func (c *Child[K, V]) take() (map[K]txnWrite[V], []K) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	writes, order := c.writes, c.order
+	c.writes = nil
+	c.order = nil
+	return writes, order
+}
+
+// isStopped is a helper for checking if the child is stopped.
+func (c *Child[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&c.stopped) == 1
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestChild(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		parent interface {
+			Cache[string, int]
+			Deleter[string]
+		}
+	}{
+		{"lru", NewLRU[string, int](10)},
+		{"sync", NewSyncLRU[string, int](10)},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			parent := tc.parent
+			defer parent.Stop()
+
+			parent.Set("foo", 1)
+			parent.Set("bar", 2)
+
+			child := NewChild[string, int](parent)
+			defer child.Stop()
+
+			// Gets fall back to the parent.
+			if v, _ := child.Get("foo"); v != 1 {
+				t.Errorf("expected %d to be %d", v, 1)
+			}
+
+			child.Set("foo", 10)
+			child.Set("baz", 30)
+			if !child.Delete("bar") {
+				t.Errorf("expected bar to exist")
+			}
+
+			if v, _ := child.Get("foo"); v != 10 {
+				t.Errorf("expected %d to be %d", v, 10)
+			}
+			if _, ok := child.Get("bar"); ok {
+				t.Errorf("expected bar to be hidden")
+			}
+			if v, _ := parent.Get("foo"); v != 1 {
+				t.Errorf("expected %d to be %d", v, 1)
+			}
+			if _, ok := parent.Get("bar"); !ok {
+				t.Errorf("expected bar to remain in parent")
+			}
+
+			child.Promote()
+			if got, want := child.Len(), 0; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if v, _ := parent.Get("foo"); v != 10 {
+				t.Errorf("expected %d to be %d", v, 10)
+			}
+			if v, _ := parent.Get("baz"); v != 30 {
+				t.Errorf("expected %d to be %d", v, 30)
+			}
+			if _, ok := parent.Get("bar"); ok {
+				t.Errorf("expected bar to be deleted from parent")
+			}
+		})
+	}
+}
+
+func TestChild_Discard(t *testing.T) {
+	t.Parallel()
+
+	parent := NewSyncLRU[string, int](10)
+	defer parent.Stop()
+
+	child := parent.Child()
+
+	v, err := child.Fetch("foo", func() (int, error) {
+		return 5, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 5 {
+		t.Errorf("expected %d to be %d", v, 5)
+	}
+	if _, ok := parent.Get("foo"); ok {
+		t.Errorf("expected fetched value to stay in child")
+	}
+
+	child.Discard()
+	if _, ok := child.Get("foo"); ok {
+		t.Errorf("expected foo to be discarded")
+	}
+
+	child.Stop()
+	if _, ok := parent.Get("foo"); ok {
+		t.Errorf("expected foo to not be promoted")
+	}
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), ErrStopped.Error(); got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+	child.Set("foo", 1)
+	t.Errorf("did not panic")
+}
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"time"
+)
+
+// clockStart is the reference point for the monotonic clock readings returned
+// by nanotime.
+var clockStart = time.Now()
+
+// nanotime returns the current reading of the monotonic clock, in nanoseconds.
+// Unlike the wall clock, it never jumps, so it is suitable for measuring
+// expirations.
+func nanotime() int64 {
+	return monoTime(time.Now())
+}
+
+// monoTime converts the given time to a monotonic clock reading. If t has its
+// own monotonic reading, as times from time.Now do, it is used; otherwise the
+// wall clock is used.
+func monoTime(t time.Time) int64 {
+	return int64(t.Sub(clockStart))
+}
+
+// wallTime converts the given monotonic clock reading to a wall clock time.
+func wallTime(ns int64) time.Time {
+	return clockStart.Add(time.Duration(ns)).Round(0)
+}
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNanotime(t *testing.T) {
+	t.Parallel()
+
+	a := nanotime()
+	time.Sleep(time.Millisecond)
+	b := nanotime()
+
+	if b <= a {
+		t.Errorf("expected %d to be greater than %d", b, a)
+	}
+}
+
+func TestWallTime(t *testing.T) {
+	t.Parallel()
+
+	// Times without a monotonic reading round trip exactly.
+	want := time.Date(2030, 1, 2, 3, 4, 5, 6, time.UTC)
+	if got := wallTime(monoTime(want)); !got.Equal(want) {
+		t.Errorf("expected %s to be %s", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// Codec converts values to and from bytes.
+type Codec[V any] interface {
+	// Marshal encodes the value as bytes.
+	Marshal(V) ([]byte, error)
+
+	// Unmarshal decodes the value from bytes produced by Marshal.
+	Unmarshal([]byte) (V, error)
+}
+
+// Ensure implements.
+var (
+	_ Codec[string] = GobCodec[string]{}
+	_ Codec[string] = JSONCodec[string]{}
+	_ Codec[string] = FuncCodec[string]{}
+)
+
+// codecs is the registry of codecs by type, populated by RegisterCodec.
+var codecs sync.Map // map[reflect.Type]any
+
+// RegisterCodec registers the codec to use for values of type V where a codec
+// is not given explicitly, such as in WriteSnapshot and ReadSnapshot. It is
+// intended for types which gob cannot encode, such as protocol buffers or
+// interface types whose concrete types are not registered with gob. A later
+// registration for the same type replaces the earlier one.
+//
+// RegisterCodec is typically called from an init function.
+func RegisterCodec[V any](codec Codec[V]) {
+	if codec == nil {
+		panic("codec cannot be nil")
+	}
+	codecs.Store(codecType[V](), codec)
+}
+
+// CodecFor returns the codec registered for values of type V with
+// RegisterCodec, or GobCodec if none is registered.
+func CodecFor[V any]() Codec[V] {
+	if codec, ok := codecs.Load(codecType[V]()); ok {
+		return codec.(Codec[V])
+	}
+	return GobCodec[V]{}
+}
+
+// codecType returns the registry key for V. It uses a pointer so that interface
+// types resolve to the interface itself rather than a nil type.
+func codecType[V any]() reflect.Type {
+	return reflect.TypeOf((*V)(nil)).Elem()
+}
+
+// GobCodec is a codec which encodes values using encoding/gob.
+type GobCodec[V any] struct{}
+
+// Marshal encodes the value using gob.
+func (GobCodec[V]) Marshal(v V) ([]byte, error) {
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(&v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Unmarshal decodes the value using gob.
+func (GobCodec[V]) Unmarshal(b []byte) (V, error) {
+	var v V
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// JSONCodec is a codec which encodes values using encoding/json.
+type JSONCodec[V any] struct{}
+
+// Marshal encodes the value as JSON.
+func (JSONCodec[V]) Marshal(v V) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes the value from JSON.
+func (JSONCodec[V]) Unmarshal(b []byte) (V, error) {
+	var v V
+	if err := json.Unmarshal(b, &v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// FuncCodec is a codec which encodes values using the given functions. It is
+// useful for registering the existing marshal functions of a type, such as
+// proto.Marshal, without defining a new type.
+type FuncCodec[V any] struct {
+	MarshalFunc   func(V) ([]byte, error)
+	UnmarshalFunc func([]byte) (V, error)
+}
+
+// Marshal encodes the value using MarshalFunc.
+func (c FuncCodec[V]) Marshal(v V) ([]byte, error) {
+	return c.MarshalFunc(v)
+}
+
+// Unmarshal decodes the value using UnmarshalFunc.
+func (c FuncCodec[V]) Unmarshal(b []byte) (V, error) {
+	return c.UnmarshalFunc(b)
+}
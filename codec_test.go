@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type codecTestValue struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+func TestCodecs(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		codec Codec[codecTestValue]
+	}{
+		{"gob", GobCodec[codecTestValue]{}},
+		{"json", JSONCodec[codecTestValue]{}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			in := codecTestValue{Name: "foo", Count: 5, Tags: []string{"a", "b"}}
+			b, err := tc.codec.Marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := tc.codec.Unmarshal(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(in, out) {
+				t.Errorf("expected %#v to be %#v", out, in)
+			}
+
+			if _, err := tc.codec.Unmarshal([]byte("not valid")); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+// codecTestShape is an interface type which gob cannot encode without
+// registering its concrete types.
+type codecTestShape interface {
+	Area() int
+}
+
+type codecTestSquare struct {
+	Side int
+}
+
+func (s codecTestSquare) Area() int { return s.Side * s.Side }
+
+func init() {
+	RegisterCodec[codecTestShape](FuncCodec[codecTestShape]{
+		MarshalFunc: func(s codecTestShape) ([]byte, error) {
+			return json.Marshal(s.(codecTestSquare))
+		},
+		UnmarshalFunc: func(b []byte) (codecTestShape, error) {
+			var s codecTestSquare
+			err := json.Unmarshal(b, &s)
+			return s, err
+		},
+	})
+}
+
+func TestCodecFor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := CodecFor[codecTestValue]().(GobCodec[codecTestValue]); !ok {
+			t.Errorf("expected %T to be GobCodec", CodecFor[codecTestValue]())
+		}
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		t.Parallel()
+
+		codec := CodecFor[codecTestShape]()
+		if _, ok := codec.(FuncCodec[codecTestShape]); !ok {
+			t.Fatalf("expected %T to be FuncCodec", codec)
+		}
+
+		b, err := codec.Marshal(codecTestSquare{Side: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := codec.Unmarshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := out.Area(), 9; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package cache
+
+// ColdestN returns up to n entries from the cache which are closest to being
+// evicted, starting with the next entry to be evicted. For caches whose entries
+// are not ordered, such as Random, the selection is arbitrary.
+func ColdestN[K comparable, V any](c EntryLister[K, V], n int) []Entry[K, V] {
+	entries := c.Entries()
+	if n < len(entries) {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// HottestN returns up to n entries from the cache which are furthest from being
+// evicted, starting with the most protected entry. For caches whose entries are
+// not ordered, such as Random, the selection is arbitrary.
+func HottestN[K comparable, V any](c EntryLister[K, V], n int) []Entry[K, V] {
+	entries := c.Entries()
+	if n < len(entries) {
+		entries = entries[len(entries)-n:]
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
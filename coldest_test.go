@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func entryKeys[K comparable, V any](entries []Entry[K, V]) []K {
+	keys := make([]K, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
+
+func TestColdestN(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+	cache.Get("foo")
+
+	if got, want := entryKeys(ColdestN[string, int](cache, 2)), []string{"bar", "baz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := entryKeys(ColdestN[string, int](cache, 10)), []string{"bar", "baz", "foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
+
+func TestHottestN(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+	cache.Get("foo")
+
+	if got, want := entryKeys(HottestN[string, int](cache, 2)), []string{"foo", "baz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := entryKeys(HottestN[string, int](cache, 10)), []string{"foo", "baz", "bar"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+)
+
+// Compressor compresses and decompresses bytes. Implementations for algorithms
+// such as snappy or zstd can be provided by wrapping the respective libraries.
+type Compressor interface {
+	// Compress returns the compressed form of the given bytes.
+	Compress([]byte) ([]byte, error)
+
+	// Decompress returns the original bytes from the output of Compress.
+	Decompress([]byte) ([]byte, error)
+}
+
+// Ensure implements.
+var (
+	_ Compressor    = (*FlateCompressor)(nil)
+	_ Codec[string] = (*CompressedCodec[string])(nil)
+)
+
+// FlateCompressor is a compressor which uses compress/flate.
+type FlateCompressor struct {
+	// Level is the flate compression level. The zero value uses
+	// flate.DefaultCompression.
+	Level int
+}
+
+// Compress compresses the bytes using flate.
+func (f *FlateCompressor) Compress(b []byte) ([]byte, error) {
+	level := f.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses the bytes using flate.
+func (f *FlateCompressor) Decompress(b []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(b))
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Headers for values encoded by a CompressedCodec, indicating whether the
+// remaining bytes are compressed.
+const (
+	compressedHeaderRaw        byte = 0
+	compressedHeaderCompressed byte = 1
+)
+
+// CompressedCodec wraps a codec, compressing encoded values which are at least
+// the configured threshold in size. Smaller values are stored uncompressed,
+// since compression overhead would outweigh any savings.
+type CompressedCodec[V any] struct {
+	codec      Codec[V]
+	compressor Compressor
+	threshold  int
+}
+
+// NewCompressedCodec wraps the given codec, using compressor to compress
+// encoded values of at least threshold bytes.
+func NewCompressedCodec[V any](codec Codec[V], compressor Compressor, threshold int) *CompressedCodec[V] {
+	if codec == nil {
+		panic("codec cannot be nil")
+	}
+	if compressor == nil {
+		panic("compressor cannot be nil")
+	}
+	if threshold < 0 {
+		panic("threshold cannot be negative")
+	}
+
+	return &CompressedCodec[V]{
+		codec:      codec,
+		compressor: compressor,
+		threshold:  threshold,
+	}
+}
+
+// Marshal encodes the value with the underlying codec, compressing the result
+// if it is at least the threshold in size.
+func (c *CompressedCodec[V]) Marshal(v V) ([]byte, error) {
+	b, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < c.threshold {
+		return append([]byte{compressedHeaderRaw}, b...), nil
+	}
+
+	compressed, err := c.compressor.Compress(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
+	}
+	return append([]byte{compressedHeaderCompressed}, compressed...), nil
+}
+
+// Unmarshal decompresses the bytes if needed and decodes the value with the
+// underlying codec.
+func (c *CompressedCodec[V]) Unmarshal(b []byte) (V, error) {
+	if len(b) == 0 {
+		var zeroV V
+		return zeroV, fmt.Errorf("missing compression header")
+	}
+
+	switch b[0] {
+	case compressedHeaderRaw:
+		return c.codec.Unmarshal(b[1:])
+	case compressedHeaderCompressed:
+		decompressed, err := c.compressor.Decompress(b[1:])
+		if err != nil {
+			var zeroV V
+			return zeroV, fmt.Errorf("failed to decompress: %w", err)
+		}
+		return c.codec.Unmarshal(decompressed)
+	default:
+		var zeroV V
+		return zeroV, fmt.Errorf("unknown compression header %d", b[0])
+	}
+}
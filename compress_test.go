@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompressedCodec(t *testing.T) {
+	t.Parallel()
+
+	codec := NewCompressedCodec[string](JSONCodec[string]{}, &FlateCompressor{}, 64)
+
+	t.Run("small_is_raw", func(t *testing.T) {
+		t.Parallel()
+
+		b, err := codec.Marshal("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := b[0], compressedHeaderRaw; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		v, err := codec.Unmarshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, "foo"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("large_is_compressed", func(t *testing.T) {
+		t.Parallel()
+
+		in := strings.Repeat("foo", 1000)
+
+		b, err := codec.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := b[0], compressedHeaderCompressed; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if len(b) >= len(in) {
+			t.Errorf("expected %d to be less than %d", len(b), len(in))
+		}
+
+		v, err := codec.Unmarshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, in; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("invalid_header", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := codec.Unmarshal(nil); err == nil {
+			t.Errorf("expected error")
+		}
+		if _, err := codec.Unmarshal([]byte{9, 'a'}); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, string]().LRU(10).Codec(codec).Build()
+		defer cache.Stop()
+
+		in := strings.Repeat("bar", 1000)
+		cache.Set("foo", in)
+		if v, _ := cache.Get("foo"); v != in {
+			t.Errorf("expected %q to be %q", v, in)
+		}
+	})
+}
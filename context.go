@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*contextCache[string, string])(nil)
+
+// contextKey is the key for a cache in a context. It is parameterized by the
+// cache's types, so caches of different types can be attached to the same
+// context.
+type contextKey[K comparable, V any] struct{}
+
+// NewContext returns a copy of the parent context which carries the given
+// cache, for request-local caching which squashes duplicate lookups within a
+// single request:
+//
+//	ctx = cache.NewContext[string, *User](r.Context(), cache.NewSyncLRU[string, *User](64))
+//
+// The cache is stopped when the context is done, so it is torn down when the
+// request ends. The cache returned by FromContext remains safe to use after
+// that: Get reports every key as missing, Set discards the value, and Fetch
+// calls the FetchFunc without storing the result. Operations which are in
+// progress when the context is done finish before the cache is stopped. If the
+// context can never be done, the cache is only stopped by calling its Stop. The
+// cache should not be used other than through FromContext.
+func NewContext[K comparable, V any](ctx context.Context, c Cache[K, V]) context.Context {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	cc := &contextCache[K, V]{
+		cache:  c,
+		stopCh: make(chan struct{}),
+	}
+	if done := ctx.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				cc.Stop()
+			case <-cc.stopCh:
+			}
+		}()
+	}
+	return context.WithValue(ctx, contextKey[K, V]{}, Cache[K, V](cc))
+}
+
+// FromContext returns the cache carried by the context, if any. The second
+// return value is false if the context does not carry a cache of the given
+// types.
+func FromContext[K comparable, V any](ctx context.Context) (Cache[K, V], bool) {
+	c, ok := ctx.Value(contextKey[K, V]{}).(Cache[K, V])
+	return c, ok
+}
+
+// contextCache wraps the cache attached to a context, so that it can be
+// stopped when the context is done without panicking callers which still hold
+// it.
+type contextCache[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// stopped indicates whether the cache has been torn down, and active counts
+	// the operations in progress on the underlying cache. They are guarded by
+	// lock, so no operation starts once stopped is set.
+	stopped bool
+	active  sync.WaitGroup
+	lock    sync.Mutex
+
+	// stopCh is closed when the cache is stopped, to end the goroutine waiting
+	// for the context.
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// Get fetches the cache item at the given key from the underlying cache. Once
+// the cache is stopped, it returns false.
+func (c *contextCache[K, V]) Get(key K) (V, bool) {
+	if !c.acquire() {
+		var zeroV V
+		return zeroV, false
+	}
+	defer c.active.Done()
+
+	return c.cache.Get(key)
+}
+
+// Set inserts the value in the underlying cache. Once the cache is stopped, the
+// value is discarded.
+func (c *contextCache[K, V]) Set(key K, val V) {
+	if !c.acquire() {
+		return
+	}
+	defer c.active.Done()
+
+	c.cache.Set(key, val)
+}
+
+// Fetch retrieves the cached value from the underlying cache. Once the cache is
+// stopped, the FetchFunc is called and the result is returned without being
+// stored.
+func (c *contextCache[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if !c.acquire() {
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+		return v, nil
+	}
+	defer c.active.Done()
+
+	return c.cache.Fetch(key, fn)
+}
+
+// Stop stops the underlying cache once the operations in progress finish. It
+// is safe to call more than once.
+func (c *contextCache[K, V]) Stop() {
+	c.stopOnce.Do(func() {
+		c.lock.Lock()
+		c.stopped = true
+		c.lock.Unlock()
+		close(c.stopCh)
+
+		c.active.Wait()
+		c.cache.Stop()
+	})
+}
+
+// acquire registers an operation on the underlying cache. It returns false if
+// the cache is stopped, in which case the operation must not use the
+// underlying cache. Otherwise, the caller must call active.Done when finished.
+func (c *contextCache[K, V]) acquire() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		return false
+	}
+	c.active.Add(1)
+	return true
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("from_context", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		c := NewLRU[string, int](10)
+		ctx = NewContext[string, int](ctx, c)
+
+		got, ok := FromContext[string, int](ctx)
+		if !ok {
+			t.Fatal("expected cache in context")
+		}
+		got.Set("foo", 5)
+		if v, ok := c.Get("foo"); !ok || v != 5 {
+			t.Errorf("expected %v to be %v", v, 5)
+		}
+
+		// Caches of other types are not found.
+		if _, ok := FromContext[string, string](ctx); ok {
+			t.Errorf("expected no cache in context")
+		}
+		if _, ok := FromContext[string, int](context.Background()); ok {
+			t.Errorf("expected no cache in context")
+		}
+	})
+
+	t.Run("teardown", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		c := NewTTL[string, int](time.Minute)
+		ctx = NewContext[string, int](ctx, c)
+
+		got, ok := FromContext[string, int](ctx)
+		if !ok {
+			t.Fatal("expected cache in context")
+		}
+		got.Set("foo", 5)
+
+		cancel()
+		deadline := time.Now().Add(time.Second)
+		for !c.isStopped() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for cache to be stopped")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		// The cache degrades to misses instead of panicking.
+		got.Set("bar", 6)
+		if _, ok := got.Get("foo"); ok {
+			t.Errorf("expected foo to be missing")
+		}
+		v, err := got.Fetch("foo", func() (int, error) {
+			return 7, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != 7 {
+			t.Errorf("expected %v to be %v", v, 7)
+		}
+		got.Stop()
+	})
+
+	t.Run("in_progress", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		c := NewLRU[string, int](10)
+		ctx = NewContext[string, int](ctx, c)
+		got, _ := FromContext[string, int](ctx)
+
+		// A Fetch in progress when the context is done finishes before the
+		// cache is stopped.
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if _, err := got.Fetch("foo", func() (int, error) {
+				close(started)
+				<-release
+				return 5, nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+		<-started
+
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		if c.isStopped() {
+			t.Errorf("expected cache not to be stopped during Fetch")
+		}
+
+		close(release)
+		<-done
+
+		deadline := time.Now().Add(time.Second)
+		for !c.isStopped() {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for cache to be stopped")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected panic")
+			}
+		}()
+		NewContext[string, int](context.Background(), nil)
+	})
+}
@@ -0,0 +1,71 @@
+package cache
+
+// Ensure implements.
+var _ Cache[string, string] = (*Copying[string, string])(nil)
+
+// Copying wraps a cache so that values are copied on the way in and on the way
+// out. Callers can freely mutate values they pass to Set or receive from Get
+// without affecting the cached value or other callers. This is useful for
+// values like slices, maps, or pointers to structs.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Copying[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// copier returns a deep copy of the given value.
+	copier func(V) V
+}
+
+// NewCopying wraps the given cache, using copier to copy values whenever they
+// are stored or returned. The copier must return a copy which shares no
+// mutable state with its input.
+func NewCopying[K comparable, V any](c Cache[K, V], copier func(V) V) *Copying[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if copier == nil {
+		panic("copier cannot be nil")
+	}
+
+	return &Copying[K, V]{
+		cache:  c,
+		copier: copier,
+	}
+}
+
+// Get fetches a copy of the cache item at the given key.
+func (c *Copying[K, V]) Get(key K) (V, bool) {
+	v, ok := c.cache.Get(key)
+	if !ok {
+		return v, false
+	}
+	return c.copier(v), true
+}
+
+// Set inserts a copy of the value in the cache.
+func (c *Copying[K, V]) Set(key K, val V) {
+	c.cache.Set(key, c.copier(val))
+}
+
+// Fetch retrieves a copy of the cached value. If the value does not exist, the
+// FetchFunc is called and a copy of the result is stored.
+func (c *Copying[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	v, err := c.cache.Fetch(key, func() (V, error) {
+		v, err := fn()
+		if err != nil {
+			return v, err
+		}
+		return c.copier(v), nil
+	})
+	if err != nil {
+		return v, err
+	}
+	return c.copier(v), nil
+}
+
+// Stop stops the underlying cache.
+func (c *Copying[K, V]) Stop() {
+	c.cache.Stop()
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func copyInts(v []int) []int {
+	return append([]int(nil), v...)
+}
+
+func TestNewCopying(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "copier cannot be nil"; got != want {
+			t.Errorf("expected %q to contain %q", got, want)
+		}
+	}()
+
+	NewCopying[string, []int](NewLRU[string, []int](10), nil)
+	t.Errorf("did not panic")
+}
+
+func TestCopying_Get(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCopying[string, []int](NewLRU[string, []int](10), copyInts)
+	defer cache.Stop()
+
+	in := []int{1, 2, 3}
+	cache.Set("foo", in)
+	in[0] = 100
+
+	out, _ := cache.Get("foo")
+	if got, want := out[0], 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	out[1] = 200
+
+	out, _ = cache.Get("foo")
+	if got, want := out[1], 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	if v, ok := cache.Get("bar"); ok {
+		t.Errorf("expected not found, got %#v", v)
+	}
+}
+
+func TestCopying_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("copies", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, []int]().LRU(10).Copier(copyInts).Build()
+		defer cache.Stop()
+
+		loaded := []int{1, 2, 3}
+		out, err := cache.Fetch("foo", func() ([]int, error) {
+			return loaded, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded[0] = 100
+		out[1] = 200
+
+		out, _ = cache.Get("foo")
+		if got, want := fmt.Sprint(out), "[1 2 3]"; got != want {
+			t.Errorf("expected %s to be %s", got, want)
+		}
+	})
+
+	t.Run("returns_error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewCopying[string, []int](NewLRU[string, []int](10), copyInts)
+		defer cache.Stop()
+
+		if _, err := cache.Fetch("foo", func() ([]int, error) {
+			return nil, fmt.Errorf("error")
+		}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
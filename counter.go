@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"time"
+)
+
+// Counter counts events per key over a sliding time window. The window is
+// divided into a fixed number of buckets, and counts older than the window are
+// discarded a bucket at a time, so the count is accurate to within one bucket's
+// duration. More buckets give a smoother window at the cost of memory.
+//
+// Counts are stored in a TTL cache, so keys which see no increments for a full
+// window are removed automatically.
+//
+// K is the counter key and must be a comparable.
+type Counter[K comparable] struct {
+	// windows are the per-key windows, which expire once they have been idle for
+	// a full window.
+	windows *TTL[K, *counterWindow]
+
+	// window is the duration of the sliding window and bucket is the duration of
+	// each bucket within it.
+	window time.Duration
+	bucket time.Duration
+}
+
+// counterWindow is the ring of buckets for a single key.
+type counterWindow struct {
+	// counts are the counts for each bucket and epochs are the absolute bucket
+	// number each slot currently holds, so stale slots can be detected.
+	counts []int64
+	epochs []int64
+}
+
+// NewCounter creates a new sliding-window counter over the given window,
+// divided into the given number of buckets.
+func NewCounter[K comparable](window time.Duration, buckets int) *Counter[K] {
+	if window <= 0 {
+		panic("window must be greater than 0")
+	}
+	if buckets <= 0 {
+		panic("buckets must be greater than 0")
+	}
+
+	bucket := window / time.Duration(buckets)
+	if bucket <= 0 {
+		panic("window must be at least one nanosecond per bucket")
+	}
+
+	return &Counter[K]{
+		windows: NewTTL[K, *counterWindow](window),
+		window:  window,
+		bucket:  bucket,
+	}
+}
+
+// Incr adds delta to the count for the given key and returns the count over
+// the current window, including delta. A negative delta decrements the count.
+func (c *Counter[K]) Incr(key K, delta int64) int64 {
+	now := time.Now()
+	epoch := monoTime(now) / int64(c.bucket)
+
+	c.windows.lock.Lock()
+	defer c.windows.lock.Unlock()
+
+	w, ok := c.windows.get(key, now)
+	if !ok {
+		n := int(c.window / c.bucket)
+		w = &counterWindow{
+			counts: make([]int64, n),
+			epochs: make([]int64, n),
+		}
+	}
+	w.add(epoch, delta)
+
+	// Every increment extends the key's lifetime by a full window, since its
+	// counts remain relevant until then.
+	c.windows.set(key, w, now, monoTime(now)+int64(c.window))
+	return w.sum(epoch)
+}
+
+// Count returns the count for the given key over the current window. It
+// returns 0 if the key has not been incremented within the window.
+func (c *Counter[K]) Count(key K) int64 {
+	now := time.Now()
+	epoch := monoTime(now) / int64(c.bucket)
+
+	c.windows.lock.RLock()
+	defer c.windows.lock.RUnlock()
+
+	w, ok := c.windows.get(key, now)
+	if !ok {
+		return 0
+	}
+	return w.sum(epoch)
+}
+
+// Reset removes the count for the given key.
+func (c *Counter[K]) Reset(key K) {
+	c.windows.lock.Lock()
+	defer c.windows.lock.Unlock()
+
+	if c.windows.isStopped() {
+		panic(ErrStopped)
+	}
+	if node, ok := c.windows.cache[key]; ok {
+		c.windows.remove(node)
+	}
+}
+
+// Len returns the number of keys with counts in the current window. Keys which
+// have expired but not yet been swept may be included.
+func (c *Counter[K]) Len() int {
+	return c.windows.Len()
+}
+
+// Stop clears the counter and stops its background sweeping.
+func (c *Counter[K]) Stop() {
+	c.windows.Stop()
+}
+
+// add adds delta to the bucket for the given epoch, clearing the slot first if
+// it holds an older bucket.
+func (w *counterWindow) add(epoch, delta int64) {
+	i := int(epoch % int64(len(w.counts)))
+	if w.epochs[i] != epoch {
+		w.epochs[i] = epoch
+		w.counts[i] = 0
+	}
+	w.counts[i] += delta
+}
+
+// sum returns the total of the buckets which fall within the window ending at
+// the given epoch.
+func (w *counterWindow) sum(epoch int64) int64 {
+	oldest := epoch - int64(len(w.counts))
+
+	var total int64
+	for i, e := range w.epochs {
+		if e > oldest && e <= epoch {
+			total += w.counts[i]
+		}
+	}
+	return total
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewCounter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		counter := NewCounter[string](time.Minute, 6)
+		defer counter.Stop()
+
+		if got, want := counter.bucket, 10*time.Second; got != want {
+			t.Errorf("expected %s to be %s", got, want)
+		}
+	})
+
+	t.Run("panic_on_invalid_buckets", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "buckets must be greater than 0"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		NewCounter[string](time.Minute, 0)
+		t.Errorf("did not panic")
+	})
+}
+
+func TestCounter_Incr(t *testing.T) {
+	t.Parallel()
+
+	t.Run("counts", func(t *testing.T) {
+		t.Parallel()
+
+		counter := NewCounter[string](time.Minute, 6)
+		defer counter.Stop()
+
+		if got, want := counter.Incr("foo", 1), int64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := counter.Incr("foo", 2), int64(3); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := counter.Incr("foo", -1), int64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := counter.Count("foo"), int64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := counter.Count("bar"), int64(0); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		t.Parallel()
+
+		counter := NewCounter[string](20*time.Millisecond, 2)
+		defer counter.Stop()
+
+		counter.Incr("foo", 5)
+		time.Sleep(40 * time.Millisecond)
+
+		if got, want := counter.Count("foo"), int64(0); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := counter.Incr("foo", 1), int64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		counter := NewCounter[string](time.Minute, 6)
+		defer counter.Stop()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					counter.Incr("foo", 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got, want := counter.Count("foo"), int64(1000); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestCounter_Reset(t *testing.T) {
+	t.Parallel()
+
+	counter := NewCounter[string](time.Minute, 6)
+	defer counter.Stop()
+
+	counter.Incr("foo", 5)
+	counter.Reset("foo")
+	counter.Reset("bar")
+
+	if got, want := counter.Count("foo"), int64(0); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := counter.Len(), 0; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestCounterWindow_sum(t *testing.T) {
+	t.Parallel()
+
+	w := &counterWindow{
+		counts: make([]int64, 3),
+		epochs: make([]int64, 3),
+	}
+
+	w.add(100, 1)
+	w.add(101, 2)
+	w.add(102, 4)
+	if got, want := w.sum(102), int64(7); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// The oldest bucket slides out of the window.
+	if got, want := w.sum(103), int64(6); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Reusing a slot clears the bucket it previously held.
+	w.add(103, 8)
+	if got, want := w.sum(103), int64(14); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := w.sum(110), int64(0); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Doorkeeper[string, string])(nil)
+
+// doorkeeperFalsePositiveRate is the target false positive rate for the
+// doorkeeper's bloom filter.
+const doorkeeperFalsePositiveRate = 0.01
+
+// Doorkeeper wraps a cache with an admission filter: a new key is only
+// inserted into the cache the second time it is set within the window. The
+// first time, the key is recorded in a bloom filter and the value is dropped.
+// This prevents keys which are only ever seen once ("one-hit wonders") from
+// evicting genuinely popular entries. Keys already in the cache are always
+// updated.
+//
+// The filter is cleared when the window elapses or when it has recorded the
+// expected number of keys, whichever comes first. Being a bloom filter, it may
+// occasionally admit a key on its first appearance.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Doorkeeper[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// filter records keys which have been seen once.
+	filter *doorkeeperFilter
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// doorkeeperFilter remembers the keys which have been seen once within a
+// window.
+type doorkeeperFilter struct {
+	// filter records keys which have been seen once, and expected is the number
+	// of keys the filter is sized for.
+	filter   *bloomFilter
+	expected int
+
+	// window is how long keys are remembered, and resetAt is when the filter
+	// will next be cleared.
+	window  time.Duration
+	resetAt time.Time
+
+	// seed seeds the hashes of the keys recorded in the filter.
+	seed hashSeed
+
+	// lock guards the filter.
+	lock sync.Mutex
+}
+
+// NewDoorkeeper wraps the given cache with a doorkeeper which remembers up to
+// expected keys for the given window.
+func NewDoorkeeper[K comparable, V any](c Cache[K, V], expected int, window time.Duration) *Doorkeeper[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	return &Doorkeeper[K, V]{
+		cache:  c,
+		filter: newDoorkeeperFilter(expected, window),
+	}
+}
+
+// NewDoorkeeperAdmission returns an admission policy which, like Doorkeeper,
+// only admits a new key the second time it is set within the window, for use
+// with WithAdmission.
+func NewDoorkeeperAdmission[K comparable, V any](expected int, window time.Duration) Admission[K, V] {
+	return &doorkeeperAdmission[K, V]{
+		filter: newDoorkeeperFilter(expected, window),
+	}
+}
+
+// doorkeeperAdmission is an admission policy backed by a doorkeeper filter.
+type doorkeeperAdmission[K comparable, V any] struct {
+	filter *doorkeeperFilter
+}
+
+// Admit returns true if the key has been seen before within the window.
+func (a *doorkeeperAdmission[K, V]) Admit(key K, _ V) bool {
+	return a.filter.seen(hashKey(a.filter.seed, key))
+}
+
+// newDoorkeeperFilter creates a filter which remembers up to expected keys for
+// the given window.
+func newDoorkeeperFilter(expected int, window time.Duration) *doorkeeperFilter {
+	if expected <= 0 {
+		panic("expected must be greater than 0")
+	}
+	if window <= 0 {
+		panic("window must be greater than 0")
+	}
+
+	return &doorkeeperFilter{
+		filter:   newBloomFilter(expected, doorkeeperFalsePositiveRate),
+		expected: expected,
+		window:   window,
+		resetAt:  time.Now().Add(window),
+		seed:     newHashSeed(),
+	}
+}
+
+// seen records the key with the given hash, and returns true if it had already
+// been recorded within the window.
+func (f *doorkeeperFilter) seen(h uint64) bool {
+	now := time.Now()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if now.After(f.resetAt) || f.filter.count >= f.expected {
+		f.filter.reset()
+		f.resetAt = now.Add(f.window)
+	}
+	return f.filter.add(h)
+}
+
+// Get fetches the cache item at the given key from the underlying cache.
+func (d *Doorkeeper[K, V]) Get(key K) (V, bool) {
+	return d.cache.Get(key)
+}
+
+// Set inserts the value in the underlying cache if the key is already cached
+// or has been seen before within the window. Otherwise, the key is recorded and
+// the value is dropped.
+func (d *Doorkeeper[K, V]) Set(key K, val V) {
+	if d.admit(key) {
+		d.cache.Set(key, val)
+	}
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is returned, but the result is only stored if the
+// key is admitted. Concurrent calls to Fetch for the same key share a single
+// invocation.
+func (d *Doorkeeper[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := d.cache.Get(key); ok {
+		return v, nil
+	}
+
+	return d.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := d.cache.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		d.Set(key, v)
+		return v, nil
+	})
+}
+
+// Stop stops the underlying cache.
+func (d *Doorkeeper[K, V]) Stop() {
+	d.loads.close()
+	d.cache.Stop()
+}
+
+// admit returns true if the key should be inserted into the cache.
+func (d *Doorkeeper[K, V]) admit(key K) bool {
+	if d.cached(key) {
+		return true
+	}
+	return d.filter.seen(hashKey(d.filter.seed, key))
+}
+
+// cached returns true if the key is already in the underlying cache, without
+// affecting its eviction order where possible.
+func (d *Doorkeeper[K, V]) cached(key K) bool {
+	if p, ok := d.cache.(interface{ Peek(K) (V, bool) }); ok {
+		_, ok := p.Peek(key)
+		return ok
+	}
+	_, ok := d.cache.Get(key)
+	return ok
+}
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoorkeeper_Set(t *testing.T) {
+	t.Parallel()
+
+	t.Run("admits_second", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewDoorkeeper[string, int](NewLRU[string, int](10), 100, time.Minute)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		if v, ok := cache.Get("foo"); ok {
+			t.Errorf("expected %#v to not be admitted", v)
+		}
+
+		cache.Set("foo", 2)
+		if v, _ := cache.Get("foo"); v != 2 {
+			t.Errorf("expected %#v, got %#v", 2, v)
+		}
+
+		// Cached keys are always updated.
+		cache.Set("foo", 3)
+		if v, _ := cache.Get("foo"); v != 3 {
+			t.Errorf("expected %#v, got %#v", 3, v)
+		}
+	})
+
+	t.Run("window", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewDoorkeeper[string, int](NewLRU[string, int](10), 100, 10*time.Millisecond)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		time.Sleep(50 * time.Millisecond)
+		cache.Set("foo", 2)
+
+		if v, ok := cache.Get("foo"); ok {
+			t.Errorf("expected %#v to not be admitted", v)
+		}
+	})
+
+	t.Run("protects_hot", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewDoorkeeper[int, int](NewLRU[int, int](2), 1000, time.Minute)
+		defer cache.Stop()
+
+		cache.Set(1, 1)
+		cache.Set(1, 1)
+		cache.Set(2, 2)
+		cache.Set(2, 2)
+
+		// A scan of one-hit wonders does not displace the hot entries.
+		for i := 100; i < 200; i++ {
+			cache.Set(i, i)
+		}
+
+		if _, ok := cache.Get(1); !ok {
+			t.Errorf("expected 1 to be cached")
+		}
+		if _, ok := cache.Get(2); !ok {
+			t.Errorf("expected 2 to be cached")
+		}
+	})
+}
+
+func TestDoorkeeper_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("admits_second", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewDoorkeeper[string, int](NewLRU[string, int](10), 100, time.Minute)
+		defer cache.Stop()
+
+		calls := 0
+		fn := func() (int, error) {
+			calls++
+			return 5, nil
+		}
+
+		for i := 0; i < 3; i++ {
+			v, err := cache.Fetch("foo", fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := v, 5; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+		}
+
+		if got, want := calls, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("deduplicates", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewDoorkeeper[string, int](NewLRU[string, int](10), 100, time.Minute)
+		defer cache.Stop()
+
+		release := make(chan struct{})
+		var calls int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := cache.Fetch("foo", func() (int, error) {
+					atomic.AddInt32(&calls, 1)
+					<-release
+					return 5, nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+				if v != 5 {
+					t.Errorf("expected %d to be %d", v, 5)
+				}
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Dump writes a human-readable table of the cache's entries to w, in the order
+// returned by Entries. For the ordered policies this is eviction order, so the
+// first row is the next entry to be evicted. Each row includes the entry's age,
+// time since last access, hit count, and time until expiration (if any).
+//
+// Dump is intended for debugging and the format is not stable.
+func Dump[K comparable, V any](w io.Writer, c EntryLister[K, V]) error {
+	now := time.Now()
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tKEY\tAGE\tIDLE\tHITS\tEXPIRES IN")
+
+	for i, entry := range c.Entries() {
+		idle := "-"
+		if !entry.LastAccessedAt.IsZero() {
+			idle = now.Sub(entry.LastAccessedAt).Round(time.Millisecond).String()
+		}
+
+		expiresIn := "-"
+		if !entry.ExpiresAt.IsZero() {
+			expiresIn = entry.ExpiresAt.Sub(now).Round(time.Millisecond).String()
+		}
+
+		fmt.Fprintf(tw, "%d\t%v\t%s\t%s\t%d\t%s\n",
+			i,
+			entry.Key,
+			now.Sub(entry.InsertedAt).Round(time.Millisecond),
+			idle,
+			entry.Hits,
+			expiresIn,
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+
+	t.Run("eviction_order", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Set("bar", 2)
+		cache.Get("foo")
+
+		var b bytes.Buffer
+		if err := Dump[string, int](&b, cache); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if got, want := len(lines), 3; got != want {
+			t.Fatalf("expected %d to be %d: %s", got, want, b.String())
+		}
+		if got, want := strings.Fields(lines[0])[1], "KEY"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := strings.Fields(lines[1])[1], "bar"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := strings.Fields(lines[2])[1], "foo"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := strings.Fields(lines[2])[4], "1"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+
+		var b bytes.Buffer
+		if err := Dump[string, int](&b, cache); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		d, err := time.ParseDuration(strings.Fields(lines[1])[5])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := d, 4*time.Minute; got < want {
+			t.Errorf("expected %s to be at least %s", got, want)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package cache
+
+// Ensure implements.
+var _ Cache[string, string] = (*Encoded[string, string])(nil)
+
+// Encoded wraps a cache of bytes, encoding values with a codec when they are
+// stored and decoding them when they are retrieved. Since the underlying cache
+// only ever holds bytes, cached values cannot be mutated by callers, and the
+// underlying cache can be any store capable of holding bytes.
+//
+// Encoding and decoding are performed on every Set and Get, so there is a cost
+// proportional to the size of the value.
+//
+// K is the cache key and must be a comparable. V can be any type supported by
+// the codec.
+type Encoded[K comparable, V any] struct {
+	// cache is the underlying cache of encoded values.
+	cache Cache[K, []byte]
+
+	// codec is the codec for encoding and decoding values.
+	codec Codec[V]
+}
+
+// NewEncoded wraps the given cache of bytes, using codec to encode and decode
+// values.
+func NewEncoded[K comparable, V any](c Cache[K, []byte], codec Codec[V]) *Encoded[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if codec == nil {
+		panic("codec cannot be nil")
+	}
+
+	return &Encoded[K, V]{
+		cache: c,
+		codec: codec,
+	}
+}
+
+// Get fetches and decodes the cache item at the given key. If the value cannot
+// be decoded, it is treated as missing.
+func (e *Encoded[K, V]) Get(key K) (V, bool) {
+	b, ok := e.cache.Get(key)
+	if !ok {
+		var zeroV V
+		return zeroV, false
+	}
+
+	v, err := e.codec.Unmarshal(b)
+	if err != nil {
+		var zeroV V
+		return zeroV, false
+	}
+	return v, true
+}
+
+// Set encodes and inserts the value in the cache. If the value cannot be
+// encoded, it is not stored and any existing value is left in place. Use
+// TrySet to observe encoding errors.
+func (e *Encoded[K, V]) Set(key K, val V) {
+	_ = e.TrySet(key, val)
+}
+
+// TrySet encodes and inserts the value in the cache, returning any error from
+// encoding the value.
+func (e *Encoded[K, V]) TrySet(key K, val V) error {
+	b, err := e.codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	e.cache.Set(key, b)
+	return nil
+}
+
+// Fetch retrieves and decodes the cached value. If the value does not exist,
+// the FetchFunc is called and the encoded result is stored. Errors from
+// encoding the result or decoding the cached value are returned as-is, rather
+// than as a LoaderError.
+func (e *Encoded[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	var loaded *V
+	var encodeErr error
+	b, err := e.cache.Fetch(key, func() ([]byte, error) {
+		v, err := fn()
+		if err != nil {
+			return nil, newLoaderError(key, err)
+		}
+
+		b, err := e.codec.Marshal(v)
+		if err != nil {
+			encodeErr = err
+			return nil, err
+		}
+		loaded = &v
+		return b, nil
+	})
+	if encodeErr != nil {
+		var zeroV V
+		return zeroV, encodeErr
+	}
+	if err != nil {
+		var zeroV V
+		return zeroV, err
+	}
+
+	// Avoid decoding the value which was just encoded.
+	if loaded != nil {
+		return *loaded, nil
+	}
+	return e.codec.Unmarshal(b)
+}
+
+// Stop stops the underlying cache.
+func (e *Encoded[K, V]) Stop() {
+	e.cache.Stop()
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestEncoded_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round_trips", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewEncoded[string, []string](NewLRU[string, []byte](10), JSONCodec[[]string]{})
+		defer cache.Stop()
+
+		in := []string{"a", "b"}
+		cache.Set("foo", in)
+		in[0] = "z"
+
+		out, ok := cache.Get("foo")
+		if !ok {
+			t.Fatalf("expected item to be cached")
+		}
+		if got, want := out, []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %#v to be %#v", got, want)
+		}
+
+		if v, ok := cache.Get("bar"); ok {
+			t.Errorf("expected not found, got %#v", v)
+		}
+	})
+
+	t.Run("invalid_is_missing", func(t *testing.T) {
+		t.Parallel()
+
+		lru := NewLRU[string, []byte](10)
+		cache := NewEncoded[string, int](lru, JSONCodec[int]{})
+		defer cache.Stop()
+
+		lru.Set("foo", []byte("not valid"))
+		if v, ok := cache.Get("foo"); ok {
+			t.Errorf("expected not found, got %#v", v)
+		}
+	})
+}
+
+func TestEncoded_TrySet(t *testing.T) {
+	t.Parallel()
+
+	cache := NewEncoded[string, any](NewLRU[string, []byte](10), JSONCodec[any]{})
+	defer cache.Stop()
+
+	if err := cache.TrySet("foo", make(chan int)); err == nil {
+		t.Errorf("expected error")
+	}
+	if v, ok := cache.Get("foo"); ok {
+		t.Errorf("expected not found, got %#v", v)
+	}
+}
+
+func TestEncoded_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("saves", func(t *testing.T) {
+		t.Parallel()
+
+		cache := New[string, int]().LRU(10).Codec(GobCodec[int]{}).Build()
+		defer cache.Stop()
+
+		v, err := cache.Fetch("foo", func() (int, error) {
+			return 5, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		v, err = cache.Fetch("foo", func() (int, error) {
+			t.Errorf("function was called")
+			return 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("returns_error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewEncoded[string, int](NewLRU[string, []byte](10), GobCodec[int]{})
+		defer cache.Stop()
+
+		if _, err := cache.Fetch("foo", func() (int, error) {
+			return 0, fmt.Errorf("error")
+		}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
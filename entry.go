@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Entry is a point-in-time snapshot of a cached value and its metadata.
+type Entry[K comparable, V any] struct {
+	// Key and Value are the cached key and value.
+	Key   K
+	Value V
+
+	// InsertedAt is the time at which the current value was set.
+	InsertedAt time.Time
+
+	// LastAccessedAt is the time at which the value was last retrieved. It is
+	// the zero value if the value has not been retrieved since it was set.
+	LastAccessedAt time.Time
+
+	// Hits is the number of times the value has been retrieved since it was set.
+	Hits uint64
+
+	// Frequency is the number of times the key has been retrieved during its
+	// whole time in the cache, for policies which decide which entries to retain
+	// by how often they are used. Unlike Hits, it is not reset when the value is
+	// overwritten or when the policy moves the entry internally, so it reflects
+	// what the policy saw. It is 0 for policies which do not use frequency.
+	Frequency uint64
+
+	// ExpiresAt is the time at which the value expires. It is the zero value for
+	// caches which do not expire entries.
+	ExpiresAt time.Time
+
+	// Version identifies the value which was set. It increases with every write,
+	// so it can be passed to SetIfVersion to detect concurrent updates. It is 0
+	// for caches which do not track versions.
+	Version uint64
+}
+
+// EntryGetter is implemented by caches which can retrieve a single entry along
+// with its metadata.
+type EntryGetter[K comparable, V any] interface {
+	// GetEntry retrieves the entry at the given key. If it does not exist, the
+	// second return value is false.
+	GetEntry(K) (Entry[K, V], bool)
+}
+
+// Ensure implements.
+var (
+	_ EntryGetter[string, string] = (*FIFO[string, string])(nil)
+	_ EntryGetter[string, string] = (*LIFO[string, string])(nil)
+	_ EntryGetter[string, string] = (*LRU[string, string])(nil)
+	_ EntryGetter[string, string] = (*Policied[string, string])(nil)
+	_ EntryGetter[string, string] = (*Random[string, string])(nil)
+	_ EntryGetter[string, string] = (*TTL[string, string])(nil)
+	_ EntryGetter[string, string] = (*Sync[string, string])(nil)
+	_ EntryGetter[string, string] = (*Sharded[string, string])(nil)
+)
+
+// VersionSetter is implemented by caches which support optimistic concurrency
+// through entry versions.
+type VersionSetter[K comparable, V any] interface {
+	// SetIfVersion inserts the value only if the version of the entry at the
+	// given key, as reported by GetEntry, is the expected version. An expected
+	// version of 0 matches a missing entry. It returns true if the value was
+	// inserted.
+	SetIfVersion(key K, val V, version uint64) bool
+}
+
+// Ensure implements.
+var (
+	_ VersionSetter[string, string] = (*FIFO[string, string])(nil)
+	_ VersionSetter[string, string] = (*LIFO[string, string])(nil)
+	_ VersionSetter[string, string] = (*LRU[string, string])(nil)
+	_ VersionSetter[string, string] = (*Policied[string, string])(nil)
+	_ VersionSetter[string, string] = (*Random[string, string])(nil)
+	_ VersionSetter[string, string] = (*TTL[string, string])(nil)
+	_ VersionSetter[string, string] = (*Sync[string, string])(nil)
+	_ VersionSetter[string, string] = (*Sharded[string, string])(nil)
+)
+
+// lastVersion is the most recently assigned entry version. Versions are drawn
+// from a single counter, so a value set after a key is removed never reuses
+// the version of the value it replaced.
+var lastVersion uint64
+
+// entryMeta is the metadata tracked for each entry. The access fields are
+// updated atomically, so they can be recorded while holding only a read lock.
+//
+// It must be the first field in any struct in which it is embedded to
+// guarantee 64-bit alignment for the atomic operations on 32-bit platforms.
+type entryMeta struct {
+	hits       uint64
+	accessedAt int64
+	insertedAt int64
+
+	// version is the version of the current value. It is only modified while
+	// holding the write lock, so it is not accessed atomically.
+	version uint64
+}
+
+// reset resets the metadata for a newly-set value. It must be called while
+// holding the write lock.
+func (m *entryMeta) reset(now int64) {
+	atomic.StoreUint64(&m.hits, 0)
+	atomic.StoreInt64(&m.accessedAt, 0)
+	m.insertedAt = now
+	m.version = atomic.AddUint64(&lastVersion, 1)
+}
+
+// recordAccess records a retrieval of the value at the given time.
+func (m *entryMeta) recordAccess(now int64) {
+	atomic.AddUint64(&m.hits, 1)
+	atomic.StoreInt64(&m.accessedAt, now)
+}
+
+// fillEntry populates the metadata fields of the given entry.
+func fillEntry[K comparable, V any](e *Entry[K, V], m *entryMeta) {
+	e.InsertedAt = time.Unix(0, m.insertedAt)
+	if accessedAt := atomic.LoadInt64(&m.accessedAt); accessedAt != 0 {
+		e.LastAccessedAt = time.Unix(0, accessedAt)
+	}
+	e.Hits = atomic.LoadUint64(&m.hits)
+	e.Version = m.version
+}
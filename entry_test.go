@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetIfVersion(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		cache interface {
+			Cache[string, int]
+			EntryGetter[string, int]
+			VersionSetter[string, int]
+		}
+	}{
+		{"fifo", NewFIFO[string, int](10)},
+		{"lifo", NewLIFO[string, int](10)},
+		{"lru", NewLRU[string, int](10)},
+		{"random", NewRandom[string, int](10)},
+		{"ttl", NewTTL[string, int](time.Minute)},
+		{"sync", NewSyncLRU[string, int](10)},
+		{"sharded", NewShardedLRU[string, int](4, 40)},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := tc.cache
+			defer cache.Stop()
+
+			// Version 0 matches a missing entry.
+			if !cache.SetIfVersion("foo", 1, 0) {
+				t.Fatalf("expected set of missing entry to succeed")
+			}
+
+			entry, ok := cache.GetEntry("foo")
+			if !ok {
+				t.Fatal("expected foo to exist")
+			}
+			if entry.Version == 0 {
+				t.Fatal("expected version to be set")
+			}
+
+			// A concurrent writer changes the version.
+			cache.Set("foo", 2)
+			if cache.SetIfVersion("foo", 3, entry.Version) {
+				t.Errorf("expected set with stale version to fail")
+			}
+			if got, _ := cache.Get("foo"); got != 2 {
+				t.Errorf("expected %d to be %d", got, 2)
+			}
+
+			entry, _ = cache.GetEntry("foo")
+			if !cache.SetIfVersion("foo", 3, entry.Version) {
+				t.Errorf("expected set with current version to succeed")
+			}
+			if got, _ := cache.Get("foo"); got != 3 {
+				t.Errorf("expected %d to be %d", got, 3)
+			}
+			if next, _ := cache.GetEntry("foo"); next.Version <= entry.Version {
+				t.Errorf("expected %d to be greater than %d", next.Version, entry.Version)
+			}
+		})
+	}
+}
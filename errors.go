@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStopped is the value with which operations on a stopped cache panic. Since
+// it is an error, a recovered panic can be checked with errors.Is.
+//
+// This is a behavior change: stopped caches used to panic with the string
+// "cache is stopped", so code which compares the recovered value to that string
+// must be updated. The error's message is unchanged, so formatting the
+// recovered value with fmt.Sprint still produces "cache is stopped".
+var ErrStopped = errors.New("cache is stopped")
+
+// LoaderError is returned by Fetch when the FetchFunc returns an error, so
+// callers can distinguish failures of the upstream from failures of the cache.
+// It wraps the FetchFunc's error, so errors.Is and errors.As see through it:
+//
+//	var lerr *cache.LoaderError
+//	if errors.As(err, &lerr) {
+//	  // the upstream failed for lerr.Key
+//	}
+type LoaderError struct {
+	// Key is the key which was being loaded.
+	Key any
+
+	// Err is the error returned by the FetchFunc.
+	Err error
+}
+
+// Error returns the key and the FetchFunc's error.
+func (e *LoaderError) Error() string {
+	return fmt.Sprintf("failed to load %v: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the FetchFunc's error.
+func (e *LoaderError) Unwrap() error {
+	return e.Err
+}
+
+// newLoaderError wraps the error returned by a FetchFunc for the given key. If
+// err is already a LoaderError, as when a wrapper passes its FetchFunc through
+// to the underlying cache, it is returned as-is.
+func newLoaderError(key any, err error) error {
+	if _, ok := err.(*LoaderError); ok {
+		return err
+	}
+	return &LoaderError{Key: key, Err: err}
+}
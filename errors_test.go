@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLoaderError(t *testing.T) {
+	t.Parallel()
+
+	errUpstream := errors.New("upstream failed")
+
+	cases := []struct {
+		name  string
+		cache Cache[string, int]
+	}{
+		{"lru", NewLRU[string, int](10)},
+		{"ttl_sync", NewSync[string, int](NewTTL[string, int](time.Minute))},
+		{"copying", NewCopying[string, int](NewFIFO[string, int](10), func(v int) int { return v })},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			defer tc.cache.Stop()
+
+			_, err := tc.cache.Fetch("foo", func() (int, error) {
+				return 0, errUpstream
+			})
+
+			var lerr *LoaderError
+			if !errors.As(err, &lerr) {
+				t.Fatalf("expected %v to be a LoaderError", err)
+			}
+			if got, want := lerr.Key, any("foo"); got != want {
+				t.Errorf("expected %v to be %v", got, want)
+			}
+			if got, want := lerr.Err, errUpstream; got != want {
+				t.Errorf("expected %v to be %v", got, want)
+			}
+			if !errors.Is(err, errUpstream) {
+				t.Errorf("expected %v to be %v", err, errUpstream)
+			}
+		})
+	}
+
+	t.Run("encode_error", func(t *testing.T) {
+		t.Parallel()
+
+		errEncode := errors.New("encode failed")
+		cache := NewEncoded[string, int](NewLRU[string, []byte](10), FuncCodec[int]{
+			MarshalFunc: func(int) ([]byte, error) { return nil, errEncode },
+		})
+		defer cache.Stop()
+
+		_, err := cache.Fetch("foo", func() (int, error) { return 1, nil })
+		if got, want := err, errEncode; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+}
+
+func TestErrStopped(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	cache.Stop()
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrStopped) {
+			t.Errorf("expected %v to be %v", err, ErrStopped)
+		}
+	}()
+	cache.Get("foo")
+	t.Errorf("did not panic")
+}
+
+func TestErrStopped_message(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	cache.Stop()
+
+	defer func() {
+		if got, want := fmt.Sprint(recover()), "cache is stopped"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+	cache.Get("foo")
+	t.Errorf("did not panic")
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"time"
+)
+
+// EvictNotifier is implemented by caches which can notify when entries are
+// evicted to make room for new entries.
+type EvictNotifier[K comparable, V any] interface {
+	// OnEvict registers a function which is invoked with each evicted entry.
+	OnEvict(func(K, V))
+}
+
+// EvictNotifierCache is a cache which can notify when entries are evicted.
+type EvictNotifierCache[K comparable, V any] interface {
+	Cache[K, V]
+	EvictNotifier[K, V]
+}
+
+// Ensure implements.
+var (
+	_ EvictNotifier[string, string] = (*Fair[string, string])(nil)
+	_ EvictNotifier[string, string] = (*FIFO[string, string])(nil)
+	_ EvictNotifier[string, string] = (*LIFO[string, string])(nil)
+	_ EvictNotifier[string, string] = (*LRU[string, string])(nil)
+	_ EvictNotifier[string, string] = (*Policied[string, string])(nil)
+	_ EvictNotifier[string, string] = (*Random[string, string])(nil)
+	_ EvictNotifier[string, string] = (*Weighted[string, string])(nil)
+)
+
+// Inserter is implemented by caches which can report the entry evicted to make
+// room for a new entry, for callers which handle displaced entries
+// synchronously, such as spilling them to a second tier.
+type Inserter[K comparable, V any] interface {
+	// Insert inserts the value, like Set, and returns the entry which was evicted
+	// to make room for it. If no entry was evicted, the third return value is
+	// false.
+	Insert(key K, val V) (K, V, bool)
+}
+
+// Ensure implements.
+var (
+	_ Inserter[string, string] = (*Fair[string, string])(nil)
+	_ Inserter[string, string] = (*FIFO[string, string])(nil)
+	_ Inserter[string, string] = (*LIFO[string, string])(nil)
+	_ Inserter[string, string] = (*LRU[string, string])(nil)
+	_ Inserter[string, string] = (*Policied[string, string])(nil)
+	_ Inserter[string, string] = (*Random[string, string])(nil)
+	_ Inserter[string, string] = (*Sync[string, string])(nil)
+	_ Inserter[string, string] = (*Sharded[string, string])(nil)
+)
+
+// lifetimeNotifier is implemented by caches which can report how long each
+// evicted entry was cached. It is used by Instrumented to record lifetimes.
+type lifetimeNotifier interface {
+	onEvictLifetime(func(time.Duration))
+}
+
+// Ensure implements.
+var (
+	_ lifetimeNotifier = (*Fair[string, string])(nil)
+	_ lifetimeNotifier = (*FIFO[string, string])(nil)
+	_ lifetimeNotifier = (*LIFO[string, string])(nil)
+	_ lifetimeNotifier = (*LRU[string, string])(nil)
+	_ lifetimeNotifier = (*Policied[string, string])(nil)
+	_ lifetimeNotifier = (*Random[string, string])(nil)
+	_ lifetimeNotifier = (*TTL[string, string])(nil)
+	_ lifetimeNotifier = (*Weighted[string, string])(nil)
+)
+
+// victim is an entry which was evicted, along with the hooks to notify. The
+// zero value has no hooks and notifies nothing.
+type victim[K comparable, V any] struct {
+	key        K
+	value      V
+	insertedAt int64
+	ok         bool
+	hooks      []func(K, V)
+	lifetimes  []func(time.Duration)
+}
+
+// newVictim returns a victim for the given entry, inserted at the given time,
+// if the eviction succeeded.
+func newVictim[K comparable, V any](key K, value V, insertedAt int64, ok bool, hooks []func(K, V), lifetimes []func(time.Duration)) victim[K, V] {
+	if !ok {
+		return victim[K, V]{}
+	}
+	return victim[K, V]{key: key, value: value, insertedAt: insertedAt, ok: true, hooks: hooks, lifetimes: lifetimes}
+}
+
+// notify invokes the hooks with the evicted entry. It must be called without
+// holding the cache's lock, so hooks are free to call back into the cache.
+func (v *victim[K, V]) notify() {
+	for _, fn := range v.hooks {
+		fn(v.key, v.value)
+	}
+
+	if len(v.lifetimes) > 0 {
+		lifetime := time.Duration(time.Now().UnixNano() - v.insertedAt)
+		for _, fn := range v.lifetimes {
+			fn(lifetime)
+		}
+	}
+}
+
+// victims are the entries evicted by a single operation.
+type victims[K comparable, V any] []victim[K, V]
+
+// notify invokes the hooks with each evicted entry, in order. Like
+// victim.notify, it must be called without holding the cache's lock.
+func (v *victims[K, V]) notify() {
+	for i := range *v {
+		(*v)[i].notify()
+	}
+}
+
+// appendHook returns a new slice with fn appended. The existing slice is never
+// modified, since it may have been captured by a victim awaiting notification.
+func appendHook[F any](hooks []F, fn F) []F {
+	return append(hooks[:len(hooks):len(hooks)], fn)
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInsert(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		cache interface {
+			Cache[string, int]
+			Inserter[string, int]
+		}
+	}{
+		{"fair", NewFair[string, int](1, func(string) string { return "" })},
+		{"fifo", NewFIFO[string, int](1)},
+		{"lifo", NewLIFO[string, int](1)},
+		{"lru", NewLRU[string, int](1)},
+		{"random", NewRandom[string, int](1)},
+		{"sync", NewSyncLRU[string, int](1)},
+		{"sharded", NewShardedLRU[string, int](1, 1)},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := tc.cache
+			defer cache.Stop()
+
+			if k, v, ok := cache.Insert("foo", 1); ok {
+				t.Errorf("expected nothing to be evicted, got %q=%d", k, v)
+			}
+
+			// Overwriting does not evict.
+			if k, v, ok := cache.Insert("foo", 2); ok {
+				t.Errorf("expected nothing to be evicted, got %q=%d", k, v)
+			}
+
+			k, v, ok := cache.Insert("bar", 3)
+			if !ok {
+				t.Fatal("expected an entry to be evicted")
+			}
+			if got, want := k, "foo"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+			if got, want := v, 2; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if got, _ := cache.Get("bar"); got != 3 {
+				t.Errorf("expected %d to be %d", got, 3)
+			}
+		})
+	}
+}
+
+func TestInsert_onEvict(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](1)
+	defer cache.Stop()
+
+	var evicted []string
+	cache.OnEvict(func(k string, _ int) { evicted = append(evicted, k) })
+
+	cache.Insert("foo", 1)
+	cache.Insert("bar", 2)
+
+	if got, want := fmt.Sprint(evicted), "[foo]"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
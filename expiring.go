@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*expiring[string, string])(nil)
+
+// expiring adds a global expiration to a capacity-bounded cache. Entries are
+// stored alongside their expiration time and treated as missing once expired.
+// Expired entries are not actively removed; they remain until the underlying
+// cache evicts them or they are overwritten.
+type expiring[K comparable, V any] struct {
+	// cache is the underlying cache, which stores values with their expiration.
+	cache Cache[K, expiringEntry[V]]
+
+	// ttl is the global TTL value.
+	ttl time.Duration
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// newExpiring wraps the given cache, expiring entries after the given TTL.
+func newExpiring[K comparable, V any](c Cache[K, expiringEntry[V]], ttl time.Duration) *expiring[K, V] {
+	if ttl <= 0 {
+		panic("ttl must be greater than 0")
+	}
+
+	return &expiring[K, V]{
+		cache: c,
+		ttl:   ttl,
+	}
+}
+
+// Get fetches the cache item at the given key. Expired entries are reported as
+// missing.
+func (e *expiring[K, V]) Get(key K) (V, bool) {
+	entry, ok := e.cache.Get(key)
+	if !ok || entry.expiresAt.Before(time.Now()) {
+		var zeroV V
+		return zeroV, false
+	}
+	return entry.value, true
+}
+
+// Set inserts the value in the cache with a fresh expiration.
+func (e *expiring[K, V]) Set(key K, val V) {
+	e.cache.Set(key, expiringEntry[V]{
+		value:     val,
+		expiresAt: time.Now().Add(e.ttl),
+	})
+}
+
+// Fetch retrieves the cached value. If the value does not exist or is expired,
+// the FetchFunc is called and the result is stored. Concurrent calls to Fetch
+// for the same key share a single invocation.
+func (e *expiring[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := e.Get(key); ok {
+		return v, nil
+	}
+
+	return e.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := e.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		e.Set(key, v)
+		return v, nil
+	})
+}
+
+// getStale returns the value at the given key even if it has expired, as long
+// as the underlying cache has not yet evicted it.
+func (e *expiring[K, V]) getStale(key K) (V, bool) {
+	entry, ok := e.cache.Get(key)
+	return entry.value, ok
+}
+
+// Stop stops the underlying cache.
+func (e *expiring[K, V]) Stop() {
+	e.loads.close()
+	e.cache.Stop()
+}
+
+// expiringEntry is a value and the time at which it expires.
+type expiringEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExpiring_Get(t *testing.T) {
+	t.Parallel()
+
+	cache := newExpiring[string, int](NewLRU[string, expiringEntry[int]](10), 50*time.Millisecond)
+	defer cache.Stop()
+
+	if v, ok := cache.Get("foo"); ok {
+		t.Errorf("expected not found, got %#v", v)
+	}
+
+	cache.Set("foo", 5)
+	if v, _ := cache.Get("foo"); v != 5 {
+		t.Errorf("expected %#v, got %#v", 5, v)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if v, ok := cache.Get("foo"); ok {
+		t.Errorf("expected %#v to be expired", v)
+	}
+}
+
+func TestExpiring_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reloads_expired", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newExpiring[string, int](NewLRU[string, expiringEntry[int]](10), 50*time.Millisecond)
+		defer cache.Stop()
+
+		cache.Set("foo", 5)
+		time.Sleep(100 * time.Millisecond)
+
+		v, err := cache.Fetch("foo", func() (int, error) {
+			return 10, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 10; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("returns_error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newExpiring[string, int](NewLRU[string, expiringEntry[int]](10), time.Minute)
+		defer cache.Stop()
+
+		if _, err := cache.Fetch("foo", func() (int, error) {
+			return 0, fmt.Errorf("error")
+		}); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("deduplicates", func(t *testing.T) {
+		t.Parallel()
+
+		cache := newExpiring[string, int](NewLRU[string, expiringEntry[int]](10), time.Minute)
+		defer cache.Stop()
+
+		release := make(chan struct{})
+		var calls int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := cache.Fetch("foo", func() (int, error) {
+					atomic.AddInt32(&calls, 1)
+					<-release
+					return 5, nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+				if v != 5 {
+					t.Errorf("expected %d to be %d", v, 5)
+				}
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
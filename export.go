@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// entryJSON is the JSON representation of an Entry. Metadata which is not set
+// is omitted.
+type entryJSON[K comparable, V any] struct {
+	Key            K          `json:"key"`
+	Value          V          `json:"value"`
+	InsertedAt     time.Time  `json:"inserted_at"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	Hits           uint64     `json:"hits"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Version        uint64     `json:"version,omitempty"`
+}
+
+// MarshalJSON encodes the entry as a JSON object with the key, value, and
+// metadata. LastAccessedAt, ExpiresAt, and Version are omitted if they are not
+// set. The key and value must be encodable by encoding/json.
+func (e Entry[K, V]) MarshalJSON() ([]byte, error) {
+	out := entryJSON[K, V]{
+		Key:        e.Key,
+		Value:      e.Value,
+		InsertedAt: e.InsertedAt,
+		Hits:       e.Hits,
+		Version:    e.Version,
+	}
+	if !e.LastAccessedAt.IsZero() {
+		out.LastAccessedAt = &e.LastAccessedAt
+	}
+	if !e.ExpiresAt.IsZero() {
+		out.ExpiresAt = &e.ExpiresAt
+	}
+	return json.Marshal(out)
+}
+
+// ExportJSON writes the cache's entries to w as a JSON array, in the order
+// returned by Entries. Each element is encoded by Entry.MarshalJSON, so keys
+// and values must be encodable by encoding/json. It is intended for attaching
+// cache contents to bug reports.
+func ExportJSON[K comparable, V any](w io.Writer, c EntryLister[K, V]) error {
+	entries := c.Entries()
+	if entries == nil {
+		entries = []Entry[K, V]{}
+	}
+	return json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestExportJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("entries", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Get("foo")
+
+		var b bytes.Buffer
+		if err := ExportJSON[string, int](&b, cache); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []map[string]any
+		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(got), 1; got != want {
+			t.Fatalf("expected %d to be %d: %s", got, want, b.String())
+		}
+		if got, want := got[0]["key"], "foo"; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := got[0]["value"], float64(1); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := got[0]["hits"], float64(1); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		for _, field := range []string{"inserted_at", "last_accessed_at", "expires_at"} {
+			if _, ok := got[0][field]; !ok {
+				t.Errorf("expected %q to be present", field)
+			}
+		}
+	})
+
+	t.Run("omits_unset", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+
+		var b bytes.Buffer
+		if err := ExportJSON[string, int](&b, cache); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []map[string]any
+		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range []string{"last_accessed_at", "expires_at"} {
+			if _, ok := got[0][field]; ok {
+				t.Errorf("expected %q to be omitted", field)
+			}
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+		defer cache.Stop()
+
+		var b bytes.Buffer
+		if err := ExportJSON[string, int](&b, cache); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := b.String(), "[]\n"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+}
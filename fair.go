@@ -0,0 +1,364 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Fair[string, string])(nil)
+
+// Fair implements a least-recently-used cache which is shared fairly between
+// tenants, such as the customers of a multi-tenant service. Each key belongs to
+// the tenant returned by a user-supplied function, typically derived from a key
+// prefix. A tenant's fair share is the capacity divided by the number of
+// tenants with entries. When the cache is at capacity, it evicts the least
+// recently used entry among the tenants above their fair share, or, if the
+// tenant of the new entry is at its share, that tenant's own least recently
+// used entry. As a result, a tenant with a large working set cannot push out
+// the entries of smaller tenants. This cache is not safe for concurrent use.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Fair[K comparable, V any] struct {
+	// cache represents the internal cache storage. It has a comparable key and
+	// points to an entry in its tenant's doubly-linked list.
+	cache map[K]*fairListItem[K, V]
+
+	// tenants are the tenants which have entries in the cache, by name.
+	tenants map[string]*fairTenant[K, V]
+
+	// tenant returns the tenant of a key.
+	tenant func(K) string
+
+	// clock is incremented on every access, to order entries across tenants.
+	clock uint64
+
+	// capacity is the total capacity for the cache.
+	capacity int64
+
+	// stopped indicates whether the cache is stopped.
+	stopped uint32
+
+	// lock is the internal lock for concurrency.
+	lock waitMutex
+
+	// onEvict are the functions to invoke when an entry is evicted.
+	onEvict []func(K, V)
+
+	// onLifetime are the functions to invoke with how long each evicted entry
+	// was cached.
+	onLifetime []func(time.Duration)
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+
+	// admission decides whether new entries are inserted, or is nil if all
+	// entries are.
+	admission Admission[K, V]
+}
+
+// fairTenant is a tenant's list of entries, from least to most recently used.
+type fairTenant[K comparable, V any] struct {
+	name       string
+	head, tail *fairListItem[K, V]
+	len        int64
+}
+
+// fairListItem represents an entry in a tenant's linked list.
+type fairListItem[K comparable, V any] struct {
+	meta       entryMeta
+	prev, next *fairListItem[K, V]
+	tenant     *fairTenant[K, V]
+	key        K
+	value      V
+
+	// used is the value of the cache's clock when the entry was last used.
+	used uint64
+}
+
+// NewFair creates a new fair cache of the given capacity, using tenant to
+// determine the tenant of each key. The tenant of a key must never change.
+//
+//	c := cache.NewFair[string, *User](10_000, func(k string) string {
+//		customer, _, _ := strings.Cut(k, ":")
+//		return customer
+//	})
+func NewFair[K comparable, V any](capacity int64, tenant func(K) string, opts ...Option[K, V]) *Fair[K, V] {
+	if capacity <= 0 {
+		panic("capacity must be greater than 0")
+	}
+	if tenant == nil {
+		panic("tenant cannot be nil")
+	}
+
+	o := newOptions(opts)
+	if o.ttl != 0 {
+		panic("cache does not support expiration")
+	}
+
+	return &Fair[K, V]{
+		cache:     make(map[K]*fairListItem[K, V], capacity),
+		tenants:   make(map[string]*fairTenant[K, V]),
+		tenant:    tenant,
+		capacity:  capacity,
+		admission: o.admission,
+	}
+}
+
+// Get fetches the cache item at the given key. If the value exists, it is
+// returned. If the value does not exist, it returns the zero value for the
+// object and the second parameter will be false.
+func (f *Fair[K, V]) Get(key K) (V, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := f.cache[key]
+	if !ok {
+		var v V
+		return v, false
+	}
+
+	node.meta.recordAccess(time.Now().UnixNano())
+	f.touch(node)
+	return node.value, true
+}
+
+// Set inserts the value in the cache. If an entry already exists at the given
+// key, it is overwritten. If an entry does not exist, a new entry is created
+// (which might trigger eviction of an entry from a tenant above its fair
+// share).
+func (f *Fair[K, V]) Set(key K, val V) {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	evicted = f.set(key, val)
+}
+
+// Insert inserts the value in the cache, like Set, and returns the entry which
+// was evicted to make room for it. If no entry was evicted, the third return
+// value is false. The evicted entry is also reported to any OnEvict hooks.
+func (f *Fair[K, V]) Insert(key K, val V) (K, V, bool) {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	evicted = f.set(key, val)
+	return evicted.key, evicted.value, evicted.ok
+}
+
+// set is the internal implementation for set. It does not lock. It returns the
+// entry which was evicted to make room, if any.
+func (f *Fair[K, V]) set(key K, val V) victim[K, V] {
+	if f.isStopped() {
+		panic(ErrStopped)
+	}
+
+	var evicted victim[K, V]
+	node, ok := f.cache[key]
+	if !ok {
+		if f.admission != nil && !f.admission.Admit(key, val) {
+			return evicted
+		}
+
+		name := f.tenant(key)
+		if int64(len(f.cache)) >= f.capacity {
+			k, v, at, ok := f.evict(name)
+			evicted = newVictim(k, v, at, ok, f.onEvict, f.onLifetime)
+		}
+
+		t, ok := f.tenants[name]
+		if !ok {
+			t = &fairTenant[K, V]{name: name}
+			f.tenants[name] = t
+		}
+
+		node = &fairListItem[K, V]{key: key, tenant: t}
+		f.cache[key] = node
+		t.len++
+	}
+	node.value = val
+	node.meta.reset(time.Now().UnixNano())
+	f.touch(node)
+
+	return evicted
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. The FetchFunc runs without holding the cache's lock, so other
+// operations proceed while it runs, and concurrent calls to Fetch for the same
+// key share a single invocation.
+func (f *Fair[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := f.Get(key); ok {
+		return v, nil
+	}
+
+	return f.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := f.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		f.Set(key, v)
+		return v, nil
+	})
+}
+
+// OnEvict registers a function which is invoked with each entry that is
+// evicted to make room for a new entry. Functions are invoked in the order they
+// were registered, after the cache's lock is released, so they may call back
+// into the cache. Entries removed with Stop are not reported.
+func (f *Fair[K, V]) OnEvict(fn func(K, V)) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.onEvict = appendHook(f.onEvict, fn)
+}
+
+// onEvictLifetime registers a function which is invoked with how long each
+// evicted entry was cached, alongside the OnEvict functions.
+func (f *Fair[K, V]) onEvictLifetime(fn func(time.Duration)) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.onLifetime = appendHook(f.onLifetime, fn)
+}
+
+// Len returns the number of entries in the cache.
+func (f *Fair[K, V]) Len() int {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return len(f.cache)
+}
+
+// TenantLen returns the number of entries in the cache which belong to the
+// given tenant.
+func (f *Fair[K, V]) TenantLen(tenant string) int {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if t, ok := f.tenants[tenant]; ok {
+		return int(t.len)
+	}
+	return 0
+}
+
+// Stop clears the cache and prevents new entries from being added and
+// retrieved.
+func (f *Fair[K, V]) Stop() {
+	f.loads.close()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&f.stopped, 0, 1) {
+		return
+	}
+
+	f.cache = nil
+	f.tenants = nil
+}
+
+// evict removes an entry to make room for an entry of the given tenant and
+// returns it, along with the time at which it was inserted. The fair share is
+// the capacity divided by the number of tenants, including the incoming one. It
+// evicts the least recently used entry among the tenants above their share; if
+// there are none, the incoming tenant is at its share and replaces its own least
+// recently used entry. Since the shares sum to the capacity, a full cache always
+// has one of the two. It does not lock.
+func (f *Fair[K, V]) evict(tenant string) (K, V, int64, bool) {
+	incoming, ok := f.tenants[tenant]
+	n := int64(len(f.tenants))
+	if !ok {
+		n++
+	}
+
+	var oldest *fairListItem[K, V]
+	for _, t := range f.tenants {
+		if t.len*n <= f.capacity {
+			continue
+		}
+		if oldest == nil || t.head.used < oldest.used {
+			oldest = t.head
+		}
+	}
+	if oldest == nil && incoming != nil {
+		oldest = incoming.head
+	}
+
+	if oldest == nil {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, 0, false
+	}
+
+	key, val, insertedAt := oldest.key, oldest.value, oldest.meta.insertedAt
+	delete(f.cache, key)
+
+	t := oldest.tenant
+	f.unlink(oldest)
+	t.len--
+	if t.len == 0 {
+		delete(f.tenants, t.name)
+	}
+
+	return key, val, insertedAt, true
+}
+
+// touch marks the node as the most recently used, moving it to the end (tail)
+// of its tenant's list. It does not lock.
+func (f *Fair[K, V]) touch(node *fairListItem[K, V]) {
+	f.clock++
+	node.used = f.clock
+
+	t := node.tenant
+	if node == t.tail {
+		return
+	}
+	if node.prev != nil || node == t.head {
+		f.unlink(node)
+	}
+
+	node.prev = t.tail
+	if t.tail != nil {
+		t.tail.next = node
+	}
+	t.tail = node
+	if t.head == nil {
+		t.head = node
+	}
+}
+
+// unlink removes the node from its tenant's list. It does not lock.
+func (f *Fair[K, V]) unlink(node *fairListItem[K, V]) {
+	t := node.tenant
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		t.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		t.tail = node.prev
+	}
+	node.prev = nil
+	node.next = nil
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (f *Fair[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&f.stopped) == 1
+}
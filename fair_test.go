@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fairTestTenant returns the portion of the key before the colon.
+func fairTestTenant(key string) string {
+	tenant, _, _ := strings.Cut(key, ":")
+	return tenant
+}
+
+func TestNewFair(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "tenant cannot be nil"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	NewFair[string, int](10, nil)
+	t.Errorf("did not panic")
+}
+
+func TestFair_Get(t *testing.T) {
+	t.Parallel()
+
+	cache := NewFair[string, int](10, fairTestTenant)
+	defer cache.Stop()
+
+	cache.Set("a:foo", 5)
+	cache.Set("b:bar", 3)
+	cache.Set("a:foo", 10)
+
+	if v, _ := cache.Get("a:foo"); v != 10 {
+		t.Errorf("expected %#v to be %#v", v, 10)
+	}
+	if v, _ := cache.Get("b:bar"); v != 3 {
+		t.Errorf("expected %#v to be %#v", v, 3)
+	}
+	if v, ok := cache.Get("a:baz"); ok {
+		t.Errorf("expected not found, got %#v", v)
+	}
+	if got, want := cache.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestFair_Set(t *testing.T) {
+	t.Parallel()
+
+	t.Run("single_tenant", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewFair[string, int](2, fairTestTenant)
+		defer cache.Stop()
+
+		cache.Set("a:1", 1)
+		cache.Set("a:2", 2)
+		cache.Get("a:1")
+		cache.Set("a:3", 3)
+
+		if v, ok := cache.Get("a:2"); ok {
+			t.Errorf("expected a:2 to be evicted, got %#v", v)
+		}
+		if _, ok := cache.Get("a:1"); !ok {
+			t.Errorf("expected a:1 to be present")
+		}
+	})
+
+	t.Run("fair_share", func(t *testing.T) {
+		t.Parallel()
+
+		var evicted []string
+		cache := NewFair[string, int](4, fairTestTenant)
+		cache.OnEvict(func(k string, v int) { evicted = append(evicted, k) })
+		defer cache.Stop()
+
+		// The small tenant's entries are the least recently used, but the large
+		// tenant is above its share, so its entries are evicted instead.
+		cache.Set("small:1", 1)
+		for i := 0; i < 10; i++ {
+			cache.Set(fmt.Sprintf("large:%d", i), i)
+		}
+
+		if got, want := cache.TenantLen("small"), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.TenantLen("large"), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if _, ok := cache.Get("small:1"); !ok {
+			t.Errorf("expected small:1 to be present")
+		}
+		if got, want := len(evicted), 7; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		for _, k := range evicted {
+			if !strings.HasPrefix(k, "large:") {
+				t.Errorf("expected %q to belong to large", k)
+			}
+		}
+
+		// The small tenant can grow to its share at the expense of the large one.
+		cache.Set("small:2", 2)
+		cache.Set("small:3", 3)
+
+		if got, want := cache.TenantLen("small"), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.TenantLen("large"), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestFair_Fetch(t *testing.T) {
+	t.Parallel()
+
+	cache := NewFair[string, int](10, fairTestTenant)
+	defer cache.Stop()
+
+	v, err := cache.Fetch("a:foo", func() (int, error) { return 5, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 5 {
+		t.Errorf("expected %#v to be %#v", v, 5)
+	}
+
+	v, err = cache.Fetch("a:foo", func() (int, error) {
+		t.Errorf("should not have been called")
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 5 {
+		t.Errorf("expected %#v to be %#v", v, 5)
+	}
+}
+
+func TestFair_Stop(t *testing.T) {
+	t.Parallel()
+
+	cache := NewFair[string, int](10, fairTestTenant)
+	cache.Stop()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "cache is stopped"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+	cache.Get("a:foo")
+	t.Errorf("did not panic")
+}
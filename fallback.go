@@ -0,0 +1,40 @@
+package cache
+
+// Fallback returns a FetchFunc which calls each of the given functions in order
+// until one succeeds, and returns its result. If all of them fail, it returns
+// the error from the last one. It is useful for pairing a fast but unreliable
+// source with a slow but reliable one.
+func Fallback[V any](fns ...FetchFunc[V]) FetchFunc[V] {
+	if len(fns) == 0 {
+		panic("at least one function is required")
+	}
+	for _, fn := range fns {
+		if fn == nil {
+			panic("function cannot be nil")
+		}
+	}
+
+	return func() (V, error) {
+		var v V
+		var err error
+		for _, fn := range fns {
+			if v, err = fn(); err == nil {
+				return v, nil
+			}
+		}
+
+		var zeroV V
+		return zeroV, err
+	}
+}
+
+// FetchWithFallback retrieves the cached value. If the value does not exist,
+// primary and then each of the secondary functions are called in order until
+// one succeeds, and its result is stored. If all of them fail, the error from
+// the last one is returned. See Fallback for more information.
+func FetchWithFallback[K comparable, V any](c Cache[K, V], key K, primary FetchFunc[V], secondary ...FetchFunc[V]) (V, error) {
+	fns := make([]FetchFunc[V], 0, 1+len(secondary))
+	fns = append(fns, primary)
+	fns = append(fns, secondary...)
+	return c.Fetch(key, Fallback(fns...))
+}
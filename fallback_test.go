@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFetchWithFallback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("primary", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+		defer cache.Stop()
+
+		secondaryCalled := false
+		v, err := FetchWithFallback[string, int](cache, "foo",
+			func() (int, error) { return 1, nil },
+			func() (int, error) { secondaryCalled = true; return 2, nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if secondaryCalled {
+			t.Errorf("expected secondary to not be called")
+		}
+	})
+
+	t.Run("falls_back", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+		defer cache.Stop()
+
+		v, err := FetchWithFallback[string, int](cache, "foo",
+			func() (int, error) { return 0, errors.New("flaky") },
+			func() (int, error) { return 0, errors.New("flaky") },
+			func() (int, error) { return 3, nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, ok := cache.Get("foo"); !ok || got != 3 {
+			t.Errorf("expected %d to be cached", 3)
+		}
+	})
+
+	t.Run("all_fail", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+		defer cache.Stop()
+
+		errLast := errors.New("last")
+		_, err := FetchWithFallback[string, int](cache, "foo",
+			func() (int, error) { return 0, errors.New("first") },
+			func() (int, error) { return 0, errLast })
+		if !errors.Is(err, errLast) {
+			t.Errorf("expected %v to be %v", err, errLast)
+		}
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to not be cached")
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"sync"
+)
+
+// flightGroup deduplicates concurrent loads of the same key, so that callers
+// only wait on loads of the key they requested rather than on a cache-wide
+// lock. The zero value is ready for use.
+//
+// Caches close the group when they are stopped, which waits for the in-flight
+// loads to finish, so a load never stores its result into a stopped cache.
+type flightGroup[K comparable, V any] struct {
+	// calls are the in-flight loads, by key, and closed indicates no new loads
+	// may start. They are guarded by lock.
+	calls  map[K]*flightCall[V]
+	closed bool
+	lock   sync.Mutex
+
+	// inflight counts the loads which have started and not finished.
+	inflight sync.WaitGroup
+}
+
+// flightCall is a single in-flight load.
+type flightCall[V any] struct {
+	// done is closed when the load completes, after which value and err are
+	// safe to read.
+	done  chan struct{}
+	value V
+	err   error
+
+	// dups is the number of callers waiting on the load besides the one which
+	// started it. It is guarded by the group's lock.
+	dups int
+}
+
+// do calls fn and returns its results. If a load of the same key is already in
+// flight, do waits for it and returns its results instead of calling fn. If the
+// group is closed, it panics with ErrStopped.
+func (g *flightGroup[K, V]) do(key K, fn func() (V, error)) (V, error) {
+	g.lock.Lock()
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.lock.Unlock()
+		<-c.done
+		return c.value, c.err
+	}
+
+	if g.closed {
+		g.lock.Unlock()
+		panic(ErrStopped)
+	}
+
+	c := g.add(key)
+	g.lock.Unlock()
+
+	defer g.finish(key, c)
+	c.value, c.err = fn()
+	return c.value, c.err
+}
+
+// start calls fn in a new goroutine, for loads which refresh a value in the
+// background, and reports whether it did. If a load of the same key is already
+// in flight or the group is closed, it does nothing. Callers of do for the same
+// key wait for the background load and return its results.
+func (g *flightGroup[K, V]) start(key K, fn func() (V, error)) bool {
+	g.lock.Lock()
+	if _, ok := g.calls[key]; ok || g.closed {
+		g.lock.Unlock()
+		return false
+	}
+
+	c := g.add(key)
+	g.lock.Unlock()
+
+	go func() {
+		defer g.finish(key, c)
+		c.value, c.err = fn()
+	}()
+	return true
+}
+
+// add registers a new in-flight load of the given key. It must be called while
+// holding the lock.
+func (g *flightGroup[K, V]) add(key K) *flightCall[V] {
+	if g.calls == nil {
+		g.calls = make(map[K]*flightCall[V])
+	}
+	c := &flightCall[V]{
+		done: make(chan struct{}),
+		err:  ErrLoaderPanicked,
+	}
+	g.calls[key] = c
+	g.inflight.Add(1)
+	return c
+}
+
+// finish unregisters the completed load of the given key and wakes its
+// waiters.
+func (g *flightGroup[K, V]) finish(key K, c *flightCall[V]) {
+	g.lock.Lock()
+	delete(g.calls, key)
+	g.lock.Unlock()
+
+	close(c.done)
+	g.inflight.Done()
+}
+
+// close prevents new loads from starting and waits for the in-flight loads to
+// finish. It is safe to call more than once.
+func (g *flightGroup[K, V]) close() {
+	g.lock.Lock()
+	g.closed = true
+	g.lock.Unlock()
+
+	g.inflight.Wait()
+}
@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightGroup_do(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deduplicates", func(t *testing.T) {
+		t.Parallel()
+
+		var g flightGroup[string, int]
+
+		var calls int32
+		release := make(chan struct{})
+		started := make(chan struct{})
+
+		var wg sync.WaitGroup
+		results := make([]int, 5)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[0], _ = g.do("foo", func() (int, error) {
+				atomic.AddInt32(&calls, 1)
+				close(started)
+				<-release
+				return 5, nil
+			})
+		}()
+		<-started
+
+		for i := 1; i < len(results); i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = g.do("foo", func() (int, error) {
+					atomic.AddInt32(&calls, 1)
+					return 0, nil
+				})
+			}(i)
+		}
+
+		// Give the other callers time to join the in-flight load.
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		for i, v := range results {
+			if got, want := v, 5; got != want {
+				t.Errorf("%d: expected %d to be %d", i, got, want)
+			}
+		}
+	})
+
+	t.Run("independent_keys", func(t *testing.T) {
+		t.Parallel()
+
+		var g flightGroup[string, int]
+
+		release := make(chan struct{})
+		started := make(chan struct{})
+		go g.do("foo", func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		<-started
+		defer close(release)
+
+		v, err := g.do("bar", func() (int, error) {
+			return 2, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		var g flightGroup[string, int]
+
+		if _, err := g.do("foo", func() (int, error) {
+			return 0, fmt.Errorf("failed")
+		}); err == nil {
+			t.Errorf("expected error")
+		}
+		if got, want := len(g.calls), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		t.Parallel()
+
+		var g flightGroup[string, int]
+
+		release := make(chan struct{})
+		started := make(chan struct{})
+		go func() {
+			defer func() { recover() }()
+			g.do("foo", func() (int, error) {
+				close(started)
+				<-release
+				panic("oops")
+			})
+		}()
+		<-started
+
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := g.do("foo", func() (int, error) {
+				return 1, nil
+			})
+			errCh <- err
+		}()
+
+		close(release)
+		if err := <-errCh; err != nil && !errors.Is(err, ErrLoaderPanicked) {
+			t.Errorf("expected %v to be %v", err, ErrLoaderPanicked)
+		}
+	})
+}
+
+func TestFlightGroup_start(t *testing.T) {
+	t.Parallel()
+
+	var g flightGroup[string, int]
+
+	release := make(chan struct{})
+	if !g.start("foo", func() (int, error) {
+		<-release
+		return 5, nil
+	}) {
+		t.Fatal("expected load to start")
+	}
+
+	// A load of the same key is already in flight.
+	if g.start("foo", func() (int, error) { return 0, nil }) {
+		t.Errorf("expected load not to start")
+	}
+
+	done := make(chan int)
+	go func() {
+		v, _ := g.do("foo", func() (int, error) { return 0, nil })
+		done <- v
+	}()
+
+	// Wait for the caller to join the in-flight load before releasing it.
+	for {
+		g.lock.Lock()
+		dups := g.calls["foo"].dups
+		g.lock.Unlock()
+		if dups > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	if got, want := <-done, 5; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	g.close()
+	if g.start("foo", func() (int, error) { return 0, nil }) {
+		t.Errorf("expected load not to start after close")
+	}
+}
+
+func TestFlightGroup_close(t *testing.T) {
+	t.Parallel()
+
+	var g flightGroup[string, int]
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go g.do("foo", func() (int, error) {
+		close(started)
+		<-release
+		return 5, nil
+	})
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		g.close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("expected close to wait for the in-flight load")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for close")
+	}
+
+	defer func() {
+		if got, want := recover(), ErrStopped; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	}()
+	g.do("bar", func() (int, error) { return 0, nil })
+	t.Errorf("did not panic")
+}
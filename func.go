@@ -0,0 +1,42 @@
+package cache
+
+// Func wraps a function of one comparable argument so its results are cached
+// by argument in the given cache, which determines the eviction policy:
+//
+//	getUser := cache.Func(cache.NewSyncLRU[string, *User](1000), db.GetUser)
+//	u, err := getUser("alice")
+//
+// The function is called through the cache's Fetch, so concurrent calls with
+// the same argument share a single invocation. Errors are not cached, and are
+// returned wrapped in a LoaderError.
+func Func[A comparable, V any](c Cache[A, V], fn func(A) (V, error)) func(A) (V, error) {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+
+	return func(a A) (V, error) {
+		return c.Fetch(a, func() (V, error) {
+			return fn(a)
+		})
+	}
+}
+
+// Func2 is like Func, for a function of two comparable arguments. Results are
+// cached by the pair of arguments.
+func Func2[A, B comparable, V any](c Cache[Key2[A, B], V], fn func(A, B) (V, error)) func(A, B) (V, error) {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+
+	return func(a A, b B) (V, error) {
+		return c.Fetch(NewKey2(a, b), func() (V, error) {
+			return fn(a, b)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFunc(t *testing.T) {
+	t.Parallel()
+
+	c := NewLRU[int, int](10)
+	defer c.Stop()
+
+	var calls int
+	double := Func[int, int](c, func(a int) (int, error) {
+		calls++
+		return a * 2, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		got, err := double(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := 10; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	}
+	if _, err := double(6); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := calls, 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestFunc2(t *testing.T) {
+	t.Parallel()
+
+	t.Run("caches_by_arguments", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewLRU[Key2[string, int], string](10)
+		defer c.Stop()
+
+		var calls int
+		repeat := Func2[string, int, string](c, func(s string, n int) (string, error) {
+			calls++
+			var out string
+			for i := 0; i < n; i++ {
+				out += s
+			}
+			return out, nil
+		})
+
+		cases := []struct {
+			s    string
+			n    int
+			want string
+		}{
+			{"a", 2, "aa"},
+			{"a", 3, "aaa"},
+			{"b", 2, "bb"},
+			{"a", 2, "aa"},
+		}
+		for _, tc := range cases {
+			got, err := repeat(tc.s, tc.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q to be %q", got, tc.want)
+			}
+		}
+
+		if got, want := calls, 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewLRU[Key2[string, int], string](10)
+		defer c.Stop()
+
+		errNope := errors.New("nope")
+		fn := Func2[string, int, string](c, func(string, int) (string, error) {
+			return "", errNope
+		})
+
+		if _, err := fn("a", 1); !errors.Is(err, errNope) {
+			t.Errorf("expected %v to be %v", err, errNope)
+		}
+		if _, ok := c.Get(NewKey2("a", 1)); ok {
+			t.Errorf("expected error not to be cached")
+		}
+	})
+}
@@ -0,0 +1,283 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var (
+	_ Cache[string, string]       = (*Generational[string, string])(nil)
+	_ EntryLister[string, string] = (*Generational[string, string])(nil)
+)
+
+// Generational implements a cache composed of a fixed number of generations,
+// each of which is a plain map. Sets go to the newest generation, and Gets
+// check the generations from newest to oldest, promoting entries found in an
+// older generation to the newest. On every rotation, the oldest generation is
+// dropped wholesale and a new, empty generation becomes the newest.
+//
+// As a result, an entry which is not accessed is removed after between
+// generations-1 and generations rotations. Expiring entries in bulk costs O(1)
+// per rotation and nothing per entry, at the expense of precise expiration.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Generational[K comparable, V any] struct {
+	// generations are the generations, newest first.
+	generations []map[K]*generationalItem[V]
+
+	// stopped indicates whether the cache is stopped. stopCh is a channel used to
+	// control cancellation.
+	stopped uint32
+	stopCh  chan struct{}
+
+	// lock is the internal lock to allow for concurrent operations.
+	lock waitMutex
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// generationalItem is an entry in a generation.
+type generationalItem[V any] struct {
+	meta  entryMeta
+	value V
+}
+
+// NewGenerational creates a new generational cache with the given number of
+// generations, rotating every interval. There must be at least two
+// generations, since entries are dropped with the oldest generation.
+func NewGenerational[K comparable, V any](generations int, interval time.Duration) *Generational[K, V] {
+	if generations < 2 {
+		panic("generations must be at least 2")
+	}
+	if interval <= 0 {
+		panic("interval must be greater than 0")
+	}
+
+	c := &Generational[K, V]{
+		generations: make([]map[K]*generationalItem[V], generations),
+		stopCh:      make(chan struct{}),
+	}
+	for i := range c.generations {
+		c.generations[i] = make(map[K]*generationalItem[V], 16)
+	}
+
+	go c.start(interval)
+
+	return c
+}
+
+// Get fetches the cache item at the given key. If the item is found in an older
+// generation, it is promoted to the newest generation. If the value does not
+// exist, it returns the zero value for the object and the second parameter will
+// be false.
+func (c *Generational[K, V]) Get(key K) (V, bool) {
+	now := time.Now().UnixNano()
+
+	// Entries in the newest generation do not need to move, so they can be read
+	// under the shared lock.
+	c.lock.RLock()
+	if c.isStopped() {
+		c.lock.RUnlock()
+		panic(ErrStopped)
+	}
+	if item, ok := c.generations[0][key]; ok {
+		// Set overwrites the value in place, so it must be read before the lock
+		// is released.
+		v := item.value
+		item.meta.recordAccess(now)
+		c.lock.RUnlock()
+		return v, true
+	}
+	c.lock.RUnlock()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	for i, gen := range c.generations {
+		item, ok := gen[key]
+		if !ok {
+			continue
+		}
+
+		if i > 0 {
+			delete(gen, key)
+			c.generations[0][key] = item
+		}
+		item.meta.recordAccess(now)
+		return item.value, true
+	}
+
+	var zeroV V
+	return zeroV, false
+}
+
+// Set inserts the value in the newest generation. If an entry already exists
+// at the given key, it is overwritten.
+func (c *Generational[K, V]) Set(key K, val V) {
+	now := time.Now().UnixNano()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	for _, gen := range c.generations[1:] {
+		delete(gen, key)
+	}
+
+	item, ok := c.generations[0][key]
+	if !ok {
+		item = new(generationalItem[V])
+		c.generations[0][key] = item
+	}
+	item.value = val
+	item.meta.reset(now)
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. The FetchFunc runs without holding the cache's lock, and
+// concurrent calls to Fetch for the same key share a single invocation.
+func (c *Generational[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+
+	return c.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := c.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		c.Set(key, v)
+		return v, nil
+	})
+}
+
+// Rotate drops the oldest generation and starts a new, empty generation. It is
+// called automatically at the configured interval, but may be called manually
+// to expire entries early. It returns the number of entries dropped.
+func (c *Generational[K, V]) Rotate() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+	return c.rotate()
+}
+
+// rotate is the internal implementation of Rotate. It does not lock.
+func (c *Generational[K, V]) rotate() int {
+	n := len(c.generations)
+	dropped := len(c.generations[n-1])
+
+	copy(c.generations[1:], c.generations[:n-1])
+	c.generations[0] = make(map[K]*generationalItem[V], 16)
+	return dropped
+}
+
+// Entries returns a snapshot of the entries in the cache, including their
+// metadata, from the oldest generation to the newest. The order of entries
+// within a generation is unspecified.
+func (c *Generational[K, V]) Entries() []Entry[K, V] {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	entries := make([]Entry[K, V], 0, c.len())
+	for i := len(c.generations) - 1; i >= 0; i-- {
+		for key, item := range c.generations[i] {
+			entry := Entry[K, V]{
+				Key:   key,
+				Value: item.value,
+			}
+			fillEntry(&entry, &item.meta)
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Len returns the number of entries across all generations.
+func (c *Generational[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.len()
+}
+
+// len is the internal implementation of Len. It does not lock.
+func (c *Generational[K, V]) len() int {
+	var n int
+	for _, gen := range c.generations {
+		n += len(gen)
+	}
+	return n
+}
+
+// LockWait returns the total time operations have spent waiting to acquire the
+// cache's internal lock. A steadily increasing value indicates contention.
+func (c *Generational[K, V]) LockWait() time.Duration {
+	return c.lock.waitTime()
+}
+
+// Stop clears the cache, stops rotation, and prevents new entries from being
+// added and retrieved.
+func (c *Generational[K, V]) Stop() {
+	c.loads.close()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&c.stopped, 0, 1) {
+		return
+	}
+	close(c.stopCh)
+
+	for i := range c.generations {
+		c.generations[i] = nil
+	}
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (c *Generational[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&c.stopped) == 1
+}
+
+// start rotates the generations at the given interval. It runs until stopped
+// via Stop() and is intended to be called as a goroutine.
+func (c *Generational[K, V]) start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.lock.Lock()
+			if !c.isStopped() {
+				c.rotate()
+			}
+			c.lock.Unlock()
+		}
+	}
+}
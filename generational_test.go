@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewGenerational(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGenerational[string, int](3, time.Hour)
+		defer cache.Stop()
+
+		if got, want := len(cache.generations), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("panic_on_too_few", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "generations must be at least 2"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		NewGenerational[string, int](1, time.Hour)
+		t.Errorf("did not panic")
+	})
+}
+
+func TestGenerational_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("promotes", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGenerational[string, int](3, time.Hour)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Rotate()
+		cache.Rotate()
+
+		if _, ok := cache.generations[2]["foo"]; !ok {
+			t.Fatalf("expected foo to be in the oldest generation")
+		}
+
+		if v, ok := cache.Get("foo"); !ok || v != 1 {
+			t.Errorf("expected %d, %t to be 1, true", v, ok)
+		}
+		if _, ok := cache.generations[0]["foo"]; !ok {
+			t.Errorf("expected foo to be promoted to the newest generation")
+		}
+		if got, want := cache.Len(), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		// The promoted entry survives the next rotation.
+		cache.Rotate()
+		if _, ok := cache.Get("foo"); !ok {
+			t.Errorf("expected foo to survive rotation")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGenerational[string, int](2, time.Hour)
+		defer cache.Stop()
+
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to be missing")
+		}
+	})
+
+	t.Run("concurrent_set", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGenerational[string, int](2, time.Hour)
+		defer cache.Stop()
+
+		cache.Set("foo", 0)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				cache.Set("foo", i)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if _, ok := cache.Get("foo"); !ok {
+					t.Errorf("expected foo to exist")
+					return
+				}
+			}
+		}()
+		wg.Wait()
+	})
+}
+
+func TestGenerational_Set(t *testing.T) {
+	t.Parallel()
+
+	cache := NewGenerational[string, int](2, time.Hour)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Rotate()
+	cache.Set("foo", 2)
+
+	if got, want := cache.Len(), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if v, _ := cache.Get("foo"); v != 2 {
+		t.Errorf("expected %d to be %d", v, 2)
+	}
+}
+
+func TestGenerational_Fetch(t *testing.T) {
+	t.Parallel()
+
+	cache := NewGenerational[string, int](2, time.Hour)
+	defer cache.Stop()
+
+	v, err := cache.Fetch("foo", func() (int, error) {
+		return 5, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, 5; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	if _, err := cache.Fetch("foo", func() (int, error) {
+		t.Errorf("function was called")
+		return 0, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.Fetch("bar", func() (int, error) {
+		return 0, fmt.Errorf("error")
+	}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGenerational_Rotate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("drops_oldest", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGenerational[string, int](2, time.Hour)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Set("bar", 2)
+
+		if got, want := cache.Rotate(), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.Rotate(), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.Len(), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("background", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGenerational[string, int](2, 10*time.Millisecond)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+
+		deadline := time.Now().Add(time.Second)
+		for cache.Len() != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for rotation")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+}
+
+func TestGenerational_Entries(t *testing.T) {
+	t.Parallel()
+
+	cache := NewGenerational[string, int](2, time.Hour)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Rotate()
+	cache.Set("bar", 2)
+
+	entries := cache.Entries()
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	if got, want := entries[0].Key, "foo"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := entries[1].Key, "bar"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
+
+func TestGenerational_Stop(t *testing.T) {
+	t.Parallel()
+
+	cache := NewGenerational[string, int](2, time.Hour)
+	cache.Set("foo", 1)
+	cache.Stop()
+	cache.Stop()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "cache is stopped"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	cache.Get("foo")
+	t.Errorf("did not panic")
+}
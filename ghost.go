@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"sync/atomic"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Ghost[string, string])(nil)
+
+// Ghost wraps a cache and remembers the keys of recently evicted entries in a
+// bounded "ghost" list. When a lookup misses the cache but the key is in the
+// ghost list, the lookup would have been a hit if the cache were larger. This
+// provides an estimate of the hit ratio the cache would achieve with more
+// capacity, without storing any additional values.
+//
+// With a ghost list the same size as the cache, the estimate is for a cache of
+// twice the capacity. For an LRU cache which is only used through the ghost
+// cache, and whose misses are followed by setting the key, as with Fetch, the
+// estimate is exact; otherwise it is an approximation.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Ghost[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// ghost holds the keys of recently evicted entries.
+	ghost *LRU[K, struct{}]
+
+	// stats are the lookup counters. They are updated atomically.
+	stats GhostStats
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, ghostLoad[V]]
+}
+
+// ghostLoad is the result of a Fetch load, shared by the callers which wait on
+// it.
+type ghostLoad[V any] struct {
+	value V
+
+	// missed indicates the FetchFunc was called, and ghost indicates the key was
+	// in the ghost list when it was.
+	missed bool
+	ghost  bool
+}
+
+// GhostStats are the lookup statistics for a ghost cache.
+type GhostStats struct {
+	// Hits and Misses are the number of lookups which found or did not find a
+	// cached value, respectively.
+	Hits   uint64
+	Misses uint64
+
+	// GhostHits is the number of misses for keys which were in the ghost list,
+	// and would therefore have been hits in a larger cache.
+	GhostHits uint64
+}
+
+// HitRatio returns the ratio of hits to total lookups, or 0 if there have been
+// no lookups.
+func (s GhostStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Delta returns the statistics accumulated since prev, which must be an earlier
+// result of Stats from the same cache, so per-interval rates can be computed
+// from periodic snapshots.
+func (s GhostStats) Delta(prev GhostStats) GhostStats {
+	return GhostStats{
+		Hits:      s.Hits - prev.Hits,
+		Misses:    s.Misses - prev.Misses,
+		GhostHits: s.GhostHits - prev.GhostHits,
+	}
+}
+
+// PotentialHitRatio returns the estimated ratio of hits to total lookups if
+// the cache's capacity were increased by the size of the ghost list, or 0 if
+// there have been no lookups.
+func (s GhostStats) PotentialHitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.GhostHits) / float64(total)
+}
+
+// NewGhost wraps the given cache, remembering up to capacity evicted keys. The
+// cache must implement EvictNotifier, which all of the capacity-bounded caches
+// in this package do.
+func NewGhost[K comparable, V any](c EvictNotifierCache[K, V], capacity int64) *Ghost[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	g := &Ghost[K, V]{
+		cache: c,
+		ghost: NewLRU[K, struct{}](capacity),
+	}
+	c.OnEvict(func(k K, _ V) {
+		g.ghost.Set(k, struct{}{})
+	})
+	return g
+}
+
+// Get fetches the cache item at the given key from the underlying cache,
+// recording whether it was a hit, miss, or ghost hit.
+func (g *Ghost[K, V]) Get(key K) (V, bool) {
+	v, ok := g.cache.Get(key)
+	g.record(key, ok)
+	return v, ok
+}
+
+// Set inserts the value in the underlying cache and removes the key from the
+// ghost list, since it is cached again.
+func (g *Ghost[K, V]) Set(key K, val V) {
+	g.cache.Set(key, val)
+	g.ghost.Delete(key)
+}
+
+// Fetch retrieves the cached value from the underlying cache, recording
+// whether it was a hit, miss, or ghost hit. Concurrent calls for the same key
+// share a single load, and each is recorded as a miss if the load called the
+// FetchFunc.
+func (g *Ghost[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	load, err := g.loads.do(key, func() (ghostLoad[V], error) {
+		var load ghostLoad[V]
+		v, err := g.cache.Fetch(key, func() (V, error) {
+			load.missed = true
+			_, load.ghost = g.ghost.Peek(key)
+			return fn()
+		})
+		if load.missed && err == nil {
+			g.ghost.Delete(key)
+		}
+		load.value = v
+		return load, err
+	})
+
+	// A load which panicked is recorded as a miss by the callers waiting on it.
+	if !load.missed && err == nil {
+		atomic.AddUint64(&g.stats.Hits, 1)
+	} else {
+		g.recordMiss(load.ghost)
+	}
+	return load.value, err
+}
+
+// Stop stops the underlying cache and clears the ghost list. It first waits for
+// in-flight Fetch loads to finish.
+func (g *Ghost[K, V]) Stop() {
+	g.loads.close()
+	g.cache.Stop()
+	g.ghost.Stop()
+}
+
+// Stats returns the lookup statistics.
+func (g *Ghost[K, V]) Stats() GhostStats {
+	return GhostStats{
+		Hits:      atomic.LoadUint64(&g.stats.Hits),
+		Misses:    atomic.LoadUint64(&g.stats.Misses),
+		GhostHits: atomic.LoadUint64(&g.stats.GhostHits),
+	}
+}
+
+// record records the result of a lookup.
+func (g *Ghost[K, V]) record(key K, hit bool) {
+	if hit {
+		atomic.AddUint64(&g.stats.Hits, 1)
+		return
+	}
+
+	_, ghost := g.ghost.Peek(key)
+	g.recordMiss(ghost)
+}
+
+// recordMiss records a lookup which missed, and whether the key was in the
+// ghost list.
+func (g *Ghost[K, V]) recordMiss(ghost bool) {
+	atomic.AddUint64(&g.stats.Misses, 1)
+	if ghost {
+		atomic.AddUint64(&g.stats.GhostHits, 1)
+	}
+}
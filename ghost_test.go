@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGhost_Stats(t *testing.T) {
+	t.Parallel()
+
+	cache := NewGhost[int, int](NewLRU[int, int](2), 2)
+	defer cache.Stop()
+
+	cache.Set(1, 1)
+	cache.Set(2, 2)
+	cache.Set(3, 3) // evicts 1
+	cache.Set(4, 4) // evicts 2
+
+	cache.Get(3) // hit
+	cache.Get(1) // ghost hit
+	cache.Get(9) // miss
+
+	if _, err := cache.Fetch(2, func() (int, error) { return 2, nil }); err != nil { // ghost hit
+		t.Fatal(err)
+	}
+
+	stats := cache.Stats()
+	if got, want := stats.Hits, uint64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.Misses, uint64(3); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.GhostHits, uint64(2); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.HitRatio(), 0.25; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+	if got, want := stats.PotentialHitRatio(), 0.75; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+}
+
+func TestGhost_Set(t *testing.T) {
+	t.Parallel()
+
+	cache := NewGhost[int, int](NewLRU[int, int](1), 2)
+	defer cache.Stop()
+
+	cache.Set(1, 1)
+	cache.Set(2, 2) // evicts 1
+	cache.Set(1, 1) // readmits 1, evicts 2
+
+	// Setting a ghost key removes it from the ghost list.
+	if _, ok := cache.ghost.Peek(1); ok {
+		t.Errorf("expected 1 not to be a ghost")
+	}
+
+	cache.Set(3, 3) // evicts 1
+	cache.Get(1)    // ghost hit
+	cache.Get(2)    // ghost hit
+	cache.Get(4)    // miss
+
+	stats := cache.Stats()
+	if got, want := stats.Misses, uint64(3); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.GhostHits, uint64(2); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestGhost_Fetch(t *testing.T) {
+	t.Parallel()
+
+	cache := NewGhost[string, int](NewLRU[string, int](1), 1)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2) // evicts foo
+
+	// Callers which wait on another caller's load are misses, not hits.
+	release := make(chan struct{})
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := cache.Fetch("foo", func() (int, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 5, nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			if v != 5 {
+				t.Errorf("expected %d to be %d", v, 5)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	stats := cache.Stats()
+	if got, want := stats.Hits, uint64(0); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.Misses, uint64(10); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.GhostHits, uint64(10); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// The loaded key was readmitted, so it is no longer a ghost.
+	if _, ok := cache.ghost.Peek("foo"); ok {
+		t.Errorf("expected foo not to be a ghost")
+	}
+}
+
+func TestGhost_empty(t *testing.T) {
+	t.Parallel()
+
+	var stats GhostStats
+	if got, want := stats.HitRatio(), 0.0; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+	if got, want := stats.PotentialHitRatio(), 0.0; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+}
+
+func TestGhostStats_Delta(t *testing.T) {
+	t.Parallel()
+
+	prev := GhostStats{Hits: 1, Misses: 2, GhostHits: 1}
+	cur := GhostStats{Hits: 5, Misses: 3, GhostHits: 1}
+
+	if got, want := cur.Delta(prev), (GhostStats{Hits: 4, Misses: 1}); got != want {
+		t.Errorf("expected %#v to be %#v", got, want)
+	}
+}
@@ -0,0 +1,21 @@
+module github.com/sethvargo/go-cache/grpccache
+
+go 1.18
+
+require (
+	github.com/sethvargo/go-cache v0.0.0
+	google.golang.org/grpc v1.57.2
+	google.golang.org/protobuf v1.33.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.17.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230525234030-28d5490b6b19 // indirect
+)
+
+// The adapter is developed against the cache in this repository rather than a
+// published release.
+replace github.com/sethvargo/go-cache => ../
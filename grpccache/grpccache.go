@@ -0,0 +1,170 @@
+// Package grpccache implements a gRPC unary client interceptor which caches
+// responses in any cache, keyed by method and request:
+//
+//	responses := cache.NewSyncLRU[string, *grpccache.Response](10_000)
+//	defer responses.Stop()
+//
+//	conn, err := grpc.Dial(target, grpc.WithUnaryInterceptor(
+//	  grpccache.UnaryClientInterceptor(responses,
+//	    grpccache.WithTTL(time.Minute),
+//	    grpccache.WithMethods("/users.v1.Users/GetUser"))))
+//
+// Only successful responses are cached. Since a cached response is returned
+// without calling the server, only read-only methods should be cached.
+//
+// The package is a separate module, so the go-cache module does not depend on
+// gRPC.
+package grpccache
+
+import (
+	"context"
+	"time"
+
+	"github.com/sethvargo/go-cache"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Response is a cached response.
+type Response struct {
+	// msg is a copy of the response message, which is never modified.
+	msg proto.Message
+
+	// expiresAt is the time at which the response expires, or the zero value if
+	// it does not expire.
+	expiresAt time.Time
+}
+
+// Message returns a copy of the cached response message.
+func (r *Response) Message() proto.Message {
+	return proto.Clone(r.msg)
+}
+
+// ExpiresAt returns the time at which the response expires, or the zero value
+// if it does not expire.
+func (r *Response) ExpiresAt() time.Time {
+	return r.expiresAt
+}
+
+// expired reports whether the response has expired at the given time.
+func (r *Response) expired(now time.Time) bool {
+	return !r.expiresAt.IsZero() && !now.Before(r.expiresAt)
+}
+
+// KeyFunc returns the cache key for a request to the given method. If the
+// second return value is false, the response is not cached.
+type KeyFunc func(ctx context.Context, method string, req proto.Message) (string, bool)
+
+// Option is an option for configuring the interceptor.
+type Option func(*options)
+
+// options are the options for the interceptor.
+type options struct {
+	ttl     time.Duration
+	key     KeyFunc
+	methods map[string]struct{}
+}
+
+// WithTTL expires cached responses after the given TTL. By default, responses
+// are kept until the cache evicts them.
+func WithTTL(ttl time.Duration) Option {
+	if ttl <= 0 {
+		panic("ttl must be greater than 0")
+	}
+
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithKeyFunc sets the function which computes the cache key for a request,
+// for example to include metadata from the context such as the caller's
+// identity, or to ignore fields which do not affect the response. The default
+// key is the method and the deterministic encoding of the request.
+func WithKeyFunc(fn KeyFunc) Option {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+
+	return func(o *options) {
+		o.key = fn
+	}
+}
+
+// WithMethods only caches responses for the given fully-qualified methods, such
+// as "/users.v1.Users/GetUser". By default, responses for all methods are
+// cached. It may be given more than once.
+func WithMethods(methods ...string) Option {
+	return func(o *options) {
+		if o.methods == nil {
+			o.methods = make(map[string]struct{}, len(methods))
+		}
+		for _, m := range methods {
+			o.methods[m] = struct{}{}
+		}
+	}
+}
+
+// DefaultKey returns the method and the deterministic encoding of the request.
+// It is the default KeyFunc. Requests which cannot be encoded are not cached.
+func DefaultKey(_ context.Context, method string, req proto.Message) (string, bool) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	return method + "\x00" + string(b), true
+}
+
+// UnaryClientInterceptor returns an interceptor which serves responses from the
+// given cache, calling the server and caching its response on a miss. Requests
+// and responses which are not protocol buffer messages are passed through.
+func UnaryClientInterceptor(c cache.Cache[string, *Response], opts ...Option) grpc.UnaryClientInterceptor {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	o := options{
+		key: DefaultKey,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		reqMsg, ok := req.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		replyMsg, ok := reply.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		if o.methods != nil {
+			if _, ok := o.methods[method]; !ok {
+				return invoker(ctx, method, req, reply, cc, callOpts...)
+			}
+		}
+
+		key, ok := o.key(ctx, method, reqMsg)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		if r, ok := c.Get(key); ok && !r.expired(time.Now()) {
+			proto.Reset(replyMsg)
+			proto.Merge(replyMsg, r.msg)
+			return nil
+		}
+
+		if err := invoker(ctx, method, req, reply, cc, callOpts...); err != nil {
+			return err
+		}
+
+		r := &Response{msg: proto.Clone(replyMsg)}
+		if o.ttl > 0 {
+			r.expiresAt = time.Now().Add(o.ttl)
+		}
+		c.Set(key, r)
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+package grpccache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-cache"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testMethod = "/test.v1.Test/Echo"
+
+// testInvoker returns an invoker which replies with the request's value and the
+// number of calls so far, and a pointer to the number of calls.
+func testInvoker() (grpc.UnaryInvoker, *int) {
+	var calls int
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		in := req.(*wrapperspb.StringValue)
+		if in.GetValue() == "fail" {
+			return errors.New("failed")
+		}
+		reply.(*wrapperspb.StringValue).Value = in.GetValue() + "!"
+		return nil
+	}, &calls
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("caches", func(t *testing.T) {
+		t.Parallel()
+
+		c := cache.NewSyncLRU[string, *Response](10)
+		defer c.Stop()
+
+		interceptor := UnaryClientInterceptor(c)
+		invoker, calls := testInvoker()
+
+		for i := 0; i < 3; i++ {
+			reply := new(wrapperspb.StringValue)
+			if err := interceptor(context.Background(), testMethod, wrapperspb.String("foo"), reply, nil, invoker); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := reply.GetValue(), "foo!"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+
+			// Modifying the reply does not modify the cached response.
+			reply.Value = "modified"
+		}
+		if got, want := *calls, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		reply := new(wrapperspb.StringValue)
+		if err := interceptor(context.Background(), testMethod, wrapperspb.String("bar"), reply, nil, invoker); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := *calls, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		c := cache.NewSyncLRU[string, *Response](10)
+		defer c.Stop()
+
+		interceptor := UnaryClientInterceptor(c)
+		invoker, calls := testInvoker()
+
+		for i := 0; i < 2; i++ {
+			err := interceptor(context.Background(), testMethod, wrapperspb.String("fail"), new(wrapperspb.StringValue), nil, invoker)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		}
+		if got, want := *calls, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		t.Parallel()
+
+		c := cache.NewSyncLRU[string, *Response](10)
+		defer c.Stop()
+
+		interceptor := UnaryClientInterceptor(c, WithTTL(10*time.Millisecond))
+		invoker, calls := testInvoker()
+
+		call := func() {
+			if err := interceptor(context.Background(), testMethod, wrapperspb.String("foo"), new(wrapperspb.StringValue), nil, invoker); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		call()
+		call()
+		time.Sleep(20 * time.Millisecond)
+		call()
+		if got, want := *calls, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("methods", func(t *testing.T) {
+		t.Parallel()
+
+		c := cache.NewSyncLRU[string, *Response](10)
+		defer c.Stop()
+
+		interceptor := UnaryClientInterceptor(c, WithMethods("/test.v1.Test/Other"))
+		invoker, calls := testInvoker()
+
+		for i := 0; i < 2; i++ {
+			if err := interceptor(context.Background(), testMethod, wrapperspb.String("foo"), new(wrapperspb.StringValue), nil, invoker); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got, want := *calls, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("key_func", func(t *testing.T) {
+		t.Parallel()
+
+		c := cache.NewSyncLRU[string, *Response](10)
+		defer c.Stop()
+
+		// Every request shares a single key.
+		interceptor := UnaryClientInterceptor(c, WithKeyFunc(func(_ context.Context, method string, _ proto.Message) (string, bool) {
+			return method, true
+		}))
+		invoker, calls := testInvoker()
+
+		reply := new(wrapperspb.StringValue)
+		for _, v := range []string{"foo", "bar"} {
+			if err := interceptor(context.Background(), testMethod, wrapperspb.String(v), reply, nil, invoker); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got, want := reply.GetValue(), "foo!"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := *calls, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		r, ok := c.Get(testMethod)
+		if !ok {
+			t.Fatal("expected response to be cached")
+		}
+		if got, want := r.Message().(*wrapperspb.StringValue).GetValue(), "foo!"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// hashSeed seeds the hashing of keys. Each cache which hashes keys has its own
+// seed, so keys which collide in one cache do not collide in every cache.
+type hashSeed struct {
+	seed maphash.Seed
+
+	// salt is derived from seed, for mixing into the hashes of integers.
+	salt uint64
+}
+
+// newHashSeed returns a new random seed.
+func newHashSeed() hashSeed {
+	var h maphash.Hash
+	seed := maphash.MakeSeed()
+	h.SetSeed(seed)
+	return hashSeed{seed: seed, salt: h.Sum64()}
+}
+
+// keySeed seeds the hashes computed by the Hash methods of multi-part keys,
+// when they are not hashed by a cache.
+var keySeed = newHashSeed()
+
+// hashKey returns a 64-bit hash of the given key with the given seed. Keys
+// which implement Hasher and common key types are hashed directly; any other
+// comparable type is hashed by its comparable representation, so that keys
+// which are == always hash the same. In particular, pointers are hashed by
+// address rather than by what they point to. The hashes of Hasher keys are
+// mixed with the seed, and the parts of Key2 and Key3 are hashed with it.
+func hashKey[K comparable](seed hashSeed, key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		var h maphash.Hash
+		h.SetSeed(seed.seed)
+		h.WriteString(k)
+		return h.Sum64()
+	case int:
+		return mix64(uint64(k) ^ seed.salt)
+	case int8:
+		return mix64(uint64(k) ^ seed.salt)
+	case int16:
+		return mix64(uint64(k) ^ seed.salt)
+	case int32:
+		return mix64(uint64(k) ^ seed.salt)
+	case int64:
+		return mix64(uint64(k) ^ seed.salt)
+	case uint:
+		return mix64(uint64(k) ^ seed.salt)
+	case uint8:
+		return mix64(uint64(k) ^ seed.salt)
+	case uint16:
+		return mix64(uint64(k) ^ seed.salt)
+	case uint32:
+		return mix64(uint64(k) ^ seed.salt)
+	case uint64:
+		return mix64(k ^ seed.salt)
+	case uintptr:
+		return mix64(uint64(k) ^ seed.salt)
+	case float32:
+		return mix64(floatBits(float64(k)) ^ seed.salt)
+	case float64:
+		return mix64(floatBits(k) ^ seed.salt)
+	default:
+		// Hasher is checked separately from calling Hash through the interface,
+		// which makes the converted key escape to the heap, so keys which do not
+		// implement it are not allocated.
+		if _, ok := any(key).(Hasher); ok {
+			if _, ok := any(key).(seededHasher); ok {
+				return any(key).(seededHasher).hashSeeded(seed)
+			}
+			return mix64(any(key).(Hasher).Hash() ^ seed.salt)
+		}
+		return hashComparable(seed.seed, key)
+	}
+}
+
+// floatBits returns the bits of the given float, with -0 normalized to 0 since
+// they are ==.
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+// hashReflect hashes the comparable representation of the given key using
+// reflection, consistently with ==: pointers, channels, and unsafe pointers are
+// hashed by address, floats are normalized, interfaces are hashed by their
+// dynamic type and value, and blank struct fields are ignored. It is the
+// fallback for toolchains without maphash.Comparable.
+func hashReflect[K comparable](seed maphash.Seed, key K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	writeHashValue(&h, reflect.ValueOf(&key).Elem())
+	return h.Sum64()
+}
+
+// writeHashValue writes the comparable representation of v to h.
+func writeHashValue(h *maphash.Hash, v reflect.Value) {
+	var buf [8]byte
+	writeUint64 := func(x uint64) {
+		binary.LittleEndian.PutUint64(buf[:], x)
+		h.Write(buf[:])
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint64(floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeUint64(floatBits(real(c)))
+		writeUint64(floatBits(imag(c)))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint64(uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			writeHashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).Name == "_" {
+				continue
+			}
+			writeHashValue(h, v.Field(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+		e := v.Elem()
+		h.WriteByte(1)
+		h.WriteString(e.Type().String())
+		writeHashValue(h, e)
+	}
+}
+
+// mix64 is the splitmix64 finalizer. It spreads sequential integers across the
+// full 64-bit space so they distribute evenly when taken modulo a small number.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// combineHashes combines two hashes into one, such that the order of the
+// inputs matters.
+func combineHashes(a, b uint64) uint64 {
+	return mix64(a*31 + b)
+}
@@ -0,0 +1,12 @@
+//go:build go1.24
+
+package cache
+
+import (
+	"hash/maphash"
+)
+
+// hashComparable hashes the comparable representation of the given key.
+func hashComparable[K comparable](seed maphash.Seed, key K) uint64 {
+	return maphash.Comparable(seed, key)
+}
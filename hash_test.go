@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"hash/maphash"
+	"math"
+	"testing"
+)
+
+func TestHashKey(t *testing.T) {
+	t.Parallel()
+
+	type compound struct {
+		a string
+		b int
+	}
+
+	seed := newHashSeed()
+
+	if got, want := hashKey(seed, "foo"), hashKey(seed, "foo"); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if hashKey(seed, "foo") == hashKey(seed, "bar") {
+		t.Errorf("expected different hashes")
+	}
+	if hashKey(seed, 1) == hashKey(seed, 2) {
+		t.Errorf("expected different hashes")
+	}
+	if got, want := hashKey(seed, compound{"a", 1}), hashKey(seed, compound{"a", 1}); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if hashKey(seed, compound{"a", 1}) == hashKey(seed, compound{"a", 2}) {
+		t.Errorf("expected different hashes")
+	}
+	if got, want := hashKey(seed, math.Copysign(0, -1)), hashKey(seed, 0.0); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Pointers are hashed by identity, not by what they point to.
+	p := &compound{"a", 1}
+	before := hashKey(seed, p)
+	p.a = "b"
+	if got, want := hashKey(seed, p), before; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if hashKey(seed, p) == hashKey(seed, &compound{"b", 1}) {
+		t.Errorf("expected different hashes")
+	}
+}
+
+func TestHashReflect(t *testing.T) {
+	t.Parallel()
+
+	type blank struct {
+		a int
+		_ int
+	}
+
+	type compound struct {
+		s string
+		f float64
+		c complex128
+		p *int
+		a [2]bool
+	}
+
+	seed := maphash.MakeSeed()
+	x, y := 1, 1
+
+	cases := []struct {
+		name  string
+		a, b  compound
+		equal bool
+	}{
+		{
+			name:  "equal",
+			a:     compound{s: "foo", f: 1.5, p: &x, a: [2]bool{true, false}},
+			b:     compound{s: "foo", f: 1.5, p: &x, a: [2]bool{true, false}},
+			equal: true,
+		},
+		{
+			name:  "negative_zero",
+			a:     compound{f: math.Copysign(0, -1), c: complex(math.Copysign(0, -1), 0)},
+			b:     compound{},
+			equal: true,
+		},
+		{
+			name: "pointer_identity",
+			a:    compound{p: &x},
+			b:    compound{p: &y},
+		},
+		{
+			name: "string",
+			a:    compound{s: "foo"},
+			b:    compound{s: "bar"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.equal != (tc.a == tc.b) {
+				t.Fatalf("expected equality to be %t", tc.equal)
+			}
+
+			equal := hashReflect(seed, tc.a) == hashReflect(seed, tc.b)
+			if equal != tc.equal {
+				t.Errorf("expected hashes equal to be %t", tc.equal)
+			}
+		})
+	}
+
+	// Blank fields are ignored by ==.
+	if got, want := hashReflect(seed, blank{a: 1}), hashReflect(seed, blank{a: 1}); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"sync"
+)
+
+// hashedLockStripes is the number of locks used to serialize writes to buckets.
+const hashedLockStripes = 64
+
+// Hashed is a cache for keys which are not comparable, such as slices or
+// structs containing maps. Keys are hashed to a uint64 with a user-supplied
+// function and stored in an underlying cache keyed by that hash; entries whose
+// keys collide share a bucket and are distinguished with a user-supplied
+// equality function.
+//
+// Eviction operates on buckets, so colliding keys are evicted together. With a
+// well-distributed 64-bit hash, collisions are rare.
+//
+// Keys must not be mutated after they are passed to the cache. Since Go
+// requires the keys of a Cache to be comparable, Hashed has the same methods as
+// a Cache but cannot be used as one.
+//
+// K can be any type for which hash and equality functions can be written. V can
+// be any type, but pointers are best for performance.
+type Hashed[K any, V any] struct {
+	// cache is the underlying cache of buckets, keyed by hash.
+	cache Cache[uint64, HashedBucket[K, V]]
+
+	// hash and equal are the user-supplied hash and equality functions.
+	hash  func(K) uint64
+	equal func(K, K) bool
+
+	// locks serialize the read-modify-write of a bucket. Buckets are
+	// copy-on-write, so readers do not need to lock.
+	locks [hashedLockStripes]sync.Mutex
+
+	// loads are the in-flight Fetch loads, by hash.
+	loads flightGroup[uint64, hashedLoad[K, V]]
+}
+
+// hashedLoad is the result of a Fetch load. Loads are deduplicated by hash, so
+// it records which key was loaded, since a caller may have waited on the load
+// of a different key with the same hash.
+type hashedLoad[K any, V any] struct {
+	key   K
+	value V
+
+	// ran is true if the load function returned, rather than panicked.
+	ran bool
+}
+
+// HashedBucket is the set of entries which share a hash in a Hashed cache. It
+// is the value type of the underlying cache.
+type HashedBucket[K any, V any] []hashedEntry[K, V]
+
+// hashedEntry is a single key-value pair within a bucket.
+type hashedEntry[K any, V any] struct {
+	key   K
+	value V
+}
+
+// NewHashed wraps the given cache of buckets, using hash and equal to locate
+// keys. The hash function must return equal hashes for keys which are equal.
+//
+//	c := cache.NewHashed[[]byte, string](cache.NewLRU[uint64, cache.HashedBucket[[]byte, string]](100),
+//		func(k []byte) uint64 { return xxhash.Sum64(k) },
+//		bytes.Equal)
+func NewHashed[K any, V any](c Cache[uint64, HashedBucket[K, V]], hash func(K) uint64, equal func(K, K) bool) *Hashed[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if hash == nil {
+		panic("hash cannot be nil")
+	}
+	if equal == nil {
+		panic("equal cannot be nil")
+	}
+
+	return &Hashed[K, V]{
+		cache: c,
+		hash:  hash,
+		equal: equal,
+	}
+}
+
+// Get fetches the cache item at the given key. If the item exists, it is
+// returned. If it does not exist, the second argument will be false.
+func (h *Hashed[K, V]) Get(key K) (V, bool) {
+	bucket, _ := h.cache.Get(h.hash(key))
+	return h.find(bucket, key)
+}
+
+// Set inserts the value in the cache. If an entry already exists at the given
+// key, it is overwritten.
+func (h *Hashed[K, V]) Set(key K, val V) {
+	hash := h.hash(key)
+
+	lock := &h.locks[hash%hashedLockStripes]
+	lock.Lock()
+	defer lock.Unlock()
+
+	h.set(hash, key, val)
+}
+
+// set is the internal implementation of Set. The caller must hold the lock for
+// the hash.
+func (h *Hashed[K, V]) set(hash uint64, key K, val V) {
+	bucket, _ := h.cache.Get(hash)
+
+	// Buckets are copy-on-write, since concurrent readers may hold the old one.
+	next := make(HashedBucket[K, V], 0, len(bucket)+1)
+	for _, entry := range bucket {
+		if !h.equal(entry.key, key) {
+			next = append(next, entry)
+		}
+	}
+	next = append(next, hashedEntry[K, V]{key: key, value: val})
+
+	h.cache.Set(hash, next)
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. Concurrent fetches of the same key wait for a single call to the
+// FetchFunc, which runs without holding any lock.
+func (h *Hashed[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	hash := h.hash(key)
+
+	for {
+		bucket, _ := h.cache.Get(hash)
+		if v, ok := h.find(bucket, key); ok {
+			return v, nil
+		}
+
+		load, err := h.loads.do(hash, func() (hashedLoad[K, V], error) {
+			load := hashedLoad[K, V]{key: key}
+
+			// Another caller may have stored the value since the lookup above.
+			bucket, _ := h.cache.Get(hash)
+			if v, ok := h.find(bucket, key); ok {
+				load.value, load.ran = v, true
+				return load, nil
+			}
+
+			v, err := fn()
+			load.ran = true
+			if err != nil {
+				return load, newLoaderError(key, err)
+			}
+
+			h.Set(key, v)
+			load.value = v
+			return load, nil
+		})
+
+		// The load was of a different key with the same hash, so look the key up
+		// again now that it has finished.
+		if load.ran && !h.equal(load.key, key) {
+			continue
+		}
+		return load.value, err
+	}
+}
+
+// Stop waits for in-flight fetches to finish and stops the underlying cache.
+func (h *Hashed[K, V]) Stop() {
+	h.loads.close()
+	h.cache.Stop()
+}
+
+// find returns the value for the given key in the bucket.
+func (h *Hashed[K, V]) find(bucket HashedBucket[K, V], key K) (V, bool) {
+	for _, entry := range bucket {
+		if h.equal(entry.key, key) {
+			return entry.value, true
+		}
+	}
+
+	var zeroV V
+	return zeroV, false
+}
@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// FNV-1a constants for 64-bit hashes.
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// hashString returns the FNV-1a hash of the given string.
+func hashString(s string) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// collidingHash hashes every key to the same value, to exercise buckets.
+func collidingHash([]byte) uint64 {
+	return 1
+}
+
+func TestNewHashed(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "equal cannot be nil"; got != want {
+			t.Errorf("expected %q to contain %q", got, want)
+		}
+	}()
+
+	NewHashed[[]byte, int](NewLRU[uint64, HashedBucket[[]byte, int]](10), collidingHash, nil)
+	t.Errorf("did not panic")
+}
+
+func TestHashed_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("distinct", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHashed[[]byte, int](NewLRU[uint64, HashedBucket[[]byte, int]](10),
+			func(k []byte) uint64 { return hashString(string(k)) }, bytes.Equal)
+		defer cache.Stop()
+
+		cache.Set([]byte("foo"), 5)
+		cache.Set([]byte("bar"), 3)
+
+		if v, _ := cache.Get([]byte("foo")); v != 5 {
+			t.Errorf("expected %#v, got %#v", 5, v)
+		}
+		if v, _ := cache.Get([]byte("bar")); v != 3 {
+			t.Errorf("expected %#v, got %#v", 3, v)
+		}
+		if v, ok := cache.Get([]byte("baz")); ok {
+			t.Errorf("expected not found, got %#v", v)
+		}
+	})
+
+	t.Run("colliding", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHashed[[]byte, int](NewLRU[uint64, HashedBucket[[]byte, int]](10), collidingHash, bytes.Equal)
+		defer cache.Stop()
+
+		cache.Set([]byte("foo"), 5)
+		cache.Set([]byte("bar"), 3)
+		cache.Set([]byte("foo"), 10)
+
+		if v, _ := cache.Get([]byte("foo")); v != 10 {
+			t.Errorf("expected %#v, got %#v", 10, v)
+		}
+		if v, _ := cache.Get([]byte("bar")); v != 3 {
+			t.Errorf("expected %#v, got %#v", 3, v)
+		}
+	})
+}
+
+func TestHashed_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("saves", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHashed[[]byte, int](NewLRU[uint64, HashedBucket[[]byte, int]](10), collidingHash, bytes.Equal)
+		defer cache.Stop()
+
+		v, err := cache.Fetch([]byte("foo"), func() (int, error) {
+			return 5, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		cache.Fetch([]byte("foo"), func() (int, error) {
+			t.Errorf("function was called")
+			return 0, nil
+		})
+	})
+
+	t.Run("returns_error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHashed[[]byte, int](NewLRU[uint64, HashedBucket[[]byte, int]](10), collidingHash, bytes.Equal)
+		defer cache.Stop()
+
+		if _, err := cache.Fetch([]byte("foo"), func() (int, error) {
+			return 0, fmt.Errorf("error")
+		}); err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("unlocked", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHashed[[]byte, int](NewLRU[uint64, HashedBucket[[]byte, int]](10), collidingHash, bytes.Equal)
+		defer cache.Stop()
+
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			cache.Fetch([]byte("foo"), func() (int, error) {
+				close(started)
+				<-release
+				return 5, nil
+			})
+		}()
+		<-started
+
+		// Writes to the same bucket are not blocked by the load.
+		cache.Set([]byte("bar"), 3)
+		if v, _ := cache.Get([]byte("bar")); v != 3 {
+			t.Errorf("expected %d to be %d", v, 3)
+		}
+
+		close(release)
+		<-done
+		if v, _ := cache.Get([]byte("foo")); v != 5 {
+			t.Errorf("expected %d to be %d", v, 5)
+		}
+	})
+
+	t.Run("deduplicates", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHashed[[]byte, int](NewLRU[uint64, HashedBucket[[]byte, int]](10), collidingHash, bytes.Equal)
+		defer cache.Stop()
+
+		release := make(chan struct{})
+		var calls int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := cache.Fetch([]byte("foo"), func() (int, error) {
+					atomic.AddInt32(&calls, 1)
+					<-release
+					return 5, nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+				if v != 5 {
+					t.Errorf("expected %d to be %d", v, 5)
+				}
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("colliding", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHashed[[]byte, int](NewLRU[uint64, HashedBucket[[]byte, int]](10), collidingHash, bytes.Equal)
+		defer cache.Stop()
+
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			cache.Fetch([]byte("foo"), func() (int, error) {
+				close(started)
+				<-release
+				return 5, nil
+			})
+		}()
+		<-started
+
+		// A key with the same hash waits for the load, then loads its own value.
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(release)
+		}()
+		v, err := cache.Fetch([]byte("bar"), func() (int, error) {
+			return 3, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		<-done
+		if v, _ := cache.Get([]byte("foo")); v != 5 {
+			t.Errorf("expected %d to be %d", v, 5)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// HistogramBuckets is the number of buckets in a Histogram.
+const HistogramBuckets = 40
+
+// Histogram is a distribution of durations, counted in exponentially sized
+// buckets. Bucket 0 counts durations under a microsecond, and each following
+// bucket i counts durations from HistogramBound(i-1) up to HistogramBound(i),
+// doubling in width, so the buckets span from a microsecond to several days.
+// The last bucket counts all longer durations.
+type Histogram struct {
+	// Counts are the number of durations in each bucket.
+	Counts [HistogramBuckets]uint64
+
+	// Sum is the total of all durations.
+	Sum time.Duration
+}
+
+// HistogramBound returns the exclusive upper bound of the durations counted in
+// the given bucket. The last bucket is unbounded, so its bound is the largest
+// duration.
+func HistogramBound(bucket int) time.Duration {
+	if bucket >= HistogramBuckets-1 {
+		return math.MaxInt64
+	}
+	return time.Microsecond << bucket
+}
+
+// Delta returns the durations recorded since prev, which must be an earlier
+// copy of the same histogram.
+func (h Histogram) Delta(prev Histogram) Histogram {
+	out := Histogram{Sum: h.Sum - prev.Sum}
+	for i := range h.Counts {
+		out.Counts[i] = h.Counts[i] - prev.Counts[i]
+	}
+	return out
+}
+
+// Count returns the number of durations in the histogram.
+func (h Histogram) Count() uint64 {
+	var n uint64
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
+
+// Mean returns the average duration, or 0 if the histogram is empty.
+func (h Histogram) Mean() time.Duration {
+	n := h.Count()
+	if n == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(n)
+}
+
+// Quantile returns an estimate of the duration below which the fraction q of
+// durations fall, as the upper bound of the bucket containing it. For the last
+// bucket, which is unbounded, it returns the bucket's lower bound. It returns 0
+// if the histogram is empty.
+func (h Histogram) Quantile(q float64) time.Duration {
+	n := h.Count()
+	if n == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(n)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= rank {
+			if i == HistogramBuckets-1 {
+				return HistogramBound(i - 1)
+			}
+			return HistogramBound(i)
+		}
+	}
+	return HistogramBound(HistogramBuckets - 2)
+}
+
+// observe records the duration. It is safe for concurrent use.
+func (h *Histogram) observe(d time.Duration) {
+	atomic.AddUint64(&h.Counts[histogramBucket(d)], 1)
+	atomic.AddInt64((*int64)(&h.Sum), int64(d))
+}
+
+// load returns a copy of the histogram, reading it atomically.
+func (h *Histogram) load() Histogram {
+	var out Histogram
+	for i := range h.Counts {
+		out.Counts[i] = atomic.LoadUint64(&h.Counts[i])
+	}
+	out.Sum = time.Duration(atomic.LoadInt64((*int64)(&h.Sum)))
+	return out
+}
+
+// histogramBucket returns the bucket which counts the given duration.
+func histogramBucket(d time.Duration) int {
+	if d < time.Microsecond {
+		return 0
+	}
+
+	i := bits.Len64(uint64(d / time.Microsecond))
+	if i >= HistogramBuckets {
+		i = HistogramBuckets - 1
+	}
+	return i
+}
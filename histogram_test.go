@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramBound(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		bucket int
+		want   time.Duration
+	}{
+		{0, time.Microsecond},
+		{1, 2 * time.Microsecond},
+		{10, 1024 * time.Microsecond},
+		{HistogramBuckets - 1, math.MaxInt64},
+	}
+
+	for _, tc := range cases {
+		if got, want := HistogramBound(tc.bucket), tc.want; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var h Histogram
+		if got, want := h.Count(), uint64(0); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := h.Mean(), time.Duration(0); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := h.Quantile(0.5), time.Duration(0); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("observe", func(t *testing.T) {
+		t.Parallel()
+
+		var h Histogram
+		h.observe(500 * time.Nanosecond)
+		h.observe(1 * time.Microsecond)
+		h.observe(3 * time.Microsecond)
+		h.observe(1000 * time.Hour)
+
+		got := h.load()
+		if got, want := got.Counts[0], uint64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := got.Counts[1], uint64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := got.Counts[2], uint64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := got.Counts[HistogramBuckets-1], uint64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := got.Count(), uint64(4); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := got.Sum, 1000*time.Hour+4500*time.Nanosecond; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+
+		if got, want := got.Quantile(0.5), 2*time.Microsecond; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := got.Quantile(0.75), 4*time.Microsecond; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+		if got, want := got.Quantile(1), HistogramBound(HistogramBuckets-2); got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+}
+
+func TestHistogram_Delta(t *testing.T) {
+	t.Parallel()
+
+	var h Histogram
+	h.observe(time.Microsecond)
+	prev := h.load()
+	h.observe(time.Microsecond)
+	h.observe(time.Millisecond)
+
+	delta := h.load().Delta(prev)
+	if got, want := delta.Count(), uint64(2); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := delta.Sum, time.Millisecond+time.Microsecond; got != want {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+}
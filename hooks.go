@@ -0,0 +1,138 @@
+package cache
+
+// Ensure implements.
+var _ Cache[string, string] = (*Hooked[string, string])(nil)
+
+// Hooked wraps a cache and runs a chain of hooks around each Get and Set, so
+// cross-cutting concerns such as validation, metrics, or encryption of values
+// can be applied to any cache without writing a wrapper for each one.
+//
+// The Before hooks run in the order the hooks were given, and the After hooks
+// run in the reverse order, so that each set of hooks wraps the ones after it.
+// For example, with an encryption hook followed by a compression hook, values
+// are encrypted and then compressed on the way in, and decompressed and then
+// decrypted on the way out.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Hooked[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// hooks are the hooks, in the order they were given.
+	hooks []Hooks[K, V]
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// Hooks are the functions which intercept operations on a Hooked cache. Any of
+// the functions may be nil.
+type Hooks[K comparable, V any] struct {
+	// BeforeGet is called with the key before each lookup.
+	BeforeGet func(key K)
+
+	// AfterGet is called with the result of each lookup and returns the result
+	// to pass on. It can transform the value, for example to decrypt it, or
+	// report a miss by returning false.
+	AfterGet func(key K, val V, ok bool) (V, bool)
+
+	// BeforeSet is called before each value is stored and returns the value to
+	// store. It can transform the value, for example to encrypt it, or skip the
+	// write by returning false, for example to reject an invalid value.
+	BeforeSet func(key K, val V) (V, bool)
+
+	// AfterSet is called with the stored value after each write. It is not
+	// called if the write was skipped.
+	AfterSet func(key K, val V)
+}
+
+// NewHooked wraps the given cache, running the given hooks around each
+// operation.
+func NewHooked[K comparable, V any](c Cache[K, V], hooks ...Hooks[K, V]) *Hooked[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	return &Hooked[K, V]{
+		cache: c,
+		hooks: hooks,
+	}
+}
+
+// Get runs the BeforeGet hooks, fetches the cache item at the given key from
+// the underlying cache, and returns the result of the AfterGet hooks.
+func (h *Hooked[K, V]) Get(key K) (V, bool) {
+	for _, hook := range h.hooks {
+		if hook.BeforeGet != nil {
+			hook.BeforeGet(key)
+		}
+	}
+
+	v, ok := h.cache.Get(key)
+
+	for i := len(h.hooks) - 1; i >= 0; i-- {
+		if hook := h.hooks[i]; hook.AfterGet != nil {
+			v, ok = hook.AfterGet(key, v, ok)
+		}
+	}
+	return v, ok
+}
+
+// Set runs the BeforeSet hooks, inserts the resulting value in the underlying
+// cache, and runs the AfterSet hooks. If any BeforeSet hook skips the write,
+// the remaining hooks are not run and nothing is stored.
+func (h *Hooked[K, V]) Set(key K, val V) {
+	for _, hook := range h.hooks {
+		if hook.BeforeSet == nil {
+			continue
+		}
+
+		var ok bool
+		if val, ok = hook.BeforeSet(key, val); !ok {
+			return
+		}
+	}
+
+	h.cache.Set(key, val)
+
+	for i := len(h.hooks) - 1; i >= 0; i-- {
+		if hook := h.hooks[i]; hook.AfterSet != nil {
+			hook.AfterSet(key, val)
+		}
+	}
+}
+
+// Fetch retrieves the cached value with Get. If the value does not exist, the
+// FetchFunc is called and the result is stored with Set, so the hooks run as
+// they would for a separate Get and Set. The FetchFunc's result is returned
+// even if a BeforeSet hook skips the write. Concurrent calls to Fetch for the
+// same key share a single invocation. The underlying cache's Fetch is not used.
+func (h *Hooked[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := h.Get(key); ok {
+		return v, nil
+	}
+
+	return h.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := h.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		h.Set(key, v)
+		return v, nil
+	})
+}
+
+// Stop stops the underlying cache.
+func (h *Hooked[K, V]) Stop() {
+	h.loads.close()
+
+	h.cache.Stop()
+}
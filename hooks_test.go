@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewHooked(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "cache cannot be nil"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	NewHooked[string, string](nil)
+	t.Errorf("did not panic")
+}
+
+// prefixHooks returns hooks which add the prefix to values on the way in and
+// strip it on the way out, recording each call in calls.
+func prefixHooks(prefix string, calls *[]string) Hooks[string, string] {
+	return Hooks[string, string]{
+		BeforeGet: func(key string) {
+			*calls = append(*calls, prefix+"before_get")
+		},
+		AfterGet: func(key, val string, ok bool) (string, bool) {
+			*calls = append(*calls, prefix+"after_get")
+			return strings.TrimPrefix(val, prefix), ok
+		},
+		BeforeSet: func(key, val string) (string, bool) {
+			*calls = append(*calls, prefix+"before_set")
+			return prefix + val, true
+		},
+		AfterSet: func(key, val string) {
+			*calls = append(*calls, prefix+"after_set")
+		},
+	}
+}
+
+func TestHooked(t *testing.T) {
+	t.Parallel()
+
+	t.Run("chain", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+		lru := NewLRU[string, string](10)
+		cache := NewHooked[string, string](lru, prefixHooks("a:", &calls), prefixHooks("b:", &calls))
+		defer cache.Stop()
+
+		cache.Set("foo", "bar")
+		if got, want := lruNode(lru, "foo").value, "b:a:bar"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		v, ok := cache.Get("foo")
+		if !ok {
+			t.Fatalf("expected foo to exist")
+		}
+		if got, want := v, "bar"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		if got, want := calls, []string{
+			"a:before_set", "b:before_set", "b:after_set", "a:after_set",
+			"a:before_get", "b:before_get", "b:after_get", "a:after_get",
+		}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("rejects", func(t *testing.T) {
+		t.Parallel()
+
+		var afterSet bool
+		cache := NewHooked[string, string](NewLRU[string, string](10), Hooks[string, string]{
+			BeforeSet: func(key, val string) (string, bool) {
+				return val, val != ""
+			},
+			AfterSet: func(key, val string) {
+				afterSet = true
+			},
+		})
+		defer cache.Stop()
+
+		cache.Set("foo", "")
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to be rejected")
+		}
+		if afterSet {
+			t.Errorf("expected AfterSet to not be called")
+		}
+
+		v, err := cache.Fetch("foo", func() (string, error) {
+			return "", nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, ""; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected fetched foo to be rejected")
+		}
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+		lru := NewLRU[string, string](10)
+		cache := NewHooked[string, string](lru, prefixHooks("a:", &calls))
+		defer cache.Stop()
+
+		v, err := cache.Fetch("foo", func() (string, error) {
+			return "bar", nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, "bar"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := lruNode(lru, "foo").value, "a:bar"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		v, err = cache.Fetch("foo", func() (string, error) {
+			t.Errorf("function was called")
+			return "", nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, "bar"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*HotKeys[string, string])(nil)
+
+// HotKeys wraps a cache and tracks the most frequently accessed keys over a
+// sliding window, using the space-saving heavy hitters algorithm. Only a fixed
+// number of keys are tracked, so memory use is bounded regardless of the size
+// of the key space, and counts are approximate: a key's count may be
+// overestimated, but never underestimated.
+//
+// The window is approximated with two consecutive periods of the configured
+// duration: counts from the current and previous periods are combined, and
+// older periods are discarded.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type HotKeys[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// capacity is the number of keys tracked per period, and window is the
+	// length of a period.
+	capacity int
+	window   time.Duration
+
+	// current and previous are the counters for the current and previous
+	// periods, and rotatedAt is the time the current period started.
+	current, previous *spaceSaving[K]
+	rotatedAt         time.Time
+
+	// lock guards the counters.
+	lock sync.Mutex
+}
+
+// KeyCount is a key and its approximate access count.
+type KeyCount[K comparable] struct {
+	Key   K
+	Count uint64
+}
+
+// NewHotKeys wraps the given cache, tracking up to capacity keys over the
+// given window.
+func NewHotKeys[K comparable, V any](c Cache[K, V], capacity int, window time.Duration) *HotKeys[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if capacity <= 0 {
+		panic("capacity must be greater than 0")
+	}
+	if window <= 0 {
+		panic("window must be greater than 0")
+	}
+
+	return &HotKeys[K, V]{
+		cache:     c,
+		capacity:  capacity,
+		window:    window,
+		current:   newSpaceSaving[K](capacity),
+		rotatedAt: time.Now(),
+	}
+}
+
+// Get fetches the cache item at the given key from the underlying cache and
+// records the access.
+func (h *HotKeys[K, V]) Get(key K) (V, bool) {
+	h.record(key)
+	return h.cache.Get(key)
+}
+
+// Set inserts the value in the underlying cache. Sets are not counted as
+// accesses.
+func (h *HotKeys[K, V]) Set(key K, val V) {
+	h.cache.Set(key, val)
+}
+
+// Fetch retrieves the cached value from the underlying cache and records the
+// access.
+func (h *HotKeys[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	h.record(key)
+	return h.cache.Fetch(key, fn)
+}
+
+// Stop stops the underlying cache.
+func (h *HotKeys[K, V]) Stop() {
+	h.cache.Stop()
+}
+
+// TopKeys returns up to n of the most frequently accessed keys in the window,
+// ordered from most to least accessed.
+func (h *HotKeys[K, V]) TopKeys(n int) []KeyCount[K] {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.rotate(time.Now())
+
+	counts := make(map[K]uint64, len(h.current.index))
+	for _, c := range h.current.counters {
+		counts[c.key] += c.count
+	}
+	if h.previous != nil {
+		for _, c := range h.previous.counters {
+			counts[c.key] += c.count
+		}
+	}
+
+	result := make([]KeyCount[K], 0, len(counts))
+	for k, c := range counts {
+		result = append(result, KeyCount[K]{Key: k, Count: c})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+
+	if n < len(result) {
+		result = result[:n]
+	}
+	return result
+}
+
+// record counts an access of the given key.
+func (h *HotKeys[K, V]) record(key K) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.rotate(time.Now())
+	h.current.add(key)
+}
+
+// rotate starts a new period if the current one has elapsed. It must be called
+// while holding the lock.
+func (h *HotKeys[K, V]) rotate(now time.Time) {
+	elapsed := now.Sub(h.rotatedAt)
+	if elapsed < h.window {
+		return
+	}
+
+	if elapsed < 2*h.window {
+		h.previous = h.current
+	} else {
+		h.previous = nil
+	}
+	h.current = newSpaceSaving[K](h.capacity)
+	h.rotatedAt = now
+}
+
+// spaceSaving implements the space-saving algorithm for approximating the most
+// frequent items in a stream. The counters form a min-heap on count.
+type spaceSaving[K comparable] struct {
+	capacity int
+	counters []*spaceSavingCounter[K]
+	index    map[K]*spaceSavingCounter[K]
+}
+
+// spaceSavingCounter is a tracked key and its count.
+type spaceSavingCounter[K comparable] struct {
+	key   K
+	count uint64
+	pos   int
+}
+
+// newSpaceSaving creates a new tracker for up to capacity keys.
+func newSpaceSaving[K comparable](capacity int) *spaceSaving[K] {
+	return &spaceSaving[K]{
+		capacity: capacity,
+		counters: make([]*spaceSavingCounter[K], 0, capacity),
+		index:    make(map[K]*spaceSavingCounter[K], capacity),
+	}
+}
+
+// add records an occurrence of the given key. If the key is not tracked and
+// all counters are in use, the key with the smallest count is replaced and the
+// new key inherits its count.
+func (s *spaceSaving[K]) add(key K) {
+	if c, ok := s.index[key]; ok {
+		c.count++
+		heap.Fix(s, c.pos)
+		return
+	}
+
+	if len(s.counters) < s.capacity {
+		heap.Push(s, &spaceSavingCounter[K]{key: key, count: 1})
+		return
+	}
+
+	min := s.counters[0]
+	delete(s.index, min.key)
+	min.key = key
+	min.count++
+	s.index[key] = min
+	heap.Fix(s, 0)
+}
+
+// Len implements heap.Interface.
+func (s *spaceSaving[K]) Len() int { return len(s.counters) }
+
+// Less implements heap.Interface.
+func (s *spaceSaving[K]) Less(i, j int) bool {
+	return s.counters[i].count < s.counters[j].count
+}
+
+// Swap implements heap.Interface.
+func (s *spaceSaving[K]) Swap(i, j int) {
+	s.counters[i], s.counters[j] = s.counters[j], s.counters[i]
+	s.counters[i].pos = i
+	s.counters[j].pos = j
+}
+
+// Push implements heap.Interface.
+func (s *spaceSaving[K]) Push(x any) {
+	c := x.(*spaceSavingCounter[K])
+	c.pos = len(s.counters)
+	s.counters = append(s.counters, c)
+	s.index[c.key] = c
+}
+
+// Pop implements heap.Interface.
+func (s *spaceSaving[K]) Pop() any {
+	n := len(s.counters)
+	c := s.counters[n-1]
+	s.counters[n-1] = nil
+	s.counters = s.counters[:n-1]
+	delete(s.index, c.key)
+	return c
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNewHotKeys(t *testing.T) {
+	t.Parallel()
+
+	t.Run("panic_on_negative", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "capacity must be greater than 0"; got != want {
+				t.Errorf("expected %q to contain %q", got, want)
+			}
+		}()
+
+		NewHotKeys[string, int](NewLRU[string, int](10), 0, time.Minute)
+		t.Errorf("did not panic")
+	})
+}
+
+func TestHotKeys_TopKeys(t *testing.T) {
+	t.Parallel()
+
+	t.Run("orders_by_count", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHotKeys[string, int](NewLRU[string, int](10), 10, time.Minute)
+		defer cache.Stop()
+
+		for i := 0; i < 5; i++ {
+			cache.Get("foo")
+		}
+		for i := 0; i < 3; i++ {
+			cache.Get("bar")
+		}
+		cache.Fetch("baz", func() (int, error) { return 1, nil })
+
+		// Sets are not accesses.
+		cache.Set("qux", 1)
+
+		got := cache.TopKeys(2)
+		want := []KeyCount[string]{{"foo", 5}, {"bar", 3}}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+
+		if got, want := len(cache.TopKeys(10)), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHotKeys[int, int](NewLRU[int, int](10), 3, time.Minute)
+		defer cache.Stop()
+
+		for i := 0; i < 100; i++ {
+			cache.Get(1)
+		}
+		for i := 0; i < 100; i++ {
+			cache.Get(i + 100)
+		}
+
+		got := cache.TopKeys(3)
+		if got, want := len(got), 3; got != want {
+			t.Fatalf("expected %d to be %d", got, want)
+		}
+		if got, want := got[0].Key, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("window", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewHotKeys[string, int](NewLRU[string, int](10), 10, 25*time.Millisecond)
+		defer cache.Stop()
+
+		cache.Get("foo")
+		time.Sleep(100 * time.Millisecond)
+
+		if got := cache.TopKeys(10); len(got) != 0 {
+			t.Errorf("expected %v to be empty", got)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"sync"
+)
+
+// Ensure implements.
+var (
+	_ Cache[string, string] = (*Indexed[string, string])(nil)
+	_ Deleter[string]       = (*Indexed[string, string])(nil)
+)
+
+// Indexed wraps a cache with secondary indexes over an attribute of its
+// values, so the entries which share an attribute can be found without
+// scanning the whole cache. For example, sessions cached by session ID can be
+// indexed by user ID to find all of a user's sessions.
+//
+// Entries are indexed when they are set. If the underlying cache implements
+// EvictNotifier, evicted entries are removed from the indexes as they are
+// evicted. Entries removed from the underlying cache without notification,
+// such as expired TTL entries, are removed from the indexes when a lookup finds
+// them missing.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Indexed[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// indexes are the secondary indexes by name. The set of indexes is fixed at
+	// construction, but their contents are guarded by lock.
+	indexes map[string]*secondaryIndex[K, V]
+	lock    sync.Mutex
+}
+
+// secondaryIndex maps an attribute of the values to the keys of the entries
+// with that attribute.
+type secondaryIndex[K comparable, V any] struct {
+	// attr returns the indexed attribute of a value.
+	attr func(V) any
+
+	// keys are the keys of the entries with each attribute, and attrs are the
+	// attributes under which each key is currently indexed.
+	keys  map[any]map[K]struct{}
+	attrs map[K]any
+}
+
+// IndexOption is an option for configuring an indexed cache.
+type IndexOption[V any] func(*indexedOptions[V])
+
+// indexedOptions are the options for an indexed cache.
+type indexedOptions[V any] struct {
+	indexes map[string]func(V) any
+}
+
+// WithIndex adds an index with the given name over the attribute of each value
+// returned by fn. The attribute must be comparable, and fn must return the same
+// attribute for a value each time it is called.
+//
+//	c := cache.NewIndexed[string, *Session](cache.NewSyncLRU[string, *Session](10_000),
+//		cache.WithIndex("user", func(s *Session) string {
+//			return s.UserID
+//		}))
+func WithIndex[V any, I comparable](name string, fn func(V) I) IndexOption[V] {
+	if name == "" {
+		panic("name cannot be empty")
+	}
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+
+	return func(o *indexedOptions[V]) {
+		if _, ok := o.indexes[name]; ok {
+			panic("duplicate index " + name)
+		}
+		o.indexes[name] = func(v V) any {
+			return fn(v)
+		}
+	}
+}
+
+// NewIndexed wraps the given cache in a cache which maintains the given
+// indexes. The indexes only include entries set through the returned cache, so
+// the underlying cache should not be used directly.
+func NewIndexed[K comparable, V any](c Cache[K, V], opts ...IndexOption[V]) *Indexed[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	o := indexedOptions[V]{
+		indexes: make(map[string]func(V) any),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	i := &Indexed[K, V]{
+		cache:   c,
+		indexes: make(map[string]*secondaryIndex[K, V], len(o.indexes)),
+	}
+	for name, attr := range o.indexes {
+		i.indexes[name] = &secondaryIndex[K, V]{
+			attr:  attr,
+			keys:  make(map[any]map[K]struct{}),
+			attrs: make(map[K]any),
+		}
+	}
+	if n, ok := c.(EvictNotifier[K, V]); ok {
+		n.OnEvict(func(key K, _ V) {
+			i.prune(key)
+		})
+	}
+	return i
+}
+
+// Get fetches the cache item at the given key from the underlying cache.
+func (i *Indexed[K, V]) Get(key K) (V, bool) {
+	return i.cache.Get(key)
+}
+
+// Set inserts the value in the underlying cache and indexes it.
+func (i *Indexed[K, V]) Set(key K, val V) {
+	i.cache.Set(key, val)
+	i.index(key, val)
+}
+
+// Fetch retrieves the cached value from the underlying cache, indexing the
+// value if the FetchFunc is called and succeeds.
+func (i *Indexed[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	loaded := false
+	v, err := i.cache.Fetch(key, func() (V, error) {
+		v, err := fn()
+		loaded = err == nil
+		return v, err
+	})
+	if loaded {
+		i.index(key, v)
+	}
+	return v, err
+}
+
+// Delete removes the entry at the given key from the underlying cache. It
+// returns false if there is no entry at the given key. It panics if the
+// underlying cache does not implement Deleter.
+func (i *Indexed[K, V]) Delete(key K) bool {
+	d, ok := i.cache.(Deleter[K])
+	if !ok {
+		panic("cache does not support delete")
+	}
+
+	ok = d.Delete(key)
+	i.prune(key)
+	return ok
+}
+
+// GetByIndex returns the entries whose attribute in the named index is attr,
+// in no particular order. Only the Key and Value of each entry are populated.
+// The values are read from the underlying cache with Get, so for caches whose
+// Get modifies the cache, such as LRU, each entry returned counts as an access.
+// It panics if there is no index with the given name.
+func (i *Indexed[K, V]) GetByIndex(name string, attr any) []Entry[K, V] {
+	idx, ok := i.indexes[name]
+	if !ok {
+		panic("unknown index " + name)
+	}
+
+	i.lock.Lock()
+	keys := make([]K, 0, len(idx.keys[attr]))
+	for key := range idx.keys[attr] {
+		keys = append(keys, key)
+	}
+	i.lock.Unlock()
+
+	var entries []Entry[K, V]
+	for _, key := range keys {
+		v, ok := i.cache.Get(key)
+		if !ok {
+			i.prune(key)
+			continue
+		}
+
+		// The value may have been replaced since the keys were read.
+		if idx.attr(v) != attr {
+			continue
+		}
+		entries = append(entries, Entry[K, V]{Key: key, Value: v})
+	}
+	return entries
+}
+
+// Stop stops the underlying cache and clears the indexes.
+func (i *Indexed[K, V]) Stop() {
+	i.cache.Stop()
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, idx := range i.indexes {
+		idx.keys = make(map[any]map[K]struct{})
+		idx.attrs = make(map[K]any)
+	}
+}
+
+// index indexes the value at the given key, replacing the key's previous
+// attributes. It is called after the value is stored in the underlying cache.
+func (i *Indexed[K, V]) index(key K, val V) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, idx := range i.indexes {
+		idx.remove(key)
+
+		attr := idx.attr(val)
+		keys, ok := idx.keys[attr]
+		if !ok {
+			keys = make(map[K]struct{}, 1)
+			idx.keys[attr] = keys
+		}
+		keys[key] = struct{}{}
+		idx.attrs[key] = attr
+	}
+}
+
+// prune removes the key from the indexes if it is no longer in the underlying
+// cache. The check is made while holding the lock, and Set stores a value
+// before indexing it, so a value which is set concurrently is never lost from
+// the indexes.
+func (i *Indexed[K, V]) prune(key K) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, ok := i.cache.Get(key); ok {
+		return
+	}
+
+	for _, idx := range i.indexes {
+		idx.remove(key)
+	}
+}
+
+// remove removes the key from the index. It does not lock.
+func (idx *secondaryIndex[K, V]) remove(key K) {
+	attr, ok := idx.attrs[key]
+	if !ok {
+		return
+	}
+
+	delete(idx.attrs, key)
+	keys := idx.keys[attr]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(idx.keys, attr)
+	}
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type indexedTestSession struct {
+	user string
+}
+
+// indexedTestKeys returns the sorted keys of the given entries.
+func indexedTestKeys[V any](entries []Entry[string, V]) []string {
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestNewIndexed(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "duplicate index user"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	byUser := func(s indexedTestSession) string { return s.user }
+	NewIndexed[string, indexedTestSession](NewLRU[string, indexedTestSession](10),
+		WithIndex("user", byUser), WithIndex("user", byUser))
+	t.Errorf("did not panic")
+}
+
+func TestIndexed_GetByIndex(t *testing.T) {
+	t.Parallel()
+
+	cache := NewIndexed[string, indexedTestSession](NewLRU[string, indexedTestSession](3),
+		WithIndex("user", func(s indexedTestSession) string { return s.user }))
+	defer cache.Stop()
+
+	cache.Set("s1", indexedTestSession{user: "alice"})
+	cache.Set("s2", indexedTestSession{user: "bob"})
+	cache.Set("s3", indexedTestSession{user: "alice"})
+
+	if got, want := indexedTestKeys(cache.GetByIndex("user", "alice")), []string{"s1", "s3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	// Overwriting a value moves it between attributes.
+	cache.Set("s3", indexedTestSession{user: "bob"})
+	if got, want := indexedTestKeys(cache.GetByIndex("user", "alice")), []string{"s1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := indexedTestKeys(cache.GetByIndex("user", "bob")), []string{"s2", "s3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	// Evicted and deleted entries are removed from the index.
+	cache.Set("s4", indexedTestSession{user: "carol"})
+	cache.Delete("s2")
+	if got := cache.GetByIndex("user", "alice"); got != nil {
+		t.Errorf("expected %v to be empty", got)
+	}
+	if got, want := indexedTestKeys(cache.GetByIndex("user", "bob")), []string{"s3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	idx := cache.indexes["user"]
+	if got, want := len(idx.attrs), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := len(idx.keys), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestIndexed_GetByIndex_unknown(t *testing.T) {
+	t.Parallel()
+
+	cache := NewIndexed[string, int](NewLRU[string, int](10))
+	defer cache.Stop()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "unknown index foo"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	cache.GetByIndex("foo", 1)
+	t.Errorf("did not panic")
+}
@@ -0,0 +1,320 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Instrumented[string, string])(nil)
+
+// Instrumented wraps a cache with statistics, logging, and tracing behind a
+// single constructor, for services which want all of the observability without
+// stacking wrappers by hand. Statistics are always collected; logging and
+// tracing are enabled with WithLogger and WithTracer.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Instrumented[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// name identifies the cache in logs and traces.
+	name string
+
+	// stats are the operation counters. They are updated atomically.
+	stats InstrumentedStats
+
+	// recent counts the lookups in the recent window, for RecentHitRatio.
+	recent windowCounter
+
+	// logger and tracer are the optional logger and tracer.
+	logger Logger
+	tracer Tracer
+}
+
+// InstrumentedStats are the operation statistics for an instrumented cache.
+type InstrumentedStats struct {
+	// Hits and Misses are the number of Get and Fetch calls which found or did
+	// not find a cached value, respectively.
+	Hits   uint64
+	Misses uint64
+
+	// Sets is the number of Set calls, and Evictions is the number of entries
+	// evicted to make room for new entries.
+	Sets      uint64
+	Evictions uint64
+
+	// LoadErrors is the number of Fetch calls whose FetchFunc returned an error.
+	LoadErrors uint64
+
+	// PeakLen is the largest number of entries observed in the cache. It is 0 if
+	// the cache does not report its length.
+	PeakLen uint64
+
+	// Lifetimes is the distribution of how long entries were cached before they
+	// were evicted or, for TTL caches, removed by the sweeper. It is only
+	// recorded for the built-in policies.
+	Lifetimes Histogram
+
+	// LoadLatency is the distribution of how long FetchFunc calls took,
+	// including those which returned an error.
+	LoadLatency Histogram
+
+	// RecentHitRatio is the ratio of hits to total lookups within the recent
+	// window, configured with WithHitRatioWindow, or 0 if there have been no
+	// lookups in the window. Unlike HitRatio, it reflects current behavior
+	// rather than the lifetime of the cache, so it is suitable for alerting.
+	RecentHitRatio float64
+}
+
+// HitRatio returns the ratio of hits to total lookups, or 0 if there have been
+// no lookups.
+func (s InstrumentedStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Delta returns the statistics accumulated since prev, which must be an earlier
+// result of Stats from the same cache, so per-interval rates can be computed
+// from periodic snapshots. PeakLen and RecentHitRatio are not counters, so they
+// are the values as of s.
+func (s InstrumentedStats) Delta(prev InstrumentedStats) InstrumentedStats {
+	return InstrumentedStats{
+		Hits:           s.Hits - prev.Hits,
+		Misses:         s.Misses - prev.Misses,
+		Sets:           s.Sets - prev.Sets,
+		Evictions:      s.Evictions - prev.Evictions,
+		LoadErrors:     s.LoadErrors - prev.LoadErrors,
+		PeakLen:        s.PeakLen,
+		Lifetimes:      s.Lifetimes.Delta(prev.Lifetimes),
+		LoadLatency:    s.LoadLatency.Delta(prev.LoadLatency),
+		RecentHitRatio: s.RecentHitRatio,
+	}
+}
+
+// Logger is the logging interface used by an instrumented cache. It is
+// satisfied by *slog.Logger. The args are alternating keys and values.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Tracer is the tracing interface used by an instrumented cache. It is small
+// enough to adapt to any tracing library, such as OpenTelemetry, by starting a
+// span in Start and ending it in the returned function.
+type Tracer interface {
+	// Start is called when an operation named op ("get", "set", or "fetch")
+	// starts on the named cache. It returns a function which is called when the
+	// operation ends, with whether a lookup found a cached value and any error
+	// from the FetchFunc. For Set, hit is always false.
+	Start(name, op string) (end func(hit bool, err error))
+}
+
+// InstrumentedOption is an option for configuring an instrumented cache.
+type InstrumentedOption func(*instrumentedOptions)
+
+// instrumentedOptions are the options for an instrumented cache.
+type instrumentedOptions struct {
+	logger Logger
+	tracer Tracer
+	window time.Duration
+}
+
+// defaultHitRatioWindow is the window for RecentHitRatio if WithHitRatioWindow
+// is not given.
+const defaultHitRatioWindow = time.Minute
+
+// WithLogger logs misses and evictions at debug level, and FetchFunc errors at
+// error level, to the given logger.
+func WithLogger(logger Logger) InstrumentedOption {
+	return func(o *instrumentedOptions) {
+		o.logger = logger
+	}
+}
+
+// WithHitRatioWindow sets the window over which RecentHitRatio is computed. The
+// default is one minute. The window advances in tenths, so lookups are counted
+// for between 90% and 100% of the window.
+func WithHitRatioWindow(window time.Duration) InstrumentedOption {
+	if window <= 0 {
+		panic("window must be greater than 0")
+	}
+
+	return func(o *instrumentedOptions) {
+		o.window = window
+	}
+}
+
+// WithTracer traces each operation with the given tracer.
+func WithTracer(tracer Tracer) InstrumentedOption {
+	return func(o *instrumentedOptions) {
+		o.tracer = tracer
+	}
+}
+
+// NewInstrumented wraps the given cache, identifying it by name in logs and
+// traces. Evictions are only counted and logged if the cache implements
+// EvictNotifier, and lifetimes are only recorded for the built-in policies.
+func NewInstrumented[K comparable, V any](c Cache[K, V], name string, opts ...InstrumentedOption) *Instrumented[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if name == "" {
+		panic("name cannot be empty")
+	}
+
+	o := instrumentedOptions{
+		window: defaultHitRatioWindow,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	i := &Instrumented[K, V]{
+		cache:  c,
+		name:   name,
+		recent: newWindowCounter(o.window),
+		logger: o.logger,
+		tracer: o.tracer,
+	}
+	if n, ok := c.(EvictNotifier[K, V]); ok {
+		n.OnEvict(func(k K, _ V) {
+			atomic.AddUint64(&i.stats.Evictions, 1)
+			if i.logger != nil {
+				i.logger.Debug("cache eviction", "cache", i.name, "key", k)
+			}
+		})
+	}
+	if n, ok := c.(lifetimeNotifier); ok {
+		n.onEvictLifetime(i.stats.Lifetimes.observe)
+	}
+	return i
+}
+
+// Get fetches the cache item at the given key from the underlying cache.
+func (i *Instrumented[K, V]) Get(key K) (V, bool) {
+	end := i.start("get")
+
+	v, ok := i.cache.Get(key)
+	i.recordLookup("get", key, ok)
+
+	end(ok, nil)
+	return v, ok
+}
+
+// Set inserts the value in the underlying cache.
+func (i *Instrumented[K, V]) Set(key K, val V) {
+	end := i.start("set")
+
+	i.cache.Set(key, val)
+	atomic.AddUint64(&i.stats.Sets, 1)
+	i.recordLen()
+
+	end(false, nil)
+}
+
+// Fetch retrieves the cached value from the underlying cache. A call which
+// invokes the FetchFunc is counted as a miss, and any other call as a hit.
+func (i *Instrumented[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	end := i.start("fetch")
+
+	missed := false
+	v, err := i.cache.Fetch(key, func() (V, error) {
+		missed = true
+
+		start := time.Now()
+		defer func() { i.stats.LoadLatency.observe(time.Since(start)) }()
+		return fn()
+	})
+	i.recordLookup("fetch", key, !missed)
+	if missed {
+		i.recordLen()
+	}
+
+	if err != nil {
+		atomic.AddUint64(&i.stats.LoadErrors, 1)
+		if i.logger != nil {
+			i.logger.Error("cache load failed", "cache", i.name, "key", key, "error", err)
+		}
+	}
+
+	end(!missed, err)
+	return v, err
+}
+
+// Stop stops the underlying cache.
+func (i *Instrumented[K, V]) Stop() {
+	i.cache.Stop()
+}
+
+// Stats returns the operation statistics.
+func (i *Instrumented[K, V]) Stats() InstrumentedStats {
+	return InstrumentedStats{
+		Hits:           atomic.LoadUint64(&i.stats.Hits),
+		Misses:         atomic.LoadUint64(&i.stats.Misses),
+		Sets:           atomic.LoadUint64(&i.stats.Sets),
+		Evictions:      atomic.LoadUint64(&i.stats.Evictions),
+		LoadErrors:     atomic.LoadUint64(&i.stats.LoadErrors),
+		PeakLen:        atomic.LoadUint64(&i.stats.PeakLen),
+		Lifetimes:      i.stats.Lifetimes.load(),
+		LoadLatency:    i.stats.LoadLatency.load(),
+		RecentHitRatio: i.recent.ratio(nanotime()),
+	}
+}
+
+// StopWithStats stops the underlying cache and returns the final statistics,
+// so batch jobs can report the cache's effectiveness at the end of a run.
+func (i *Instrumented[K, V]) StopWithStats() InstrumentedStats {
+	i.cache.Stop()
+	return i.Stats()
+}
+
+// recordLen updates the peak length from the cache's current length, if the
+// cache reports it.
+func (i *Instrumented[K, V]) recordLen() {
+	l, ok := i.cache.(lener)
+	if !ok {
+		return
+	}
+
+	n := uint64(l.Len())
+	for {
+		peak := atomic.LoadUint64(&i.stats.PeakLen)
+		if n <= peak || atomic.CompareAndSwapUint64(&i.stats.PeakLen, peak, n) {
+			return
+		}
+	}
+}
+
+// start starts tracing the given operation and returns the function which ends
+// it. If there is no tracer, the function does nothing.
+func (i *Instrumented[K, V]) start(op string) func(bool, error) {
+	if i.tracer == nil {
+		return endNothing
+	}
+	return i.tracer.Start(i.name, op)
+}
+
+// endNothing is the function returned by start when there is no tracer. It is
+// declared at the package level, since a closure in a generic method captures
+// the method's type information and so allocates on each call.
+func endNothing(bool, error) {}
+
+// recordLookup counts and logs the result of a lookup.
+func (i *Instrumented[K, V]) recordLookup(op string, key K, hit bool) {
+	i.recent.record(nanotime(), hit)
+
+	if hit {
+		atomic.AddUint64(&i.stats.Hits, 1)
+		return
+	}
+
+	atomic.AddUint64(&i.stats.Misses, 1)
+	if i.logger != nil {
+		i.logger.Debug("cache miss", "cache", i.name, "op", op, "key", key)
+	}
+}
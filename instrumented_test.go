@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLogger records the messages logged to it.
+type testLogger struct {
+	lock     sync.Mutex
+	messages []string
+}
+
+func (l *testLogger) Debug(msg string, args ...any) { l.log("debug", msg) }
+func (l *testLogger) Error(msg string, args ...any) { l.log("error", msg) }
+
+func (l *testLogger) log(level, msg string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.messages = append(l.messages, level+": "+msg)
+}
+
+// testTracer records the operations traced with it.
+type testTracer struct {
+	spans []string
+}
+
+func (t *testTracer) Start(name, op string) func(bool, error) {
+	return func(hit bool, err error) {
+		t.spans = append(t.spans, fmt.Sprintf("%s.%s hit=%t err=%v", name, op, hit, err))
+	}
+}
+
+func TestNewInstrumented(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "name cannot be empty"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	NewInstrumented[string, int](NewLRU[string, int](1), "")
+	t.Errorf("did not panic")
+}
+
+func TestInstrumented(t *testing.T) {
+	t.Parallel()
+
+	var logger testLogger
+	var tracer testTracer
+	cache := NewInstrumented[string, int](NewLRU[string, int](1), "users",
+		WithLogger(&logger), WithTracer(&tracer))
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Get("foo")
+	cache.Get("bar")
+	cache.Fetch("bar", func() (int, error) { return 2, nil })
+	cache.Fetch("baz", func() (int, error) { return 0, fmt.Errorf("oops") })
+
+	stats := cache.Stats()
+	if got, want := stats.Lifetimes.Count(), uint64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.LoadLatency.Count(), uint64(2); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// The histograms depend on timing, so they are compared separately above.
+	stats.Lifetimes, stats.LoadLatency = Histogram{}, Histogram{}
+	if got, want := stats, (InstrumentedStats{
+		Hits:           1,
+		Misses:         3,
+		Sets:           1,
+		Evictions:      1,
+		LoadErrors:     1,
+		PeakLen:        1,
+		RecentHitRatio: 0.25,
+	}); got != want {
+		t.Errorf("expected %#v to be %#v", got, want)
+	}
+	if got, want := cache.Stats().HitRatio(), 0.25; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+
+	if got, want := logger.messages, []string{
+		"debug: cache miss",
+		"debug: cache eviction",
+		"debug: cache miss",
+		"debug: cache miss",
+		"error: cache load failed",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	if got, want := tracer.spans, []string{
+		"users.set hit=false err=<nil>",
+		"users.get hit=true err=<nil>",
+		"users.get hit=false err=<nil>",
+		"users.fetch hit=false err=<nil>",
+		"users.fetch hit=false err=failed to load baz: oops",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
+
+func TestInstrumented_StopWithStats(t *testing.T) {
+	t.Parallel()
+
+	cache := NewInstrumented[string, int](NewLRU[string, int](10), "users")
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Fetch("baz", func() (int, error) { return 3, nil })
+	cache.Get("foo")
+
+	stats := cache.StopWithStats()
+	if got, want := stats.PeakLen, uint64(3); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.Hits, uint64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "cache is stopped"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+	cache.Get("foo")
+	t.Errorf("did not panic")
+}
+
+func TestInstrumented_Lifetimes(t *testing.T) {
+	t.Parallel()
+
+	cache := NewInstrumented[string, int](NewFIFO[string, int](1), "users")
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	time.Sleep(5 * time.Millisecond)
+	cache.Set("bar", 2)
+
+	lifetimes := cache.Stats().Lifetimes
+	if got, want := lifetimes.Count(), uint64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := lifetimes.Sum, 5*time.Millisecond; got < want {
+		t.Errorf("expected %v to be at least %v", got, want)
+	}
+}
+
+func TestInstrumented_LoadLatency(t *testing.T) {
+	t.Parallel()
+
+	cache := NewInstrumented[string, int](NewLRU[string, int](10), "users")
+	defer cache.Stop()
+
+	cache.Fetch("foo", func() (int, error) {
+		time.Sleep(5 * time.Millisecond)
+		return 1, nil
+	})
+	cache.Fetch("foo", func() (int, error) { return 1, nil })
+
+	latency := cache.Stats().LoadLatency
+	if got, want := latency.Count(), uint64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := latency.Quantile(0.5), 5*time.Millisecond; got < want {
+		t.Errorf("expected %v to be at least %v", got, want)
+	}
+}
+
+func TestInstrumentedStats_Delta(t *testing.T) {
+	t.Parallel()
+
+	cache := NewInstrumented[string, int](NewLRU[string, int](10), "users")
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Get("foo")
+	cache.Fetch("bar", func() (int, error) { return 2, nil })
+	prev := cache.Stats()
+
+	cache.Get("foo")
+	cache.Get("foo")
+	cache.Get("baz")
+	cache.Fetch("baz", func() (int, error) { return 3, nil })
+
+	delta := cache.Stats().Delta(prev)
+	if got, want := delta.LoadLatency.Count(), uint64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	delta.LoadLatency = Histogram{}
+	if got, want := delta, (InstrumentedStats{
+		Hits:           2,
+		Misses:         2,
+		PeakLen:        3,
+		RecentHitRatio: 0.5,
+	}); got != want {
+		t.Errorf("expected %#v to be %#v", got, want)
+	}
+	if got, want := delta.HitRatio(), 0.5; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+}
+
+func TestWithHitRatioWindow(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "window must be greater than 0"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	WithHitRatioWindow(0)
+	t.Errorf("did not panic")
+}
@@ -0,0 +1,74 @@
+package cache
+
+// Hasher is implemented by keys which compute their own hash. Caches which hash
+// keys, such as Sharded, use it in place of the generic hashing fallback, which
+// is considerably slower for composite keys, and mix their own seed into the
+// result.
+type Hasher interface {
+	// Hash returns a 64-bit hash of the key. Equal keys must have equal hashes.
+	Hash() uint64
+}
+
+// Ensure implements.
+var (
+	_ Hasher       = Key2[string, string]{}
+	_ Hasher       = Key3[string, string, string]{}
+	_ seededHasher = Key2[string, string]{}
+	_ seededHasher = Key3[string, string, string]{}
+)
+
+// seededHasher is implemented by the package's multi-part keys, which hash their
+// parts with the seed of the cache hashing them rather than a global seed.
+type seededHasher interface {
+	hashSeeded(seed hashSeed) uint64
+}
+
+// Key2 is a comparable key composed of two values. It avoids encoding
+// multi-part keys into strings:
+//
+//	c := cache.NewLRU[cache.Key2[string, int], string](100)
+//	c.Set(cache.NewKey2("tenant", 42), "value")
+type Key2[A, B comparable] struct {
+	A A
+	B B
+}
+
+// NewKey2 creates a new two-part key.
+func NewKey2[A, B comparable](a A, b B) Key2[A, B] {
+	return Key2[A, B]{A: a, B: b}
+}
+
+// Hash returns a hash of the key's parts. Caches which hash keys hash the parts
+// with their own seed instead, so the result differs from the hash they use.
+func (k Key2[A, B]) Hash() uint64 {
+	return k.hashSeeded(keySeed)
+}
+
+// hashSeeded returns a hash of the key's parts with the given seed.
+func (k Key2[A, B]) hashSeeded(seed hashSeed) uint64 {
+	return combineHashes(hashKey(seed, k.A), hashKey(seed, k.B))
+}
+
+// Key3 is a comparable key composed of three values. It avoids encoding
+// multi-part keys into strings.
+type Key3[A, B, C comparable] struct {
+	A A
+	B B
+	C C
+}
+
+// NewKey3 creates a new three-part key.
+func NewKey3[A, B, C comparable](a A, b B, c C) Key3[A, B, C] {
+	return Key3[A, B, C]{A: a, B: b, C: c}
+}
+
+// Hash returns a hash of the key's parts. Caches which hash keys hash the parts
+// with their own seed instead, so the result differs from the hash they use.
+func (k Key3[A, B, C]) Hash() uint64 {
+	return k.hashSeeded(keySeed)
+}
+
+// hashSeeded returns a hash of the key's parts with the given seed.
+func (k Key3[A, B, C]) hashSeeded(seed hashSeed) uint64 {
+	return combineHashes(combineHashes(hashKey(seed, k.A), hashKey(seed, k.B)), hashKey(seed, k.C))
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestKey2(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[Key2[string, int], string](10)
+	defer cache.Stop()
+
+	cache.Set(NewKey2("foo", 1), "a")
+	cache.Set(NewKey2("foo", 2), "b")
+
+	if v, _ := cache.Get(NewKey2("foo", 1)); v != "a" {
+		t.Errorf("expected %q, got %q", "a", v)
+	}
+	if v, _ := cache.Get(NewKey2("foo", 2)); v != "b" {
+		t.Errorf("expected %q, got %q", "b", v)
+	}
+
+	if got, want := NewKey2("foo", 1).Hash(), NewKey2("foo", 1).Hash(); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if NewKey2("foo", 1).Hash() == NewKey2("foo", 2).Hash() {
+		t.Errorf("expected different hashes")
+	}
+	if NewKey2(1, 2).Hash() == NewKey2(2, 1).Hash() {
+		t.Errorf("expected order to matter")
+	}
+
+	// Caches hash the parts with their own seed.
+	seed := newHashSeed()
+	if got, want := hashKey(seed, NewKey2("foo", 1)), hashKey(seed, NewKey2("foo", 1)); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := hashKey(seed, NewKey2("foo", 1)), combineHashes(hashKey(seed, "foo"), hashKey(seed, 1)); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if hashKey(seed, NewKey2("foo", 1)) == hashKey(newHashSeed(), NewKey2("foo", 1)) {
+		t.Errorf("expected seeds to change the hash")
+	}
+}
+
+func TestKey3(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(4, func() Cache[Key3[string, int, int], string] {
+		return NewLRU[Key3[string, int, int], string](10)
+	})
+	defer cache.Stop()
+
+	cache.Set(NewKey3("tenant", 42, 1), "a")
+	cache.Set(NewKey3("tenant", 42, 2), "b")
+
+	if v, _ := cache.Get(NewKey3("tenant", 42, 1)); v != "a" {
+		t.Errorf("expected %q, got %q", "a", v)
+	}
+	if v, _ := cache.Get(NewKey3("tenant", 42, 2)); v != "b" {
+		t.Errorf("expected %q, got %q", "b", v)
+	}
+	if NewKey3("a", 1, 2).Hash() == NewKey3("a", 2, 1).Hash() {
+		t.Errorf("expected order to matter")
+	}
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Keyspace[string, string])(nil)
+
+// Keyspace wraps a cache and publishes its sets and evictions to subscribers
+// which select keys by pattern or namespace, similar to Redis keyspace
+// notifications. For example, to receive all events for keys under "user:":
+//
+//	sub := ks.Subscribe(cache.HasPrefix("user:"), 100)
+//	defer sub.Unsubscribe()
+//	for event := range sub.C {
+//	  // ...
+//	}
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Keyspace[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// subs are the active subscriptions. stopped indicates whether the cache is
+	// stopped, after which no new subscriptions are accepted. Both are guarded
+	// by lock.
+	subs    map[*Subscription[K, V]]struct{}
+	stopped bool
+	lock    sync.RWMutex
+}
+
+// KeyspaceOp is the kind of operation a KeyspaceEvent reports.
+type KeyspaceOp uint8
+
+// The operations which are published. Evictions are only published for caches
+// which implement EvictNotifier.
+const (
+	KeyspaceSet KeyspaceOp = iota + 1
+	KeyspaceEvict
+)
+
+// String returns the name of the operation.
+func (o KeyspaceOp) String() string {
+	switch o {
+	case KeyspaceSet:
+		return "set"
+	case KeyspaceEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// KeyspaceEvent is a single published cache operation. Value is the value
+// which was set or evicted.
+type KeyspaceEvent[K comparable, V any] struct {
+	Op    KeyspaceOp
+	Key   K
+	Value V
+}
+
+// Subscription is a subscription to the events for a set of keys, created with
+// Keyspace.Subscribe.
+type Subscription[K comparable, V any] struct {
+	// C receives the events for the matching keys. It is closed when the
+	// subscription is unsubscribed or the cache is stopped.
+	C <-chan KeyspaceEvent[K, V]
+
+	ch    chan KeyspaceEvent[K, V]
+	match func(K) bool
+	ks    *Keyspace[K, V]
+}
+
+// NewKeyspace wraps the given cache, publishing its operations to subscribers.
+// If the cache implements EvictNotifier, evictions are published too.
+func NewKeyspace[K comparable, V any](c Cache[K, V]) *Keyspace[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	k := &Keyspace[K, V]{
+		cache: c,
+		subs:  make(map[*Subscription[K, V]]struct{}),
+	}
+	if n, ok := c.(EvictNotifier[K, V]); ok {
+		n.OnEvict(func(key K, val V) {
+			k.publish(KeyspaceEvict, key, val)
+		})
+	}
+	return k
+}
+
+// HasPrefix returns a match function for Subscribe which selects the keys with
+// the given prefix.
+func HasPrefix(prefix string) func(string) bool {
+	return func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}
+}
+
+// Subscribe returns a subscription to the events for keys for which match
+// returns true, or for all keys if match is nil. Up to size events are
+// buffered; events published while the buffer is full are dropped rather than
+// blocking the cache. The match function is called for every operation, so it
+// should return quickly.
+func (k *Keyspace[K, V]) Subscribe(match func(K) bool, size int) *Subscription[K, V] {
+	if size <= 0 {
+		panic("size must be greater than 0")
+	}
+
+	ch := make(chan KeyspaceEvent[K, V], size)
+	sub := &Subscription[K, V]{
+		C:     ch,
+		ch:    ch,
+		match: match,
+		ks:    k,
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if k.stopped {
+		panic(ErrStopped)
+	}
+	k.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe stops the delivery of events and closes C. It is safe to call
+// more than once.
+func (s *Subscription[K, V]) Unsubscribe() {
+	s.ks.lock.Lock()
+	defer s.ks.lock.Unlock()
+
+	if _, ok := s.ks.subs[s]; !ok {
+		return
+	}
+	delete(s.ks.subs, s)
+	close(s.ch)
+}
+
+// Get fetches the cache item at the given key from the underlying cache.
+// Lookups are not published.
+func (k *Keyspace[K, V]) Get(key K) (V, bool) {
+	return k.cache.Get(key)
+}
+
+// Set inserts the value in the underlying cache and publishes the write.
+func (k *Keyspace[K, V]) Set(key K, val V) {
+	k.cache.Set(key, val)
+	k.publish(KeyspaceSet, key, val)
+}
+
+// Fetch retrieves the cached value from the underlying cache. If the FetchFunc
+// is invoked and succeeds, the stored value is published as a set.
+func (k *Keyspace[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	missed := false
+	v, err := k.cache.Fetch(key, func() (V, error) {
+		missed = true
+		return fn()
+	})
+	if err == nil && missed {
+		k.publish(KeyspaceSet, key, v)
+	}
+	return v, err
+}
+
+// Stop stops the underlying cache and closes all subscriptions.
+func (k *Keyspace[K, V]) Stop() {
+	k.cache.Stop()
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.stopped = true
+	for sub := range k.subs {
+		delete(k.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// publish delivers an event to each matching subscription which has room.
+func (k *Keyspace[K, V]) publish(op KeyspaceOp, key K, val V) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	for sub := range k.subs {
+		if sub.match != nil && !sub.match(key) {
+			continue
+		}
+
+		select {
+		case sub.ch <- KeyspaceEvent[K, V]{Op: op, Key: key, Value: val}:
+		default:
+		}
+	}
+}
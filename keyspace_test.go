@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestKeyspace(t *testing.T) {
+	t.Parallel()
+
+	t.Run("prefix", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewKeyspace[string, int](NewLRU[string, int](1))
+		users := cache.Subscribe(HasPrefix("user:"), 10)
+		all := cache.Subscribe(nil, 10)
+
+		cache.Set("user:1", 1)
+		cache.Set("post:1", 2)
+		cache.Fetch("user:2", func() (int, error) { return 3, nil })
+
+		cache.Stop()
+
+		var got []KeyspaceEvent[string, int]
+		for event := range users.C {
+			got = append(got, event)
+		}
+
+		want := []KeyspaceEvent[string, int]{
+			{Op: KeyspaceSet, Key: "user:1", Value: 1},
+			{Op: KeyspaceEvict, Key: "user:1", Value: 1},
+			{Op: KeyspaceSet, Key: "user:2", Value: 3},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("expected %#v to be %#v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("expected %#v to be %#v", got[i], want[i])
+			}
+		}
+
+		var n int
+		for range all.C {
+			n++
+		}
+		if got, want := n, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewKeyspace[string, int](NewLRU[string, int](10))
+		defer cache.Stop()
+
+		sub := cache.Subscribe(nil, 1)
+		sub.Unsubscribe()
+		sub.Unsubscribe()
+
+		cache.Set("foo", 1)
+		if _, ok := <-sub.C; ok {
+			t.Errorf("expected channel to be closed")
+		}
+	})
+
+	t.Run("drops_when_full", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewKeyspace[string, int](NewLRU[string, int](10))
+		defer cache.Stop()
+
+		sub := cache.Subscribe(nil, 1)
+		cache.Set("foo", 1)
+		cache.Set("bar", 2)
+
+		if got, want := len(sub.C), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := (<-sub.C).Key, "foo"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+}
@@ -18,10 +18,10 @@ func TestNewLIFO(t *testing.T) {
 		if got, want := cache.capacity, int64(10); got != want {
 			t.Errorf("expected %d to be %d", got, want)
 		}
-		if got, want := cache.cache, make(map[string]*lifoListItem[string, string], 10); !reflect.DeepEqual(got, want) {
+		if got, want := cache.cache, make(map[string]int, 10); !reflect.DeepEqual(got, want) {
 			t.Errorf("expected %#v to be %#v", got, want)
 		}
-		if got, want := cache.head, (*lifoListItem[string, string])(nil); got != want {
+		if got, want := cache.policy.(*listPolicy[string]).tail, (*policyNode[string])(nil); got != want {
 			t.Errorf("expected %#v to be %#v", got, want)
 		}
 	})
@@ -42,6 +42,41 @@ func TestNewLIFO(t *testing.T) {
 	})
 }
 
+func TestNewLIFOFromMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("populates", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLIFOFromMap(10, map[string]int{"a": 1, "b": 2, "c": 3})
+		defer cache.Stop()
+
+		if got, want := cache.Len(), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		for k, want := range map[string]int{"a": 1, "b": 2, "c": 3} {
+			got, ok := cache.Get(k)
+			if !ok {
+				t.Errorf("expected %q to be in the cache", k)
+			}
+			if got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+		}
+	})
+
+	t.Run("over_capacity", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLIFOFromMap(2, map[string]int{"a": 1, "b": 2, "c": 3})
+		defer cache.Stop()
+
+		if got, want := cache.Len(), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
 func TestLIFO_Get(t *testing.T) {
 	t.Parallel()
 
@@ -184,6 +219,39 @@ func TestLIFO_Fetch(t *testing.T) {
 			t.Error("expected error")
 		}
 	})
+
+	t.Run("does_not_block", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLIFO[string, string](3)
+		defer cache.Stop()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		doneCh := make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			cache.Fetch("foo", func() (string, error) {
+				close(started)
+				<-release
+				return "bar", nil
+			})
+		}()
+		<-started
+
+		// Other keys are usable while the load is in progress.
+		cache.Set("zip", "zap")
+		if v, _ := cache.Get("zip"); v != "zap" {
+			t.Errorf("expected %q to be %q", v, "zap")
+		}
+
+		close(release)
+		<-doneCh
+
+		if v, _ := cache.Get("foo"); v != "bar" {
+			t.Errorf("expected %q to be %q", v, "bar")
+		}
+	})
 }
 
 func TestLIFO_Stop(t *testing.T) {
@@ -203,8 +271,8 @@ func TestLIFO_Stop(t *testing.T) {
 		if cache.cache != nil {
 			t.Errorf("expected %#v to be nil", cache.cache)
 		}
-		if cache.head != nil {
-			t.Errorf("expected %#v to be nil", cache.head)
+		if tail := cache.policy.(*listPolicy[string]).tail; tail != nil {
+			t.Errorf("expected %#v to be nil", tail)
 		}
 	})
 
@@ -238,3 +306,187 @@ func TestLIFO_Stop(t *testing.T) {
 		t.Errorf("did not panic")
 	})
 }
+
+func TestLIFO_Entries(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLIFO[string, int](10)
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	cache.Set("bar", 3)
+	cache.Get("foo")
+	cache.Get("foo")
+
+	entries := cache.Entries()
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	if got, want := entries[0].Key, "bar"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	for _, entry := range entries {
+		if entry.InsertedAt.IsZero() {
+			t.Errorf("expected %q to have an insertion time", entry.Key)
+		}
+
+		switch entry.Key {
+		case "foo":
+			if got, want := entry.Value, 5; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if got, want := entry.Hits, uint64(2); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if entry.LastAccessedAt.IsZero() {
+				t.Errorf("expected %q to have an access time", entry.Key)
+			}
+		case "bar":
+			if got, want := entry.Hits, uint64(0); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if !entry.LastAccessedAt.IsZero() {
+				t.Errorf("expected %q to not have an access time", entry.Key)
+			}
+		}
+
+		if !entry.ExpiresAt.IsZero() {
+			t.Errorf("expected %q to not expire", entry.Key)
+		}
+	}
+}
+
+func TestLIFO_AscendEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLIFO[string, int](10)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+
+	var keys []string
+	cache.AscendEvictionOrder(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if got, want := keys, []string{"baz", "bar", "foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	keys = nil
+	cache.AscendEvictionOrder(func(k string, v int) bool {
+		keys = append(keys, k)
+		return false
+	})
+	if got, want := len(keys), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestLIFO_RemoveOldest(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLIFO[string, int](3)
+	defer cache.Stop()
+
+	if k, v, ok := cache.RemoveOldest(); ok {
+		t.Errorf("expected empty, got %q=%d", k, v)
+	}
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+
+	var removed []string
+	for {
+		k, v, ok := cache.RemoveOldest()
+		if !ok {
+			break
+		}
+		if got, ok := map[string]int{"foo": 1, "bar": 2, "baz": 3}[k]; !ok || got != v {
+			t.Errorf("unexpected entry %q=%d", k, v)
+		}
+		removed = append(removed, k)
+	}
+	if got, want := removed, []string{"baz", "bar", "foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := cache.Len(), 0; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Ensure the cache is still usable once empty.
+	cache.Set("qux", 4)
+	if v, _ := cache.Get("qux"); v != 4 {
+		t.Errorf("expected %#v, got %#v", 4, v)
+	}
+}
+
+func TestLIFO_OnEvict(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLIFO[string, int](2)
+	defer cache.Stop()
+
+	var evicted []string
+	cache.OnEvict(func(k string, v int) {
+		evicted = append(evicted, k)
+
+		// Hooks run without the lock held, so they may use the cache.
+		cache.Len()
+	})
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Fetch("baz", func() (int, error) { return 3, nil })
+	cache.RemoveOldest()
+
+	if got, want := len(evicted), 1; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	if got, want := evicted[0], "bar"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
+
+func TestLIFO_Resize(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLIFO[string, int](4)
+	defer cache.Stop()
+
+	var evicted int
+	cache.OnEvict(func(string, int) {
+		evicted++
+	})
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+
+	cache.Resize(1)
+	if got, want := cache.Capacity(), int64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.Len(), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := evicted, 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	cache.Resize(10)
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprint(i), i)
+	}
+	if got, want := cache.Len(), 10; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
@@ -0,0 +1,550 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Loading[string, string])(nil)
+
+// ErrLoaderRateLimited is returned by Fetch when the loader rate limit has been
+// exhausted and no stale value is available. Since no loader ran, it is not
+// wrapped in a LoaderError.
+var ErrLoaderRateLimited = errors.New("loader rate limit exceeded")
+
+// ErrLoaderTimeout is returned by Fetch when a loader does not complete within
+// the configured timeout. It is not wrapped in a LoaderError, since the loader
+// did not fail.
+var ErrLoaderTimeout = errors.New("loader timed out")
+
+// ErrLoaderPanicked is returned by Fetch when a loader panicked. Without
+// WithLoaderPanicRecovery, the panic propagates to the caller which ran the
+// loader, and only the callers waiting on the same load receive this error.
+var ErrLoaderPanicked = errors.New("loader panicked")
+
+// FetchContextFunc is a function that is invoked when a cached value is not
+// found. It should abandon its work when the context is done.
+type FetchContextFunc[V any] func(ctx context.Context) (V, error)
+
+// Loading wraps a cache and controls how the FetchFunc passed to Fetch is
+// invoked, protecting fragile upstreams from the burst of loads that follows a
+// cache flush or a cold start. Get and Set are passed through unchanged.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Loading[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[K, V]
+
+	// limiter bounds how often loaders are invoked overall, or nil if loaders
+	// are not rate limited overall.
+	limiter *tokenBucket
+
+	// keyRate and keyBurst configure the per-key rate limit, and keyLimiters
+	// holds the per-key token buckets. keyLimiters is nil if loaders are not
+	// rate limited per key. keyLimiters and pruneAt, the number of buckets at
+	// which they are next pruned, are guarded by keyLimitersLock.
+	keyRate         float64
+	keyBurst        int
+	keyLimiters     map[K]*tokenBucket
+	pruneAt         int
+	keyLimitersLock sync.Mutex
+
+	// attempts is the maximum number of times a loader is invoked for a single
+	// load, and backoff returns the delay before each retry.
+	attempts int
+	backoff  Backoff
+
+	// timeout caps each loader invocation, or is 0 if loaders are not capped.
+	timeout time.Duration
+
+	// slots is a semaphore bounding the number of loaders running at once, or
+	// nil if loads are unbounded.
+	slots chan struct{}
+
+	// recover indicates whether panics in loaders are recovered.
+	recover bool
+}
+
+// LoaderOption is an option for configuring a loading cache.
+type LoaderOption func(*loaderOptions)
+
+// loaderOptions are the options for a loading cache.
+type loaderOptions struct {
+	rate     float64
+	burst    int
+	keyRate  float64
+	keyBurst int
+	attempts int
+	backoff  Backoff
+	timeout  time.Duration
+	maxLoads int
+	recover  bool
+}
+
+// Backoff returns the delay before the given retry attempt, starting at 1 for
+// the first retry.
+type Backoff func(attempt int) time.Duration
+
+// ConstantBackoff returns a Backoff which always waits the given duration.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a Backoff which waits base before the first retry
+// and doubles the delay for each subsequent retry, up to max.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	if base <= 0 {
+		panic("base must be greater than 0")
+	}
+	if max < base {
+		panic("max must be at least base")
+	}
+
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// WithLoaderRateLimit bounds how often loaders may be invoked across all keys
+// to rate per second, with bursts of up to burst invocations. When the budget
+// is exhausted, Fetch returns the stale value if the underlying cache still
+// holds an expired one, or ErrLoaderRateLimited otherwise.
+func WithLoaderRateLimit(rate float64, burst int) LoaderOption {
+	if rate <= 0 {
+		panic("rate must be greater than 0")
+	}
+	if burst <= 0 {
+		panic("burst must be greater than 0")
+	}
+
+	return func(o *loaderOptions) {
+		o.rate = rate
+		o.burst = burst
+	}
+}
+
+// WithLoaderRateLimitPerKey is like WithLoaderRateLimit, but the budget applies
+// to each key independently. It can be combined with WithLoaderRateLimit, in
+// which case a load must be permitted by both.
+func WithLoaderRateLimitPerKey(rate float64, burst int) LoaderOption {
+	if rate <= 0 {
+		panic("rate must be greater than 0")
+	}
+	if burst <= 0 {
+		panic("burst must be greater than 0")
+	}
+
+	return func(o *loaderOptions) {
+		o.keyRate = rate
+		o.keyBurst = burst
+	}
+}
+
+// WithLoaderRetry retries loaders which return an error, invoking them up to
+// attempts times in total and waiting according to backoff between attempts.
+// Retries happen inside Fetch, so callers waiting on the same key still share
+// the result. Each attempt is subject to the rate limits; if a retry is not
+// permitted, the most recent loader error is returned.
+func WithLoaderRetry(attempts int, backoff Backoff) LoaderOption {
+	if attempts <= 0 {
+		panic("attempts must be greater than 0")
+	}
+	if backoff == nil {
+		panic("backoff cannot be nil")
+	}
+
+	return func(o *loaderOptions) {
+		o.attempts = attempts
+		o.backoff = backoff
+	}
+}
+
+// WithLoaderTimeout caps each loader invocation at the given duration. The
+// loader receives a context which is done when the timeout elapses, and Fetch
+// returns ErrLoaderTimeout as soon as it does, even if the loader ignores the
+// context and keeps running in the background. Combined with WithLoaderRetry,
+// the timeout applies to each attempt.
+func WithLoaderTimeout(d time.Duration) LoaderOption {
+	if d <= 0 {
+		panic("timeout must be greater than 0")
+	}
+
+	return func(o *loaderOptions) {
+		o.timeout = d
+	}
+}
+
+// WithMaxConcurrentLoads bounds the number of loaders running at once across
+// all keys. Loads beyond the limit wait for a running loader to finish, or for
+// the context passed to FetchContext to be done, whichever comes first. A
+// loader abandoned by WithLoaderTimeout holds its slot until it returns.
+func WithMaxConcurrentLoads(n int) LoaderOption {
+	if n <= 0 {
+		panic("n must be greater than 0")
+	}
+
+	return func(o *loaderOptions) {
+		o.maxLoads = n
+	}
+}
+
+// WithLoaderPanicRecovery recovers panics in loaders, so Fetch returns an error
+// wrapping ErrLoaderPanicked and the panic value instead of crashing the
+// caller, or the process if the loader was abandoned by WithLoaderTimeout.
+// Combined with WithLoaderRetry, a panic counts as a failed attempt.
+func WithLoaderPanicRecovery() LoaderOption {
+	return func(o *loaderOptions) {
+		o.recover = true
+	}
+}
+
+// NewLoading wraps the given cache, applying the given options to every
+// loader invocation.
+func NewLoading[K comparable, V any](c Cache[K, V], opts ...LoaderOption) *Loading[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	var o loaderOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	l := &Loading[K, V]{
+		cache:    c,
+		keyRate:  o.keyRate,
+		keyBurst: o.keyBurst,
+		attempts: 1,
+		backoff:  o.backoff,
+		timeout:  o.timeout,
+		recover:  o.recover,
+	}
+	if o.attempts > 0 {
+		l.attempts = o.attempts
+	}
+	if o.maxLoads > 0 {
+		l.slots = make(chan struct{}, o.maxLoads)
+	}
+	if o.rate > 0 {
+		l.limiter = newTokenBucket(o.rate, o.burst)
+	}
+	if o.keyRate > 0 {
+		l.keyLimiters = make(map[K]*tokenBucket, 16)
+		l.pruneAt = minPruneAt
+	}
+	return l
+}
+
+// Get fetches the cache item at the given key from the underlying cache.
+func (l *Loading[K, V]) Get(key K) (V, bool) {
+	return l.cache.Get(key)
+}
+
+// Set inserts the value in the underlying cache.
+func (l *Loading[K, V]) Set(key K, val V) {
+	l.cache.Set(key, val)
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called, subject to the configured options, and the result is stored. If
+// the value does exist, the FetchFunc is not invoked.
+func (l *Loading[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	return l.FetchContext(context.Background(), key, func(context.Context) (V, error) {
+		return fn()
+	})
+}
+
+// FetchContext is like Fetch, but the loader receives a context derived from
+// ctx. If ctx is done while loading or waiting to retry, FetchContext returns
+// its error, which is not wrapped in a LoaderError.
+func (l *Loading[K, V]) FetchContext(ctx context.Context, key K, fn FetchContextFunc[V]) (V, error) {
+	limited := false
+	v, err := l.cache.Fetch(key, func() (V, error) {
+		v, err := l.load(ctx, key, fn)
+		if errors.Is(err, ErrLoaderRateLimited) {
+			limited = true
+		}
+		return v, err
+	})
+
+	// The underlying cache wraps every error from the FetchFunc in a
+	// LoaderError, including those produced by Loading itself rather than by
+	// the loader, so those are unwrapped.
+	var lerr *loadingError
+	if errors.As(err, &lerr) {
+		err = lerr.err
+	}
+
+	// The stale value is looked up after Fetch returns, since some caches hold
+	// their lock while the loader runs.
+	if limited {
+		if sg, ok := l.cache.(staleGetter[K, V]); ok {
+			if v, ok := sg.getStale(key); ok {
+				return v, nil
+			}
+		}
+	}
+	return v, err
+}
+
+// Stop stops the underlying cache.
+func (l *Loading[K, V]) Stop() {
+	l.cache.Stop()
+}
+
+// load invokes the loader for the given key, subject to the rate limits and
+// retries. It returns ErrLoaderRateLimited if the first attempt is not
+// permitted.
+func (l *Loading[K, V]) load(ctx context.Context, key K, fn FetchContextFunc[V]) (V, error) {
+	var v V
+	var err error
+
+	for attempt := 0; attempt < l.attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(l.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				var zeroV V
+				return zeroV, &loadingError{ctx.Err()}
+			case <-timer.C:
+			}
+		}
+
+		if !l.allow(key, time.Now()) {
+			if attempt == 0 {
+				var zeroV V
+				return zeroV, &loadingError{ErrLoaderRateLimited}
+			}
+			break
+		}
+
+		v, err = l.invoke(ctx, fn)
+		if err == nil {
+			return v, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return v, err
+}
+
+// invoke calls the loader once, enforcing the concurrency limit and the
+// timeout if they are configured.
+func (l *Loading[K, V]) invoke(ctx context.Context, fn FetchContextFunc[V]) (V, error) {
+	if l.recover {
+		fn = recoverLoader(fn)
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			var zeroV V
+			return zeroV, &loadingError{ctx.Err()}
+		}
+	}
+
+	if l.timeout == 0 {
+		defer l.release()
+		return fn(ctx)
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	type result struct {
+		value V
+		err   error
+	}
+
+	// The channel is buffered so the loader can finish and exit even after the
+	// result has been abandoned.
+	resultCh := make(chan result, 1)
+	go func() {
+		defer l.release()
+		v, err := fn(loadCtx)
+		resultCh <- result{v, err}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.value, r.err
+	case <-loadCtx.Done():
+		// Prefer the loader's result if it finished at the same time.
+		select {
+		case r := <-resultCh:
+			return r.value, r.err
+		default:
+		}
+
+		var zeroV V
+		if err := ctx.Err(); err != nil {
+			return zeroV, &loadingError{err}
+		}
+		return zeroV, &loadingError{ErrLoaderTimeout}
+	}
+}
+
+// loadingError is an error produced by Loading rather than by the loader, such
+// as ErrLoaderTimeout. FetchContext unwraps it from the LoaderError in which
+// the underlying cache wraps it.
+type loadingError struct {
+	err error
+}
+
+// Error returns the underlying error's message.
+func (e *loadingError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *loadingError) Unwrap() error {
+	return e.err
+}
+
+// recoverLoader wraps the loader so that a panic is returned as an error
+// wrapping ErrLoaderPanicked.
+func recoverLoader[V any](fn FetchContextFunc[V]) FetchContextFunc[V] {
+	return func(ctx context.Context) (v V, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				var zeroV V
+				v, err = zeroV, fmt.Errorf("%w: %v", ErrLoaderPanicked, r)
+			}
+		}()
+		return fn(ctx)
+	}
+}
+
+// release frees the concurrency slot acquired by invoke.
+func (l *Loading[K, V]) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// allow reports whether a load of the given key is permitted by the rate
+// limits, consuming a token from each applicable bucket if so.
+func (l *Loading[K, V]) allow(key K, now time.Time) bool {
+	if l.keyLimiters != nil {
+		l.keyLimitersLock.Lock()
+		b, ok := l.keyLimiters[key]
+		if !ok {
+			b = newTokenBucket(l.keyRate, l.keyBurst)
+			l.keyLimiters[key] = b
+		}
+		allowed := b.allow(now)
+		l.pruneKeyLimiters(now)
+		l.keyLimitersLock.Unlock()
+
+		if !allowed {
+			return false
+		}
+	}
+
+	if l.limiter != nil && !l.limiter.allow(now) {
+		return false
+	}
+	return true
+}
+
+// pruneKeyLimiters removes per-key buckets which have refilled completely,
+// since they are indistinguishable from new buckets. To amortize the cost, it
+// only runs once the number of buckets has doubled since the last prune. It
+// must be called while holding keyLimitersLock.
+func (l *Loading[K, V]) pruneKeyLimiters(now time.Time) {
+	if len(l.keyLimiters) < l.pruneAt {
+		return
+	}
+
+	for k, b := range l.keyLimiters {
+		if b.full(now) {
+			delete(l.keyLimiters, k)
+		}
+	}
+
+	l.pruneAt = 2 * len(l.keyLimiters)
+	if l.pruneAt < minPruneAt {
+		l.pruneAt = minPruneAt
+	}
+}
+
+// minPruneAt is the minimum number of per-key buckets before they are pruned.
+const minPruneAt = 1024
+
+// staleGetter is implemented by caches which can return a value which has
+// expired but not yet been removed.
+type staleGetter[K comparable, V any] interface {
+	getStale(key K) (V, bool)
+}
+
+// tokenBucket is a token bucket rate limiter. It is safe for concurrent use.
+type tokenBucket struct {
+	// rate is the number of tokens added per second and burst is the maximum
+	// number of tokens.
+	rate  float64
+	burst float64
+
+	// tokens is the number of tokens as of last. It is guarded by lock.
+	tokens float64
+	last   time.Time
+	lock   sync.Mutex
+}
+
+// newTokenBucket creates a new token bucket which starts full.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow consumes a token and returns true if one is available at the given
+// time.
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// full reports whether the bucket is full at the given time.
+func (b *tokenBucket) full(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// refill adds the tokens accrued since the last refill. It does not lock.
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
@@ -0,0 +1,577 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewLoading(t *testing.T) {
+	t.Parallel()
+
+	t.Run("panic_on_nil", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "cache cannot be nil"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		NewLoading[string, int](nil)
+		t.Errorf("did not panic")
+	})
+
+	t.Run("panic_on_invalid_rate", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "rate must be greater than 0"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		WithLoaderRateLimit(0, 1)
+		t.Errorf("did not panic")
+	})
+}
+
+func TestLoading_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("passes_through", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10))
+		defer cache.Stop()
+
+		v, err := cache.Fetch("foo", func() (int, error) {
+			return 5, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if v, _ := cache.Get("foo"); v != 5 {
+			t.Errorf("expected %d to be %d", v, 5)
+		}
+	})
+
+	t.Run("rate_limited", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithLoaderRateLimit(0.001, 2))
+		defer cache.Stop()
+
+		calls := 0
+		fn := func() (int, error) {
+			calls++
+			return calls, nil
+		}
+
+		for _, key := range []string{"a", "b"} {
+			if _, err := cache.Fetch(key, fn); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := cache.Fetch("c", fn)
+		if !errors.Is(err, ErrLoaderRateLimited) {
+			t.Errorf("expected %v to be %v", err, ErrLoaderRateLimited)
+		}
+
+		// No loader ran, so the error is not a LoaderError.
+		var lerr *LoaderError
+		if errors.As(err, &lerr) {
+			t.Errorf("expected %v not to be a LoaderError", err)
+		}
+		if got, want := calls, 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		// Cached values are still served.
+		if v, err := cache.Fetch("a", fn); err != nil || v != 1 {
+			t.Errorf("expected %d, %v to be 1, nil", v, err)
+		}
+	})
+
+	t.Run("rate_limited_per_key", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithLoaderRateLimitPerKey(0.001, 1))
+		defer cache.Stop()
+
+		failing := func() (int, error) {
+			return 0, fmt.Errorf("upstream failed")
+		}
+
+		if _, err := cache.Fetch("a", failing); err == nil || errors.Is(err, ErrLoaderRateLimited) {
+			t.Errorf("expected upstream error, got %v", err)
+		}
+		if _, err := cache.Fetch("a", failing); !errors.Is(err, ErrLoaderRateLimited) {
+			t.Errorf("expected %v to be %v", err, ErrLoaderRateLimited)
+		}
+		if _, err := cache.Fetch("b", failing); errors.Is(err, ErrLoaderRateLimited) {
+			t.Errorf("expected b to have its own budget")
+		}
+	})
+
+	t.Run("serves_stale", func(t *testing.T) {
+		t.Parallel()
+
+		ttl := NewTTL[string, int](time.Hour)
+		cache := NewLoading[string, int](ttl, WithLoaderRateLimit(0.001, 1))
+		defer cache.Stop()
+
+		ttl.SetWithExpireAt("foo", 5, time.Now().Add(-time.Second))
+
+		if _, err := cache.Fetch("bar", func() (int, error) { return 1, nil }); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := cache.Fetch("foo", func() (int, error) {
+			t.Errorf("loader should not be called")
+			return 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		// The stale value is not stored as fresh.
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to remain expired")
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithLoaderRetry(3, ConstantBackoff(time.Millisecond)))
+		defer cache.Stop()
+
+		calls := 0
+		v, err := cache.Fetch("foo", func() (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, fmt.Errorf("transient")
+			}
+			return 5, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := calls, 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("retries_exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithLoaderRetry(2, ConstantBackoff(time.Millisecond)))
+		defer cache.Stop()
+
+		calls := 0
+		_, err := cache.Fetch("foo", func() (int, error) {
+			calls++
+			return 0, fmt.Errorf("attempt %d", calls)
+		})
+		if got, want := fmt.Sprintf("%v", err), "failed to load foo: attempt 2"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to not be cached")
+		}
+	})
+
+	t.Run("retries_rate_limited", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithLoaderRateLimit(0.001, 2),
+			WithLoaderRetry(5, ConstantBackoff(time.Millisecond)))
+		defer cache.Stop()
+
+		calls := 0
+		_, err := cache.Fetch("foo", func() (int, error) {
+			calls++
+			return 0, fmt.Errorf("attempt %d", calls)
+		})
+		if got, want := fmt.Sprintf("%v", err), "failed to load foo: attempt 2"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("recovers_panic", func(t *testing.T) {
+		t.Parallel()
+
+		lru := NewLRU[string, int](10)
+		cache := NewLoading[string, int](lru,
+			WithLoaderPanicRecovery(),
+			WithLoaderRetry(2, ConstantBackoff(time.Millisecond)))
+		defer cache.Stop()
+
+		calls := 0
+		v, err := cache.Fetch("foo", func() (int, error) {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			return 5, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		_, err = cache.Fetch("bar", func() (int, error) {
+			panic("boom")
+		})
+		if !errors.Is(err, ErrLoaderPanicked) {
+			t.Errorf("expected %v to be %v", err, ErrLoaderPanicked)
+		}
+		if got, want := fmt.Sprintf("%v", err), "failed to load bar: loader panicked: boom"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		// The cache is usable again.
+		lru.Set("bar", 1)
+		if got, ok := cache.Get("bar"); !ok || got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("recovers_panic_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithLoaderPanicRecovery(),
+			WithLoaderTimeout(time.Second))
+		defer cache.Stop()
+
+		_, err := cache.Fetch("foo", func() (int, error) {
+			panic("boom")
+		})
+		if !errors.Is(err, ErrLoaderPanicked) {
+			t.Errorf("expected %v to be %v", err, ErrLoaderPanicked)
+		}
+	})
+}
+
+func TestLoading_FetchContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("passes_context", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10))
+		defer cache.Stop()
+
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, 5)
+
+		v, err := cache.FetchContext(ctx, "foo", func(ctx context.Context) (int, error) {
+			return ctx.Value(ctxKey{}).(int), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		ttl := NewTTL[string, int](time.Minute)
+		cache := NewLoading[string, int](ttl, WithLoaderTimeout(10*time.Millisecond))
+		defer cache.Stop()
+
+		// The loader ignores its context, so Fetch must not wait for it.
+		release := make(chan struct{})
+		defer close(release)
+
+		start := time.Now()
+		_, err := cache.Fetch("foo", func() (int, error) {
+			<-release
+			return 5, nil
+		})
+		if !errors.Is(err, ErrLoaderTimeout) {
+			t.Errorf("expected %v to be %v", err, ErrLoaderTimeout)
+		}
+		var lerr *LoaderError
+		if errors.As(err, &lerr) {
+			t.Errorf("expected %v not to be a LoaderError", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected Fetch to return promptly, took %s", elapsed)
+		}
+
+		// The cache is usable again.
+		ttl.Set("bar", 1)
+		if _, ok := ttl.Get("foo"); ok {
+			t.Errorf("expected foo to not be cached")
+		}
+	})
+
+	t.Run("timeout_retries", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithLoaderTimeout(10*time.Millisecond),
+			WithLoaderRetry(2, ConstantBackoff(time.Millisecond)))
+		defer cache.Stop()
+
+		// The loader runs in a separate goroutine for each attempt, and the
+		// abandoned attempt is never joined, so the count must be atomic.
+		var calls int32
+		v, err := cache.FetchContext(context.Background(), "foo", func(ctx context.Context) (int, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return 5, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithLoaderTimeout(time.Minute),
+			WithLoaderRetry(5, ConstantBackoff(time.Minute)))
+		defer cache.Stop()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		calls := 0
+		_, err := cache.FetchContext(ctx, "foo", func(ctx context.Context) (int, error) {
+			calls++
+			cancel()
+			return 0, fmt.Errorf("failed")
+		})
+		if got, want := fmt.Sprintf("%v", err), "failed to load foo: failed"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := calls, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("canceled_queued", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10), WithMaxConcurrentLoads(1))
+		defer cache.Stop()
+
+		// Fill the only slot, so the next load waits for the context.
+		cache.slots <- struct{}{}
+		defer cache.release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := cache.FetchContext(ctx, "foo", func(ctx context.Context) (int, error) {
+			t.Errorf("loader should not be called")
+			return 0, nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v to be %v", err, context.Canceled)
+		}
+		var lerr *LoaderError
+		if errors.As(err, &lerr) {
+			t.Errorf("expected %v not to be a LoaderError", err)
+		}
+	})
+}
+
+func TestLoading_invoke(t *testing.T) {
+	t.Parallel()
+
+	t.Run("max_concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10), WithMaxConcurrentLoads(2))
+		defer cache.Stop()
+
+		var running, peak int32
+		fn := func(ctx context.Context) (int, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return 1, nil
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := cache.invoke(context.Background(), fn); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&peak), int32(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("queued_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10), WithMaxConcurrentLoads(1))
+		defer cache.Stop()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go cache.invoke(context.Background(), func(ctx context.Context) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := cache.invoke(ctx, func(ctx context.Context) (int, error) {
+			t.Errorf("loader should not be called")
+			return 0, nil
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v to be %v", err, context.DeadlineExceeded)
+		}
+		close(release)
+	})
+
+	t.Run("timeout_holds_slot", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLoading[string, int](NewLRU[string, int](10),
+			WithMaxConcurrentLoads(1),
+			WithLoaderTimeout(5*time.Millisecond))
+		defer cache.Stop()
+
+		release := make(chan struct{})
+		_, err := cache.invoke(context.Background(), func(ctx context.Context) (int, error) {
+			<-release
+			return 1, nil
+		})
+		if !errors.Is(err, ErrLoaderTimeout) {
+			t.Errorf("expected %v to be %v", err, ErrLoaderTimeout)
+		}
+		if got, want := len(cache.slots), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		close(release)
+		deadline := time.Now().Add(time.Second)
+		for len(cache.slots) != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for slot to be released")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
+
+func TestLoading_pruneKeyLimiters(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLoading[int, int](NewLRU[int, int](10),
+		WithLoaderRateLimitPerKey(1000, 1))
+	defer cache.Stop()
+
+	now := time.Now()
+	for i := 0; i < minPruneAt-1; i++ {
+		cache.allow(i, now)
+	}
+	if got, want := len(cache.keyLimiters), minPruneAt-1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// The other buckets have refilled a second later, so only the bucket which
+	// was just used remains.
+	cache.allow(-1, now.Add(time.Second))
+	if got, want := len(cache.keyLimiters), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Parallel()
+
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+
+	cases := []struct {
+		attempt int
+		exp     time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 50 * time.Millisecond},
+		{100, 50 * time.Millisecond},
+	}
+
+	for _, tc := range cases {
+		if got, want := backoff(tc.attempt), tc.exp; got != want {
+			t.Errorf("attempt %d: expected %s to be %s", tc.attempt, got, want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	b := newTokenBucket(10, 2)
+	now := b.last
+
+	if !b.allow(now) || !b.allow(now) {
+		t.Errorf("expected burst to be allowed")
+	}
+	if b.allow(now) {
+		t.Errorf("expected bucket to be empty")
+	}
+	if !b.allow(now.Add(100 * time.Millisecond)) {
+		t.Errorf("expected bucket to refill")
+	}
+	if !b.full(now.Add(time.Hour)) {
+		t.Errorf("expected bucket to be full")
+	}
+}
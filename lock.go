@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// waitMutex is a sync.RWMutex which records the total time callers spent
+// blocked waiting to acquire it. Uncontended acquisitions take a fast path and
+// are not timed, so the overhead is only paid when there is contention.
+type waitMutex struct {
+	sync.RWMutex
+
+	// waited is the total time spent waiting for the lock. It is guarded by
+	// waitedLock, which is only taken on the contended path.
+	waited     time.Duration
+	waitedLock sync.Mutex
+}
+
+// Lock locks the mutex for writing, recording any time spent waiting.
+func (m *waitMutex) Lock() {
+	if m.RWMutex.TryLock() {
+		return
+	}
+
+	start := time.Now()
+	m.RWMutex.Lock()
+	m.addWait(time.Since(start))
+}
+
+// RLock locks the mutex for reading, recording any time spent waiting.
+func (m *waitMutex) RLock() {
+	if m.RWMutex.TryRLock() {
+		return
+	}
+
+	start := time.Now()
+	m.RWMutex.RLock()
+	m.addWait(time.Since(start))
+}
+
+// waitTime returns the total time callers have spent waiting for the lock.
+func (m *waitMutex) waitTime() time.Duration {
+	m.waitedLock.Lock()
+	defer m.waitedLock.Unlock()
+	return m.waited
+}
+
+// addWait adds the given duration to the total wait time.
+func (m *waitMutex) addWait(d time.Duration) {
+	m.waitedLock.Lock()
+	m.waited += d
+	m.waitedLock.Unlock()
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitMutex(t *testing.T) {
+	t.Parallel()
+
+	t.Run("uncontended", func(t *testing.T) {
+		t.Parallel()
+
+		var m waitMutex
+		m.Lock()
+		m.Unlock()
+		m.RLock()
+		m.RUnlock()
+
+		if got, want := m.waitTime(), time.Duration(0); got != want {
+			t.Errorf("expected %s to be %s", got, want)
+		}
+	})
+
+	t.Run("contended", func(t *testing.T) {
+		t.Parallel()
+
+		var m waitMutex
+		m.Lock()
+		go func() {
+			time.Sleep(25 * time.Millisecond)
+			m.Unlock()
+		}()
+
+		m.RLock()
+		m.RUnlock()
+
+		if got, want := m.waitTime(), 10*time.Millisecond; got < want {
+			t.Errorf("expected %s to be at least %s", got, want)
+		}
+	})
+}
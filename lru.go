@@ -1,8 +1,8 @@
 package cache
 
 import (
-	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Ensure implements.
@@ -16,12 +16,18 @@ var _ Cache[string, string] = (*LRU[string, string])(nil)
 // are best for performance.
 type LRU[K comparable, V any] struct {
 	// cache represents the internal cache storage. It has a comparable key and
-	// points to an entry in the doubly-linked list. The node in the linked list
-	// contains the actual cached data.
-	cache map[K]*lruListItem[K, V]
+	// holds the index of an entry in nodes. The node contains the actual cached
+	// data.
+	cache map[K]int
 
-	// head points to the head of the linked list and tail points to the tail.
-	head, tail *lruListItem[K, V]
+	// nodes are the nodes of the doubly-linked list, which link to each other
+	// by index. The node at index 0 is a sentinel: its next is the head of the
+	// list and its prev is the tail, or 0 if the list is empty.
+	nodes []lruListItem[K, V]
+
+	// free is the index of the first node which is not in use, or 0 if there
+	// is none. Free nodes are linked by next.
+	free int
 
 	// capacity is the total capacity for the cache.
 	capacity int64
@@ -30,25 +36,175 @@ type LRU[K comparable, V any] struct {
 	stopped uint32
 
 	// lock is the internal lock for concurrency.
-	lock sync.Mutex
+	lock waitMutex
+
+	// onEvict are the functions to invoke when an entry is evicted.
+	onEvict []func(K, V)
+
+	// onLifetime are the functions to invoke with how long each evicted entry
+	// was cached.
+	onLifetime []func(time.Duration)
+
+	// chunkSize is the number of nodes by which nodes grows when it is full, or
+	// 0 if it doubles.
+	chunkSize int
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+
+	// buffered indicates Get reads under the shared lock and buffers recency
+	// updates in accesses, which are applied in batches.
+	buffered bool
+	accesses accessBuffer[K]
+
+	// approximate indicates Get reads under the shared lock and only marks the
+	// entry as referenced, and eviction gives referenced entries a second
+	// chance instead of evicting them.
+	approximate bool
+
+	// admission decides whether new entries are inserted, or is nil if all
+	// entries are.
+	admission Admission[K, V]
+}
+
+// LRUOption is an option for configuring an LRU cache. It is either an
+// LRU-specific option or an Option shared with other capacity-bounded caches.
+type LRUOption[K comparable, V any] interface {
+	applyLRU(o *lruOptions[K, V])
+}
+
+// lruOption is an LRU-specific option.
+type lruOption[K comparable, V any] func(*lruOptions[K, V])
+
+// applyLRU implements LRUOption.
+func (opt lruOption[K, V]) applyLRU(o *lruOptions[K, V]) {
+	opt(o)
+}
+
+// lruOptions are the options for an LRU cache.
+type lruOptions[K comparable, V any] struct {
+	options[K, V]
+
+	buffered    bool
+	approximate bool
+	preallocate bool
+	chunkSize   int
+}
+
+// WithBufferedRecency configures Get to read under a shared lock, so
+// concurrent readers do not serialize. Instead of moving the entry in the list
+// immediately, Get buffers the access, and buffered accesses are applied in
+// batches once enough accumulate or before the next eviction. As a result, the
+// LRU ordering may briefly lag behind the actual access order.
+func WithBufferedRecency[K comparable, V any]() LRUOption[K, V] {
+	return lruOption[K, V](func(o *lruOptions[K, V]) {
+		o.buffered = true
+	})
+}
+
+// WithApproximateRecency configures Get to read under a shared lock and only
+// mark the entry as referenced, without moving it in the list. When an entry
+// must be evicted, entries at the front of the list which have been referenced
+// since they were last considered are given a second chance: their mark is
+// cleared and they are moved to the back. This is the CLOCK approximation of
+// LRU, which trades exact ordering for reads which never take the write lock.
+// It takes precedence over WithBufferedRecency.
+func WithApproximateRecency[K comparable, V any]() LRUOption[K, V] {
+	return lruOption[K, V](func(o *lruOptions[K, V]) {
+		o.approximate = true
+	})
+}
+
+// WithPreallocatedNodes allocates the nodes for every entry up front, sized to
+// the capacity. Sets then never allocate a node, at the cost of allocating the
+// full capacity when the cache is created. Without it, the nodes grow by
+// doubling as the cache fills, up to the capacity.
+func WithPreallocatedNodes[K comparable, V any]() LRUOption[K, V] {
+	return lruOption[K, V](func(o *lruOptions[K, V]) {
+		o.preallocate = true
+	})
+}
+
+// WithChunkedNodes grows the nodes by the given number at a time as the cache
+// fills, rather than doubling them, so at most size nodes are allocated but
+// unused. Each growth copies the existing nodes, so a larger size trades memory
+// for fewer copies. Unlike WithPreallocatedNodes, memory is only allocated as
+// it is needed.
+func WithChunkedNodes[K comparable, V any](size int) LRUOption[K, V] {
+	if size <= 0 {
+		panic("size must be greater than 0")
+	}
+
+	return lruOption[K, V](func(o *lruOptions[K, V]) {
+		o.chunkSize = size
+	})
 }
 
 // NewLRU creates a new LRU cache with the given of the given capacity.
-func NewLRU[K comparable, V any](capacity int64) *LRU[K, V] {
+func NewLRU[K comparable, V any](capacity int64, opts ...LRUOption[K, V]) *LRU[K, V] {
 	if capacity <= 0 {
 		panic("capacity must be greater than 0")
 	}
 
-	return &LRU[K, V]{
-		cache:    make(map[K]*lruListItem[K, V], capacity),
-		capacity: capacity,
+	var o lruOptions[K, V]
+	for _, opt := range opts {
+		opt.applyLRU(&o)
+	}
+	if o.ttl != 0 {
+		panic("cache does not support expiration")
+	}
+
+	l := &LRU[K, V]{
+		cache:       make(map[K]int, capacity),
+		nodes:       make([]lruListItem[K, V], 1),
+		capacity:    capacity,
+		buffered:    o.buffered && !o.approximate,
+		approximate: o.approximate,
+		chunkSize:   o.chunkSize,
+		admission:   o.admission,
+	}
+	if int64(l.chunkSize) > capacity {
+		l.chunkSize = int(capacity)
 	}
+	if o.preallocate {
+		l.nodes = make([]lruListItem[K, V], 1, capacity+1)
+	}
+	return l
+}
+
+// NewLRUFromMap creates a new LRU cache of the given capacity, populated with
+// the entries in m. It is considerably faster than calling Set for each entry.
+// Since maps are unordered, the initial eviction order is arbitrary, and if m
+// has more entries than the capacity, arbitrary entries are evicted.
+func NewLRUFromMap[K comparable, V any](capacity int64, m map[K]V) *LRU[K, V] {
+	c := NewLRU[K, V](capacity)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k, v := range m {
+		c.set(k, v)
+	}
+	return c
 }
 
 // Get fetches the cache item at the given key. If the value exists, it is
 // returned. If the value does not exist, it returns the zero value for the
 // object and the second parameter will be false.
 func (l *LRU[K, V]) Get(key K) (V, bool) {
+	if l.approximate {
+		return l.getReferenced(key)
+	}
+
+	if l.buffered {
+		v, ok := l.peek(key)
+		if ok && l.accesses.add(key) && l.lock.TryLock() {
+			defer l.lock.Unlock()
+			l.drain()
+		}
+		return v, ok
+	}
+
 	l.lock.Lock()
 	defer l.lock.Unlock()
 	return l.get(key)
@@ -57,93 +213,394 @@ func (l *LRU[K, V]) Get(key K) (V, bool) {
 // get is the internal implementation of Get. It does not lock.
 func (l *LRU[K, V]) get(key K) (V, bool) {
 	if l.isStopped() {
-		panic("cache is stopped")
+		panic(ErrStopped)
+	}
+
+	i, ok := l.cache[key]
+	if !ok {
+		var v V
+		return v, false
+	}
+
+	node := &l.nodes[i]
+	node.meta.recordAccess(time.Now().UnixNano())
+	l.moveToTail(i)
+	return node.value, true
+}
+
+// getReferenced is the implementation of Get with approximate recency. It marks
+// the entry as referenced under the shared lock.
+func (l *LRU[K, V]) getReferenced(key K) (V, bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := l.node(key)
+	if !ok {
+		var v V
+		return v, false
+	}
+
+	node.meta.recordAccess(time.Now().UnixNano())
+	if atomic.LoadUint32(&node.referenced) == 0 {
+		atomic.StoreUint32(&node.referenced, 1)
+	}
+	return node.value, true
+}
+
+// Peek fetches the cache item at the given key without marking it as recently
+// used. Unlike Get, it does not modify the cache and is safe to call under a
+// read lock.
+func (l *LRU[K, V]) Peek(key K) (V, bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := l.node(key)
+	if !ok {
+		var v V
+		return v, false
+	}
+	return node.value, true
+}
+
+// peek is like Peek, but records the access in the entry's metadata. It is
+// used by Sync to read under a shared lock and defer the recency update.
+func (l *LRU[K, V]) peek(key K) (V, bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
 	}
 
-	node, ok := l.cache[key]
+	node, ok := l.node(key)
 	if !ok {
 		var v V
 		return v, false
 	}
 
-	l.moveToTail(node)
+	node.meta.recordAccess(time.Now().UnixNano())
 	return node.value, true
 }
 
+// drain applies the recency updates buffered by Get. It does not lock.
+func (l *LRU[K, V]) drain() {
+	if !l.buffered || l.isStopped() {
+		return
+	}
+
+	for _, key := range l.accesses.take() {
+		if i, ok := l.cache[key]; ok {
+			l.moveToTail(i)
+		}
+	}
+}
+
+// promote marks the given keys as recently used, in order. Keys which are no
+// longer in the cache are ignored, as is a stopped cache.
+func (l *LRU[K, V]) promote(keys []K) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		return
+	}
+
+	for _, key := range keys {
+		if i, ok := l.cache[key]; ok {
+			l.moveToTail(i)
+		}
+	}
+}
+
 // Set inserts the value in the cache. If an entry already exists at the given
 // key, it is overwritten. If an entry does not exist, a new entry is created
 // (which might trigger eviction of an older entry).
 func (l *LRU[K, V]) Set(key K, val V) {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
 	l.lock.Lock()
 	defer l.lock.Unlock()
-	l.set(key, val)
+	evicted = l.set(key, val)
 }
 
-// set is the internal implementation for set. It does not lock.
-func (l *LRU[K, V]) set(key K, val V) {
-	if l.isStopped() {
-		panic("cache is stopped")
-	}
-
-	if int64(len(l.cache)) >= l.capacity {
-		head := l.head
-		next := head.next
+// Insert inserts the value in the cache, like Set, and returns the entry which
+// was evicted to make room for it. If no entry was evicted, the third return
+// value is false. The evicted entry is also reported to any OnEvict hooks.
+func (l *LRU[K, V]) Insert(key K, val V) (K, V, bool) {
+	var evicted victim[K, V]
+	defer evicted.notify()
 
-		delete(l.cache, *head.key)
-
-		// Zero out the old node to improve gc sweeps.
-		var zeroK *K
-		var zeroV V
-		head.key = zeroK
-		head.value = zeroV
-		head.prev = nil
-		head.next = nil
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	evicted = l.set(key, val)
+	return evicted.key, evicted.value, evicted.ok
+}
 
-		if next != nil {
-			next.prev = nil
-		}
-		l.head = next
+// set is the internal implementation for set. It does not lock. It returns the
+// entry which was evicted to make room, if any.
+func (l *LRU[K, V]) set(key K, val V) victim[K, V] {
+	if l.isStopped() {
+		panic(ErrStopped)
 	}
 
-	node, ok := l.cache[key]
-	if !ok {
-		node = &lruListItem[K, V]{
-			key: &key,
+	// Only a new entry needs room, so overwriting never evicts.
+	var evicted victim[K, V]
+	i, exists := l.cache[key]
+	if exists {
+		l.moveToTail(i)
+	} else {
+		if l.admission != nil && !l.admission.Admit(key, val) {
+			return evicted
+		}
+		if int64(len(l.cache)) >= l.capacity {
+			k, v, at, ok := l.evict()
+			evicted = newVictim(k, v, at, ok, l.onEvict, l.onLifetime)
 		}
-		l.cache[key] = node
+
+		i = l.newNode(key)
+		l.cache[key] = i
+		l.pushTail(i)
 	}
+
+	node := &l.nodes[i]
 	node.value = val
-	l.moveToTail(node)
+	node.meta.reset(time.Now().UnixNano())
+
+	return evicted
 }
 
 // Fetch retrieves the cached value. If the value does not exist, the FetchFunc
 // is called and the result is stored. If the value does exist, the FetchFunc is
-// not invoked.
+// not invoked. The FetchFunc runs without holding the cache's lock, so other
+// operations proceed while it runs, and concurrent calls to Fetch for the same
+// key share a single invocation.
 func (l *LRU[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := l.Get(key); ok {
+		return v, nil
+	}
+
+	return l.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := l.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		l.Set(key, v)
+		return v, nil
+	})
+}
+
+// GetEntry fetches the cache item at the given key along with its metadata.
+// It counts as an access, like Get, and the returned metadata includes it. If
+// the value does not exist, the second return value is false.
+//
+// Unlike Get, GetEntry always takes the exclusive lock and applies the recency
+// update immediately, even with WithBufferedRecency.
+func (l *LRU[K, V]) GetEntry(key K) (Entry[K, V], bool) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
+	if _, ok := l.get(key); !ok {
+		return Entry[K, V]{}, false
+	}
+
+	node, _ := l.node(key)
+	entry := Entry[K, V]{
+		Key:   key,
+		Value: node.value,
+	}
+	fillEntry(&entry, &node.meta)
+	return entry, true
+}
+
+// Entries returns a snapshot of the entries in the cache, including their
+// metadata, in eviction order: the first entry is the next to be evicted.
+func (l *LRU[K, V]) Entries() []Entry[K, V] {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
 	if l.isStopped() {
-		panic("cache is stopped")
+		panic(ErrStopped)
 	}
 
-	if v, ok := l.get(key); ok {
-		return v, nil
+	entries := make([]Entry[K, V], 0, len(l.cache))
+	for i := l.nodes[0].next; i != 0; i = l.nodes[i].next {
+		node := &l.nodes[i]
+		entry := Entry[K, V]{
+			Key:   node.key,
+			Value: node.value,
+		}
+		fillEntry(&entry, &node.meta)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Snapshot returns an immutable view of the cache's entries as of the call.
+// The entries are copied while holding the shared lock, which takes time
+// linear in the size of the cache but is much quicker than walking it with
+// AscendEvictionOrder, and walking the snapshot does not hold the lock. It does
+// not count as an access. For constant-time snapshots, use Snapshotting.
+func (l *LRU[K, V]) Snapshot() *Snapshot[K, V] {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	snap := newSnapshot[K, V](len(l.cache))
+	for i := l.nodes[0].next; i != 0; i = l.nodes[i].next {
+		snap.add(l.nodes[i].key, l.nodes[i].value)
 	}
+	return snap
+}
+
+// Range calls fn for each entry in a snapshot of the cache, in no particular
+// order, until fn returns false. Since the cache is not locked while fn runs, fn
+// may call methods on the cache.
+func (l *LRU[K, V]) Range(fn func(K, V) bool) {
+	l.Snapshot().Range(fn)
+}
+
+// AscendEvictionOrder calls fn for each entry in the cache in eviction order,
+// starting with the next entry to be evicted (the least recently used).
+// Iteration stops if fn returns false. The cache is locked for the duration of
+// the iteration, so fn must not call methods on the cache.
+func (l *LRU[K, V]) AscendEvictionOrder(fn func(K, V) bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	for i := l.nodes[0].next; i != 0; i = l.nodes[i].next {
+		if node := &l.nodes[i]; !fn(node.key, node.value) {
+			return
+		}
+	}
+}
 
-	v, err := fn()
-	if err != nil {
+// Oldest returns the least recently used entry in the cache, which is the next to be
+// evicted. It does not count as an access. If the cache is empty, the third
+// return value is false.
+func (l *LRU[K, V]) Oldest() (K, V, bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.entryAt(l.nodes[0].next)
+}
+
+// Newest returns the most recently used entry in the cache. It does not count as an
+// access. If the cache is empty, the third return value is false.
+func (l *LRU[K, V]) Newest() (K, V, bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.entryAt(l.nodes[0].prev)
+}
+
+// entryAt returns the key and value of the node at the given index, which may
+// be the sentinel. It does not lock.
+func (l *LRU[K, V]) entryAt(i int) (K, V, bool) {
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	if i == 0 {
+		var zeroK K
 		var zeroV V
-		return zeroV, err
+		return zeroK, zeroV, false
 	}
+	return l.nodes[i].key, l.nodes[i].value, true
+}
 
-	l.set(key, v)
-	return v, nil
+// OnEvict registers a function which is invoked with each entry that is
+// evicted to make room for a new entry. Functions are invoked in the order they
+// were registered, after the cache's lock is released, so they may call back
+// into the cache. Entries removed with RemoveOldest or Stop are not reported.
+func (l *LRU[K, V]) OnEvict(fn func(K, V)) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.onEvict = appendHook(l.onEvict, fn)
+}
+
+// onEvictLifetime registers a function which is invoked with how long each
+// evicted entry was cached, alongside the OnEvict functions.
+func (l *LRU[K, V]) onEvictLifetime(fn func(time.Duration)) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.onLifetime = appendHook(l.onLifetime, fn)
+}
+
+// Capacity returns the capacity of the cache.
+func (l *LRU[K, V]) Capacity() int64 {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.capacity
+}
+
+// Resize changes the capacity of the cache. If the cache holds more entries
+// than the new capacity, entries are evicted according to the cache's policy
+// until it fits, and reported to any OnEvict hooks.
+func (l *LRU[K, V]) Resize(capacity int64) {
+	if capacity <= 0 {
+		panic("capacity must be greater than 0")
+	}
+
+	var evicted []victim[K, V]
+	defer func() {
+		for i := range evicted {
+			evicted[i].notify()
+		}
+	}()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	l.capacity = capacity
+	for int64(len(l.cache)) > capacity {
+		k, v, at, ok := l.evict()
+		evicted = append(evicted, newVictim(k, v, at, ok, l.onEvict, l.onLifetime))
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (l *LRU[K, V]) Len() int {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return len(l.cache)
+}
+
+// LockWait returns the total time operations have spent waiting to acquire the
+// cache's internal lock. A steadily increasing value indicates contention.
+func (l *LRU[K, V]) LockWait() time.Duration {
+	return l.lock.waitTime()
 }
 
 // Stop clears the cache and prevents new entries from being added and
 // retrieved.
 func (l *LRU[K, V]) Stop() {
+	l.loads.close()
+
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
@@ -155,50 +612,200 @@ func (l *LRU[K, V]) Stop() {
 		delete(l.cache, k)
 	}
 	l.cache = nil
+	l.accesses.take()
+
+	// Keep only the sentinel, so the list is empty.
+	l.nodes = make([]lruListItem[K, V], 1)
+	l.free = 0
+}
+
+// SetIfVersion inserts the value in the cache only if the version of the entry
+// at the given key is the expected version, as with Set. An expected version
+// of 0 matches a missing entry. It returns true if the value was inserted.
+func (l *LRU[K, V]) SetIfVersion(key K, val V, version uint64) bool {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	var current uint64
+	if node, ok := l.node(key); ok {
+		current = node.meta.version
+	}
+	if current != version {
+		return false
+	}
+
+	// The value is not inserted if the admission policy rejects it.
+	evicted = l.set(key, val)
+	_, ok := l.cache[key]
+	return ok
+}
+
+// Delete removes the entry at the given key from the cache. It returns false if
+// there is no entry at the given key.
+func (l *LRU[K, V]) Delete(key K) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	_, ok := l.remove(key)
+	return ok
+}
 
-	var zeroK *K
-	var zeroV V
+// RemoveOldest removes the least recently used entry from the cache, which is
+// the entry that would be evicted next, and returns it. If the cache is empty,
+// the third return value is false.
+func (l *LRU[K, V]) RemoveOldest() (K, V, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+	k, v, _, ok := l.evict()
+	return k, v, ok
+}
 
-	node := l.head
-	for node != nil {
-		node.key = zeroK
-		node.value = zeroV
-		node.prev = nil
-		node, node.next = node.next, nil
+// evict removes the least recently used entry from the cache and returns it,
+// along with the time at which it was inserted. It does not lock.
+func (l *LRU[K, V]) evict() (K, V, int64, bool) {
+	// Apply any buffered accesses first, so a recently read entry is not
+	// mistaken for the least recently used.
+	l.drain()
+	l.secondChance()
+
+	head := l.nodes[0].next
+	if head == 0 {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, 0, false
 	}
 
-	l.head = nil
-	l.tail = nil
+	node := &l.nodes[head]
+	key, val, insertedAt := node.key, node.value, node.meta.insertedAt
+	delete(l.cache, key)
+	l.unlink(head)
+	l.release(head)
+
+	return key, val, insertedAt, true
 }
 
-// moveToTail moves the given node to the end (tail) of the linked list.
-func (l *LRU[K, V]) moveToTail(node *lruListItem[K, V]) {
-	if node == l.tail {
+// secondChance moves the referenced entries at the front of the list to the
+// back, clearing their marks, until the front entry is unreferenced. Since each
+// mark is cleared as it is passed, it visits each entry at most once. It does
+// not lock.
+func (l *LRU[K, V]) secondChance() {
+	if !l.approximate {
 		return
 	}
 
-	if node == l.head {
-		l.head = node.next
+	for n := len(l.cache); n > 0 && l.nodes[0].next != 0; n-- {
+		head := l.nodes[0].next
+		if atomic.LoadUint32(&l.nodes[head].referenced) == 0 {
+			return
+		}
+		atomic.StoreUint32(&l.nodes[head].referenced, 0)
+		l.moveToTail(head)
 	}
+}
 
-	if node.prev != nil {
-		node.prev.next = node.next
+// node returns the node for the given key. It does not lock.
+func (l *LRU[K, V]) node(key K) (*lruListItem[K, V], bool) {
+	i, ok := l.cache[key]
+	if !ok {
+		return nil, false
 	}
+	return &l.nodes[i], true
+}
 
-	if node.next != nil {
-		node.next.prev = node.prev
+// newNode returns the index of a node for the given key, which is not yet in
+// the list. It reuses a free node if there is one, and otherwise grows the
+// nodes, by doubling or by the chunk size, up to the capacity. It does not
+// lock.
+func (l *LRU[K, V]) newNode(key K) int {
+	i := l.free
+	if i != 0 {
+		l.free = l.nodes[i].next
+	} else {
+		if len(l.nodes) == cap(l.nodes) {
+			grow := len(l.nodes)
+			if l.chunkSize > 0 {
+				grow = l.chunkSize
+			}
+			if rest := l.capacity - int64(len(l.nodes)) + 1; int64(grow) > rest {
+				grow = int(rest)
+			}
+
+			nodes := make([]lruListItem[K, V], len(l.nodes), len(l.nodes)+grow)
+			copy(nodes, l.nodes)
+			l.nodes = nodes
+		}
+
+		i = len(l.nodes)
+		l.nodes = l.nodes[:i+1]
 	}
 
-	if l.tail != nil {
-		l.tail.next = node
+	l.nodes[i].key = key
+	return i
+}
+
+// release zeroes the node at the given index, which must not be in the list,
+// to improve gc sweeps, and adds it to the free list. It does not lock.
+func (l *LRU[K, V]) release(i int) {
+	l.nodes[i] = lruListItem[K, V]{next: l.free}
+	l.free = i
+}
+
+// remove removes the entry at the given key from the cache and returns its
+// value. It does not lock.
+func (l *LRU[K, V]) remove(key K) (V, bool) {
+	i, ok := l.cache[key]
+	if !ok {
+		var zeroV V
+		return zeroV, false
 	}
-	node.next = nil
-	node.prev = l.tail
-	l.tail = node
+	delete(l.cache, key)
+
+	val := l.nodes[i].value
+	l.unlink(i)
+	l.release(i)
+	return val, true
+}
 
-	if l.head == nil {
-		l.head = node
+// unlink removes the node at the given index from the list.
+func (l *LRU[K, V]) unlink(i int) {
+	prev, next := l.nodes[i].prev, l.nodes[i].next
+	l.nodes[prev].next = next
+	l.nodes[next].prev = prev
+}
+
+// pushTail adds the node at the given index, which must not be in the list, to
+// the end (tail) of the list.
+func (l *LRU[K, V]) pushTail(i int) {
+	tail := l.nodes[0].prev
+	l.nodes[i].prev = tail
+	l.nodes[i].next = 0
+	l.nodes[tail].next = i
+	l.nodes[0].prev = i
+}
+
+// moveToTail moves the node at the given index to the end (tail) of the list.
+func (l *LRU[K, V]) moveToTail(i int) {
+	if i == l.nodes[0].prev {
+		return
 	}
+
+	l.unlink(i)
+	l.pushTail(i)
 }
 
 // isStopped is a helper for checking if the queue is stopped.
@@ -206,9 +813,16 @@ func (l *LRU[K, V]) isStopped() bool {
 	return atomic.LoadUint32(&l.stopped) == 1
 }
 
-// lruListItem represents an entry in the linked list.
+// lruListItem represents an entry in the linked list. prev and next are the
+// indexes of the adjacent nodes.
 type lruListItem[K comparable, V any] struct {
-	prev, next *lruListItem[K, V]
-	key        *K
+	meta       entryMeta
+	prev, next int
+	key        K
 	value      V
+
+	// referenced indicates the entry was retrieved since it was last considered
+	// for eviction, with WithApproximateRecency. It is set atomically under the
+	// shared lock.
+	referenced uint32
 }
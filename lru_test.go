@@ -3,7 +3,9 @@ package cache
 import (
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewLRU(t *testing.T) {
@@ -18,13 +20,13 @@ func TestNewLRU(t *testing.T) {
 		if got, want := cache.capacity, int64(10); got != want {
 			t.Errorf("expected %d to be %d", got, want)
 		}
-		if got, want := cache.cache, make(map[string]*lruListItem[string, string], 10); !reflect.DeepEqual(got, want) {
+		if got, want := cache.cache, make(map[string]int, 10); !reflect.DeepEqual(got, want) {
 			t.Errorf("expected %#v to be %#v", got, want)
 		}
-		if got, want := cache.head, (*lruListItem[string, string])(nil); got != want {
+		if got, want := lruHead(cache), (*lruListItem[string, string])(nil); got != want {
 			t.Errorf("expected %#v to be %#v", got, want)
 		}
-		if got, want := cache.tail, (*lruListItem[string, string])(nil); got != want {
+		if got, want := lruTail(cache), (*lruListItem[string, string])(nil); got != want {
 			t.Errorf("expected %#v to be %#v", got, want)
 		}
 	})
@@ -45,6 +47,41 @@ func TestNewLRU(t *testing.T) {
 	})
 }
 
+func TestNewLRUFromMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("populates", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRUFromMap(10, map[string]int{"a": 1, "b": 2, "c": 3})
+		defer cache.Stop()
+
+		if got, want := cache.Len(), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		for k, want := range map[string]int{"a": 1, "b": 2, "c": 3} {
+			got, ok := cache.Get(k)
+			if !ok {
+				t.Errorf("expected %q to be in the cache", k)
+			}
+			if got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+		}
+	})
+
+	t.Run("over_capacity", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRUFromMap(2, map[string]int{"a": 1, "b": 2, "c": 3})
+		defer cache.Stop()
+
+		if got, want := cache.Len(), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
 func TestLRU_Get(t *testing.T) {
 	t.Parallel()
 
@@ -92,30 +129,335 @@ func TestLRU_Get(t *testing.T) {
 		cache.Set("bar", 3)
 		cache.Set("baz", 1)
 
-		if got, want := cache.head.key, "foo"; *got != want {
+		if got, want := lruHead(cache).key, "foo"; got != want {
 			t.Errorf("expected %v to be %v", got, want)
 		}
-		if got, want := cache.tail.key, "baz"; *got != want {
+		if got, want := lruTail(cache).key, "baz"; got != want {
 			t.Errorf("expected %v to be %v", got, want)
 		}
 
 		cache.Get("baz")
-		if got, want := cache.tail.key, "baz"; *got != want {
+		if got, want := lruTail(cache).key, "baz"; got != want {
 			t.Errorf("expected %v to be %v", got, want)
 		}
 
 		cache.Get("baz")
-		if got, want := cache.tail.key, "baz"; *got != want {
+		if got, want := lruTail(cache).key, "baz"; got != want {
 			t.Errorf("expected %v to be %v", got, want)
 		}
 
 		cache.Get("foo")
-		if got, want := cache.tail.key, "foo"; *got != want {
+		if got, want := lruTail(cache).key, "foo"; got != want {
 			t.Errorf("expected %v to be %v", got, want)
 		}
 	})
 }
 
+func TestLRU_preallocatedNodes(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[int, int](3, WithPreallocatedNodes[int, int]())
+	defer cache.Stop()
+
+	if got, want := cap(cache.nodes), 4; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+
+	nodes := cache.nodes[:cap(cache.nodes)]
+	for i := 0; i < 3; i++ {
+		cache.Set(i, i)
+	}
+	for i := 0; i < 3; i++ {
+		if got, want := cache.cache[i], i+1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	}
+
+	// Once every node is used, evicted nodes are reused.
+	cache.Set(3, 3)
+	if got, want := cache.cache[3], 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if &cache.nodes[0] != &nodes[0] {
+		t.Errorf("expected nodes not to be reallocated")
+	}
+	if v, ok := cache.Get(3); !ok || v != 3 {
+		t.Errorf("expected %d, %t to be 3, true", v, ok)
+	}
+}
+
+func TestLRU_chunkedNodes(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[int, int](5, WithChunkedNodes[int, int](2))
+	defer cache.Stop()
+
+	// The sentinel fills the initial nodes, so the first Set grows them by a
+	// chunk.
+	cache.Set(0, 0)
+	if got, want := cap(cache.nodes), 3; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	cache.Set(1, 1)
+	if got, want := cap(cache.nodes), 3; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	cache.Set(2, 2)
+	if got, want := cap(cache.nodes), 5; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+
+	// Growth stops at the capacity.
+	for i := 0; i < 10; i++ {
+		cache.Set(i, i)
+	}
+	if got, want := cap(cache.nodes), 6; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	for i := 5; i < 10; i++ {
+		if v, ok := cache.Get(i); !ok || v != i {
+			t.Errorf("expected %d, %t to be %d, true", v, ok, i)
+		}
+	}
+}
+
+func TestLRU_freeList(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[int, int](4)
+	defer cache.Stop()
+
+	for i := 0; i < 4; i++ {
+		cache.Set(i, i)
+	}
+	n := len(cache.nodes)
+
+	// Deleted nodes are reused, most recently deleted first, rather than
+	// growing the nodes.
+	cache.Delete(1)
+	cache.Delete(2)
+	if got, want := cache.free, 3; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	cache.Set(10, 10)
+	cache.Set(11, 11)
+	if got, want := cache.cache[10], 3; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.cache[11], 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := len(cache.nodes), n; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Repeated deletes and sets never grow the nodes.
+	for i := 0; i < 100; i++ {
+		cache.Delete(i % 4)
+		cache.Set(i%4, i)
+	}
+	if got, want := len(cache.nodes), n; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.Len(), 4; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+// lruHead and lruTail return the nodes at the head and tail of the list, or nil
+// if it is empty.
+func lruHead[K comparable, V any](l *LRU[K, V]) *lruListItem[K, V] {
+	if i := l.nodes[0].next; i != 0 {
+		return &l.nodes[i]
+	}
+	return nil
+}
+
+func lruTail[K comparable, V any](l *LRU[K, V]) *lruListItem[K, V] {
+	if i := l.nodes[0].prev; i != 0 {
+		return &l.nodes[i]
+	}
+	return nil
+}
+
+// lruNode returns the node for the given key, which must be in the cache.
+func lruNode[K comparable, V any](l *LRU[K, V], key K) *lruListItem[K, V] {
+	node, _ := l.node(key)
+	return node
+}
+
+func TestLRU_approximateRecency(t *testing.T) {
+	t.Parallel()
+
+	t.Run("second_chance", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](3, WithApproximateRecency[string, int]())
+		defer cache.Stop()
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", 3)
+
+		// The access only marks the entry, so the order is unchanged.
+		if v, _ := cache.Get("a"); v != 1 {
+			t.Errorf("expected %d to be %d", v, 1)
+		}
+		if got, want := lruHead(cache).key, "a"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		// The referenced entry is given a second chance on eviction.
+		cache.Set("d", 4)
+		if _, ok := cache.Peek("b"); ok {
+			t.Errorf("expected b to be evicted")
+		}
+		if _, ok := cache.Peek("a"); !ok {
+			t.Errorf("expected a to remain")
+		}
+
+		// Its mark was cleared, so it is evicted next unless referenced again.
+		cache.Set("e", 5)
+		cache.Set("f", 6)
+		if _, ok := cache.Peek("a"); ok {
+			t.Errorf("expected a to be evicted")
+		}
+	})
+
+	t.Run("all_referenced", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](2, WithApproximateRecency[string, int]())
+		defer cache.Stop()
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Get("a")
+		cache.Get("b")
+
+		// Every entry gets a second chance, after which the oldest is evicted.
+		cache.Set("c", 3)
+		if _, ok := cache.Peek("a"); ok {
+			t.Errorf("expected a to be evicted")
+		}
+		if got, want := cache.Len(), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestLRU_bufferedRecency(t *testing.T) {
+	t.Parallel()
+
+	t.Run("evicts_least_recent", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](3, WithBufferedRecency[string, int]())
+		defer cache.Stop()
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", 3)
+
+		// The access is buffered, so the order is unchanged.
+		if v, _ := cache.Get("a"); v != 1 {
+			t.Errorf("expected %d to be %d", v, 1)
+		}
+		if got, want := lruHead(cache).key, "a"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		// Buffered accesses are applied before evicting.
+		cache.Set("d", 4)
+		if _, ok := cache.Peek("b"); ok {
+			t.Errorf("expected b to be evicted")
+		}
+		if _, ok := cache.Peek("a"); !ok {
+			t.Errorf("expected a to remain")
+		}
+	})
+
+	t.Run("drains_when_full", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](3, WithBufferedRecency[string, int]())
+		defer cache.Stop()
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+
+		for i := 0; i < accessBufferFlush; i++ {
+			cache.Get("a")
+		}
+
+		if got, want := len(cache.accesses.keys), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := lruTail(cache).key, "a"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := lruNode(cache, "a").meta.hits, uint64(accessBufferFlush); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[int, int](100, WithBufferedRecency[int, int]())
+		defer cache.Stop()
+
+		for i := 0; i < 100; i++ {
+			cache.Set(i, i)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					if j%10 == 0 {
+						cache.Set(100+i*1000+j, j)
+					} else {
+						cache.Get((i + j) % 100)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if got, want := cache.Len(), 100; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestLRU_Peek(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](3)
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	cache.Set("bar", 3)
+
+	if v, _ := cache.Peek("foo"); v != 5 {
+		t.Errorf("expected %#v, got %#v", 5, v)
+	}
+	if v, ok := cache.Peek("baz"); ok {
+		t.Errorf("expected not found, got %#v", v)
+	}
+	if got, want := lruTail(cache).key, "bar"; got != want {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+
+	cache.promote([]string{"foo", "baz"})
+	if got, want := lruTail(cache).key, "foo"; got != want {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+}
+
 func TestLRU_Set(t *testing.T) {
 	t.Parallel()
 
@@ -216,6 +558,39 @@ func TestLRU_Fetch(t *testing.T) {
 			t.Error("expected error")
 		}
 	})
+
+	t.Run("does_not_block", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, string](3)
+		defer cache.Stop()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		doneCh := make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			cache.Fetch("foo", func() (string, error) {
+				close(started)
+				<-release
+				return "bar", nil
+			})
+		}()
+		<-started
+
+		// Other keys are usable while the load is in progress.
+		cache.Set("zip", "zap")
+		if v, _ := cache.Get("zip"); v != "zap" {
+			t.Errorf("expected %q to be %q", v, "zap")
+		}
+
+		close(release)
+		<-doneCh
+
+		if v, _ := cache.Get("foo"); v != "bar" {
+			t.Errorf("expected %q to be %q", v, "bar")
+		}
+	})
 }
 
 func TestLRU_Stop(t *testing.T) {
@@ -235,11 +610,11 @@ func TestLRU_Stop(t *testing.T) {
 		if cache.cache != nil {
 			t.Errorf("expected %#v to be nil", cache.cache)
 		}
-		if cache.head != nil {
-			t.Errorf("expected %#v to be nil", cache.head)
+		if lruHead(cache) != nil {
+			t.Errorf("expected %#v to be nil", lruHead(cache))
 		}
-		if cache.tail != nil {
-			t.Errorf("expected %#v to be nil", cache.tail)
+		if lruTail(cache) != nil {
+			t.Errorf("expected %#v to be nil", lruTail(cache))
 		}
 	})
 
@@ -272,4 +647,437 @@ func TestLRU_Stop(t *testing.T) {
 		cache.Set("foo", 5)
 		t.Errorf("did not panic")
 	})
+
+	t.Run("waits_for_fetch", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](10)
+
+		release := make(chan struct{})
+		started := make(chan struct{})
+		fetched := make(chan error, 1)
+		go func() {
+			_, err := cache.Fetch("foo", func() (int, error) {
+				close(started)
+				<-release
+				return 5, nil
+			})
+			fetched <- err
+		}()
+		<-started
+
+		stopped := make(chan struct{})
+		go func() {
+			cache.Stop()
+			close(stopped)
+		}()
+
+		// Give Stop time to start waiting, then let the load store its result.
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for stop")
+		}
+		if err := <-fetched; err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestLRU_Entries(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	cache.Set("bar", 3)
+	cache.Get("foo")
+	cache.Get("foo")
+
+	entries := cache.Entries()
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	if got, want := entries[0].Key, "bar"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	for _, entry := range entries {
+		if entry.InsertedAt.IsZero() {
+			t.Errorf("expected %q to have an insertion time", entry.Key)
+		}
+
+		switch entry.Key {
+		case "foo":
+			if got, want := entry.Value, 5; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if got, want := entry.Hits, uint64(2); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if entry.LastAccessedAt.IsZero() {
+				t.Errorf("expected %q to have an access time", entry.Key)
+			}
+		case "bar":
+			if got, want := entry.Hits, uint64(0); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if !entry.LastAccessedAt.IsZero() {
+				t.Errorf("expected %q to not have an access time", entry.Key)
+			}
+		}
+
+		if !entry.ExpiresAt.IsZero() {
+			t.Errorf("expected %q to not expire", entry.Key)
+		}
+	}
+}
+
+func TestLRU_AscendEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+	cache.Get("foo")
+
+	var keys []string
+	cache.AscendEvictionOrder(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if got, want := keys, []string{"bar", "baz", "foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	keys = nil
+	cache.AscendEvictionOrder(func(k string, v int) bool {
+		keys = append(keys, k)
+		return false
+	})
+	if got, want := len(keys), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestLRU_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+
+	snap := cache.Snapshot()
+
+	// Later changes do not affect the snapshot.
+	cache.Set("foo", 10)
+	cache.Set("baz", 3)
+	cache.Delete("bar")
+
+	if got, want := snap.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if v, ok := snap.Get("foo"); !ok || v != 1 {
+		t.Errorf("expected %d to be %d", v, 1)
+	}
+	if v, ok := snap.Get("bar"); !ok || v != 2 {
+		t.Errorf("expected %d to be %d", v, 2)
+	}
+	if _, ok := snap.Get("baz"); ok {
+		t.Errorf("expected baz not to be in the snapshot")
+	}
+
+	// Range does not hold the lock, so fn can modify the cache.
+	got := make(map[string]int)
+	cache.Range(func(k string, v int) bool {
+		got[k] = v
+		cache.Delete(k)
+		return true
+	})
+	if want := map[string]int{"foo": 10, "baz": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+	if got, want := cache.Len(), 0; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestLRU_Oldest(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	defer cache.Stop()
+
+	if k, v, ok := cache.Oldest(); ok {
+		t.Errorf("expected empty, got %q=%d", k, v)
+	}
+	if k, v, ok := cache.Newest(); ok {
+		t.Errorf("expected empty, got %q=%d", k, v)
+	}
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+	cache.Get("foo")
+
+	if k, v, _ := cache.Oldest(); k != "bar" || v != 2 {
+		t.Errorf("unexpected oldest %q=%d", k, v)
+	}
+	if k, v, _ := cache.Newest(); k != "foo" || v != 1 {
+		t.Errorf("unexpected newest %q=%d", k, v)
+	}
+}
+
+func TestLRU_RemoveOldest(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](3)
+	defer cache.Stop()
+
+	if k, v, ok := cache.RemoveOldest(); ok {
+		t.Errorf("expected empty, got %q=%d", k, v)
+	}
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+	cache.Get("foo")
+
+	var removed []string
+	for {
+		k, v, ok := cache.RemoveOldest()
+		if !ok {
+			break
+		}
+		if got, ok := map[string]int{"foo": 1, "bar": 2, "baz": 3}[k]; !ok || got != v {
+			t.Errorf("unexpected entry %q=%d", k, v)
+		}
+		removed = append(removed, k)
+	}
+	if got, want := removed, []string{"bar", "baz", "foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := cache.Len(), 0; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Ensure the cache is still usable once empty.
+	cache.Set("qux", 4)
+	if v, _ := cache.Get("qux"); v != 4 {
+		t.Errorf("expected %#v, got %#v", 4, v)
+	}
+}
+
+func TestLRU_OnEvict(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](2)
+	defer cache.Stop()
+
+	var evicted []string
+	cache.OnEvict(func(k string, v int) {
+		evicted = append(evicted, k)
+
+		// Hooks run without the lock held, so they may use the cache.
+		cache.Len()
+	})
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Fetch("baz", func() (int, error) { return 3, nil })
+	cache.RemoveOldest()
+
+	if got, want := len(evicted), 1; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	if got, want := evicted[0], "foo"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
+
+func TestLRU_Resize(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](4)
+	defer cache.Stop()
+
+	var evicted int
+	cache.OnEvict(func(string, int) {
+		evicted++
+	})
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+
+	cache.Resize(1)
+	if got, want := cache.Capacity(), int64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.Len(), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := evicted, 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	cache.Resize(10)
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprint(i), i)
+	}
+	if got, want := cache.Len(), 10; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestLRU_remove(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](3)
+	defer cache.Stop()
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+
+	for _, key := range []string{"b", "a", "c"} {
+		if _, ok := cache.remove(key); !ok {
+			t.Errorf("expected %q to be removed", key)
+		}
+	}
+	if _, ok := cache.remove("a"); ok {
+		t.Errorf("expected a to be missing")
+	}
+	if lruHead(cache) != nil || lruTail(cache) != nil {
+		t.Errorf("expected list to be empty")
+	}
+}
+
+func TestLRU_GetEntry(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	defer cache.Stop()
+
+	if _, ok := cache.GetEntry("foo"); ok {
+		t.Errorf("expected foo to be missing")
+	}
+
+	cache.Set("foo", 5)
+	cache.Get("foo")
+
+	entry, ok := cache.GetEntry("foo")
+	if !ok {
+		t.Fatalf("expected foo to exist")
+	}
+	if got, want := entry.Value, 5; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := entry.Hits, uint64(2); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if entry.InsertedAt.IsZero() || entry.LastAccessedAt.Before(entry.InsertedAt) {
+		t.Errorf("expected valid timestamps, got %s and %s", entry.InsertedAt, entry.LastAccessedAt)
+	}
+
+	// GetEntry marks the entry as recently used.
+	cache.Set("bar", 1)
+	cache.GetEntry("foo")
+	if got, want := lruTail(cache).key, "foo"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
+
+func TestLRU_Delete(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLRU[string, int](10)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+
+	if !cache.Delete("bar") {
+		t.Errorf("expected bar to be deleted")
+	}
+	if cache.Delete("bar") {
+		t.Errorf("expected bar to not exist")
+	}
+	if _, ok := cache.Get("bar"); ok {
+		t.Errorf("expected bar to be missing")
+	}
+
+	// The remaining entries are still evicted in order.
+	if k, _, _ := cache.RemoveOldest(); k != "foo" {
+		t.Errorf("expected %q to be %q", k, "foo")
+	}
+	if k, _, _ := cache.RemoveOldest(); k != "baz" {
+		t.Errorf("expected %q to be %q", k, "baz")
+	}
+}
+
+func TestWithAdmission(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rejects", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](2, WithAdmission[string, int](
+			AdmissionFunc[string, int](func(_ string, v int) bool {
+				return v < 10
+			})))
+		defer cache.Stop()
+
+		cache.Set("foo", 5)
+		cache.Set("bar", 3)
+		cache.Set("baz", 50)
+
+		// The rejected entry does not evict anything.
+		if _, ok := cache.Get("baz"); ok {
+			t.Errorf("expected baz not to be admitted")
+		}
+		if got, want := cache.Len(), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		// Existing entries are always updated.
+		cache.Set("foo", 50)
+		if v, ok := cache.Get("foo"); !ok || v != 50 {
+			t.Errorf("expected %d to be %d", v, 50)
+		}
+
+		if cache.SetIfVersion("qux", 50, 0) {
+			t.Errorf("expected qux not to be inserted")
+		}
+	})
+
+	t.Run("doorkeeper", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[string, int](2, WithAdmission(NewDoorkeeperAdmission[string, int](100, time.Hour)))
+		defer cache.Stop()
+
+		cache.Set("foo", 5)
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo not to be admitted the first time")
+		}
+		cache.Set("foo", 5)
+		if _, ok := cache.Get("foo"); !ok {
+			t.Errorf("expected foo to be admitted the second time")
+		}
+	})
 }
@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"sync/atomic"
+)
+
+// Ensure implements.
+var _ Cache[string, []byte] = (*Mapped[string])(nil)
+
+// Mapped is a cache of bytes whose values are stored in a fixed-size arena of
+// memory mapped outside of the Go heap, so that multi-gigabyte caches do not
+// inflate the heap or the garbage collector's work. Only keys and the offsets
+// of their values are held on the heap. Wrap it in NewEncoded to cache values
+// of other types.
+//
+// The arena is written as a ring: each value is appended after the previous
+// one, and when the arena is full the oldest values are evicted to make room,
+// in first-in-first-out order. Overwriting a key appends the new value, so the
+// space of the old value is only reclaimed when the ring reaches it.
+//
+// On platforms without mmap, the arena is allocated on the heap.
+type Mapped[K comparable] struct {
+	// cache maps each key to the position of its value in the arena.
+	cache map[K]mappedValue
+
+	// arena is the mapped memory holding the values.
+	arena []byte
+
+	// records are the values written to the arena, oldest first. Records for
+	// overwritten keys remain until the ring reaches them.
+	records []mappedRecord[K]
+
+	// pos is the logical position of the next write. The arena offset is pos
+	// modulo the arena size; positions only increase, so the oldest live byte
+	// is always at pos minus the arena size.
+	pos int64
+
+	// stopped indicates whether the cache is stopped.
+	stopped uint32
+
+	// lock is the internal lock for concurrency.
+	lock waitMutex
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, []byte]
+}
+
+// mappedValue is the location of a value in the arena.
+type mappedValue struct {
+	pos int64
+	n   int
+}
+
+// mappedRecord is a value written to the arena.
+type mappedRecord[K comparable] struct {
+	key K
+	pos int64
+}
+
+// NewMapped creates a new mapped cache with an arena of the given size in
+// bytes. It panics if the arena cannot be mapped.
+func NewMapped[K comparable](size int) *Mapped[K] {
+	if size <= 0 {
+		panic("size must be greater than 0")
+	}
+
+	arena, err := mapArena(size)
+	if err != nil {
+		panic("failed to map arena: " + err.Error())
+	}
+
+	return &Mapped[K]{
+		cache: make(map[K]mappedValue),
+		arena: arena,
+	}
+}
+
+// Get fetches the cache item at the given key. The returned bytes are a copy,
+// since the arena is reused as values are evicted. If the value does not
+// exist, the second return value is false.
+func (m *Mapped[K]) Get(key K) ([]byte, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if m.isStopped() {
+		panic(ErrStopped)
+	}
+
+	v, ok := m.cache[key]
+	if !ok {
+		return nil, false
+	}
+
+	off := m.offset(v.pos)
+	b := make([]byte, v.n)
+	copy(b, m.arena[off:off+int64(v.n)])
+	return b, true
+}
+
+// Set copies the value into the arena, evicting the oldest values if there is
+// not enough room. Values larger than the arena are not stored, and any
+// existing value at the key is removed. Empty values take one byte of the
+// arena, so that they are evicted as the ring advances like any other value.
+func (m *Mapped[K]) Set(key K, val []byte) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.isStopped() {
+		panic(ErrStopped)
+	}
+
+	size := int64(len(m.arena))
+	n := int64(len(val))
+	if n > size {
+		delete(m.cache, key)
+		return
+	}
+
+	// Each record must advance the ring, or records for empty values would
+	// accumulate without ever being evicted.
+	span := n
+	if span == 0 {
+		span = 1
+	}
+
+	// Values are stored contiguously, so skip to the start of the arena if the
+	// value would run past the end.
+	if off := m.offset(m.pos); off+span > size {
+		m.pos += size - off
+	}
+
+	m.evictBefore(m.pos + span - size)
+
+	off := m.offset(m.pos)
+	copy(m.arena[off:off+n], val)
+
+	m.cache[key] = mappedValue{pos: m.pos, n: len(val)}
+	m.records = append(m.records, mappedRecord[K]{key: key, pos: m.pos})
+	m.pos += span
+}
+
+// evictBefore removes the values written before the given logical position. It
+// does not lock.
+func (m *Mapped[K]) evictBefore(pos int64) {
+	i := 0
+	for ; i < len(m.records) && m.records[i].pos < pos; i++ {
+		record := m.records[i]
+
+		// Only remove the key if this record still holds its value.
+		if v, ok := m.cache[record.key]; ok && v.pos == record.pos {
+			delete(m.cache, record.key)
+		}
+
+		var zeroK K
+		m.records[i].key = zeroK
+	}
+	m.records = m.records[i:]
+}
+
+// offset returns the arena offset for the given logical position.
+func (m *Mapped[K]) offset(pos int64) int64 {
+	return pos % int64(len(m.arena))
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. Concurrent calls to Fetch for the same key share a single
+// invocation.
+func (m *Mapped[K]) Fetch(key K, fn FetchFunc[[]byte]) ([]byte, error) {
+	if v, ok := m.Get(key); ok {
+		return v, nil
+	}
+
+	return m.loads.do(key, func() ([]byte, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := m.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			return nil, newLoaderError(key, err)
+		}
+
+		m.Set(key, v)
+		return v, nil
+	})
+}
+
+// Len returns the number of entries in the cache.
+func (m *Mapped[K]) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.cache)
+}
+
+// Size returns the size of the arena in bytes.
+func (m *Mapped[K]) Size() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.arena)
+}
+
+// Stop clears the cache, unmaps the arena, and prevents new entries from being
+// added and retrieved.
+func (m *Mapped[K]) Stop() {
+	m.loads.close()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&m.stopped, 0, 1) {
+		return
+	}
+
+	m.cache = nil
+	m.records = nil
+	unmapArena(m.arena)
+	m.arena = nil
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (m *Mapped[K]) isStopped() bool {
+	return atomic.LoadUint32(&m.stopped) == 1
+}
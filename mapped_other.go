@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package cache
+
+// mapArena allocates the arena on the heap, since mmap is not available.
+func mapArena(size int) ([]byte, error) {
+	return make([]byte, size), nil
+}
+
+// unmapArena is a no-op; the arena is released by the garbage collector.
+func unmapArena(b []byte) {}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMapped(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get_set", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewMapped[string](64)
+		defer cache.Stop()
+
+		cache.Set("foo", []byte("bar"))
+		cache.Set("zip", []byte{})
+
+		if got, ok := cache.Get("foo"); !ok || !bytes.Equal(got, []byte("bar")) {
+			t.Errorf("expected %q to be %q", got, "bar")
+		}
+		if got, ok := cache.Get("zip"); !ok || len(got) != 0 {
+			t.Errorf("expected %q to be empty", got)
+		}
+		if _, ok := cache.Get("baz"); ok {
+			t.Errorf("expected baz to be missing")
+		}
+
+		cache.Set("foo", []byte("qux"))
+		if got, ok := cache.Get("foo"); !ok || !bytes.Equal(got, []byte("qux")) {
+			t.Errorf("expected %q to be %q", got, "qux")
+		}
+	})
+
+	t.Run("evicts_oldest", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewMapped[int](10)
+		defer cache.Stop()
+
+		for i := 0; i < 4; i++ {
+			cache.Set(i, []byte(fmt.Sprintf("v%d_", i)))
+		}
+
+		// Each value is 3 bytes, so the fourth does not fit after the third and
+		// wraps to the start of the arena, overwriting the first.
+		if _, ok := cache.Get(0); ok {
+			t.Errorf("expected 0 to be evicted")
+		}
+		for i := 1; i < 4; i++ {
+			if got, ok := cache.Get(i); !ok || string(got) != fmt.Sprintf("v%d_", i) {
+				t.Errorf("expected %q to be v%d_", got, i)
+			}
+		}
+		if got, want := cache.Len(), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("overwritten", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewMapped[string](8)
+		defer cache.Stop()
+
+		cache.Set("foo", []byte("1234"))
+		cache.Set("foo", []byte("5678"))
+
+		// The ring reaches the old value of foo first, which must not remove the
+		// new value.
+		cache.Set("bar", []byte("ab"))
+		if got, ok := cache.Get("foo"); !ok || string(got) != "5678" {
+			t.Errorf("expected %q to be %q", got, "5678")
+		}
+		if got, ok := cache.Get("bar"); !ok || string(got) != "ab" {
+			t.Errorf("expected %q to be %q", got, "ab")
+		}
+	})
+
+	t.Run("empty_values", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewMapped[int](4)
+		defer cache.Stop()
+
+		for i := 0; i < 100; i++ {
+			cache.Set(i%2, []byte{})
+		}
+
+		// Each empty value takes one byte, so the records are bounded by the size
+		// of the arena.
+		if got, want := len(cache.records), 4; got > want {
+			t.Errorf("expected %d to be at most %d", got, want)
+		}
+		if got, ok := cache.Get(1); !ok || len(got) != 0 {
+			t.Errorf("expected %q to be empty", got)
+		}
+		if got, want := cache.Len(), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewMapped[string](4)
+		defer cache.Stop()
+
+		cache.Set("foo", []byte("bar"))
+		cache.Set("foo", []byte("too large"))
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to be removed")
+		}
+	})
+
+	t.Run("encoded", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewEncoded[string, codecTestValue](NewMapped[string](1024), JSONCodec[codecTestValue]{})
+		defer cache.Stop()
+
+		cache.Set("foo", codecTestValue{Name: "foo", Count: 5})
+		if got, ok := cache.Get("foo"); !ok || got.Count != 5 {
+			t.Errorf("expected %#v to have count 5", got)
+		}
+	})
+
+	t.Run("panic_on_stopped", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewMapped[string](64)
+		cache.Stop()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "cache is stopped"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+		cache.Get("foo")
+		t.Errorf("did not panic")
+	})
+}
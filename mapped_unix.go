@@ -0,0 +1,17 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package cache
+
+import (
+	"syscall"
+)
+
+// mapArena maps an anonymous region of the given size outside of the Go heap.
+func mapArena(size int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+// unmapArena releases a region returned by mapArena.
+func unmapArena(b []byte) {
+	syscall.Munmap(b)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+// EntryLister is implemented by caches which can list their entries. All of
+// the caches in this package implement it.
+type EntryLister[K comparable, V any] interface {
+	// Entries returns a snapshot of the entries in the cache.
+	Entries() []Entry[K, V]
+}
+
+// Ensure implements.
+var (
+	_ EntryLister[string, string] = (*FIFO[string, string])(nil)
+	_ EntryLister[string, string] = (*LIFO[string, string])(nil)
+	_ EntryLister[string, string] = (*LRU[string, string])(nil)
+	_ EntryLister[string, string] = (*Policied[string, string])(nil)
+	_ EntryLister[string, string] = (*Random[string, string])(nil)
+	_ EntryLister[string, string] = (*TTL[string, string])(nil)
+	_ EntryLister[string, string] = (*Sync[string, string])(nil)
+	_ EntryLister[string, string] = (*Sharded[string, string])(nil)
+)
+
+// Merge copies all entries from src into dst, which may use different eviction
+// policies or capacities. It is useful for migrating to a new cache without
+// starting cold.
+//
+// Entries are inserted from least to most recently used, where an entry's last
+// use is the later of when it was set and when it was last retrieved. This
+// preserves relative recency in destinations which track it, and ensures that
+// if dst is smaller than src, the most recently used entries are the ones which
+// are retained. Metadata such as hit counts and expirations are not copied;
+// entries are treated as newly set in dst.
+//
+// Merge is not atomic: concurrent writes to src during the merge may or may not
+// be reflected in dst.
+func Merge[K comparable, V any](dst Cache[K, V], src EntryLister[K, V]) {
+	entries := src.Entries()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return lastUsed(&entries[i]).Before(lastUsed(&entries[j]))
+	})
+
+	for _, entry := range entries {
+		dst.Set(entry.Key, entry.Value)
+	}
+}
+
+// lastUsed returns the later of the entry's insertion and last access times.
+func lastUsed[K comparable, V any](e *Entry[K, V]) time.Time {
+	if e.LastAccessedAt.After(e.InsertedAt) {
+		return e.LastAccessedAt
+	}
+	return e.InsertedAt
+}
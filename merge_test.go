@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("copies_all", func(t *testing.T) {
+		t.Parallel()
+
+		src := NewRandom[string, int](10)
+		defer src.Stop()
+
+		dst := NewTTL[string, int](5 * time.Minute)
+		defer dst.Stop()
+
+		src.Set("foo", 1)
+		src.Set("bar", 2)
+
+		Merge[string, int](dst, src)
+
+		if v, _ := dst.Get("foo"); v != 1 {
+			t.Errorf("expected %#v, got %#v", 1, v)
+		}
+		if v, _ := dst.Get("bar"); v != 2 {
+			t.Errorf("expected %#v, got %#v", 2, v)
+		}
+	})
+
+	t.Run("preserves_recency", func(t *testing.T) {
+		t.Parallel()
+
+		src := NewFIFO[string, int](10)
+		defer src.Stop()
+
+		dst := NewLRU[string, int](2)
+		defer dst.Stop()
+
+		src.Set("foo", 1)
+		time.Sleep(time.Millisecond)
+		src.Set("bar", 2)
+		time.Sleep(time.Millisecond)
+		src.Set("baz", 3)
+		time.Sleep(time.Millisecond)
+		src.Get("foo")
+
+		Merge[string, int](dst, src)
+
+		// "bar" is the least recently used, so it does not fit.
+		if v, ok := dst.Get("bar"); ok {
+			t.Errorf("expected %#v to be evicted", v)
+		}
+		if got, want := lruHead(dst).key, "baz"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := lruTail(dst).key, "foo"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("sharded_source", func(t *testing.T) {
+		t.Parallel()
+
+		// Keys are spread by a random seed, so each shard must be able to hold
+		// every key.
+		src := NewSharded(4, func() Cache[int, int] {
+			return NewLRU[int, int](20)
+		})
+		defer src.Stop()
+
+		dst := NewSyncLRU[int, int](40)
+		defer dst.Stop()
+
+		for i := 0; i < 20; i++ {
+			src.Set(i, i)
+		}
+
+		Merge[int, int](dst, src)
+
+		if got, want := len(dst.Entries()), 20; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package cache
+
+// Middleware layers behavior over a cache, returning a cache which wraps it.
+// The wrappers in this package, such as Sync and Copying, are each available
+// as a Middleware, and any function with this signature can be combined with
+// them using Chain.
+type Middleware[K comparable, V any] func(Cache[K, V]) Cache[K, V]
+
+// Chain combines the given middleware into one. The first middleware is the
+// outermost, so it sees each operation first:
+//
+//	mw := cache.Chain(cache.SyncMiddleware[string, []byte](), cache.CopyingMiddleware(copier))
+//	c := mw(cache.NewLRU[string, []byte](1000))
+//
+// is equivalent to:
+//
+//	c := cache.NewSync(cache.NewCopying(cache.NewLRU[string, []byte](1000), copier))
+func Chain[K comparable, V any](mws ...Middleware[K, V]) Middleware[K, V] {
+	return func(c Cache[K, V]) Cache[K, V] {
+		for i := len(mws) - 1; i >= 0; i-- {
+			c = mws[i](c)
+		}
+		return c
+	}
+}
+
+// SyncMiddleware returns middleware which wraps a cache in a Sync cache.
+func SyncMiddleware[K comparable, V any]() Middleware[K, V] {
+	return func(c Cache[K, V]) Cache[K, V] {
+		return NewSync(c)
+	}
+}
+
+// CopyingMiddleware returns middleware which wraps a cache in a Copying cache
+// with the given copier.
+func CopyingMiddleware[K comparable, V any](copier func(V) V) Middleware[K, V] {
+	if copier == nil {
+		panic("copier cannot be nil")
+	}
+
+	return func(c Cache[K, V]) Cache[K, V] {
+		return NewCopying(c, copier)
+	}
+}
+
+// HooksMiddleware returns middleware which wraps a cache in a Hooked cache with
+// the given hooks.
+func HooksMiddleware[K comparable, V any](hooks ...Hooks[K, V]) Middleware[K, V] {
+	return func(c Cache[K, V]) Cache[K, V] {
+		return NewHooked(c, hooks...)
+	}
+}
+
+// InstrumentedMiddleware returns middleware which wraps a cache in an
+// Instrumented cache with the given name and options. Since the Instrumented
+// cache is hidden behind the Cache interface, retrieve its statistics by
+// asserting the result to *Instrumented.
+func InstrumentedMiddleware[K comparable, V any](name string, opts ...InstrumentedOption) Middleware[K, V] {
+	if name == "" {
+		panic("name cannot be empty")
+	}
+
+	return func(c Cache[K, V]) Cache[K, V] {
+		return NewInstrumented(c, name, opts...)
+	}
+}
+
+// AuditMiddleware returns middleware which wraps a cache in an Audited cache
+// recording the last size operations. Since the Audited cache is hidden behind
+// the Cache interface, retrieve its events by asserting the result to
+// *Audited.
+func AuditMiddleware[K comparable, V any](size int) Middleware[K, V] {
+	if size <= 0 {
+		panic("size must be greater than 0")
+	}
+
+	return func(c Cache[K, V]) Cache[K, V] {
+		return NewAudited(c, size)
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	t.Run("order", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+		record := func(name string) Middleware[string, int] {
+			return func(c Cache[string, int]) Cache[string, int] {
+				return NewHooked(c, Hooks[string, int]{
+					BeforeGet: func(string) {
+						calls = append(calls, name)
+					},
+				})
+			}
+		}
+
+		cache := Chain(record("outer"), record("inner"))(NewLRU[string, int](10))
+		defer cache.Stop()
+
+		cache.Get("foo")
+		if got, want := calls, []string{"outer", "inner"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("wrappers", func(t *testing.T) {
+		t.Parallel()
+
+		mw := Chain(
+			SyncMiddleware[string, []int](),
+			CopyingMiddleware[string, []int](func(v []int) []int {
+				return append([]int(nil), v...)
+			}),
+		)
+		cache := mw(NewLRU[string, []int](10))
+		defer cache.Stop()
+
+		s, ok := cache.(*Sync[string, []int])
+		if !ok {
+			t.Fatalf("expected %T to be *Sync", cache)
+		}
+		if _, ok := s.cache.(*Copying[string, []int]); !ok {
+			t.Fatalf("expected %T to be *Copying", s.cache)
+		}
+
+		v := []int{1}
+		cache.Set("foo", v)
+		v[0] = 2
+		if got, _ := cache.Get("foo"); got[0] != 1 {
+			t.Errorf("expected %d to be %d", got[0], 1)
+		}
+	})
+
+	t.Run("instrumented", func(t *testing.T) {
+		t.Parallel()
+
+		cache := Chain(InstrumentedMiddleware[string, int]("test"))(NewLRU[string, int](10))
+		defer cache.Stop()
+
+		instrumented, ok := cache.(*Instrumented[string, int])
+		if !ok {
+			t.Fatalf("expected %T to be *Instrumented", cache)
+		}
+
+		cache.Get("foo")
+		if got, want := instrumented.Stats().Misses, uint64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		lru := NewLRU[string, int](10)
+		defer lru.Stop()
+
+		if got := Chain[string, int]()(lru); got != Cache[string, int](lru) {
+			t.Errorf("expected %T to be the input cache", got)
+		}
+	})
+}
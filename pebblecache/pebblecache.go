@@ -0,0 +1,137 @@
+// Package pebblecache implements a cache.Persister backed by a pebble database,
+// for persisting and restoring a cache's entries:
+//
+//	db, err := pebble.Open("cache", &pebble.Options{})
+//	p := pebblecache.New(db, []byte("users/"))
+//
+//	err = cache.Persist[string, *User](p, users, nil, nil)
+//	err = cache.Restore[string, *User](users, p, nil, nil)
+//
+// The package is a separate module, so the go-cache module does not depend on
+// pebble.
+package pebblecache
+
+import (
+	"errors"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/sethvargo/go-cache"
+)
+
+// Ensure implements.
+var _ cache.BatchPersister = (*Persister)(nil)
+
+// Persister stores entries in a pebble database, under a key prefix so several
+// caches can share a database. Writes are synced to disk. It is safe for
+// concurrent use.
+type Persister struct {
+	db     *pebble.DB
+	prefix []byte
+}
+
+// New creates a persister which stores entries in the given database, with the
+// given prefix prepended to each key. The prefix may be empty, in which case
+// Iterate visits every key in the database. The database is not closed by the
+// persister.
+func New(db *pebble.DB, prefix []byte) *Persister {
+	if db == nil {
+		panic("db cannot be nil")
+	}
+
+	return &Persister{
+		db:     db,
+		prefix: append([]byte(nil), prefix...),
+	}
+}
+
+// Put stores the value at the given key, overwriting any existing value.
+func (p *Persister) Put(key, value []byte) error {
+	return p.db.Set(p.key(key), value, pebble.Sync)
+}
+
+// PutAll stores each value at the key with the same index, in a single batch
+// which is applied atomically and synced once.
+func (p *Persister) PutAll(keys, values [][]byte) (err error) {
+	batch := p.db.NewBatch()
+	defer func() {
+		if cerr := batch.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	for i := range keys {
+		if err := batch.Set(p.key(keys[i]), values[i], nil); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+// Get retrieves a copy of the value at the given key. If it does not exist,
+// the second return value is false.
+func (p *Persister) Get(key []byte) ([]byte, bool, error) {
+	v, closer, err := p.db.Get(p.key(key))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The value is only valid until the closer is closed.
+	value := append([]byte{}, v...)
+	if err := closer.Close(); err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Delete removes the given key. It is not an error if the key does not exist.
+func (p *Persister) Delete(key []byte) error {
+	return p.db.Delete(p.key(key), pebble.Sync)
+}
+
+// Iterate calls fn for each key and value under the prefix, in key order, with
+// the prefix removed from the keys. It iterates over a consistent view of the
+// database.
+func (p *Persister) Iterate(fn func(key, value []byte) error) (err error) {
+	iter, err := p.db.NewIter(&pebble.IterOptions{
+		LowerBound: p.prefix,
+		UpperBound: upperBound(p.prefix),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := iter.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	for iter.First(); iter.Valid(); iter.Next() {
+		if err := fn(iter.Key()[len(p.prefix):], iter.Value()); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// key returns the given key with the prefix prepended.
+func (p *Persister) key(key []byte) []byte {
+	k := make([]byte, 0, len(p.prefix)+len(key))
+	k = append(k, p.prefix...)
+	return append(k, key...)
+}
+
+// upperBound returns the smallest key which is greater than every key with the
+// given prefix, or nil if there is none.
+func upperBound(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			end := append([]byte(nil), prefix[:i+1]...)
+			end[i]++
+			return end
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package pebblecache
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/sethvargo/go-cache"
+)
+
+func testDB(t *testing.T) *pebble.DB {
+	t.Helper()
+
+	db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return db
+}
+
+func TestPersister(t *testing.T) {
+	t.Parallel()
+
+	p := New(testDB(t), []byte("test/"))
+
+	if err := p.Put([]byte("foo"), []byte("bar")); err != nil {
+		t.Fatal(err)
+	}
+
+	v, ok, err := p.Get([]byte("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || string(v) != "bar" {
+		t.Errorf("expected %q to be %q", v, "bar")
+	}
+
+	if err := p.Delete([]byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := p.Get([]byte("foo")); err != nil || ok {
+		t.Errorf("expected foo to be deleted (%v)", err)
+	}
+}
+
+func TestPersister_Iterate(t *testing.T) {
+	t.Parallel()
+
+	db := testDB(t)
+	a := New(db, []byte("a"))
+	b := New(db, []byte("b"))
+
+	for _, k := range []string{"2", "1", "3"} {
+		if err := a.Put([]byte(k), []byte("v"+k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Put([]byte("1"), []byte("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only keys under the prefix are visited, in order, without the prefix.
+	var keys []string
+	if err := a.Iterate(func(k, v []byte) error {
+		if !bytes.Equal(v, append([]byte("v"), k...)) {
+			t.Errorf("unexpected value %q for %q", v, k)
+		}
+		keys = append(keys, string(k))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(keys), 3; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	for i, want := range []string{"1", "2", "3"} {
+		if keys[i] != want {
+			t.Errorf("expected %q to be %q", keys[i], want)
+		}
+	}
+}
+
+func TestUpperBound(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		prefix []byte
+		want   []byte
+	}{
+		{[]byte("a"), []byte("b")},
+		{[]byte{'a', 0xff}, []byte("b")},
+		{[]byte{0xff, 0xff}, nil},
+		{nil, nil},
+	}
+
+	for _, tc := range cases {
+		if got := upperBound(tc.prefix); !bytes.Equal(got, tc.want) {
+			t.Errorf("expected %q to be %q", got, tc.want)
+		}
+	}
+}
+
+func TestPersistRestore(t *testing.T) {
+	t.Parallel()
+
+	p := New(testDB(t), []byte("test/"))
+
+	src := cache.NewLRU[string, int](10)
+	defer src.Stop()
+	src.Set("foo", 1)
+	src.Set("bar", 2)
+
+	if err := cache.Persist[string, int](p, src, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := cache.NewLRU[string, int](10)
+	defer dst.Stop()
+
+	if err := cache.Restore[string, int](dst, p, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]int{"foo": 1, "bar": 2} {
+		if got, ok := dst.Get(k); !ok || got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	}
+}
+
+func TestPersister_PutAll(t *testing.T) {
+	t.Parallel()
+
+	p := New(testDB(t), []byte("test/"))
+
+	keys := [][]byte{[]byte("foo"), []byte("bar")}
+	values := [][]byte{[]byte("1"), []byte("2")}
+	if err := p.PutAll(keys, values); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, k := range keys {
+		v, ok, err := p.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || string(v) != string(values[i]) {
+			t.Errorf("expected %q to be %q", v, values[i])
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"fmt"
+)
+
+// Persister is implemented by key-value stores which can hold a cache's
+// entries, such as embedded databases like bbolt or pebble, for which the
+// bboltcache and pebblecache modules provide adapters. Keys and values are the
+// bytes produced by the cache's codecs.
+//
+// Slices passed to Put and Delete must not be retained by the store, and slices
+// passed to the Iterate callback are only valid until the callback returns, so
+// adapters for stores which reuse buffers during iteration stay simple.
+type Persister interface {
+	// Put stores the value at the given key, overwriting any existing value.
+	Put(key, value []byte) error
+
+	// Get retrieves the value at the given key. If it does not exist, the
+	// second return value is false.
+	Get(key []byte) ([]byte, bool, error)
+
+	// Delete removes the given key. It is not an error if the key does not
+	// exist.
+	Delete(key []byte) error
+
+	// Iterate calls fn for each key and value in the store, in the store's
+	// order. If fn returns an error, iteration stops and the error is returned.
+	Iterate(fn func(key, value []byte) error) error
+}
+
+// BatchPersister is implemented by Persisters which can store many entries in a
+// single write, such as one database transaction. Persist uses it when it is
+// available, rather than calling Put for each entry.
+type BatchPersister interface {
+	Persister
+
+	// PutAll stores each value at the key with the same index, overwriting any
+	// existing values. The entries are stored atomically if the store supports
+	// it.
+	PutAll(keys, values [][]byte) error
+}
+
+// Persist writes the cache's entries to p, encoding keys and values with the
+// given codecs. If keys or values is nil, the codec from CodecFor is used.
+// Entries already in p which are not in the cache are left as-is. Metadata
+// such as hit counts and expirations is not persisted. If p is a
+// BatchPersister, the entries are stored with a single call to PutAll.
+func Persist[K comparable, V any](p Persister, c EntryLister[K, V], keys Codec[K], values Codec[V]) error {
+	if keys == nil {
+		keys = CodecFor[K]()
+	}
+	if values == nil {
+		values = CodecFor[V]()
+	}
+
+	entries := c.Entries()
+	batch, isBatch := p.(BatchPersister)
+
+	var kbs, vbs [][]byte
+	if isBatch {
+		kbs = make([][]byte, 0, len(entries))
+		vbs = make([][]byte, 0, len(entries))
+	}
+
+	for _, entry := range entries {
+		k, err := keys.Marshal(entry.Key)
+		if err != nil {
+			return fmt.Errorf("failed to encode key %v: %w", entry.Key, err)
+		}
+		v, err := values.Marshal(entry.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encode value for %v: %w", entry.Key, err)
+		}
+
+		if isBatch {
+			kbs = append(kbs, k)
+			vbs = append(vbs, v)
+			continue
+		}
+		if err := p.Put(k, v); err != nil {
+			return fmt.Errorf("failed to persist %v: %w", entry.Key, err)
+		}
+	}
+
+	if isBatch && len(kbs) > 0 {
+		if err := batch.PutAll(kbs, vbs); err != nil {
+			return fmt.Errorf("failed to persist entries: %w", err)
+		}
+	}
+	return nil
+}
+
+// Restore sets each entry in p in the cache, in the order returned by
+// Iterate, decoding keys and values with the given codecs. If keys or values
+// is nil, the codec from CodecFor is used. It stops at the first error, in
+// which case the entries read before the error have already been set.
+func Restore[K comparable, V any](c Cache[K, V], p Persister, keys Codec[K], values Codec[V]) error {
+	if keys == nil {
+		keys = CodecFor[K]()
+	}
+	if values == nil {
+		values = CodecFor[V]()
+	}
+
+	return p.Iterate(func(kb, vb []byte) error {
+		k, err := keys.Unmarshal(kb)
+		if err != nil {
+			return fmt.Errorf("failed to decode key: %w", err)
+		}
+		v, err := values.Unmarshal(vb)
+		if err != nil {
+			return fmt.Errorf("failed to decode value for %v: %w", k, err)
+		}
+		c.Set(k, v)
+		return nil
+	})
+}
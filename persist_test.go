@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"sort"
+	"testing"
+)
+
+// testPersister is a Persister backed by a map, iterating in key order.
+type testPersister struct {
+	m map[string][]byte
+}
+
+func (p *testPersister) Put(key, value []byte) error {
+	p.m[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+// testBatchPersister is a testPersister which counts the calls to Put and
+// PutAll.
+type testBatchPersister struct {
+	testPersister
+	puts, putAlls int
+}
+
+func (p *testBatchPersister) Put(key, value []byte) error {
+	p.puts++
+	return p.testPersister.Put(key, value)
+}
+
+func (p *testBatchPersister) PutAll(keys, values [][]byte) error {
+	p.putAlls++
+	for i := range keys {
+		if err := p.testPersister.Put(keys[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *testPersister) Get(key []byte) ([]byte, bool, error) {
+	v, ok := p.m[string(key)]
+	return v, ok, nil
+}
+
+func (p *testPersister) Delete(key []byte) error {
+	delete(p.m, string(key))
+	return nil
+}
+
+func (p *testPersister) Iterate(fn func(key, value []byte) error) error {
+	keys := make([]string, 0, len(p.m))
+	for k := range p.m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := fn([]byte(k), p.m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestPersist(t *testing.T) {
+	t.Parallel()
+
+	p := &testPersister{m: make(map[string][]byte)}
+
+	src := NewLRU[string, int](10)
+	defer src.Stop()
+
+	src.Set("foo", 1)
+	src.Set("bar", 2)
+
+	if err := Persist[string, int](p, src, JSONCodec[string]{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(p.m), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if _, ok, _ := p.Get([]byte(`"foo"`)); !ok {
+		t.Errorf("expected foo to be persisted")
+	}
+
+	dst := NewLRU[string, int](10)
+	defer dst.Stop()
+
+	if err := Restore[string, int](dst, p, JSONCodec[string]{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := dst.Get("foo"); !ok || got != 1 {
+		t.Errorf("expected %d to be %d", got, 1)
+	}
+	if got, ok := dst.Get("bar"); !ok || got != 2 {
+		t.Errorf("expected %d to be %d", got, 2)
+	}
+
+	if err := p.Put([]byte(`"baz"`), []byte("not valid")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore[string, int](dst, p, JSONCodec[string]{}, nil); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestPersist_batch(t *testing.T) {
+	t.Parallel()
+
+	p := &testBatchPersister{testPersister: testPersister{m: make(map[string][]byte)}}
+
+	src := NewLRU[string, int](10)
+	defer src.Stop()
+
+	src.Set("foo", 1)
+	src.Set("bar", 2)
+	src.Set("baz", 3)
+
+	if err := Persist[string, int](p, src, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.putAlls, 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := p.puts, 0; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := len(p.m), 3; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
@@ -0,0 +1,611 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var (
+	_ Cache[string, string] = (*Policied[string, string])(nil)
+	_ Deleter[string]       = (*Policied[string, string])(nil)
+)
+
+// Policied implements a capacity-bounded cache whose eviction order is decided
+// by a Policy, for caching with a custom eviction policy without reimplementing
+// the storage, locking, loading, expiration, entry statistics, and eviction
+// hooks:
+//
+//	c := cache.NewPolicied[string, *User](1000, cache.NewLFUPolicy[string]())
+//
+// The FIFO, LIFO, and Random caches share this storage, with their policies
+// built in. LRU has its own storage, which supports reads under a shared lock
+// and is faster than a Policied cache with an LRU policy.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Policied[K comparable, V any] struct {
+	policyCache[K, V]
+}
+
+// NewPolicied creates a new cache of the given capacity, which evicts entries
+// in the order decided by the given policy. The policy must not be used by any
+// other cache.
+func NewPolicied[K comparable, V any](capacity int64, policy Policy[K], opts ...Option[K, V]) *Policied[K, V] {
+	if capacity <= 0 {
+		panic("capacity must be greater than 0")
+	}
+	if policy == nil {
+		panic("policy cannot be nil")
+	}
+
+	c := new(Policied[K, V])
+	c.init(capacity, policy, opts)
+	return c
+}
+
+// WithExpiration expires each entry the given duration after it was set. An
+// expired entry is never returned, and is removed when it is next read or
+// evicted; until then, it counts toward the capacity and Len. Expired entries
+// are not reported to OnEvict hooks when they are removed on read. It is only
+// supported by the caches which share Policied's storage: Policied, FIFO,
+// LIFO, and Random. Other caches panic if given it.
+func WithExpiration[K comparable, V any](ttl time.Duration) Option[K, V] {
+	if ttl <= 0 {
+		panic("ttl must be greater than 0")
+	}
+
+	return func(o *options[K, V]) {
+		o.ttl = ttl
+	}
+}
+
+// policyCache is the storage shared by the caches whose eviction order is
+// decided by a Policy. Its exported methods are promoted to those caches.
+type policyCache[K comparable, V any] struct {
+	// cache maps each key to the index of its entry in items, and policy orders
+	// the keys for eviction. The entries are stored by value in a single slice,
+	// so values live inline and reading one does not follow a per-entry pointer.
+	cache  map[K]int
+	items  []policiedItem[V]
+	policy Policy[K]
+
+	// passive indicates the policy ignores hits, so reads take the shared lock
+	// and do not tell the policy.
+	passive bool
+
+	// frequency is the policy if it counts how often keys are used, or nil
+	// otherwise.
+	frequency FrequencyPolicy[K]
+
+	// capacity is the total capacity for the cache.
+	capacity int64
+
+	// ttl is how long entries live after they are set, or 0 if they do not
+	// expire.
+	ttl time.Duration
+
+	// stopped indicates whether the cache is stopped.
+	stopped uint32
+
+	// lock is the internal lock for concurrency. Reads take it exclusively
+	// unless the policy is passive, since they update the policy.
+	lock waitMutex
+
+	// onEvict are the functions to invoke when an entry is evicted.
+	onEvict []func(K, V)
+
+	// onLifetime are the functions to invoke with how long each evicted entry
+	// was cached.
+	onLifetime []func(time.Duration)
+
+	// free are the indexes of items released by removals, which insertions
+	// reuse before growing items.
+	free []int
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+
+	// admission decides whether new entries are inserted, or is nil if all
+	// entries are.
+	admission Admission[K, V]
+}
+
+// policiedItem is an entry in a policy cache.
+type policiedItem[V any] struct {
+	meta  entryMeta
+	value V
+
+	// expiresAt is the time at which the entry expires, in nanoseconds, or 0 if
+	// it does not expire.
+	expiresAt int64
+}
+
+// expired returns true if the item has expired as of now.
+func (i *policiedItem[V]) expired(now int64) bool {
+	return i.expiresAt != 0 && now >= i.expiresAt
+}
+
+// init initializes the cache with the given policy and options.
+func (c *policyCache[K, V]) init(capacity int64, policy Policy[K], opts []Option[K, V]) {
+	o := newOptions(opts)
+
+	c.cache = make(map[K]int, capacity)
+	c.policy = policy
+	c.capacity = capacity
+	c.ttl = o.ttl
+	c.admission = o.admission
+
+	if p, ok := policy.(passivePolicy); ok {
+		c.passive = p.passive()
+	}
+	if p, ok := policy.(FrequencyPolicy[K]); ok {
+		c.frequency = p
+	}
+}
+
+// Get fetches the cache item at the given key. If the value exists, it is
+// returned and the policy is told of the hit. If the value does not exist or
+// has expired, it returns the zero value for the object and the second
+// parameter will be false.
+func (c *policyCache[K, V]) Get(key K) (V, bool) {
+	item, ok := c.lookup(key)
+	if !ok {
+		var zeroV V
+		return zeroV, false
+	}
+	return item.value, true
+}
+
+// lookup returns a copy of the item at the given key, recording the access,
+// and removes the item if it has expired. It locks.
+func (c *policyCache[K, V]) lookup(key K) (policiedItem[V], bool) {
+	now := time.Now().UnixNano()
+
+	if c.passive {
+		c.lock.RLock()
+		item, ok, expired := c.get(key, now)
+		c.lock.RUnlock()
+
+		if expired {
+			c.expire(key)
+		}
+		return item, ok
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, ok, expired := c.get(key, now)
+	if expired {
+		c.remove(key)
+	}
+	return item, ok
+}
+
+// get is the internal implementation of Get. It returns a copy of the item at
+// the given key and records the access, or reports whether the item exists but
+// has expired. It tells the policy of the hit unless the policy is passive, in
+// which case it only needs the shared lock. It does not lock.
+func (c *policyCache[K, V]) get(key K, now int64) (policiedItem[V], bool, bool) {
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	item, ok := c.item(key)
+	if !ok {
+		return policiedItem[V]{}, false, false
+	}
+	if item.expired(now) {
+		return policiedItem[V]{}, false, true
+	}
+
+	item.meta.recordAccess(now)
+	if !c.passive {
+		c.policy.OnHit(key)
+	}
+	return policiedItem[V]{
+		meta: entryMeta{
+			hits:       atomic.LoadUint64(&item.meta.hits),
+			accessedAt: atomic.LoadInt64(&item.meta.accessedAt),
+			insertedAt: item.meta.insertedAt,
+			version:    item.meta.version,
+		},
+		value:     item.value,
+		expiresAt: item.expiresAt,
+	}, true, false
+}
+
+// expire removes the entry at the given key if it has expired, after a read
+// under the shared lock found it expired.
+func (c *policyCache[K, V]) expire(key K) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if item, ok := c.item(key); ok && item.expired(time.Now().UnixNano()) {
+		c.remove(key)
+	}
+}
+
+// item returns the entry at the given key. The pointer is only valid until the
+// next insertion. It does not lock.
+func (c *policyCache[K, V]) item(key K) (*policiedItem[V], bool) {
+	i, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+	return &c.items[i], true
+}
+
+// Set inserts the value in the cache. If an entry already exists at the given
+// key, it is overwritten. If an entry does not exist, a new entry is created
+// (which might trigger eviction of the entry chosen by the policy).
+func (c *policyCache[K, V]) Set(key K, val V) {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	evicted = c.set(key, val)
+}
+
+// Insert inserts the value in the cache, like Set, and returns the entry which
+// was evicted to make room for it. If no entry was evicted, the third return
+// value is false. The evicted entry is also reported to any OnEvict hooks.
+func (c *policyCache[K, V]) Insert(key K, val V) (K, V, bool) {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	evicted = c.set(key, val)
+	return evicted.key, evicted.value, evicted.ok
+}
+
+// set is the internal implementation of Set. It does not lock. It returns the
+// entry which was evicted to make room, if any.
+func (c *policyCache[K, V]) set(key K, val V) victim[K, V] {
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	now := time.Now().UnixNano()
+
+	// Only a new entry needs room, so overwriting never evicts.
+	var evicted victim[K, V]
+	item, ok := c.item(key)
+	if ok {
+		c.policy.OnHit(key)
+	} else {
+		if c.admission != nil && !c.admission.Admit(key, val) {
+			return evicted
+		}
+		if int64(len(c.cache)) >= c.capacity {
+			evicted = c.evict()
+		}
+
+		var i int
+		if n := len(c.free); n > 0 {
+			i = c.free[n-1]
+			c.free = c.free[:n-1]
+		} else {
+			i = len(c.items)
+			c.items = append(c.items, policiedItem[V]{})
+		}
+
+		c.cache[key] = i
+		c.policy.OnAdd(key)
+		item = &c.items[i]
+	}
+
+	item.value = val
+	item.meta.reset(now)
+	item.expiresAt = 0
+	if c.ttl > 0 {
+		item.expiresAt = now + int64(c.ttl)
+	}
+	return evicted
+}
+
+// SetIfVersion inserts the value in the cache only if the version of the entry
+// at the given key is the expected version, as with Set. An expected version
+// of 0 matches a missing or expired entry. It returns true if the value was
+// inserted.
+func (c *policyCache[K, V]) SetIfVersion(key K, val V, version uint64) bool {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	var current uint64
+	if item, ok := c.item(key); ok && !item.expired(time.Now().UnixNano()) {
+		current = item.meta.version
+	}
+	if current != version {
+		return false
+	}
+
+	// The value is not inserted if the admission policy rejects it.
+	evicted = c.set(key, val)
+	_, ok := c.cache[key]
+	return ok
+}
+
+// evict removes the entry chosen by the policy and returns it. It does not
+// lock.
+func (c *policyCache[K, V]) evict() victim[K, V] {
+	if len(c.cache) == 0 {
+		return victim[K, V]{}
+	}
+
+	key := c.policy.Victim()
+	item, ok := c.item(key)
+	if !ok {
+		panic("policy returned a key which is not in the cache")
+	}
+
+	val, insertedAt := item.value, item.meta.insertedAt
+	c.remove(key)
+	return newVictim(key, val, insertedAt, true, c.onEvict, c.onLifetime)
+}
+
+// remove removes the entry at the given key, which must exist, from the cache
+// and the policy. It does not lock.
+func (c *policyCache[K, V]) remove(key K) {
+	i := c.cache[key]
+	delete(c.cache, key)
+	c.policy.OnRemove(key)
+
+	// Zero out the old item to improve gc sweeps.
+	c.items[i] = policiedItem[V]{}
+	c.free = append(c.free, i)
+}
+
+// Delete removes the entry at the given key from the cache. It returns false if
+// there is no entry at the given key.
+func (c *policyCache[K, V]) Delete(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	if _, ok := c.cache[key]; !ok {
+		return false
+	}
+	c.remove(key)
+	return true
+}
+
+// RemoveOldest removes the entry that would be evicted next, as chosen by the
+// policy, from the cache and returns it. If the cache is empty, the third
+// return value is false.
+func (c *policyCache[K, V]) RemoveOldest() (K, V, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	if len(c.cache) == 0 {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+
+	key := c.policy.Victim()
+	val := c.items[c.cache[key]].value
+	c.remove(key)
+	return key, val, true
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. The FetchFunc runs without holding the cache's lock, so other
+// operations proceed while it runs, and concurrent calls to Fetch for the same
+// key share a single invocation.
+func (c *policyCache[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+
+	return c.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := c.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		c.Set(key, v)
+		return v, nil
+	})
+}
+
+// GetEntry fetches the cache item at the given key along with its metadata.
+// It counts as an access, like Get, and the returned metadata includes it. If
+// the value does not exist or has expired, the second return value is false.
+func (c *policyCache[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	item, ok := c.lookup(key)
+	if !ok {
+		return Entry[K, V]{}, false
+	}
+	return c.entry(key, &item), true
+}
+
+// Entries returns a snapshot of the unexpired entries in the cache, including
+// their metadata. If the policy can list its keys in eviction order, as those
+// of FIFO and LIFO can, the first entry is the next to be evicted; otherwise
+// the entries are returned in no particular order.
+func (c *policyCache[K, V]) Entries() []Entry[K, V] {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	now := time.Now().UnixNano()
+	entries := make([]Entry[K, V], 0, len(c.cache))
+	c.ascend(now, func(key K, item *policiedItem[V]) bool {
+		entries = append(entries, c.entry(key, item))
+		return true
+	})
+	return entries
+}
+
+// ascend calls fn for each unexpired entry, in eviction order if the policy
+// reports it, until fn returns false. It does not lock.
+func (c *policyCache[K, V]) ascend(now int64, fn func(K, *policiedItem[V]) bool) {
+	if p, ok := c.policy.(orderedPolicy[K]); ok {
+		p.ascend(func(key K) bool {
+			if item, _ := c.item(key); !item.expired(now) {
+				return fn(key, item)
+			}
+			return true
+		})
+		return
+	}
+
+	for key, i := range c.cache {
+		if item := &c.items[i]; !item.expired(now) && !fn(key, item) {
+			return
+		}
+	}
+}
+
+// ascendEvictionOrder calls fn for each unexpired entry in the cache in the
+// eviction order of its policy, which must be ordered, until fn returns false.
+// It locks.
+func ascendEvictionOrder[K comparable, V any](c *policyCache[K, V], fn func(K, V) bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	c.ascend(time.Now().UnixNano(), func(key K, item *policiedItem[V]) bool {
+		return fn(key, item.value)
+	})
+}
+
+// entry returns the entry for the given key and item.
+func (c *policyCache[K, V]) entry(key K, item *policiedItem[V]) Entry[K, V] {
+	entry := Entry[K, V]{
+		Key:   key,
+		Value: item.value,
+	}
+	fillEntry(&entry, &item.meta)
+	if item.expiresAt != 0 {
+		entry.ExpiresAt = time.Unix(0, item.expiresAt)
+	}
+	if c.frequency != nil {
+		entry.Frequency = c.frequency.Frequency(key)
+	}
+	return entry
+}
+
+// OnEvict registers a function which is invoked with each entry that is
+// evicted to make room for a new entry. Functions are invoked in the order they
+// were registered, after the cache's lock is released, so they may call back
+// into the cache. Entries removed with Delete, RemoveOldest, or Stop are not
+// reported.
+func (c *policyCache[K, V]) OnEvict(fn func(K, V)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.onEvict = appendHook(c.onEvict, fn)
+}
+
+// onEvictLifetime registers a function which is invoked with how long each
+// evicted entry was cached, alongside the OnEvict functions.
+func (c *policyCache[K, V]) onEvictLifetime(fn func(time.Duration)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.onLifetime = appendHook(c.onLifetime, fn)
+}
+
+// Capacity returns the capacity of the cache.
+func (c *policyCache[K, V]) Capacity() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.capacity
+}
+
+// Resize changes the capacity of the cache. If the cache holds more entries
+// than the new capacity, entries are evicted according to the cache's policy
+// until it fits, and reported to any OnEvict hooks.
+func (c *policyCache[K, V]) Resize(capacity int64) {
+	if capacity <= 0 {
+		panic("capacity must be greater than 0")
+	}
+
+	var evicted []victim[K, V]
+	defer func() {
+		for i := range evicted {
+			evicted[i].notify()
+		}
+	}()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	c.capacity = capacity
+	for int64(len(c.cache)) > capacity {
+		evicted = append(evicted, c.evict())
+	}
+}
+
+// Len returns the number of entries in the cache, including expired entries
+// which have not yet been removed.
+func (c *policyCache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.cache)
+}
+
+// LockWait returns the total time operations have spent waiting to acquire the
+// cache's internal lock. A steadily increasing value indicates contention.
+func (c *policyCache[K, V]) LockWait() time.Duration {
+	return c.lock.waitTime()
+}
+
+// Stop clears the cache and prevents new entries from being added and
+// retrieved.
+func (c *policyCache[K, V]) Stop() {
+	c.loads.close()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&c.stopped, 0, 1) {
+		return
+	}
+
+	for key := range c.cache {
+		c.policy.OnRemove(key)
+	}
+	c.cache = nil
+	c.items = nil
+	c.free = nil
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (c *policyCache[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&c.stopped) == 1
+}
@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPolicied(t *testing.T) {
+	t.Parallel()
+
+	t.Run("evicts_by_policy", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPolicied[string, int](2, NewLFUPolicy[string]())
+		defer c.Stop()
+
+		var evicted []string
+		c.OnEvict(func(k string, _ int) {
+			evicted = append(evicted, k)
+		})
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3)
+
+		if _, ok := c.Get("b"); ok {
+			t.Errorf("expected b to be evicted")
+		}
+		if v, ok := c.Get("a"); !ok || v != 1 {
+			t.Errorf("expected %d to be %d", v, 1)
+		}
+		if got, want := len(evicted), 1; got != want {
+			t.Fatalf("expected %d to be %d", got, want)
+		}
+		if got, want := evicted[0], "b"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPolicied[string, int](1, NewFIFOPolicy[string]())
+		defer c.Stop()
+
+		if _, _, ok := c.Insert("a", 1); ok {
+			t.Errorf("expected no eviction")
+		}
+		// Overwriting does not evict.
+		if _, _, ok := c.Insert("a", 2); ok {
+			t.Errorf("expected no eviction")
+		}
+		k, v, ok := c.Insert("b", 3)
+		if !ok || k != "a" || v != 2 {
+			t.Errorf("expected %q=%d to be evicted", k, v)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPolicied[string, int](2, NewLRUPolicy[string]())
+		defer c.Stop()
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		if !c.Delete("a") {
+			t.Errorf("expected a to be deleted")
+		}
+		if c.Delete("a") {
+			t.Errorf("expected a to be missing")
+		}
+
+		// The deleted key is gone from the policy too.
+		c.Set("c", 3)
+		c.Set("d", 4)
+		if _, ok := c.Get("b"); ok {
+			t.Errorf("expected b to be evicted")
+		}
+		if got, want := c.Len(), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPolicied[string, int](2, NewLRUPolicy[string]())
+		defer c.Stop()
+
+		var calls int
+		for i := 0; i < 3; i++ {
+			v, err := c.Fetch("a", func() (int, error) {
+				calls++
+				return 5, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := v, 5; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+		}
+		if got, want := calls, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPolicied[string, int](3, NewFIFOPolicy[string](), WithExpiration[string, int](time.Hour))
+		defer c.Stop()
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+
+		entry, ok := c.GetEntry("a")
+		if !ok {
+			t.Fatal("expected entry")
+		}
+		if got, want := entry.Hits, uint64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if entry.ExpiresAt.Before(entry.InsertedAt.Add(time.Hour)) {
+			t.Errorf("expected %v to be an hour after %v", entry.ExpiresAt, entry.InsertedAt)
+		}
+
+		// The policy is ordered, so entries are in eviction order.
+		entries := c.Entries()
+		if got, want := len(entries), 2; got != want {
+			t.Fatalf("expected %d to be %d", got, want)
+		}
+		if got, want := entries[0].Key, "a"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		if !c.SetIfVersion("a", 5, entry.Version) {
+			t.Errorf("expected a to be set")
+		}
+		if c.SetIfVersion("a", 6, entry.Version) {
+			t.Errorf("expected a not to be set")
+		}
+	})
+
+	t.Run("frequency", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPolicied[string, int](3, NewLFUPolicy[string]())
+		defer c.Stop()
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Get("a")
+
+		frequencies := make(map[string]uint64)
+		for _, entry := range c.Entries() {
+			frequencies[entry.Key] = entry.Frequency
+		}
+		if got, want := frequencies["a"], uint64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := frequencies["b"], uint64(0); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		// Policies which do not count uses report no frequency.
+		fifo := NewPolicied[string, int](3, NewFIFOPolicy[string]())
+		defer fifo.Stop()
+
+		fifo.Set("a", 1)
+		fifo.Get("a")
+		if entry, _ := fifo.GetEntry("a"); entry.Frequency != 0 {
+			t.Errorf("expected %d to be 0", entry.Frequency)
+		}
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		t.Parallel()
+
+		caches := map[string]Cache[string, int]{
+			"lfu":    NewPolicied[string, int](3, NewLFUPolicy[string](), WithExpiration[string, int](10*time.Millisecond)),
+			"fifo":   NewFIFO[string, int](3, WithExpiration[string, int](10*time.Millisecond)),
+			"random": NewRandom[string, int](3, WithExpiration[string, int](10*time.Millisecond)),
+		}
+		for name, c := range caches {
+			defer c.Stop()
+
+			c.Set("a", 1)
+			if v, ok := c.Get("a"); !ok || v != 1 {
+				t.Errorf("%s: expected %d to be %d", name, v, 1)
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			if _, ok := c.Get("a"); ok {
+				t.Errorf("%s: expected a to be expired", name)
+			}
+
+			// The expired entry was removed when it was read.
+			if got, want := c.(lener).Len(), 0; got != want {
+				t.Errorf("%s: expected %d to be %d", name, got, want)
+			}
+		}
+	})
+
+	t.Run("expiration_unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "cache does not support expiration"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+		NewLRU[string, int](1, WithExpiration[string, int](time.Hour))
+		t.Errorf("did not panic")
+	})
+
+	t.Run("panics", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected panic")
+			}
+		}()
+		NewPolicied[string, int](1, nil)
+	})
+}
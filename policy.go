@@ -0,0 +1,350 @@
+package cache
+
+import (
+	"container/heap"
+)
+
+// Policy decides which entry a Policied cache evicts when it is full. The
+// cache stores the entries and handles locking, loading, expiration, entry
+// statistics, and eviction hooks, and tells the policy how its keys are used,
+// so a custom policy only tracks keys. The FIFO, LIFO, and Random caches are
+// built the same way, on the policies returned by NewFIFOPolicy, NewLIFOPolicy,
+// and NewRandomPolicy.
+//
+// The cache calls the policy while holding its lock, so a policy need not be
+// safe for concurrent use, and must not call back into the cache. A policy
+// must not be shared between caches.
+type Policy[K comparable] interface {
+	// OnAdd is called when a new key is added to the cache.
+	OnAdd(key K)
+
+	// OnHit is called when an existing key is read or overwritten.
+	OnHit(key K)
+
+	// Victim returns the key to evict next. It is only called when the cache
+	// holds at least one key, and must return a key which has been added and
+	// not removed.
+	Victim() K
+
+	// OnRemove is called when a key is removed from the cache, including when
+	// it is evicted.
+	OnRemove(key K)
+}
+
+// Ensure implements.
+var (
+	_ Policy[string] = (*listPolicy[string])(nil)
+	_ Policy[string] = (*lfuPolicy[string])(nil)
+	_ Policy[string] = (*randomPolicy[string])(nil)
+
+	_ FrequencyPolicy[string] = (*lfuPolicy[string])(nil)
+
+	_ orderedPolicy[string] = (*listPolicy[string])(nil)
+	_ passivePolicy         = (*listPolicy[string])(nil)
+	_ passivePolicy         = (*randomPolicy[string])(nil)
+)
+
+// FrequencyPolicy is implemented by policies which decide which keys to retain
+// by how often they are used, such as the policy returned by NewLFUPolicy. A
+// Policied cache reports the policy's count in the Frequency field of its
+// entries.
+type FrequencyPolicy[K comparable] interface {
+	Policy[K]
+
+	// Frequency returns the number of times the key has been hit since it was
+	// added, or 0 if the key has not been added.
+	Frequency(key K) uint64
+}
+
+// orderedPolicy is implemented by policies which can list their keys in
+// eviction order, so the cache can report its entries in that order.
+type orderedPolicy[K comparable] interface {
+	// ascend calls fn for each key in eviction order, starting with the next
+	// to be evicted, until fn returns false.
+	ascend(fn func(K) bool)
+}
+
+// passivePolicy is implemented by policies which may ignore hits, so the cache
+// can read under a shared lock without telling the policy.
+type passivePolicy interface {
+	// passive returns true if OnHit does nothing.
+	passive() bool
+}
+
+// NewLRUPolicy returns a policy which evicts the least recently used key.
+func NewLRUPolicy[K comparable]() Policy[K] {
+	return newListPolicy[K](true, false)
+}
+
+// NewFIFOPolicy returns a policy which evicts keys in the order in which they
+// were added.
+func NewFIFOPolicy[K comparable]() Policy[K] {
+	return newListPolicy[K](false, false)
+}
+
+// NewLIFOPolicy returns a policy which evicts the most recently added key.
+func NewLIFOPolicy[K comparable]() Policy[K] {
+	return newListPolicy[K](false, true)
+}
+
+// NewRandomPolicy returns a policy which evicts an arbitrary key.
+func NewRandomPolicy[K comparable]() Policy[K] {
+	return &randomPolicy[K]{
+		keys: make(map[K]struct{}),
+	}
+}
+
+// NewLFUPolicy returns a policy which evicts the least frequently used key.
+// Keys which have been used equally often are evicted in the order in which
+// they were added.
+func NewLFUPolicy[K comparable]() Policy[K] {
+	return &lfuPolicy[K]{
+		items: make(map[K]*lfuItem[K]),
+	}
+}
+
+// listPolicy keeps keys in a doubly-linked list, from the oldest at the head
+// to the most recent at the tail. If moveOnHit is true, keys are moved to the
+// tail when they are used, which makes it LRU. If lifo is true, keys are
+// evicted from the tail, which makes it LIFO; otherwise they are evicted from
+// the head.
+type listPolicy[K comparable] struct {
+	nodes      map[K]*policyNode[K]
+	head, tail *policyNode[K]
+	moveOnHit  bool
+	lifo       bool
+
+	// free is a node released by OnRemove, which the next OnAdd reuses instead
+	// of allocating.
+	free *policyNode[K]
+}
+
+// newListPolicy creates a list policy.
+func newListPolicy[K comparable](moveOnHit, lifo bool) *listPolicy[K] {
+	return &listPolicy[K]{
+		nodes:     make(map[K]*policyNode[K]),
+		moveOnHit: moveOnHit,
+		lifo:      lifo,
+	}
+}
+
+// policyNode is a key in a list policy.
+type policyNode[K comparable] struct {
+	key        K
+	prev, next *policyNode[K]
+}
+
+// OnAdd adds the key at the tail.
+func (p *listPolicy[K]) OnAdd(key K) {
+	node := p.free
+	if node == nil {
+		node = new(policyNode[K])
+	}
+	p.free = nil
+
+	node.key = key
+	p.nodes[key] = node
+	p.pushTail(node)
+}
+
+// OnHit moves the key to the tail if the policy is LRU.
+func (p *listPolicy[K]) OnHit(key K) {
+	if !p.moveOnHit {
+		return
+	}
+	if node, ok := p.nodes[key]; ok && node != p.tail {
+		p.unlink(node)
+		p.pushTail(node)
+	}
+}
+
+// Victim returns the key at the head, or at the tail if the policy is LIFO.
+func (p *listPolicy[K]) Victim() K {
+	if p.lifo {
+		return p.tail.key
+	}
+	return p.head.key
+}
+
+// OnRemove removes the key from the list.
+func (p *listPolicy[K]) OnRemove(key K) {
+	if node, ok := p.nodes[key]; ok {
+		delete(p.nodes, key)
+		p.unlink(node)
+
+		var zeroK K
+		node.key = zeroK
+		p.free = node
+	}
+}
+
+// ascend implements orderedPolicy.
+func (p *listPolicy[K]) ascend(fn func(K) bool) {
+	if p.lifo {
+		for node := p.tail; node != nil; node = node.prev {
+			if !fn(node.key) {
+				return
+			}
+		}
+		return
+	}
+
+	for node := p.head; node != nil; node = node.next {
+		if !fn(node.key) {
+			return
+		}
+	}
+}
+
+// passive implements passivePolicy.
+func (p *listPolicy[K]) passive() bool {
+	return !p.moveOnHit
+}
+
+// pushTail appends the node to the list.
+func (p *listPolicy[K]) pushTail(node *policyNode[K]) {
+	node.prev = p.tail
+	if p.tail != nil {
+		p.tail.next = node
+	}
+	p.tail = node
+	if p.head == nil {
+		p.head = node
+	}
+}
+
+// unlink removes the node from the list.
+func (p *listPolicy[K]) unlink(node *policyNode[K]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		p.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		p.tail = node.prev
+	}
+	node.prev, node.next = nil, nil
+}
+
+// randomPolicy keeps keys in a set, and evicts whichever key iterating the set
+// yields first. Go randomizes map iteration, so the key is arbitrary.
+type randomPolicy[K comparable] struct {
+	keys map[K]struct{}
+}
+
+// OnAdd adds the key to the set.
+func (p *randomPolicy[K]) OnAdd(key K) {
+	p.keys[key] = struct{}{}
+}
+
+// OnHit does nothing, since hits do not affect which key is evicted.
+func (p *randomPolicy[K]) OnHit(K) {}
+
+// Victim returns an arbitrary key.
+func (p *randomPolicy[K]) Victim() K {
+	for key := range p.keys {
+		return key
+	}
+
+	var zeroK K
+	return zeroK
+}
+
+// OnRemove removes the key from the set.
+func (p *randomPolicy[K]) OnRemove(key K) {
+	delete(p.keys, key)
+}
+
+// passive implements passivePolicy.
+func (p *randomPolicy[K]) passive() bool {
+	return true
+}
+
+// lfuPolicy keeps keys in a min-heap ordered by use count, then by the order in
+// which they were added.
+type lfuPolicy[K comparable] struct {
+	items map[K]*lfuItem[K]
+	heap  lfuHeap[K]
+	seq   uint64
+}
+
+// lfuItem is a key in an LFU policy.
+type lfuItem[K comparable] struct {
+	key   K
+	count uint64
+	seq   uint64
+	index int
+}
+
+// OnAdd adds the key with a use count of 1.
+func (p *lfuPolicy[K]) OnAdd(key K) {
+	p.seq++
+	item := &lfuItem[K]{key: key, count: 1, seq: p.seq}
+	p.items[key] = item
+	heap.Push(&p.heap, item)
+}
+
+// OnHit increments the key's use count.
+func (p *lfuPolicy[K]) OnHit(key K) {
+	if item, ok := p.items[key]; ok {
+		item.count++
+		heap.Fix(&p.heap, item.index)
+	}
+}
+
+// Victim returns the least frequently used key.
+func (p *lfuPolicy[K]) Victim() K {
+	return p.heap[0].key
+}
+
+// Frequency returns the number of times the key has been hit since it was
+// added. The use count starts at 1 when the key is added.
+func (p *lfuPolicy[K]) Frequency(key K) uint64 {
+	if item, ok := p.items[key]; ok {
+		return item.count - 1
+	}
+	return 0
+}
+
+// OnRemove removes the key from the heap.
+func (p *lfuPolicy[K]) OnRemove(key K) {
+	if item, ok := p.items[key]; ok {
+		delete(p.items, key)
+		heap.Remove(&p.heap, item.index)
+	}
+}
+
+// lfuHeap implements heap.Interface for an LFU policy.
+type lfuHeap[K comparable] []*lfuItem[K]
+
+func (h lfuHeap[K]) Len() int { return len(h) }
+
+func (h lfuHeap[K]) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[K]) Push(x any) {
+	item := x.(*lfuItem[K])
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *lfuHeap[K]) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestPolicies(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		policy Policy[string]
+		want   []string
+	}{
+		{
+			name:   "lru",
+			policy: NewLRUPolicy[string](),
+			want:   []string{"a", "c", "b"},
+		},
+		{
+			name:   "fifo",
+			policy: NewFIFOPolicy[string](),
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:   "lfu",
+			policy: NewLFUPolicy[string](),
+			want:   []string{"c", "a", "b"},
+		},
+		{
+			name:   "lifo",
+			policy: NewLIFOPolicy[string](),
+			want:   []string{"c", "b", "a"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := tc.policy
+			p.OnAdd("a")
+			p.OnAdd("b")
+			p.OnAdd("c")
+			p.OnHit("a")
+			p.OnHit("a")
+			p.OnHit("b")
+			p.OnHit("c")
+			p.OnHit("b")
+
+			for _, want := range tc.want {
+				got := p.Victim()
+				if got != want {
+					t.Errorf("expected %q to be %q", got, want)
+				}
+				p.OnRemove(got)
+			}
+		})
+	}
+}
+
+func TestNewRandomPolicy(t *testing.T) {
+	t.Parallel()
+
+	p := NewRandomPolicy[string]()
+	p.OnAdd("a")
+	p.OnAdd("b")
+	p.OnHit("a")
+
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		got := p.Victim()
+		if got != "a" && got != "b" {
+			t.Fatalf("expected %q to be a or b", got)
+		}
+		if seen[got] {
+			t.Errorf("expected %q to be removed", got)
+		}
+		seen[got] = true
+		p.OnRemove(got)
+	}
+}
@@ -0,0 +1,239 @@
+//go:build go1.23
+
+package cache
+
+import (
+	"iter"
+	"sort"
+	"sync"
+)
+
+// Ensure implements.
+var (
+	_ Cache[string, string] = (*Prefixed[string])(nil)
+	_ Deleter[string]       = (*Prefixed[string])(nil)
+)
+
+// Prefixed wraps a cache keyed by strings with an index of its keys, so the
+// entries under a hierarchical key prefix (such as "user:42:") can be
+// enumerated or invalidated without scanning the whole cache.
+//
+// Keys are added to the index when they are set. If the underlying cache
+// implements EvictNotifier, evicted keys are removed from the index as they are
+// evicted. Keys removed from the underlying cache without notification, such as
+// expired TTL entries, are removed from the index when a scan finds them
+// missing. Prefixed requires Go 1.23 or later.
+//
+// V can be any type, but pointers are best for performance.
+type Prefixed[V any] struct {
+	// cache is the underlying cache implementation.
+	cache Cache[string, V]
+
+	// root is the root of the key index. It is guarded by lock.
+	root prefixNode
+	lock sync.Mutex
+}
+
+// prefixNode is a node in the key index, which is a trie keyed by byte.
+type prefixNode struct {
+	children map[byte]*prefixNode
+
+	// leaf indicates a key ends at this node.
+	leaf bool
+}
+
+// NewPrefixed wraps the given cache in a cache which indexes its keys by
+// prefix. The index only includes keys set through the returned cache, so the
+// underlying cache should not be used directly.
+func NewPrefixed[V any](c Cache[string, V]) *Prefixed[V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	p := &Prefixed[V]{
+		cache: c,
+	}
+	if n, ok := c.(EvictNotifier[string, V]); ok {
+		n.OnEvict(func(key string, _ V) {
+			p.prune(key)
+		})
+	}
+	return p
+}
+
+// Get fetches the cache item at the given key from the underlying cache.
+func (p *Prefixed[V]) Get(key string) (V, bool) {
+	return p.cache.Get(key)
+}
+
+// Set inserts the value in the underlying cache and indexes its key.
+func (p *Prefixed[V]) Set(key string, val V) {
+	p.cache.Set(key, val)
+	p.index(key)
+}
+
+// Fetch retrieves the cached value from the underlying cache, indexing the key
+// if the FetchFunc is called and succeeds.
+func (p *Prefixed[V]) Fetch(key string, fn FetchFunc[V]) (V, error) {
+	loaded := false
+	v, err := p.cache.Fetch(key, func() (V, error) {
+		v, err := fn()
+		loaded = err == nil
+		return v, err
+	})
+	if loaded {
+		p.index(key)
+	}
+	return v, err
+}
+
+// Delete removes the entry at the given key from the underlying cache. It
+// returns false if there is no entry at the given key. It panics if the
+// underlying cache does not implement Deleter.
+func (p *Prefixed[V]) Delete(key string) bool {
+	d, ok := p.cache.(Deleter[string])
+	if !ok {
+		panic("cache does not support delete")
+	}
+
+	ok = d.Delete(key)
+	p.prune(key)
+	return ok
+}
+
+// ScanPrefix returns an iterator over the entries whose keys begin with the
+// given prefix, in lexical order of their keys. The keys are read from the
+// index when iteration begins, and each value is read from the underlying
+// cache with Get as it is reached, so entries removed during iteration are
+// skipped. For caches whose Get modifies the cache, such as LRU, each entry
+// reached counts as an access.
+func (p *Prefixed[V]) ScanPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		for _, key := range p.keys(prefix) {
+			v, ok := p.cache.Get(key)
+			if !ok {
+				p.prune(key)
+				continue
+			}
+			if !yield(key, v) {
+				return
+			}
+		}
+	}
+}
+
+// DeletePrefix removes the entries whose keys begin with the given prefix from
+// the underlying cache, and returns the number of entries which were removed.
+// It panics if the underlying cache does not implement Deleter.
+func (p *Prefixed[V]) DeletePrefix(prefix string) int {
+	d, ok := p.cache.(Deleter[string])
+	if !ok {
+		panic("cache does not support delete")
+	}
+
+	var removed int
+	for _, key := range p.keys(prefix) {
+		if d.Delete(key) {
+			removed++
+		}
+		p.prune(key)
+	}
+	return removed
+}
+
+// Stop stops the underlying cache and clears the index.
+func (p *Prefixed[V]) Stop() {
+	p.cache.Stop()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.root = prefixNode{}
+}
+
+// index adds the key to the index. It is called after the key is stored in the
+// underlying cache.
+func (p *Prefixed[V]) index(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node := &p.root
+	for i := 0; i < len(key); i++ {
+		child, ok := node.children[key[i]]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[byte]*prefixNode, 1)
+			}
+			child = &prefixNode{}
+			node.children[key[i]] = child
+		}
+		node = child
+	}
+	node.leaf = true
+}
+
+// prune removes the key from the index if it is no longer in the underlying
+// cache. The check is made while holding the index lock, and Set stores a key
+// before indexing it, so a key which is set concurrently is never lost from
+// the index.
+func (p *Prefixed[V]) prune(key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.cache.Get(key); ok {
+		return
+	}
+
+	// Record the path to the key, so nodes left without keys can be removed.
+	path := make([]*prefixNode, 0, len(key)+1)
+	node := &p.root
+	path = append(path, node)
+	for i := 0; i < len(key); i++ {
+		child, ok := node.children[key[i]]
+		if !ok {
+			return
+		}
+		node = child
+		path = append(path, node)
+	}
+	node.leaf = false
+
+	for i := len(key); i > 0; i-- {
+		if n := path[i]; n.leaf || len(n.children) > 0 {
+			return
+		}
+		delete(path[i-1].children, key[i-1])
+	}
+}
+
+// keys returns the indexed keys which begin with the given prefix, sorted.
+func (p *Prefixed[V]) keys(prefix string) []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	node := &p.root
+	for i := 0; i < len(prefix); i++ {
+		child, ok := node.children[prefix[i]]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+
+	var keys []string
+	buf := []byte(prefix)
+	var walk func(n *prefixNode)
+	walk = func(n *prefixNode) {
+		if n.leaf {
+			keys = append(keys, string(buf))
+		}
+		for b, child := range n.children {
+			buf = append(buf, b)
+			walk(child)
+			buf = buf[:len(buf)-1]
+		}
+	}
+	walk(node)
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+//go:build go1.23
+
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// scanKeys returns the keys yielded by ScanPrefix for the given prefix.
+func scanKeys[V any](p *Prefixed[V], prefix string) []string {
+	var keys []string
+	for k := range p.ScanPrefix(prefix) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestPrefixed_ScanPrefix(t *testing.T) {
+	t.Parallel()
+
+	cache := NewPrefixed[int](NewLRU[string, int](10))
+	defer cache.Stop()
+
+	cache.Set("user:42:name", 1)
+	cache.Set("user:42:email", 2)
+	cache.Set("user:420:name", 3)
+	cache.Set("user:7:name", 4)
+
+	if got, want := scanKeys(cache, "user:42:"), []string{"user:42:email", "user:42:name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := scanKeys(cache, "user:42"), []string{"user:420:name", "user:42:email", "user:42:name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got := scanKeys(cache, "group:"); got != nil {
+		t.Errorf("expected %q to be empty", got)
+	}
+
+	for k, v := range cache.ScanPrefix("user:7:") {
+		if k != "user:7:name" || v != 4 {
+			t.Errorf("expected %q=%d to be %q=%d", k, v, "user:7:name", 4)
+		}
+	}
+
+	// Stopping early does not yield more entries.
+	var n int
+	for range cache.ScanPrefix("user:") {
+		n++
+		break
+	}
+	if got, want := n, 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestPrefixed_DeletePrefix(t *testing.T) {
+	t.Parallel()
+
+	cache := NewPrefixed[int](NewLRU[string, int](10))
+	defer cache.Stop()
+
+	cache.Set("user:42:name", 1)
+	cache.Set("user:42:email", 2)
+	cache.Set("user:7:name", 3)
+
+	if got, want := cache.DeletePrefix("user:42:"), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if _, ok := cache.Get("user:42:name"); ok {
+		t.Errorf("expected user:42:name to be deleted")
+	}
+	if got, want := scanKeys(cache, "user:"), []string{"user:7:name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := len(cache.root.children), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestPrefixed_prune(t *testing.T) {
+	t.Parallel()
+
+	t.Run("evicted", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewPrefixed[int](NewLRU[string, int](2))
+		defer cache.Stop()
+
+		cache.Set("a:1", 1)
+		cache.Set("a:2", 2)
+		cache.Set("a:3", 3)
+
+		if got, want := cache.keys("a:"), []string{"a:2", "a:3"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+
+		ttl := NewTTL[string, int](time.Hour)
+		cache := NewPrefixed[int](ttl)
+		defer cache.Stop()
+
+		cache.Set("a:1", 1)
+		ttl.SetWithExpireAt("a:1", 1, time.Now().Add(-time.Second))
+
+		if got := scanKeys(cache, "a:"); got != nil {
+			t.Errorf("expected %q to be empty", got)
+		}
+		if got := cache.keys("a:"); got != nil {
+			t.Errorf("expected %q to be pruned", got)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+// Package ratelimit implements per-key token bucket rate limiting, with the
+// buckets stored in a TTL cache so that idle keys are forgotten automatically:
+//
+//	limiter := ratelimit.New[string](10, 20) // 10/s, bursts of 20
+//	defer limiter.Stop()
+//
+//	if !limiter.Allow(clientIP) {
+//	  http.Error(w, "too many requests", http.StatusTooManyRequests)
+//	  return
+//	}
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sethvargo/go-cache"
+)
+
+// Limiter is a set of token buckets, one per key. Each bucket holds up to burst
+// tokens and refills at rate tokens per second. It is safe for concurrent use.
+//
+// A bucket which has been idle long enough to refill completely is
+// indistinguishable from a new one, so it expires from the cache after that
+// long. Memory use is therefore bounded by the number of keys active within
+// one refill period.
+//
+// K is the key and must be a comparable.
+type Limiter[K comparable] struct {
+	// buckets are the per-key buckets.
+	buckets *cache.TTL[K, *bucket]
+
+	// rate is the number of tokens added per second and burst is the maximum
+	// number of tokens in a bucket.
+	rate  float64
+	burst int
+}
+
+// New creates a new limiter which allows rate events per second for each key,
+// with bursts of up to burst events.
+func New[K comparable](rate float64, burst int) *Limiter[K] {
+	if rate <= 0 {
+		panic("rate must be greater than 0")
+	}
+	if burst <= 0 {
+		panic("burst must be greater than 0")
+	}
+
+	// An idle bucket is full again after this long.
+	ttl := time.Duration(float64(burst) / rate * float64(time.Second))
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+
+	return &Limiter[K]{
+		buckets: cache.NewTTL[K, *bucket](ttl),
+		rate:    rate,
+		burst:   burst,
+	}
+}
+
+// Allow reports whether an event for the given key may happen now, consuming a
+// token if so.
+func (l *Limiter[K]) Allow(key K) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN reports whether n events for the given key may happen now, consuming n
+// tokens if so. If there are fewer than n tokens, no tokens are consumed. If n
+// is greater than the burst, it always returns false.
+func (l *Limiter[K]) AllowN(key K, n int) bool {
+	now := time.Now()
+	b := l.bucket(key, now)
+
+	ok := b.take(now, float64(n))
+
+	// Keep the bucket alive for a full refill period from its last use.
+	l.buckets.Touch(key)
+	return ok
+}
+
+// Tokens returns the number of tokens currently available for the given key.
+// Keys which have not been seen recently have a full bucket.
+func (l *Limiter[K]) Tokens(key K) float64 {
+	now := time.Now()
+
+	b, ok := l.buckets.Get(key)
+	if !ok {
+		return float64(l.burst)
+	}
+	return b.available(now)
+}
+
+// Len returns the number of keys with tracked buckets. Keys which have expired
+// but not yet been swept may be included.
+func (l *Limiter[K]) Len() int {
+	return l.buckets.Len()
+}
+
+// Stop clears the limiter and stops its background sweeping.
+func (l *Limiter[K]) Stop() {
+	l.buckets.Stop()
+}
+
+// bucket returns the bucket for the given key, creating a full one if the key
+// is not tracked.
+func (l *Limiter[K]) bucket(key K, now time.Time) *bucket {
+	// The loader never fails, so the error is always nil.
+	b, _ := l.buckets.Fetch(key, func() (*bucket, error) {
+		return &bucket{
+			rate:   l.rate,
+			burst:  float64(l.burst),
+			tokens: float64(l.burst),
+			last:   now,
+		}, nil
+	})
+	return b
+}
+
+// bucket is a single token bucket.
+type bucket struct {
+	// rate is the number of tokens added per second and burst is the maximum
+	// number of tokens.
+	rate  float64
+	burst float64
+
+	// tokens is the number of tokens as of last. It is guarded by lock.
+	tokens float64
+	last   time.Time
+	lock   sync.Mutex
+}
+
+// take consumes n tokens and returns true if they are available at the given
+// time.
+func (b *bucket) take(now time.Time, n float64) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill(now)
+	if b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// available returns the number of tokens available at the given time.
+func (b *bucket) available(now time.Time) float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.refill(now)
+	return b.tokens
+}
+
+// refill adds the tokens accrued since the last refill. It does not lock.
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
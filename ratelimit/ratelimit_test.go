@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("panic_on_invalid_rate", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "rate must be greater than 0"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		New[string](0, 1)
+		t.Errorf("did not panic")
+	})
+
+	t.Run("panic_on_invalid_burst", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "burst must be greater than 0"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		New[string](1, 0)
+		t.Errorf("did not panic")
+	})
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("burst", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := New[string](0.001, 3)
+		defer limiter.Stop()
+
+		for i := 0; i < 3; i++ {
+			if !limiter.Allow("foo") {
+				t.Errorf("expected attempt %d to be allowed", i)
+			}
+		}
+		if limiter.Allow("foo") {
+			t.Errorf("expected attempt to be limited")
+		}
+
+		// Keys are limited independently.
+		if !limiter.Allow("bar") {
+			t.Errorf("expected bar to be allowed")
+		}
+	})
+
+	t.Run("refills", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := New[string](100, 1)
+		defer limiter.Stop()
+
+		if !limiter.Allow("foo") {
+			t.Fatalf("expected first attempt to be allowed")
+		}
+		if limiter.Allow("foo") {
+			t.Fatalf("expected second attempt to be limited")
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		if !limiter.Allow("foo") {
+			t.Errorf("expected attempt after refill to be allowed")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := New[string](0.001, 10)
+		defer limiter.Stop()
+
+		var allowed int32
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					if limiter.Allow("foo") {
+						atomic.AddInt32(&allowed, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&allowed), int32(10); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestLimiter_AllowN(t *testing.T) {
+	t.Parallel()
+
+	limiter := New[string](0.001, 5)
+	defer limiter.Stop()
+
+	if limiter.AllowN("foo", 6) {
+		t.Errorf("expected more than the burst to be limited")
+	}
+	if !limiter.AllowN("foo", 4) {
+		t.Errorf("expected 4 to be allowed")
+	}
+	if limiter.AllowN("foo", 2) {
+		t.Errorf("expected 2 to be limited")
+	}
+	if got, want := limiter.Tokens("foo"), 1.0; got < want || got > want+0.01 {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+}
+
+func TestLimiter_expires(t *testing.T) {
+	t.Parallel()
+
+	// A single token refills after 10ms, so idle buckets expire quickly.
+	limiter := New[string](100, 1)
+	defer limiter.Stop()
+
+	limiter.Allow("foo")
+	if got, want := limiter.Len(), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for limiter.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for bucket to expire")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got, want := limiter.Tokens("foo"), 1.0; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+}
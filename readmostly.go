@@ -0,0 +1,356 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var (
+	_ Cache[string, string] = (*ReadMostly[string, string])(nil)
+	_ Deleter[string]       = (*ReadMostly[string, string])(nil)
+)
+
+// ReadMostly implements a cache optimized, like sync.Map, for entries which are
+// written rarely and read very often, such as configuration or feature flags.
+// Reads of existing keys, and writes which replace the value of an existing
+// key, take no lock at all. New keys are written to a separate dirty map under
+// a lock, which is promoted to replace the lock-free map once enough reads have
+// missed it. As a result, a cache whose keys change often is slower than a Sync
+// cache.
+//
+// If the cache has a TTL, entries are not returned past their expiration.
+// Expired entries are dropped when the dirty map is next built from the
+// lock-free map, which happens the first time a new key is added after a
+// promotion.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type ReadMostly[K comparable, V any] struct {
+	// read holds the *readMostlyMap which is read without locking.
+	read atomic.Value
+
+	// dirty holds the entries in read which have not been deleted, along with
+	// any new entries. It is nil if read is up to date. It is guarded by lock,
+	// along with misses, the number of lookups which missed read since dirty was
+	// last promoted.
+	dirty  map[K]*readMostlyEntry[V]
+	misses int
+	lock   sync.Mutex
+
+	// ttl is the lifetime of each entry, or 0 if entries do not expire.
+	ttl time.Duration
+
+	// stopped indicates whether the cache is stopped.
+	stopped uint32
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// readMostlyMap is the read-only map of a read-mostly cache. If amended is
+// true, the dirty map holds keys which are not in m.
+type readMostlyMap[K comparable, V any] struct {
+	m       map[K]*readMostlyEntry[V]
+	amended bool
+}
+
+// readMostlyEntry is a slot for an entry. It holds a *readMostlyValue, which is
+// replaced atomically, so the slot can be updated without changing the map.
+type readMostlyEntry[V any] struct {
+	p atomic.Value
+}
+
+// readMostlyValue is a value and its expiration. If deleted is true, the entry
+// has been deleted and its slot is only reused under the lock.
+type readMostlyValue[V any] struct {
+	value     V
+	expiresAt int64
+	deleted   bool
+}
+
+// NewReadMostly creates a new read-mostly cache in which entries expire after
+// the given TTL. If the TTL is 0, entries do not expire.
+func NewReadMostly[K comparable, V any](ttl time.Duration) *ReadMostly[K, V] {
+	if ttl < 0 {
+		panic("ttl must not be negative")
+	}
+
+	c := &ReadMostly[K, V]{
+		ttl: ttl,
+	}
+	c.read.Store(&readMostlyMap[K, V]{})
+	return c
+}
+
+// Get fetches the cache item at the given key. If the value exists, it is
+// returned. If the value does not exist, it returns the zero value for the
+// object and the second parameter will be false.
+func (c *ReadMostly[K, V]) Get(key K) (V, bool) {
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	read := c.loadRead()
+	e, ok := read.m[key]
+	if !ok && read.amended {
+		c.lock.Lock()
+		// The dirty map may have been promoted while waiting for the lock.
+		read = c.loadRead()
+		e, ok = read.m[key]
+		if !ok && read.amended {
+			e, ok = c.dirty[key]
+			c.missLocked()
+		}
+		c.lock.Unlock()
+	}
+
+	if !ok {
+		var zeroV V
+		return zeroV, false
+	}
+	return c.live(e.load())
+}
+
+// Set inserts the value in the cache. If an entry already exists at the given
+// key, it is overwritten. If an entry does not exist, a new entry is created.
+func (c *ReadMostly[K, V]) Set(key K, val V) {
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	v := &readMostlyValue[V]{value: val}
+	if c.ttl > 0 {
+		v.expiresAt = nanotime() + int64(c.ttl)
+	}
+
+	// An entry which has not been deleted is in both maps, so it can be replaced
+	// without the lock.
+	if e, ok := c.loadRead().m[key]; ok && e.tryStore(v) {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	read := c.loadRead()
+	if e, ok := read.m[key]; ok {
+		// The entry was deleted, and may have been left out of the dirty map.
+		e.store(v)
+		if c.dirty != nil {
+			c.dirty[key] = e
+		}
+		return
+	}
+	if e, ok := c.dirty[key]; ok {
+		e.store(v)
+		return
+	}
+
+	if c.dirty == nil {
+		c.buildDirty(read)
+		c.read.Store(&readMostlyMap[K, V]{m: read.m, amended: true})
+	}
+	e := &readMostlyEntry[V]{}
+	e.store(v)
+	c.dirty[key] = e
+}
+
+// Delete removes the entry at the given key from the cache. It returns false if
+// there is no entry at the given key.
+func (c *ReadMostly[K, V]) Delete(key K) bool {
+	if c.isStopped() {
+		panic(ErrStopped)
+	}
+
+	read := c.loadRead()
+	e, ok := read.m[key]
+	if !ok && read.amended {
+		c.lock.Lock()
+		read = c.loadRead()
+		e, ok = read.m[key]
+		if !ok && read.amended {
+			e, ok = c.dirty[key]
+			delete(c.dirty, key)
+			c.missLocked()
+		}
+		c.lock.Unlock()
+	}
+	if !ok {
+		return false
+	}
+
+	for {
+		old := e.load()
+		if old.deleted {
+			return false
+		}
+		if e.p.CompareAndSwap(old, &readMostlyValue[V]{deleted: true}) {
+			_, live := c.live(old)
+			return live
+		}
+	}
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. Concurrent calls to Fetch for the same key share a single
+// invocation.
+func (c *ReadMostly[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+
+	return c.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := c.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		c.Set(key, v)
+		return v, nil
+	})
+}
+
+// Len returns the number of unexpired entries in the cache. It promotes the
+// dirty map and counts the entries, so it takes time proportional to the size
+// of the cache.
+func (c *ReadMostly[K, V]) Len() int {
+	read := c.loadRead()
+	if read.amended {
+		c.lock.Lock()
+		read = c.loadRead()
+		if read.amended {
+			read = c.promoteLocked()
+		}
+		c.lock.Unlock()
+	}
+
+	var n int
+	for _, e := range read.m {
+		if _, ok := c.live(e.load()); ok {
+			n++
+		}
+	}
+	return n
+}
+
+// Stop clears the cache and prevents new entries from being added and
+// retrieved.
+func (c *ReadMostly[K, V]) Stop() {
+	c.loads.close()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&c.stopped, 0, 1) {
+		return
+	}
+
+	c.read.Store(&readMostlyMap[K, V]{})
+	c.dirty = nil
+}
+
+// loadRead returns the lock-free map.
+func (c *ReadMostly[K, V]) loadRead() *readMostlyMap[K, V] {
+	return c.read.Load().(*readMostlyMap[K, V])
+}
+
+// live returns the value if it has not been deleted or expired.
+func (c *ReadMostly[K, V]) live(v *readMostlyValue[V]) (V, bool) {
+	if v.deleted || (v.expiresAt != 0 && v.expiresAt < nanotime()) {
+		var zeroV V
+		return zeroV, false
+	}
+	return v.value, true
+}
+
+// missLocked records a lookup which missed the lock-free map, promoting the
+// dirty map once the misses have cost as much as copying it. It must be called
+// while holding the lock.
+func (c *ReadMostly[K, V]) missLocked() {
+	c.misses++
+	if c.misses < len(c.dirty) {
+		return
+	}
+	c.promoteLocked()
+}
+
+// promoteLocked replaces the lock-free map with the dirty map and returns it.
+// It must be called while holding the lock.
+func (c *ReadMostly[K, V]) promoteLocked() *readMostlyMap[K, V] {
+	read := &readMostlyMap[K, V]{m: c.dirty}
+	c.read.Store(read)
+	c.dirty = nil
+	c.misses = 0
+	return read
+}
+
+// buildDirty creates the dirty map from the entries in the lock-free map which
+// have not been deleted, deleting expired entries along the way. It must be
+// called while holding the lock.
+func (c *ReadMostly[K, V]) buildDirty(read *readMostlyMap[K, V]) {
+	c.dirty = make(map[K]*readMostlyEntry[V], len(read.m)+1)
+	for k, e := range read.m {
+		if !c.expunge(e) {
+			c.dirty[k] = e
+		}
+	}
+}
+
+// expunge marks the entry as deleted if it has expired, and returns whether it
+// is deleted. Once deleted, an entry is only reused under the lock, so it can
+// safely be left out of the dirty map.
+func (c *ReadMostly[K, V]) expunge(e *readMostlyEntry[V]) bool {
+	for {
+		old := e.load()
+		if old.deleted {
+			return true
+		}
+		if _, ok := c.live(old); ok {
+			return false
+		}
+		if e.p.CompareAndSwap(old, &readMostlyValue[V]{deleted: true}) {
+			return true
+		}
+	}
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (c *ReadMostly[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&c.stopped) == 1
+}
+
+// load returns the entry's current value.
+func (e *readMostlyEntry[V]) load() *readMostlyValue[V] {
+	return e.p.Load().(*readMostlyValue[V])
+}
+
+// store replaces the entry's value.
+func (e *readMostlyEntry[V]) store(v *readMostlyValue[V]) {
+	e.p.Store(v)
+}
+
+// tryStore replaces the entry's value if it has not been deleted.
+func (e *readMostlyEntry[V]) tryStore(v *readMostlyValue[V]) bool {
+	for {
+		old := e.load()
+		if old.deleted {
+			return false
+		}
+		if e.p.CompareAndSwap(old, v) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadMostly(t *testing.T) {
+	t.Parallel()
+
+	cache := NewReadMostly[string, int](0)
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	cache.Set("bar", 3)
+	cache.Set("foo", 10)
+
+	if v, _ := cache.Get("foo"); v != 10 {
+		t.Errorf("expected %d to be %d", v, 10)
+	}
+	if v, ok := cache.Get("baz"); ok {
+		t.Errorf("expected not found, got %d", v)
+	}
+	if got, want := cache.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	if !cache.Delete("foo") {
+		t.Errorf("expected foo to be deleted")
+	}
+	if cache.Delete("foo") {
+		t.Errorf("expected second delete to return false")
+	}
+	if _, ok := cache.Get("foo"); ok {
+		t.Errorf("expected foo to be deleted")
+	}
+
+	// A deleted key can be set again.
+	cache.Set("foo", 20)
+	if v, _ := cache.Get("foo"); v != 20 {
+		t.Errorf("expected %d to be %d", v, 20)
+	}
+	if got, want := cache.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestReadMostly_promote(t *testing.T) {
+	t.Parallel()
+
+	cache := NewReadMostly[string, int](0)
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	if _, ok := cache.loadRead().m["foo"]; ok {
+		t.Fatalf("expected foo to only be in the dirty map")
+	}
+
+	// Misses on the lock-free map promote the dirty map.
+	cache.Get("foo")
+	read := cache.loadRead()
+	if _, ok := read.m["foo"]; !ok {
+		t.Errorf("expected foo to be promoted")
+	}
+	if read.amended {
+		t.Errorf("expected read to not be amended")
+	}
+
+	// Replacing an existing value does not touch the dirty map.
+	cache.Set("foo", 6)
+	if cache.dirty != nil {
+		t.Errorf("expected dirty map to be nil")
+	}
+	if v, _ := cache.Get("foo"); v != 6 {
+		t.Errorf("expected %d to be %d", v, 6)
+	}
+}
+
+func TestReadMostly_ttl(t *testing.T) {
+	t.Parallel()
+
+	cache := NewReadMostly[string, int](10 * time.Millisecond)
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	cache.Len()
+	if _, ok := cache.Get("foo"); !ok {
+		t.Fatalf("expected foo to exist")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := cache.Get("foo"); ok {
+		t.Errorf("expected foo to be expired")
+	}
+
+	// Expired entries are dropped when the dirty map is built.
+	cache.Set("bar", 3)
+	if _, ok := cache.dirty["foo"]; ok {
+		t.Errorf("expected foo to be dropped")
+	}
+	if got, want := cache.Len(), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestReadMostly_concurrent(t *testing.T) {
+	t.Parallel()
+
+	cache := NewReadMostly[string, int](time.Minute)
+	defer cache.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		i := i
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				key := strconv.Itoa(j % 50)
+				switch (i + j) % 4 {
+				case 0:
+					cache.Set(key, j)
+				case 1:
+					cache.Delete(key)
+				default:
+					cache.Get(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for j := 0; j < 50; j++ {
+		key := strconv.Itoa(j)
+		cache.Set(key, j)
+		if v, ok := cache.Get(key); !ok || v != j {
+			t.Errorf("expected %d to be %d", v, j)
+		}
+	}
+}
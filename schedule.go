@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ensure implements.
+var (
+	_ Schedule = ScheduleFunc(nil)
+	_ Schedule = (*dailySchedule)(nil)
+	_ Schedule = (*everySchedule)(nil)
+)
+
+// Schedule determines when a recurring background operation, such as a
+// scheduled purge, runs.
+type Schedule interface {
+	// Next returns the next time the operation should run, which must be after
+	// the given time. Returning the zero time ends the schedule.
+	Next(after time.Time) time.Time
+}
+
+// ScheduleFunc is an adapter to allow the use of ordinary functions as
+// schedules.
+type ScheduleFunc func(after time.Time) time.Time
+
+// Next calls f(after).
+func (f ScheduleFunc) Next(after time.Time) time.Time {
+	return f(after)
+}
+
+// Daily returns a schedule which runs every day at the given hour and minute in
+// the given location. If loc is nil, UTC is used.
+func Daily(hour, minute int, loc *time.Location) Schedule {
+	if hour < 0 || hour > 23 {
+		panic(fmt.Sprintf("hour must be between 0 and 23, got %d", hour))
+	}
+	if minute < 0 || minute > 59 {
+		panic(fmt.Sprintf("minute must be between 0 and 59, got %d", minute))
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return &dailySchedule{
+		hour:   hour,
+		minute: minute,
+		loc:    loc,
+	}
+}
+
+// dailySchedule runs every day at a fixed wall-clock time.
+type dailySchedule struct {
+	hour, minute int
+	loc          *time.Location
+}
+
+// Next returns the first occurrence of the wall-clock time after the given time.
+func (d *dailySchedule) Next(after time.Time) time.Time {
+	after = after.In(d.loc)
+
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.hour, d.minute, 0, 0, d.loc)
+	if !next.After(after) {
+		next = time.Date(after.Year(), after.Month(), after.Day()+1, d.hour, d.minute, 0, 0, d.loc)
+	}
+	return next
+}
+
+// Every returns a schedule which runs at a fixed interval.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("interval must be greater than 0")
+	}
+	return &everySchedule{interval: interval}
+}
+
+// everySchedule runs at a fixed interval.
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next returns the given time plus the interval.
+func (e *everySchedule) Next(after time.Time) time.Time {
+	return after.Add(e.interval)
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDaily(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("test", -5*60*60)
+
+	cases := []struct {
+		name  string
+		after time.Time
+		exp   time.Time
+	}{
+		{
+			name:  "later_today",
+			after: time.Date(2020, 1, 1, 10, 0, 0, 0, loc),
+			exp:   time.Date(2020, 1, 1, 12, 30, 0, 0, loc),
+		},
+		{
+			name:  "tomorrow",
+			after: time.Date(2020, 1, 1, 13, 0, 0, 0, loc),
+			exp:   time.Date(2020, 1, 2, 12, 30, 0, 0, loc),
+		},
+		{
+			name:  "exact",
+			after: time.Date(2020, 1, 1, 12, 30, 0, 0, loc),
+			exp:   time.Date(2020, 1, 2, 12, 30, 0, 0, loc),
+		},
+		{
+			name:  "end_of_month",
+			after: time.Date(2020, 1, 31, 13, 0, 0, 0, loc),
+			exp:   time.Date(2020, 2, 1, 12, 30, 0, 0, loc),
+		},
+		{
+			name:  "other_location",
+			after: time.Date(2020, 1, 1, 16, 0, 0, 0, time.UTC),
+			exp:   time.Date(2020, 1, 1, 12, 30, 0, 0, loc),
+		},
+	}
+
+	schedule := Daily(12, 30, loc)
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, want := schedule.Next(tc.after), tc.exp; !got.Equal(want) {
+				t.Errorf("expected %s to be %s", got, want)
+			}
+		})
+	}
+
+	t.Run("default_utc", func(t *testing.T) {
+		t.Parallel()
+
+		after := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		if got, want := Daily(1, 0, nil).Next(after), after.Add(time.Hour); !got.Equal(want) {
+			t.Errorf("expected %s to be %s", got, want)
+		}
+	})
+
+	t.Run("panic_on_invalid_hour", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "hour must be between 0 and 23, got 24"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		Daily(24, 0, nil)
+		t.Errorf("did not panic")
+	})
+}
+
+func TestEvery(t *testing.T) {
+	t.Parallel()
+
+	after := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := Every(time.Minute).Next(after), after.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("expected %s to be %s", got, want)
+	}
+}
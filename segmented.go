@@ -0,0 +1,305 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var (
+	_ Cache[string, string]         = (*Segmented[string, string])(nil)
+	_ EntryGetter[string, string]   = (*Segmented[string, string])(nil)
+	_ EntryLister[string, string]   = (*Segmented[string, string])(nil)
+	_ EvictNotifier[string, string] = (*Segmented[string, string])(nil)
+)
+
+// Segmented implements a cache split into two LRU segments with independent
+// capacities. New entries go into the cold segment, and are only promoted to
+// the hot segment once they have been retrieved a configurable number of times
+// while cold. When the hot segment is full, its least recently used entry is
+// demoted back to the cold segment rather than evicted, and entries are only
+// evicted from the cold segment.
+//
+// Since a bulk scan only touches each key once, it churns through the cold
+// segment without displacing the working set in the hot segment.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Segmented[K comparable, V any] struct {
+	// cold and hot are the segments. They are only accessed through their
+	// internal methods, so their own locks are never contended.
+	cold, hot *LRU[K, V]
+
+	// threshold is the number of hits an entry needs while cold to be promoted.
+	threshold uint64
+
+	// freq is the number of times each key has been retrieved while in the
+	// cache, across both segments. Keys which have never been retrieved are
+	// absent.
+	freq map[K]uint64
+
+	// stopped indicates whether the cache is stopped.
+	stopped uint32
+
+	// lock is the internal lock for concurrency.
+	lock waitMutex
+
+	// onEvict are the functions to invoke when an entry is evicted.
+	onEvict []func(K, V)
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// NewSegmented creates a new segmented cache with the given cold and hot
+// segment capacities. Entries are promoted to the hot segment once they have
+// been retrieved threshold times while cold.
+func NewSegmented[K comparable, V any](coldCapacity, hotCapacity int64, threshold int) *Segmented[K, V] {
+	if coldCapacity <= 0 {
+		panic("cold capacity must be greater than 0")
+	}
+	if hotCapacity <= 0 {
+		panic("hot capacity must be greater than 0")
+	}
+	if threshold <= 0 {
+		panic("threshold must be greater than 0")
+	}
+
+	return &Segmented[K, V]{
+		cold:      NewLRU[K, V](coldCapacity),
+		hot:       NewLRU[K, V](hotCapacity),
+		threshold: uint64(threshold),
+		freq:      make(map[K]uint64),
+	}
+}
+
+// Get fetches the cache item at the given key. If the value exists, it is
+// returned and, if it has reached the threshold, promoted to the hot segment.
+// If the value does not exist, it returns the zero value for the object and the
+// second parameter will be false.
+func (s *Segmented[K, V]) Get(key K) (V, bool) {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.isStopped() {
+		panic(ErrStopped)
+	}
+
+	var v V
+	var ok bool
+	v, ok, evicted = s.get(key)
+	return v, ok
+}
+
+// get is the internal implementation of Get. It returns the entry which was
+// evicted by a promotion, if any. It does not lock.
+func (s *Segmented[K, V]) get(key K) (V, bool, victim[K, V]) {
+	if v, ok := s.hot.get(key); ok {
+		s.freq[key]++
+		return v, true, victim[K, V]{}
+	}
+
+	v, ok := s.cold.get(key)
+	if !ok {
+		return v, false, victim[K, V]{}
+	}
+	s.freq[key]++
+
+	var evicted victim[K, V]
+	if node, _ := s.cold.node(key); atomic.LoadUint64(&node.meta.hits) >= s.threshold {
+		evicted = s.promote(key)
+	}
+	return v, true, evicted
+}
+
+// Set inserts the value in the cache. If an entry already exists at the given
+// key, it is overwritten in whichever segment holds it. If an entry does not
+// exist, a new entry is created in the cold segment (which might trigger
+// eviction of an older entry).
+func (s *Segmented[K, V]) Set(key K, val V) {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.isStopped() {
+		panic(ErrStopped)
+	}
+
+	if _, ok := s.hot.remove(key); ok {
+		s.hot.set(key, val)
+		return
+	}
+	evicted = s.setCold(key, val)
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. The FetchFunc runs without holding the cache's lock, and
+// concurrent calls to Fetch for the same key share a single invocation.
+func (s *Segmented[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := s.Get(key); ok {
+		return v, nil
+	}
+
+	return s.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := s.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		s.Set(key, v)
+		return v, nil
+	})
+}
+
+// GetEntry fetches the cache item at the given key along with its metadata. It
+// counts as an access, like Get, and may promote the entry. Hits is the number
+// of times the value has been retrieved in its current segment, and Frequency
+// is the number of times across both segments. If the value does not exist,
+// the second return value is false.
+func (s *Segmented[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	var evicted victim[K, V]
+	defer evicted.notify()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.isStopped() {
+		panic(ErrStopped)
+	}
+
+	var ok bool
+	if _, ok, evicted = s.get(key); !ok {
+		return Entry[K, V]{}, false
+	}
+
+	node, ok := s.hot.node(key)
+	if !ok {
+		node, _ = s.cold.node(key)
+	}
+	entry := Entry[K, V]{
+		Key:       key,
+		Value:     node.value,
+		Frequency: s.freq[key],
+	}
+	fillEntry(&entry, &node.meta)
+	return entry, true
+}
+
+// Entries returns a snapshot of the entries in the cache, including their
+// metadata, in eviction order: the cold segment from least to most recently
+// used, followed by the hot segment from least to most recently used.
+func (s *Segmented[K, V]) Entries() []Entry[K, V] {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.isStopped() {
+		panic(ErrStopped)
+	}
+
+	entries := append(s.cold.Entries(), s.hot.Entries()...)
+	for i := range entries {
+		entries[i].Frequency = s.freq[entries[i].Key]
+	}
+	return entries
+}
+
+// Lens returns the number of entries in the cold and hot segments.
+func (s *Segmented[K, V]) Lens() (cold, hot int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.cold.cache), len(s.hot.cache)
+}
+
+// Len returns the number of entries in the cache.
+func (s *Segmented[K, V]) Len() int {
+	cold, hot := s.Lens()
+	return cold + hot
+}
+
+// OnEvict registers a function which is invoked with each entry that is
+// evicted from the cold segment to make room for a new or demoted entry.
+// Functions are invoked in the order they were registered, after the cache's
+// lock is released, so they may call back into the cache. Entries removed with
+// Stop are not reported.
+func (s *Segmented[K, V]) OnEvict(fn func(K, V)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onEvict = appendHook(s.onEvict, fn)
+}
+
+// LockWait returns the total time operations have spent waiting to acquire the
+// cache's internal lock. A steadily increasing value indicates contention.
+func (s *Segmented[K, V]) LockWait() time.Duration {
+	return s.lock.waitTime()
+}
+
+// Stop clears the cache and prevents new entries from being added and
+// retrieved.
+func (s *Segmented[K, V]) Stop() {
+	s.loads.close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&s.stopped, 0, 1) {
+		return
+	}
+	s.cold.Stop()
+	s.hot.Stop()
+	s.freq = nil
+}
+
+// promote moves the entry at the given key from the cold segment to the hot
+// segment, demoting the least recently used hot entry if the hot segment is
+// full. It returns the entry evicted from the cold segment to make room for
+// the demoted entry, if any. It does not lock.
+func (s *Segmented[K, V]) promote(key K) victim[K, V] {
+	val, _ := s.cold.remove(key)
+
+	var evicted victim[K, V]
+	if int64(len(s.hot.cache)) >= s.hot.capacity {
+		if k, v, _, ok := s.hot.evict(); ok {
+			evicted = s.setCold(k, v)
+		}
+	}
+
+	s.hot.set(key, val)
+	return evicted
+}
+
+// setCold inserts the value in the cold segment, evicting its least recently
+// used entry if it is full. It returns the evicted entry, if any. It does not
+// lock.
+func (s *Segmented[K, V]) setCold(key K, val V) victim[K, V] {
+	// Remove any existing entry first, so overwriting does not evict.
+	s.cold.remove(key)
+
+	var evicted victim[K, V]
+	if int64(len(s.cold.cache)) >= s.cold.capacity {
+		k, v, _, ok := s.cold.evict()
+		if ok {
+			delete(s.freq, k)
+		}
+		evicted = newVictim(k, v, 0, ok, s.onEvict, nil)
+	}
+
+	s.cold.set(key, val)
+	return evicted
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (s *Segmented[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&s.stopped) == 1
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNewSegmented(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSegmented[string, int](2, 3, 2)
+		defer cache.Stop()
+
+		if got, want := cache.cold.capacity, int64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.hot.capacity, int64(3); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.threshold, uint64(2); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("panic_on_invalid_threshold", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "threshold must be greater than 0"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		NewSegmented[string, int](2, 3, 0)
+		t.Errorf("did not panic")
+	})
+}
+
+func TestSegmented_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("promotes_at_threshold", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSegmented[string, int](2, 2, 2)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+
+		cache.Get("foo")
+		if cold, hot := cache.Lens(); cold != 1 || hot != 0 {
+			t.Errorf("expected %d, %d to be 1, 0", cold, hot)
+		}
+
+		if v, ok := cache.Get("foo"); !ok || v != 1 {
+			t.Errorf("expected %d, %t to be 1, true", v, ok)
+		}
+		if cold, hot := cache.Lens(); cold != 0 || hot != 1 {
+			t.Errorf("expected %d, %d to be 0, 1", cold, hot)
+		}
+		if v, ok := cache.Get("foo"); !ok || v != 1 {
+			t.Errorf("expected %d, %t to be 1, true", v, ok)
+		}
+	})
+
+	t.Run("demotes", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSegmented[string, int](2, 1, 1)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Get("foo")
+		cache.Set("bar", 2)
+		cache.Get("bar")
+
+		// bar was promoted, so foo was demoted to the cold segment.
+		if _, ok := cache.hot.cache["bar"]; !ok {
+			t.Errorf("expected bar to be hot")
+		}
+		if _, ok := cache.cold.cache["foo"]; !ok {
+			t.Errorf("expected foo to be cold")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSegmented[string, int](2, 2, 1)
+		defer cache.Stop()
+
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to be missing")
+		}
+	})
+}
+
+func TestSegmented_Set(t *testing.T) {
+	t.Parallel()
+
+	t.Run("scan_resistant", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSegmented[int, int](2, 2, 1)
+		defer cache.Stop()
+
+		var evicted []int
+		cache.OnEvict(func(k, v int) {
+			evicted = append(evicted, k)
+		})
+
+		cache.Set(1, 1)
+		cache.Get(1)
+		cache.Set(2, 2)
+		cache.Get(2)
+
+		// A scan of keys which are only seen once does not displace the hot
+		// entries.
+		for i := 100; i < 110; i++ {
+			cache.Set(i, i)
+		}
+
+		for _, k := range []int{1, 2} {
+			if _, ok := cache.Get(k); !ok {
+				t.Errorf("expected %d to remain", k)
+			}
+		}
+		if got, want := len(evicted), 8; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("overwrites_hot", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSegmented[string, int](1, 1, 1)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Get("foo")
+		cache.Set("foo", 2)
+
+		if cold, hot := cache.Lens(); cold != 0 || hot != 1 {
+			t.Errorf("expected %d, %d to be 0, 1", cold, hot)
+		}
+		if v, _ := cache.Get("foo"); v != 2 {
+			t.Errorf("expected %d to be %d", v, 2)
+		}
+	})
+
+	t.Run("overwrites_cold", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSegmented[string, int](2, 1, 5)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Set("bar", 2)
+		cache.Set("foo", 3)
+
+		if got, want := cache.Len(), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestSegmented_Fetch(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSegmented[string, int](2, 2, 1)
+	defer cache.Stop()
+
+	v, err := cache.Fetch("foo", func() (int, error) {
+		return 5, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, 5; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	if _, err := cache.Fetch("bar", func() (int, error) {
+		return 0, fmt.Errorf("error")
+	}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestSegmented_Entries(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSegmented[string, int](2, 2, 1)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Get("foo")
+	cache.Set("bar", 2)
+	cache.Set("baz", 3)
+
+	var keys []string
+	for _, entry := range cache.Entries() {
+		keys = append(keys, entry.Key)
+	}
+	if got, want := keys, []string{"bar", "baz", "foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+}
+
+func TestSegmented_Frequency(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSegmented[string, int](2, 2, 2)
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	cache.Get("foo")
+	cache.Get("foo") // promoted
+
+	entry, ok := cache.GetEntry("foo")
+	if !ok {
+		t.Fatal("expected foo to exist")
+	}
+	if got, want := entry.Frequency, uint64(3); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := entry.Hits, uint64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Overwriting the value keeps the frequency.
+	cache.Set("foo", 6)
+	cache.Set("bar", 3)
+	for _, entry := range cache.Entries() {
+		var want uint64
+		if entry.Key == "foo" {
+			want = 3
+		}
+		if got := entry.Frequency; got != want {
+			t.Errorf("expected %s frequency %d to be %d", entry.Key, got, want)
+		}
+	}
+
+	// Evicted keys are forgotten.
+	cache.Get("bar")
+	cache.Set("baz", 1)
+	cache.Set("qux", 1)
+	if _, ok := cache.freq["bar"]; ok {
+		t.Errorf("expected bar frequency to be forgotten")
+	}
+}
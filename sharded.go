@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Sharded[string, string])(nil)
+
+// Sharded partitions the key space across multiple independent caches, chosen
+// by a hash of the key. Since each shard has its own storage and lock,
+// operations on keys in different shards do not contend with each other.
+//
+// Eviction happens per-shard, so the cache as a whole only approximates the
+// eviction policy of the underlying shards.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Sharded[K comparable, V any] struct {
+	// shards are the underlying caches.
+	shards []Cache[K, V]
+
+	// counters are the per-shard hit and miss counters, indexed the same as
+	// shards.
+	counters []shardCounters
+
+	// seed seeds the hash which chooses a key's shard.
+	seed hashSeed
+}
+
+// shardCounters are the hit and miss counters for a single shard. They are
+// updated atomically.
+type shardCounters struct {
+	hits   uint64
+	misses uint64
+}
+
+// ShardStats are statistics about a single shard of a sharded cache. Comparing
+// the statistics across shards can reveal skew in how keys hash to shards.
+type ShardStats struct {
+	// Len is the number of entries in the shard. It is 0 if the shard does not
+	// report its length.
+	Len int
+
+	// Hits and Misses are the number of Get and Fetch calls which found or did
+	// not find a cached value, respectively.
+	Hits   uint64
+	Misses uint64
+
+	// LockWait is the total time operations on the shard have spent waiting to
+	// acquire the shard's lock. It is 0 if the shard does not report it.
+	LockWait time.Duration
+}
+
+// HitRatio returns the ratio of hits to total lookups, or 0 if there have been
+// no lookups.
+func (s ShardStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Delta returns the statistics accumulated since prev, which must be an earlier
+// snapshot of the same shard, so per-interval rates can be computed from
+// periodic snapshots. Len is not a counter, so it is the length as of s.
+func (s ShardStats) Delta(prev ShardStats) ShardStats {
+	return ShardStats{
+		Len:      s.Len,
+		Hits:     s.Hits - prev.Hits,
+		Misses:   s.Misses - prev.Misses,
+		LockWait: s.LockWait - prev.LockWait,
+	}
+}
+
+// lener is implemented by caches which can report their length.
+type lener interface {
+	Len() int
+}
+
+// lockWaiter is implemented by caches which can report their lock wait time.
+type lockWaiter interface {
+	LockWait() time.Duration
+}
+
+// NewSharded creates a new sharded cache with the given number of shards. The
+// newFn function is called once per shard to construct the underlying cache.
+func NewSharded[K comparable, V any](shards int, newFn func() Cache[K, V]) *Sharded[K, V] {
+	if shards <= 0 {
+		panic("shards must be greater than 0")
+	}
+	if newFn == nil {
+		panic("newFn cannot be nil")
+	}
+
+	c := &Sharded[K, V]{
+		shards:   make([]Cache[K, V], shards),
+		counters: make([]shardCounters, shards),
+		seed:     newHashSeed(),
+	}
+	for i := range c.shards {
+		c.shards[i] = newFn()
+	}
+	return c
+}
+
+// NewShardedLRU creates a new sharded cache in which each shard is an LRU cache
+// with the given capacity and options. Each shard has its own storage and lock,
+// so throughput scales with the number of shards, but the least recently used
+// entry is only evicted from among the entries in the same shard. The total
+// capacity is shards * capacityPerShard.
+func NewShardedLRU[K comparable, V any](shards int, capacityPerShard int64, opts ...LRUOption[K, V]) *Sharded[K, V] {
+	if capacityPerShard <= 0 {
+		panic("capacity must be greater than 0")
+	}
+
+	return NewSharded(shards, func() Cache[K, V] {
+		return NewLRU[K, V](capacityPerShard, opts...)
+	})
+}
+
+// Get fetches the cache item at the given key from the shard that owns it.
+func (s *Sharded[K, V]) Get(key K) (V, bool) {
+	i := s.shardIndex(key)
+
+	v, ok := s.shards[i].Get(key)
+	if ok {
+		atomic.AddUint64(&s.counters[i].hits, 1)
+	} else {
+		atomic.AddUint64(&s.counters[i].misses, 1)
+	}
+	return v, ok
+}
+
+// Set inserts the value into the shard that owns the given key.
+func (s *Sharded[K, V]) Set(key K, val V) {
+	s.shardFor(key).Set(key, val)
+}
+
+// Insert inserts the value into the shard that owns the given key and returns
+// the entry which was evicted from that shard to make room for it. If no entry
+// was evicted, the third return value is false. It panics if the shard does not
+// implement Inserter.
+func (s *Sharded[K, V]) Insert(key K, val V) (K, V, bool) {
+	ins, ok := s.shardFor(key).(Inserter[K, V])
+	if !ok {
+		panic("cache does not support insert")
+	}
+	return ins.Insert(key, val)
+}
+
+// SetIfVersion inserts the value in the shard that owns the given key only if
+// the version of the entry at the key is the expected version. It panics if the
+// shard does not implement VersionSetter.
+func (s *Sharded[K, V]) SetIfVersion(key K, val V, version uint64) bool {
+	vs, ok := s.shardFor(key).(VersionSetter[K, V])
+	if !ok {
+		panic("cache does not support versions")
+	}
+	return vs.SetIfVersion(key, val, version)
+}
+
+// Fetch retrieves the cached value from the shard that owns the given key. If
+// the value does not exist, the FetchFunc is called and the result is stored.
+// If the value does exist, the FetchFunc is not invoked.
+func (s *Sharded[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	i := s.shardIndex(key)
+
+	missed := false
+	v, err := s.shards[i].Fetch(key, func() (V, error) {
+		missed = true
+		return fn()
+	})
+	if missed {
+		atomic.AddUint64(&s.counters[i].misses, 1)
+	} else {
+		atomic.AddUint64(&s.counters[i].hits, 1)
+	}
+	return v, err
+}
+
+// ShardStats returns statistics for each shard, in shard order.
+func (s *Sharded[K, V]) ShardStats() []ShardStats {
+	stats := make([]ShardStats, len(s.shards))
+	for i, shard := range s.shards {
+		stats[i].Hits = atomic.LoadUint64(&s.counters[i].hits)
+		stats[i].Misses = atomic.LoadUint64(&s.counters[i].misses)
+
+		if l, ok := shard.(lener); ok {
+			stats[i].Len = l.Len()
+		}
+		if w, ok := shard.(lockWaiter); ok {
+			stats[i].LockWait = w.LockWait()
+		}
+	}
+	return stats
+}
+
+// Stop stops all shards.
+func (s *Sharded[K, V]) Stop() {
+	for _, shard := range s.shards {
+		shard.Stop()
+	}
+}
+
+// GetEntry fetches the cache item at the given key along with its metadata
+// from the shard that owns it. If the shard does not implement EntryGetter,
+// only the key and value are populated.
+func (s *Sharded[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	i := s.shardIndex(key)
+
+	getter, ok := s.shards[i].(EntryGetter[K, V])
+	if !ok {
+		v, ok := s.Get(key)
+		return Entry[K, V]{Key: key, Value: v}, ok
+	}
+
+	entry, ok := getter.GetEntry(key)
+	if ok {
+		atomic.AddUint64(&s.counters[i].hits, 1)
+	} else {
+		atomic.AddUint64(&s.counters[i].misses, 1)
+	}
+	return entry, ok
+}
+
+// Entries returns a snapshot of the entries in all shards. Shards which do not
+// implement EntryLister are skipped. The order of entries is only meaningful
+// within a single shard.
+func (s *Sharded[K, V]) Entries() []Entry[K, V] {
+	var entries []Entry[K, V]
+	for _, shard := range s.shards {
+		if lister, ok := shard.(EntryLister[K, V]); ok {
+			entries = append(entries, lister.Entries()...)
+		}
+	}
+	return entries
+}
+
+// shardFor returns the shard that owns the given key.
+func (s *Sharded[K, V]) shardFor(key K) Cache[K, V] {
+	return s.shards[s.shardIndex(key)]
+}
+
+// shardIndex returns the index of the shard that owns the given key.
+func (s *Sharded[K, V]) shardIndex(key K) int {
+	return int(hashKey(s.seed, key) % uint64(len(s.shards)))
+}
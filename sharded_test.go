@@ -0,0 +1,262 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewSharded(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSharded(4, func() Cache[string, int] {
+			return NewLRU[string, int](10)
+		})
+		defer cache.Stop()
+
+		if got, want := len(cache.shards), 4; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("panic_on_negative", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "shards must be greater than 0"; got != want {
+				t.Errorf("expected %q to contain %q", got, want)
+			}
+		}()
+
+		NewSharded(0, func() Cache[string, int] {
+			return NewLRU[string, int](10)
+		})
+		t.Errorf("did not panic")
+	})
+}
+
+func TestNewShardedLRU(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewShardedLRU[int, int](4, 2, WithBufferedRecency[int, int]())
+		defer cache.Stop()
+
+		if got, want := len(cache.shards), 4; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		for i, shard := range cache.shards {
+			lru, ok := shard.(*LRU[int, int])
+			if !ok {
+				t.Fatalf("expected shard %d to be an LRU, got %T", i, shard)
+			}
+			if got, want := lru.capacity, int64(2); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if !lru.buffered {
+				t.Errorf("expected shard %d to be buffered", i)
+			}
+		}
+
+		for i := 0; i < 100; i++ {
+			cache.Set(i, i)
+		}
+
+		total := 0
+		for _, stats := range cache.ShardStats() {
+			if stats.Len > 2 {
+				t.Errorf("expected %d to be at most %d", stats.Len, 2)
+			}
+			total += stats.Len
+		}
+		if got, want := total, 8; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("panic_on_negative", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "capacity must be greater than 0"; got != want {
+				t.Errorf("expected %q to contain %q", got, want)
+			}
+		}()
+
+		NewShardedLRU[string, int](4, 0)
+		t.Errorf("did not panic")
+	})
+}
+
+func TestSharded_Get(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(8, func() Cache[int, int] {
+		return NewLRU[int, int](100)
+	})
+	defer cache.Stop()
+
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i*2)
+	}
+	for i := 0; i < 100; i++ {
+		if v, _ := cache.Get(i); v != i*2 {
+			t.Errorf("expected %#v, got %#v", i*2, v)
+		}
+	}
+
+	// Ensure keys are actually distributed across shards.
+	for i, shard := range cache.shards {
+		if got := len(shard.(*LRU[int, int]).cache); got == 0 {
+			t.Errorf("expected shard %d to have entries", i)
+		}
+	}
+}
+
+func TestSharded_Fetch(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(4, func() Cache[string, string] {
+		return NewFIFO[string, string](10)
+	})
+	defer cache.Stop()
+
+	v, err := cache.Fetch("foo", func() (string, error) {
+		return "bar", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v, "bar"; got != want {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	cache.Fetch("foo", func() (string, error) {
+		t.Errorf("function was called")
+		return "", nil
+	})
+}
+
+func TestSharded_ShardStats(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSharded(4, func() Cache[int, int] {
+		return NewLRU[int, int](100)
+	})
+	defer cache.Stop()
+
+	for i := 0; i < 100; i++ {
+		cache.Set(i, i)
+	}
+	for i := 0; i < 200; i++ {
+		cache.Get(i)
+	}
+	cache.Fetch(500, func() (int, error) { return 1, nil })
+	cache.Fetch(500, func() (int, error) { return 1, nil })
+
+	stats := cache.ShardStats()
+	if got, want := len(stats), 4; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+
+	var total ShardStats
+	for _, s := range stats {
+		total.Len += s.Len
+		total.Hits += s.Hits
+		total.Misses += s.Misses
+	}
+
+	if got, want := total.Len, 101; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := total.Hits, uint64(101); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := total.Misses, uint64(101); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := total.HitRatio(), 0.5; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+}
+
+func TestSharded_GetEntry(t *testing.T) {
+	t.Parallel()
+
+	cache := NewShardedLRU[string, int](4, 10)
+	defer cache.Stop()
+
+	if _, ok := cache.GetEntry("foo"); ok {
+		t.Errorf("expected foo to be missing")
+	}
+
+	cache.Set("foo", 5)
+	cache.Get("foo")
+
+	entry, ok := cache.GetEntry("foo")
+	if !ok {
+		t.Fatalf("expected foo to exist")
+	}
+	if got, want := entry.Value, 5; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := entry.Hits, uint64(2); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if entry.InsertedAt.IsZero() || entry.LastAccessedAt.Before(entry.InsertedAt) {
+		t.Errorf("expected valid timestamps, got %s and %s", entry.InsertedAt, entry.LastAccessedAt)
+	}
+}
+
+func TestShardStats_Delta(t *testing.T) {
+	t.Parallel()
+
+	prev := ShardStats{Len: 4, Hits: 1, Misses: 2, LockWait: time.Second}
+	cur := ShardStats{Len: 3, Hits: 5, Misses: 3, LockWait: 3 * time.Second}
+
+	if got, want := cur.Delta(prev), (ShardStats{Len: 3, Hits: 4, Misses: 1, LockWait: 2 * time.Second}); got != want {
+		t.Errorf("expected %#v to be %#v", got, want)
+	}
+}
+
+func TestSharded_keyEquality(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pointer", func(t *testing.T) {
+		t.Parallel()
+
+		type user struct {
+			Name string
+		}
+
+		cache := NewShardedLRU[*user, int](64, 10)
+		defer cache.Stop()
+
+		// Pointer keys are equal by identity, so changing what they point to does
+		// not change the shard.
+		u := &user{Name: "foo"}
+		cache.Set(u, 5)
+		u.Name = "bar"
+		if v, ok := cache.Get(u); !ok || v != 5 {
+			t.Errorf("expected %d to be %d", v, 5)
+		}
+	})
+
+	t.Run("negative_zero", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewShardedLRU[float64, int](64, 10)
+		defer cache.Stop()
+
+		cache.Set(0.0, 5)
+		if v, ok := cache.Get(math.Copysign(0, -1)); !ok || v != 5 {
+			t.Errorf("expected %d to be %d", v, 5)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// WriteSnapshot writes the cache's keys and values to w, encoding them with the
+// given codecs. Use GobCodec for snapshots consumed only by Go, or JSONCodec (or
+// any other Codec) for snapshots produced or consumed by other tooling. If keys
+// or values is nil, the codec from CodecFor is used.
+//
+// The format is a sequence of records, one per entry, with no header. Each
+// record is the encoded key followed by the encoded value, each prefixed with
+// its length as an unsigned varint (as in encoding/binary and protocol
+// buffers). Records are written from least to most recently used, as in Merge.
+// Metadata such as hit counts and expirations is not included.
+func WriteSnapshot[K comparable, V any](w io.Writer, c EntryLister[K, V], keys Codec[K], values Codec[V]) error {
+	if keys == nil {
+		keys = CodecFor[K]()
+	}
+	if values == nil {
+		values = CodecFor[V]()
+	}
+
+	entries := c.Entries()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return lastUsed(&entries[i]).Before(lastUsed(&entries[j]))
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, entry := range entries {
+		k, err := keys.Marshal(entry.Key)
+		if err != nil {
+			return fmt.Errorf("failed to encode key %v: %w", entry.Key, err)
+		}
+		v, err := values.Marshal(entry.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encode value for %v: %w", entry.Key, err)
+		}
+
+		if err := writeSnapshotField(bw, k); err != nil {
+			return err
+		}
+		if err := writeSnapshotField(bw, v); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot from r, decoding keys
+// and values with the given codecs, and sets each entry in c in the order it
+// was written. It stops at the first error, in which case the entries read
+// before the error have already been set. As with WriteSnapshot, nil codecs are
+// replaced by the codec from CodecFor.
+func ReadSnapshot[K comparable, V any](r io.Reader, c Cache[K, V], keys Codec[K], values Codec[V]) error {
+	if keys == nil {
+		keys = CodecFor[K]()
+	}
+	if values == nil {
+		values = CodecFor[V]()
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		kb, err := readSnapshotField(br)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+
+		vb, err := readSnapshotField(br)
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read value: %w", err)
+		}
+
+		k, err := keys.Unmarshal(kb)
+		if err != nil {
+			return fmt.Errorf("failed to decode key: %w", err)
+		}
+		v, err := values.Unmarshal(vb)
+		if err != nil {
+			return fmt.Errorf("failed to decode value for %v: %w", k, err)
+		}
+		c.Set(k, v)
+	}
+}
+
+// writeSnapshotField writes b prefixed with its length.
+func writeSnapshotField(w *bufio.Writer, b []byte) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(b)))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// readSnapshotField reads a length-prefixed field. It returns io.EOF only if r
+// is exhausted before the length; a partial field is io.ErrUnexpectedEOF.
+func readSnapshotField(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		keys   Codec[string]
+		values Codec[codecTestValue]
+	}{
+		{"gob", GobCodec[string]{}, GobCodec[codecTestValue]{}},
+		{"json", JSONCodec[string]{}, JSONCodec[codecTestValue]{}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := NewLRU[string, codecTestValue](10)
+			defer src.Stop()
+
+			src.Set("foo", codecTestValue{Name: "foo", Count: 1})
+			src.Set("bar", codecTestValue{Name: "bar", Count: 2})
+			src.Set("baz", codecTestValue{Name: "baz", Count: 3})
+			src.Get("foo")
+
+			var b bytes.Buffer
+			if err := WriteSnapshot[string, codecTestValue](&b, src, tc.keys, tc.values); err != nil {
+				t.Fatal(err)
+			}
+
+			// The destination only fits two entries, so the least recently used
+			// entry is the one which is dropped.
+			dst := NewLRU[string, codecTestValue](2)
+			defer dst.Stop()
+
+			if err := ReadSnapshot[string, codecTestValue](&b, dst, tc.keys, tc.values); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := dst.Get("bar"); ok {
+				t.Errorf("expected bar to be evicted")
+			}
+			if got, ok := dst.Get("foo"); !ok || got.Count != 1 {
+				t.Errorf("expected %#v to have count 1", got)
+			}
+			if got, ok := dst.Get("baz"); !ok || got.Count != 3 {
+				t.Errorf("expected %#v to have count 3", got)
+			}
+		})
+	}
+
+	t.Run("registered", func(t *testing.T) {
+		t.Parallel()
+
+		src := NewLRU[string, codecTestShape](10)
+		defer src.Stop()
+		src.Set("foo", codecTestSquare{Side: 2})
+
+		var b bytes.Buffer
+		if err := WriteSnapshot[string, codecTestShape](&b, src, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		dst := NewLRU[string, codecTestShape](10)
+		defer dst.Stop()
+
+		if err := ReadSnapshot[string, codecTestShape](&b, dst, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := dst.Get("foo"); !ok || got.Area() != 4 {
+			t.Errorf("expected %#v to have area 4", got)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		t.Parallel()
+
+		src := NewLRU[string, string](10)
+		defer src.Stop()
+		src.Set("foo", "bar")
+
+		var b bytes.Buffer
+		if err := WriteSnapshot[string, string](&b, src, JSONCodec[string]{}, JSONCodec[string]{}); err != nil {
+			t.Fatal(err)
+		}
+
+		dst := NewLRU[string, string](10)
+		defer dst.Stop()
+
+		truncated := bytes.NewReader(b.Bytes()[:b.Len()-1])
+		err := ReadSnapshot[string, string](truncated, dst, JSONCodec[string]{}, JSONCodec[string]{})
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("expected %v to be %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+}
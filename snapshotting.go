@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var (
+	_ Cache[string, string]       = (*Snapshotting[string, string])(nil)
+	_ Deleter[string]             = (*Snapshotting[string, string])(nil)
+	_ EntryLister[string, string] = (*Snapshotting[string, string])(nil)
+)
+
+// snapshotBuckets is the number of buckets in a snapshotting cache. A write
+// after a snapshot copies one bucket, so more buckets make each copy cheaper.
+const snapshotBuckets = 256
+
+// Snapshotting implements a cache which supports taking immutable
+// point-in-time snapshots in constant time, for exporting or inspecting a large
+// cache without blocking other operations while it is walked. The entries are
+// spread by hash across buckets, and a snapshot shares the buckets with the
+// cache; the first write to a bucket after a snapshot copies that bucket, so
+// the snapshot is never modified. Snapshotting does not evict entries; they
+// are removed with Delete.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Snapshotting[K comparable, V any] struct {
+	// buckets hold the entries, by hash of the key.
+	buckets [snapshotBuckets]snapshotBucket[K, V]
+
+	// len is the number of entries in the cache.
+	len int
+
+	// seed seeds the hash which chooses a key's bucket.
+	seed hashSeed
+
+	// stopped indicates whether the cache is stopped.
+	stopped uint32
+
+	// lock is the internal lock for concurrency.
+	lock waitMutex
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// snapshotBucket is a bucket of entries. If shared is true, the entries are
+// referenced by a snapshot and must be copied before they are modified.
+type snapshotBucket[K comparable, V any] struct {
+	entries map[K]V
+	shared  bool
+}
+
+// Snapshot is an immutable point-in-time view of a cache, returned by the
+// Snapshot method of Snapshotting, LRU and TTL. It is safe for concurrent use,
+// and is unaffected by later changes to the cache.
+type Snapshot[K comparable, V any] struct {
+	buckets [snapshotBuckets]map[K]V
+	len     int
+	seed    hashSeed
+}
+
+// NewSnapshotting creates a new snapshotting cache.
+func NewSnapshotting[K comparable, V any]() *Snapshotting[K, V] {
+	return &Snapshotting[K, V]{
+		seed: newHashSeed(),
+	}
+}
+
+// Get fetches the cache item at the given key. If the value exists, it is
+// returned. If the value does not exist, it returns the zero value for the
+// object and the second parameter will be false.
+func (s *Snapshotting[K, V]) Get(key K) (V, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.isStopped() {
+		panic(ErrStopped)
+	}
+
+	v, ok := s.buckets[s.bucketIndex(key)].entries[key]
+	return v, ok
+}
+
+// Set inserts the value in the cache. If an entry already exists at the given
+// key, it is overwritten. If an entry does not exist, a new entry is created.
+func (s *Snapshotting[K, V]) Set(key K, val V) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.isStopped() {
+		panic(ErrStopped)
+	}
+
+	b := s.writable(s.bucketIndex(key))
+	if _, ok := b.entries[key]; !ok {
+		s.len++
+	}
+	b.entries[key] = val
+}
+
+// Delete removes the entry at the given key from the cache. It returns false if
+// there is no entry at the given key.
+func (s *Snapshotting[K, V]) Delete(key K) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.isStopped() {
+		panic(ErrStopped)
+	}
+
+	i := s.bucketIndex(key)
+	if _, ok := s.buckets[i].entries[key]; !ok {
+		return false
+	}
+
+	delete(s.writable(i).entries, key)
+	s.len--
+	return true
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. The FetchFunc runs without holding the cache's lock, so other
+// operations proceed while it runs, and concurrent calls to Fetch for the same
+// key share a single invocation.
+func (s *Snapshotting[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := s.Get(key); ok {
+		return v, nil
+	}
+
+	return s.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := s.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		s.Set(key, v)
+		return v, nil
+	})
+}
+
+// Snapshot returns an immutable view of the cache's entries as of the call. It
+// takes constant time, regardless of the size of the cache, and walking the
+// snapshot does not hold the cache's lock.
+func (s *Snapshotting[K, V]) Snapshot() *Snapshot[K, V] {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.isStopped() {
+		panic(ErrStopped)
+	}
+
+	snap := &Snapshot[K, V]{len: s.len, seed: s.seed}
+	for i := range s.buckets {
+		b := &s.buckets[i]
+		b.shared = true
+		snap.buckets[i] = b.entries
+	}
+	return snap
+}
+
+// Entries returns the entries in the cache, in no particular order. It walks a
+// snapshot, so the cache's lock is not held while the entries are copied and
+// exporting the cache with WriteSnapshot does not block other operations. Only
+// the Key and Value of each entry are populated.
+func (s *Snapshotting[K, V]) Entries() []Entry[K, V] {
+	snap := s.Snapshot()
+
+	entries := make([]Entry[K, V], 0, snap.Len())
+	snap.Range(func(k K, v V) bool {
+		entries = append(entries, Entry[K, V]{Key: k, Value: v})
+		return true
+	})
+	return entries
+}
+
+// Len returns the number of entries in the cache.
+func (s *Snapshotting[K, V]) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.len
+}
+
+// LockWait returns the total time operations have spent waiting to acquire the
+// cache's lock.
+func (s *Snapshotting[K, V]) LockWait() time.Duration {
+	return s.lock.waitTime()
+}
+
+// Stop clears the cache and prevents new entries from being added and
+// retrieved. Snapshots taken before Stop remain valid.
+func (s *Snapshotting[K, V]) Stop() {
+	s.loads.close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&s.stopped, 0, 1) {
+		return
+	}
+
+	s.buckets = [snapshotBuckets]snapshotBucket[K, V]{}
+	s.len = 0
+}
+
+// writable returns the bucket at the given index, copying its entries first if
+// they are shared with a snapshot. It does not lock.
+func (s *Snapshotting[K, V]) writable(i int) *snapshotBucket[K, V] {
+	b := &s.buckets[i]
+	switch {
+	case b.entries == nil:
+		b.entries = make(map[K]V)
+	case b.shared:
+		entries := make(map[K]V, len(b.entries)+1)
+		for k, v := range b.entries {
+			entries[k] = v
+		}
+		b.entries = entries
+	}
+	b.shared = false
+	return b
+}
+
+// bucketIndex returns the index of the bucket for the given key.
+func (s *Snapshotting[K, V]) bucketIndex(key K) int {
+	return int(hashKey(s.seed, key) % snapshotBuckets)
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (s *Snapshotting[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&s.stopped) == 1
+}
+
+// newSnapshot creates an empty snapshot with room for n entries, to be filled
+// with add by caches which copy their entries into a snapshot.
+func newSnapshot[K comparable, V any](n int) *Snapshot[K, V] {
+	s := &Snapshot[K, V]{seed: newHashSeed()}
+	for i := range s.buckets {
+		s.buckets[i] = make(map[K]V, n/snapshotBuckets+1)
+	}
+	return s
+}
+
+// add adds the entry to the snapshot. It must only be called before the
+// snapshot is returned.
+func (s *Snapshot[K, V]) add(key K, val V) {
+	s.buckets[hashKey(s.seed, key)%snapshotBuckets][key] = val
+	s.len++
+}
+
+// Get fetches the item at the given key as of the snapshot.
+func (s *Snapshot[K, V]) Get(key K) (V, bool) {
+	v, ok := s.buckets[hashKey(s.seed, key)%snapshotBuckets][key]
+	return v, ok
+}
+
+// Len returns the number of entries in the snapshot.
+func (s *Snapshot[K, V]) Len() int {
+	return s.len
+}
+
+// Range calls fn for each entry in the snapshot, in no particular order, until
+// fn returns false.
+func (s *Snapshot[K, V]) Range(fn func(K, V) bool) {
+	for _, entries := range s.buckets {
+		for k, v := range entries {
+			if !fn(k, v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSnapshotting(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSnapshotting[int, int]()
+	defer cache.Stop()
+
+	for i := 0; i < 1000; i++ {
+		cache.Set(i, i)
+	}
+
+	snap := cache.Snapshot()
+
+	// Writes after the snapshot do not affect it.
+	cache.Set(0, 100)
+	cache.Set(1000, 1000)
+	cache.Delete(1)
+
+	if got, want := snap.Len(), 1000; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if v, _ := snap.Get(0); v != 0 {
+		t.Errorf("expected %d to be %d", v, 0)
+	}
+	if _, ok := snap.Get(1); !ok {
+		t.Errorf("expected 1 to be in snapshot")
+	}
+	if _, ok := snap.Get(1000); ok {
+		t.Errorf("expected 1000 to not be in snapshot")
+	}
+
+	var sum int
+	snap.Range(func(k, v int) bool {
+		sum += v
+		return true
+	})
+	if got, want := sum, 999*1000/2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	if got, want := cache.Len(), 1000; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if v, _ := cache.Get(0); v != 100 {
+		t.Errorf("expected %d to be %d", v, 100)
+	}
+	if _, ok := cache.Get(1); ok {
+		t.Errorf("expected 1 to be deleted")
+	}
+	if cache.Delete(1) {
+		t.Errorf("expected second delete to return false")
+	}
+
+	// Snapshots remain valid after the cache is stopped.
+	cache.Stop()
+	if v, _ := snap.Get(5); v != 5 {
+		t.Errorf("expected %d to be %d", v, 5)
+	}
+}
+
+func TestSnapshotting_Entries(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSnapshotting[string, int]()
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	cache.Set("bar", 3)
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot[string, int](&buf, cache, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewSnapshotting[string, int]()
+	defer restored.Stop()
+
+	if err := ReadSnapshot[string, int](&buf, restored, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := restored.Get("foo"); v != 5 {
+		t.Errorf("expected %d to be %d", v, 5)
+	}
+	if got, want := restored.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
@@ -0,0 +1,395 @@
+// Package statsd emits cache metrics to a statsd or DogStatsD server. Caches
+// are wrapped with Instrument, which counts hits, misses, and evictions, and an
+// Emitter periodically sends the counts along with the size of each cache:
+//
+//	emitter, err := statsd.Dial("127.0.0.1:8125", statsd.WithDogStatsD())
+//	if err != nil {
+//	  return err
+//	}
+//	defer emitter.Stop()
+//
+//	users := statsd.Instrument(emitter, "users", cache.NewLRU[string, *User](1024), "team:identity")
+//
+// Each flush sends the following metrics for every instrumented cache, where
+// the prefix defaults to "cache":
+//
+//	<prefix>.hits       counter
+//	<prefix>.misses     counter
+//	<prefix>.evictions  counter
+//	<prefix>.size       gauge
+//
+// With DogStatsD, the cache name is sent as a "cache:<name>" tag alongside any
+// other configured tags. Plain statsd does not support tags, so the cache name
+// is included in the metric name instead, as <prefix>.<name>.hits, and other
+// tags are ignored.
+package statsd
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sethvargo/go-cache"
+)
+
+// Ensure implements.
+var _ cache.Cache[string, string] = (*Cache[string, string])(nil)
+
+// Emitter periodically sends the metrics for a set of instrumented caches. It
+// is safe for concurrent use.
+type Emitter struct {
+	// w is the destination for metrics. Each flush writes a single packet per
+	// cache, with one metric per line.
+	w io.Writer
+
+	// closer closes w when the emitter is stopped, if the emitter owns it.
+	closer io.Closer
+
+	// prefix is prepended to each metric name, tags are sent with every metric,
+	// and dogstatsd indicates whether to use the DogStatsD tag extension.
+	prefix    string
+	tags      []string
+	dogstatsd bool
+
+	// onError is the optional function invoked when a background flush fails.
+	onError func(error)
+
+	// caches are the instrumented caches. It is guarded by lock.
+	caches []*counters
+	lock   sync.Mutex
+
+	// stopped indicates whether the emitter is stopped. stopCh is a channel used
+	// to control cancellation.
+	stopped uint32
+	stopCh  chan struct{}
+}
+
+// Option is an option for configuring an Emitter.
+type Option func(*options)
+
+// options are the options for an Emitter.
+type options struct {
+	prefix    string
+	tags      []string
+	dogstatsd bool
+	interval  time.Duration
+	onError   func(error)
+}
+
+// WithPrefix sets the prefix for metric names. The default is "cache".
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithTags adds tags, in "key:value" form, which are sent with every metric.
+// They are only sent when using DogStatsD.
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithDogStatsD enables the DogStatsD tag extension, so that the cache name and
+// tags are sent as tags rather than being included in the metric name.
+func WithDogStatsD() Option {
+	return func(o *options) {
+		o.dogstatsd = true
+	}
+}
+
+// WithInterval sets how often metrics are sent. The default is 10 seconds.
+func WithInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// WithErrorHandler registers a function which is invoked when a background
+// flush fails to send metrics. By default, such errors are ignored, since
+// statsd delivery is best-effort.
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.onError = fn
+	}
+}
+
+// Dial creates a new emitter which sends metrics over UDP to the statsd server
+// at the given address. The connection is closed when the emitter is stopped.
+func Dial(addr string, opts ...Option) (*Emitter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	e := New(conn, opts...)
+	e.closer = conn
+	return e, nil
+}
+
+// New creates a new emitter which writes metrics to w in the statsd line
+// format. It is primarily useful for custom transports and testing; most
+// callers should use Dial.
+func New(w io.Writer, opts ...Option) *Emitter {
+	if w == nil {
+		panic("writer cannot be nil")
+	}
+
+	o := options{
+		prefix:   "cache",
+		interval: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.interval <= 0 {
+		panic("interval must be greater than 0")
+	}
+
+	e := &Emitter{
+		w:         w,
+		prefix:    o.prefix,
+		tags:      o.tags,
+		dogstatsd: o.dogstatsd,
+		onError:   o.onError,
+		stopCh:    make(chan struct{}),
+	}
+
+	go e.start(o.interval)
+
+	return e
+}
+
+// Flush sends the metrics accumulated since the previous flush for every
+// instrumented cache. It is called automatically at the configured interval,
+// but may be called manually, for example before shutdown. It returns the
+// first error encountered, but attempts to send the metrics for every cache.
+func (e *Emitter) Flush() error {
+	e.lock.Lock()
+	caches := make([]*counters, len(e.caches))
+	copy(caches, e.caches)
+	e.lock.Unlock()
+
+	var firstErr error
+	for _, c := range caches {
+		if err := e.send(c); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Stop flushes any remaining metrics and stops sending metrics. If the emitter
+// was created with Dial, the connection is closed. The instrumented caches are
+// not stopped.
+func (e *Emitter) Stop() error {
+	if !atomic.CompareAndSwapUint32(&e.stopped, 0, 1) {
+		return nil
+	}
+	close(e.stopCh)
+
+	err := e.Flush()
+	if e.closer != nil {
+		if cerr := e.closer.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// register adds the counters to the set which are flushed.
+func (e *Emitter) register(c *counters) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.caches = append(e.caches, c)
+}
+
+// unregister removes the counters from the set which are flushed.
+func (e *Emitter) unregister(c *counters) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for i, existing := range e.caches {
+		if existing == c {
+			e.caches = append(e.caches[:i], e.caches[i+1:]...)
+			return
+		}
+	}
+}
+
+// send writes the metrics for a single cache as one packet.
+func (e *Emitter) send(c *counters) error {
+	var b bytes.Buffer
+	e.writeMetric(&b, c, "hits", atomic.SwapUint64(&c.hits, 0), "c")
+	e.writeMetric(&b, c, "misses", atomic.SwapUint64(&c.misses, 0), "c")
+	e.writeMetric(&b, c, "evictions", atomic.SwapUint64(&c.evictions, 0), "c")
+	if c.size != nil {
+		e.writeMetric(&b, c, "size", uint64(c.size()), "g")
+	}
+
+	_, err := e.w.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+	return err
+}
+
+// writeMetric appends a single metric line to b.
+func (e *Emitter) writeMetric(b *bytes.Buffer, c *counters, name string, value uint64, typ string) {
+	var parts []string
+	if e.prefix != "" {
+		parts = append(parts, e.prefix)
+	}
+	if !e.dogstatsd {
+		parts = append(parts, c.name)
+	}
+	parts = append(parts, name)
+
+	b.WriteString(strings.Join(parts, "."))
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatUint(value, 10))
+	b.WriteByte('|')
+	b.WriteString(typ)
+
+	if e.dogstatsd {
+		b.WriteString("|#cache:")
+		b.WriteString(c.name)
+		for _, tag := range e.tags {
+			b.WriteByte(',')
+			b.WriteString(tag)
+		}
+		for _, tag := range c.tags {
+			b.WriteByte(',')
+			b.WriteString(tag)
+		}
+	}
+	b.WriteByte('\n')
+}
+
+// start flushes at the given interval. It runs until stopped via Stop() and is
+// intended to be called as a goroutine.
+func (e *Emitter) start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stopCh:
+			return
+		case <-ticker.C:
+			if err := e.Flush(); err != nil && e.onError != nil {
+				e.onError(err)
+			}
+		}
+	}
+}
+
+// counters are the metrics for a single instrumented cache. The counts are
+// updated atomically and reset on each flush.
+type counters struct {
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
+	// name is the cache name and tags are the cache's own tags.
+	name string
+	tags []string
+
+	// size returns the number of entries in the cache, or is nil if the cache
+	// does not report its length.
+	size func() int
+}
+
+// Cache wraps a cache, counting hits, misses, and evictions for an Emitter.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Cache[K comparable, V any] struct {
+	// cache is the underlying cache implementation.
+	cache cache.Cache[K, V]
+
+	// emitter is the emitter the metrics are registered with, and counters are
+	// the metrics.
+	emitter  *Emitter
+	counters *counters
+}
+
+// Instrument wraps the given cache, registering its metrics with the emitter
+// under the given name. The tags, in "key:value" form, are sent with this
+// cache's metrics in addition to the emitter's tags. Evictions are only
+// counted if the cache implements cache.EvictNotifier, and size is only
+// reported if it has a Len method.
+func Instrument[K comparable, V any](e *Emitter, name string, c cache.Cache[K, V], tags ...string) *Cache[K, V] {
+	if e == nil {
+		panic("emitter cannot be nil")
+	}
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+	if name == "" {
+		panic("name cannot be empty")
+	}
+
+	counters := &counters{
+		name: name,
+		tags: tags,
+	}
+	if l, ok := c.(interface{ Len() int }); ok {
+		counters.size = l.Len
+	}
+	if n, ok := c.(cache.EvictNotifier[K, V]); ok {
+		n.OnEvict(func(K, V) {
+			atomic.AddUint64(&counters.evictions, 1)
+		})
+	}
+
+	e.register(counters)
+
+	return &Cache[K, V]{
+		cache:    c,
+		emitter:  e,
+		counters: counters,
+	}
+}
+
+// Get fetches the value from the underlying cache, counting a hit or a miss.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	v, ok := c.cache.Get(key)
+	if ok {
+		atomic.AddUint64(&c.counters.hits, 1)
+	} else {
+		atomic.AddUint64(&c.counters.misses, 1)
+	}
+	return v, ok
+}
+
+// Set inserts the value in the underlying cache.
+func (c *Cache[K, V]) Set(key K, val V) {
+	c.cache.Set(key, val)
+}
+
+// Fetch retrieves the value from the underlying cache, counting a miss if the
+// FetchFunc is invoked and a hit otherwise.
+func (c *Cache[K, V]) Fetch(key K, fn cache.FetchFunc[V]) (V, error) {
+	missed := false
+	v, err := c.cache.Fetch(key, func() (V, error) {
+		missed = true
+		return fn()
+	})
+	if missed {
+		atomic.AddUint64(&c.counters.misses, 1)
+	} else {
+		atomic.AddUint64(&c.counters.hits, 1)
+	}
+	return v, err
+}
+
+// Stop stops the underlying cache and unregisters its metrics from the
+// emitter. Counts accumulated since the last flush are discarded.
+func (c *Cache[K, V]) Stop() {
+	c.emitter.unregister(c.counters)
+	c.cache.Stop()
+}
@@ -0,0 +1,196 @@
+package statsd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-cache"
+)
+
+// packetWriter records each write as a separate packet.
+type packetWriter struct {
+	lock    sync.Mutex
+	packets []string
+}
+
+func (w *packetWriter) Write(b []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.packets = append(w.packets, string(b))
+	return len(b), nil
+}
+
+func (w *packetWriter) take() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	packets := w.packets
+	w.packets = nil
+	return packets
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "writer cannot be nil"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	New(nil)
+	t.Errorf("did not panic")
+}
+
+func TestEmitter_Flush(t *testing.T) {
+	t.Parallel()
+
+	t.Run("statsd", func(t *testing.T) {
+		t.Parallel()
+
+		var w packetWriter
+		emitter := New(&w, WithInterval(time.Hour), WithTags("env:test"))
+		defer emitter.Stop()
+
+		c := Instrument[string, int](emitter, "users", cache.NewLRU[string, int](1))
+		defer c.Stop()
+
+		c.Set("foo", 1)
+		c.Get("foo")
+		c.Get("bar")
+		c.Set("bar", 2)
+
+		if err := emitter.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		packets := w.take()
+		if got, want := len(packets), 1; got != want {
+			t.Fatalf("expected %d to be %d", got, want)
+		}
+		if got, want := packets[0], strings.Join([]string{
+			"cache.users.hits:1|c",
+			"cache.users.misses:1|c",
+			"cache.users.evictions:1|c",
+			"cache.users.size:1|g",
+		}, "\n"); got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		// Counters are reset on each flush, but gauges are not.
+		if err := emitter.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := w.take()[0], strings.Join([]string{
+			"cache.users.hits:0|c",
+			"cache.users.misses:0|c",
+			"cache.users.evictions:0|c",
+			"cache.users.size:1|g",
+		}, "\n"); got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("dogstatsd", func(t *testing.T) {
+		t.Parallel()
+
+		var w packetWriter
+		emitter := New(&w, WithInterval(time.Hour), WithDogStatsD(), WithPrefix("app.cache"), WithTags("env:test"))
+		defer emitter.Stop()
+
+		c := Instrument[string, int](emitter, "users", cache.NewLRU[string, int](10), "team:identity")
+		defer c.Stop()
+
+		c.Fetch("foo", func() (int, error) { return 1, nil })
+		c.Fetch("foo", func() (int, error) { return 1, nil })
+
+		if err := emitter.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := w.take()[0], strings.Join([]string{
+			"app.cache.hits:1|c|#cache:users,env:test,team:identity",
+			"app.cache.misses:1|c|#cache:users,env:test,team:identity",
+			"app.cache.evictions:0|c|#cache:users,env:test,team:identity",
+			"app.cache.size:1|g|#cache:users,env:test,team:identity",
+		}, "\n"); got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("unregisters_on_stop", func(t *testing.T) {
+		t.Parallel()
+
+		var w packetWriter
+		emitter := New(&w, WithInterval(time.Hour))
+		defer emitter.Stop()
+
+		c := Instrument[string, int](emitter, "users", cache.NewLRU[string, int](10))
+		c.Stop()
+
+		if err := emitter.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(w.take()), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
+
+func TestEmitter_background(t *testing.T) {
+	t.Parallel()
+
+	var w packetWriter
+	emitter := New(&w, WithInterval(10*time.Millisecond))
+	defer emitter.Stop()
+
+	c := Instrument[string, int](emitter, "users", cache.NewLRU[string, int](10))
+	defer c.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for len(w.take()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for flush")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDial(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	emitter, err := Dial(conn.LocalAddr().String(), WithInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Instrument[string, int](emitter, "users", cache.NewFIFO[string, int](10))
+	defer c.Stop()
+	c.Get("foo")
+
+	// Stop flushes before closing the connection.
+	if err := emitter.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf[:n], []byte("cache.users.misses:1|c")) {
+		t.Errorf("expected %q to contain misses", buf[:n])
+	}
+}
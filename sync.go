@@ -0,0 +1,406 @@
+package cache
+
+import (
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Sync[string, string])(nil)
+
+// Sync wraps any cache implementation and serializes all operations through a
+// single lock, making the wrapped cache safe for concurrent use.
+//
+// By default, every operation takes the lock exclusively, since some policies
+// (such as LRU) modify internal state on Get. For the built-in policies, Sync
+// is aware of how Get behaves: policies whose Get is read-only (FIFO, LIFO,
+// Random, and TTL) are read under a shared lock, and LRU reads are performed
+// under a shared lock with the recency updates buffered and applied in batches.
+// As a result, the LRU ordering may briefly lag behind the actual access order.
+//
+// A sync cache created with NewSync serializes on a single lock, since the
+// wrapped cache is a single data structure and an eviction triggered by a Set
+// of one key may remove any other key. To scale with the number of cores, use
+// NewShardedSync, which partitions the key space by hash across independent
+// caches that each have their own lock, so operations on keys in different
+// shards do not contend.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Sync[K comparable, V any] struct {
+	// cache is the underlying cache implementation. It is nil if the cache is
+	// sharded.
+	cache Cache[K, V]
+
+	// shards are the sync caches which each own part of the key space, chosen
+	// by a hash of the key, or nil if the cache is not sharded. Operations on a
+	// sharded cache take only the lock of the shard which owns the key.
+	shards []*Sync[K, V]
+
+	// seed seeds the hash which chooses a key's shard.
+	seed hashSeed
+
+	// readOnly indicates the underlying cache's Get does not modify the cache,
+	// so it can be called under a shared lock.
+	readOnly bool
+
+	// locked indicates the underlying cache has its own lock, so its Fetch can
+	// be called without holding the sync cache's lock.
+	locked bool
+
+	// batcher is the underlying cache if it supports deferring recency updates,
+	// or nil otherwise.
+	batcher recencyBatcher[K, V]
+
+	// pending are the keys which were read under the shared lock, but whose
+	// recency updates have not yet been applied.
+	pending accessBuffer[K]
+
+	// lock is the lock that guards all access to the underlying cache.
+	lock waitMutex
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+}
+
+// readOnlyGetter is implemented by caches whose Get does not modify the cache.
+type readOnlyGetter interface {
+	readOnlyGet()
+}
+
+// recencyBatcher is implemented by caches that can look up an entry without
+// modifying the cache and apply the resulting recency updates later in bulk.
+type recencyBatcher[K comparable, V any] interface {
+	peek(K) (V, bool)
+	promote([]K)
+}
+
+// NewSync wraps the given cache in a cache that is safe for concurrent use.
+func NewSync[K comparable, V any](c Cache[K, V]) *Sync[K, V] {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	s := &Sync[K, V]{
+		cache: c,
+	}
+	if b, ok := c.(recencyBatcher[K, V]); ok {
+		s.batcher = b
+	}
+	if _, ok := c.(readOnlyGetter); ok {
+		s.readOnly = true
+	}
+	if _, ok := c.(lockWaiter); ok {
+		s.locked = true
+	}
+	return s
+}
+
+// NewShardedSync creates a sync cache which partitions the key space across the
+// given number of shards by a hash of the key. The newFn function is called
+// once per shard to construct the underlying caches, each of which is guarded
+// by its own lock, so unlike NewSync, throughput scales with the number of
+// shards. Eviction happens per shard, so the cache as a whole only approximates
+// the eviction policy of the underlying caches. Txn locks every shard.
+func NewShardedSync[K comparable, V any](shards int, newFn func() Cache[K, V]) *Sync[K, V] {
+	if shards <= 0 {
+		panic("shards must be greater than 0")
+	}
+	if newFn == nil {
+		panic("newFn cannot be nil")
+	}
+
+	s := &Sync[K, V]{
+		shards: make([]*Sync[K, V], shards),
+		seed:   newHashSeed(),
+	}
+	for i := range s.shards {
+		s.shards[i] = NewSync[K, V](newFn())
+	}
+	return s
+}
+
+// NewSyncFIFO creates a new FIFO cache of the given capacity and wraps it in a
+// sync cache. It is equivalent to calling NewSync(NewFIFO(capacity)), but only
+// requires declaring the type parameters once.
+func NewSyncFIFO[K comparable, V any](capacity int64) *Sync[K, V] {
+	return NewSync[K, V](NewFIFO[K, V](capacity))
+}
+
+// NewSyncLIFO creates a new LIFO cache of the given capacity and wraps it in a
+// sync cache. It is equivalent to calling NewSync(NewLIFO(capacity)), but only
+// requires declaring the type parameters once.
+func NewSyncLIFO[K comparable, V any](capacity int64) *Sync[K, V] {
+	return NewSync[K, V](NewLIFO[K, V](capacity))
+}
+
+// NewSyncLRU creates a new LRU cache of the given capacity and wraps it in a
+// sync cache. It is equivalent to calling NewSync(NewLRU(capacity)), but only
+// requires declaring the type parameters once.
+func NewSyncLRU[K comparable, V any](capacity int64) *Sync[K, V] {
+	return NewSync[K, V](NewLRU[K, V](capacity))
+}
+
+// NewSyncRandom creates a new random replacement cache of the given capacity
+// and wraps it in a sync cache. It is equivalent to calling
+// NewSync(NewRandom(capacity)), but only requires declaring the type parameters
+// once.
+func NewSyncRandom[K comparable, V any](capacity int64) *Sync[K, V] {
+	return NewSync[K, V](NewRandom[K, V](capacity))
+}
+
+// NewSyncTTL creates a new TTL cache with the given TTL and wraps it in a sync
+// cache. It is equivalent to calling NewSync(NewTTL(ttl, opts...)), but only
+// requires declaring the type parameters once.
+func NewSyncTTL[K comparable, V any](ttl time.Duration, opts ...TTLOption) *Sync[K, V] {
+	return NewSync[K, V](NewTTL[K, V](ttl, opts...))
+}
+
+// Get fetches the cache item at the given key from the underlying cache.
+func (s *Sync[K, V]) Get(key K) (V, bool) {
+	if s.shards != nil {
+		return s.shardFor(key).Get(key)
+	}
+
+	switch {
+	case s.batcher != nil:
+		s.lock.RLock()
+		v, ok := s.batcher.peek(key)
+		s.lock.RUnlock()
+
+		if ok {
+			s.recordAccess(key)
+		}
+		return v, ok
+	case s.readOnly:
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+		return s.cache.Get(key)
+	default:
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return s.cache.Get(key)
+	}
+}
+
+// Set inserts the value in the underlying cache.
+func (s *Sync[K, V]) Set(key K, val V) {
+	if s.shards != nil {
+		s.shardFor(key).Set(key, val)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.drain()
+	s.cache.Set(key, val)
+}
+
+// Fetch retrieves the cached value from the underlying cache. If the value
+// does not exist, the FetchFunc is called and the result is stored. If the
+// value does exist, the FetchFunc is not invoked.
+//
+// The FetchFunc runs without holding the lock, so other operations proceed
+// while it runs, and concurrent calls to Fetch for the same key share a single
+// invocation. If the underlying cache has its own lock, as the built-in caches
+// do, its Fetch is called directly, so behavior such as refreshing entries past
+// their soft TTL is preserved. Otherwise the result is stored with Set.
+func (s *Sync[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if s.shards != nil {
+		return s.shardFor(key).Fetch(key, fn)
+	}
+	if s.locked {
+		return s.cache.Fetch(key, fn)
+	}
+
+	if v, ok := s.Get(key); ok {
+		return v, nil
+	}
+
+	return s.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := s.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		s.Set(key, v)
+		return v, nil
+	})
+}
+
+// Stop stops the underlying cache.
+func (s *Sync[K, V]) Stop() {
+	if s.shards != nil {
+		for _, shard := range s.shards {
+			shard.Stop()
+		}
+		return
+	}
+
+	s.loads.close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pending.take()
+
+	s.cache.Stop()
+}
+
+// GetEntry fetches the cache item at the given key along with its metadata
+// from the underlying cache. If the underlying cache does not implement
+// EntryGetter, only the key and value are populated.
+func (s *Sync[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	if s.shards != nil {
+		return s.shardFor(key).GetEntry(key)
+	}
+
+	getter, ok := s.cache.(EntryGetter[K, V])
+	if !ok {
+		v, ok := s.Get(key)
+		return Entry[K, V]{Key: key, Value: v}, ok
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.drain()
+	return getter.GetEntry(key)
+}
+
+// Insert inserts the value in the underlying cache and returns the entry which
+// was evicted to make room for it. If no entry was evicted, the third return
+// value is false. It panics if the underlying cache does not implement
+// Inserter.
+func (s *Sync[K, V]) Insert(key K, val V) (K, V, bool) {
+	if s.shards != nil {
+		return s.shardFor(key).Insert(key, val)
+	}
+
+	ins, ok := s.cache.(Inserter[K, V])
+	if !ok {
+		panic("cache does not support insert")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.drain()
+	return ins.Insert(key, val)
+}
+
+// SetIfVersion inserts the value in the underlying cache only if the version of
+// the entry at the given key is the expected version. It panics if the
+// underlying cache does not implement VersionSetter.
+func (s *Sync[K, V]) SetIfVersion(key K, val V, version uint64) bool {
+	if s.shards != nil {
+		return s.shardFor(key).SetIfVersion(key, val, version)
+	}
+
+	vs, ok := s.cache.(VersionSetter[K, V])
+	if !ok {
+		panic("cache does not support versions")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.drain()
+	return vs.SetIfVersion(key, val, version)
+}
+
+// Entries returns a snapshot of the entries in the underlying cache. If the
+// underlying cache does not implement EntryLister, it returns nil. If the cache
+// is sharded, the order of entries is only meaningful within a single shard.
+func (s *Sync[K, V]) Entries() []Entry[K, V] {
+	if s.shards != nil {
+		var entries []Entry[K, V]
+		for _, shard := range s.shards {
+			entries = append(entries, shard.Entries()...)
+		}
+		return entries
+	}
+
+	lister, ok := s.cache.(EntryLister[K, V])
+	if !ok {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.drain()
+	return lister.Entries()
+}
+
+// Len returns the number of entries in the underlying cache. If the underlying
+// cache does not report its length, it returns 0.
+func (s *Sync[K, V]) Len() int {
+	if s.shards != nil {
+		n := 0
+		for _, shard := range s.shards {
+			n += shard.Len()
+		}
+		return n
+	}
+
+	l, ok := s.cache.(lener)
+	if !ok {
+		return 0
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return l.Len()
+}
+
+// LockWait returns the total time operations have spent waiting to acquire the
+// sync cache's lock, or the locks of all of its shards.
+func (s *Sync[K, V]) LockWait() time.Duration {
+	if s.shards != nil {
+		var d time.Duration
+		for _, shard := range s.shards {
+			d += shard.LockWait()
+		}
+		return d
+	}
+	return s.lock.waitTime()
+}
+
+// shardFor returns the shard which owns the given key. It must only be called
+// if the cache is sharded.
+func (s *Sync[K, V]) shardFor(key K) *Sync[K, V] {
+	return s.shards[hashKey(s.seed, key)%uint64(len(s.shards))]
+}
+
+// owner returns the sync cache whose underlying cache holds the given key,
+// which is the shard which owns the key if the cache is sharded.
+func (s *Sync[K, V]) owner(key K) *Sync[K, V] {
+	if s.shards != nil {
+		return s.shardFor(key)
+	}
+	return s
+}
+
+// recordAccess buffers a recency update for the given key. If the buffer is
+// full and the lock is uncontended, the buffered updates are applied
+// immediately.
+func (s *Sync[K, V]) recordAccess(key K) {
+	if s.pending.add(key) && s.lock.TryLock() {
+		defer s.lock.Unlock()
+		s.drain()
+	}
+}
+
+// drain applies any buffered recency updates. It must be called while holding
+// the exclusive lock.
+func (s *Sync[K, V]) drain() {
+	if s.batcher == nil {
+		return
+	}
+
+	if keys := s.pending.take(); len(keys) > 0 {
+		s.batcher.promote(keys)
+	}
+}
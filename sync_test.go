@@ -0,0 +1,475 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewSync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("wraps", func(t *testing.T) {
+		t.Parallel()
+
+		lru := NewLRU[string, string](10)
+		cache := NewSync[string, string](lru)
+		defer cache.Stop()
+
+		if got, want := cache.cache, Cache[string, string](lru); got != want {
+			t.Errorf("expected %#v to be %#v", got, want)
+		}
+	})
+
+	t.Run("panic_on_nil", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "cache cannot be nil"; got != want {
+				t.Errorf("expected %q to contain %q", got, want)
+			}
+		}()
+
+		cache := NewSync[string, string](nil)
+		defer cache.Stop()
+
+		t.Errorf("did not panic")
+	})
+}
+
+func TestNewSync_constructors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		cache *Sync[string, int]
+		check func(c Cache[string, int]) bool
+	}{
+		{
+			name:  "fifo",
+			cache: NewSyncFIFO[string, int](10),
+			check: func(c Cache[string, int]) bool { _, ok := c.(*FIFO[string, int]); return ok },
+		},
+		{
+			name:  "lifo",
+			cache: NewSyncLIFO[string, int](10),
+			check: func(c Cache[string, int]) bool { _, ok := c.(*LIFO[string, int]); return ok },
+		},
+		{
+			name:  "lru",
+			cache: NewSyncLRU[string, int](10),
+			check: func(c Cache[string, int]) bool { _, ok := c.(*LRU[string, int]); return ok },
+		},
+		{
+			name:  "random",
+			cache: NewSyncRandom[string, int](10),
+			check: func(c Cache[string, int]) bool { _, ok := c.(*Random[string, int]); return ok },
+		},
+		{
+			name:  "ttl",
+			cache: NewSyncTTL[string, int](5 * time.Minute),
+			check: func(c Cache[string, int]) bool { _, ok := c.(*TTL[string, int]); return ok },
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := tc.cache
+			defer cache.Stop()
+
+			if !tc.check(cache.cache) {
+				t.Errorf("unexpected underlying cache %T", cache.cache)
+			}
+
+			cache.Set("foo", 5)
+			if v, _ := cache.Get("foo"); v != 5 {
+				t.Errorf("expected %#v, got %#v", 5, v)
+			}
+		})
+	}
+}
+
+func TestSync_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("read_only", func(t *testing.T) {
+		t.Parallel()
+
+		for _, c := range []Cache[string, int]{
+			NewFIFO[string, int](10),
+			NewLIFO[string, int](10),
+			NewRandom[string, int](10),
+			NewTTL[string, int](5 * time.Minute),
+		} {
+			cache := NewSync(c)
+			if !cache.readOnly {
+				t.Errorf("expected %T to be read-only", c)
+			}
+			if cache.batcher != nil {
+				t.Errorf("expected %T to not be a batcher", c)
+			}
+			cache.Stop()
+		}
+	})
+
+	t.Run("exclusive", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSync[string, int](NewSharded(2, func() Cache[string, int] {
+			return NewLRU[string, int](10)
+		}))
+		defer cache.Stop()
+
+		if cache.readOnly {
+			t.Errorf("expected sharded cache to not be read-only")
+		}
+		if cache.batcher != nil {
+			t.Errorf("expected sharded cache to not be a batcher")
+		}
+
+		cache.Set("foo", 5)
+		if v, _ := cache.Get("foo"); v != 5 {
+			t.Errorf("expected %#v, got %#v", 5, v)
+		}
+	})
+
+	t.Run("batches_recency", func(t *testing.T) {
+		t.Parallel()
+
+		lru := NewLRU[string, int](3)
+		cache := NewSync[string, int](lru)
+		defer cache.Stop()
+
+		if cache.batcher == nil {
+			t.Fatalf("expected lru to be a batcher")
+		}
+
+		cache.Set("foo", 1)
+		cache.Set("bar", 2)
+		cache.Set("baz", 3)
+
+		// The read is buffered, so the order is not yet updated.
+		if v, _ := cache.Get("foo"); v != 1 {
+			t.Errorf("expected %#v, got %#v", 1, v)
+		}
+		if got, want := lruTail(lru).key, "baz"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		// The next write applies the buffered reads, so "bar" is now the oldest
+		// and is evicted.
+		cache.Set("qux", 4)
+		if v, ok := cache.Get("bar"); ok {
+			t.Errorf("expected %#v to be evicted", v)
+		}
+		if v, _ := cache.Get("foo"); v != 1 {
+			t.Errorf("expected %#v, got %#v", 1, v)
+		}
+	})
+
+	t.Run("drains_when_full", func(t *testing.T) {
+		t.Parallel()
+
+		lru := NewLRU[string, int](3)
+		cache := NewSync[string, int](lru)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.Set("bar", 2)
+
+		for i := 0; i < accessBufferFlush; i++ {
+			cache.Get("foo")
+		}
+
+		if got, want := lruTail(lru).key, "foo"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got := len(cache.pending.keys); got != 0 {
+			t.Errorf("expected pending to be empty, got %d", got)
+		}
+	})
+}
+
+func TestNewShardedSync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("partitions", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewShardedSync(4, func() Cache[int, int] {
+			return NewLRU[int, int](100)
+		})
+		defer cache.Stop()
+
+		for i := 0; i < 100; i++ {
+			cache.Set(i, i)
+		}
+
+		// Each key is stored in exactly one shard.
+		total := 0
+		for _, shard := range cache.shards {
+			total += shard.Len()
+		}
+		if got, want := total, 100; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.Len(), 100; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := len(cache.Entries()), 100; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		for i := 0; i < 100; i++ {
+			if v, ok := cache.Get(i); !ok || v != i {
+				t.Errorf("expected %d to be %d", v, i)
+			}
+		}
+
+		if !cache.Delete(5) {
+			t.Errorf("expected 5 to be deleted")
+		}
+		if _, ok := cache.Get(5); ok {
+			t.Errorf("expected 5 to be missing")
+		}
+	})
+
+	t.Run("txn", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewShardedSync(4, func() Cache[int, int] {
+			return NewLRU[int, int](100)
+		})
+		defer cache.Stop()
+
+		cache.Set(0, 0)
+
+		if err := cache.Txn(func(tx Txn[int, int]) error {
+			for i := 1; i < 20; i++ {
+				tx.Set(i, i)
+			}
+			tx.Delete(0)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := cache.Len(), 19; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if _, ok := cache.Get(0); ok {
+			t.Errorf("expected 0 to be deleted")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewShardedSync(8, func() Cache[int, int] {
+			return NewLRU[int, int](100)
+		})
+		defer cache.Stop()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					cache.Set(i*100+j, j)
+					cache.Get(j)
+					if _, err := cache.Fetch(j, func() (int, error) { return j, nil }); err != nil {
+						t.Error(err)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	t.Run("panic_on_invalid_shards", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "shards must be greater than 0"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		NewShardedSync(0, func() Cache[int, int] { return NewLRU[int, int](10) })
+		t.Errorf("did not panic")
+	})
+}
+
+func TestSync_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("saves", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSyncLRU[string, string](3)
+		defer cache.Stop()
+
+		v, err := cache.Fetch("foo", func() (string, error) {
+			return "bar", nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, "bar"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		v, ok := cache.Get("foo")
+		if !ok {
+			t.Errorf("expected item to be cached")
+		}
+		if got, want := v, "bar"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("returns_error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSyncLRU[string, string](3)
+		defer cache.Stop()
+
+		if _, err := cache.Fetch("foo", func() (string, error) {
+			return "", fmt.Errorf("error")
+		}); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("does_not_block", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSyncLRU[string, string](3)
+		defer cache.Stop()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		doneCh := make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			cache.Fetch("foo", func() (string, error) {
+				close(started)
+				<-release
+				return "bar", nil
+			})
+		}()
+		<-started
+
+		// Other keys are usable while the load is in progress.
+		cache.Set("zip", "zap")
+		if v, _ := cache.Get("zip"); v != "zap" {
+			t.Errorf("expected %q to be %q", v, "zap")
+		}
+
+		close(release)
+		<-doneCh
+
+		if v, _ := cache.Get("foo"); v != "bar" {
+			t.Errorf("expected %q to be %q", v, "bar")
+		}
+	})
+
+	t.Run("soft_ttl", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSyncTTL[string, int](time.Hour, WithSoftTTL(50*time.Millisecond))
+		defer cache.Stop()
+
+		ttl := cache.cache.(*TTL[string, int])
+
+		refreshed := make(chan struct{})
+		ttl.RefreshWith(func(string) (int, error) {
+			defer close(refreshed)
+			return 6, nil
+		})
+
+		cache.Set("foo", 5)
+		time.Sleep(100 * time.Millisecond)
+
+		// The stale value is served, and refreshed by the TTL cache's Fetch.
+		v, err := cache.Fetch("foo", func() (int, error) {
+			t.Errorf("expected FetchFunc not to be called")
+			return 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		<-refreshed
+		ttl.loads.close()
+		if v, fresh, ok := ttl.GetFresh("foo"); !ok || !fresh || v != 6 {
+			t.Errorf("expected %d to be refreshed", v)
+		}
+	})
+}
+
+func TestSync_concurrent(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSyncLRU[int, int](20)
+	defer cache.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		i := i
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				cache.Set(i, j)
+				cache.Get(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 10; i++ {
+		if v, _ := cache.Get(i); v != 99 {
+			t.Errorf("expected %#v, got %#v", 99, v)
+		}
+	}
+}
+
+func TestSync_GetEntry(t *testing.T) {
+	t.Parallel()
+
+	cache := NewSyncLRU[string, int](10)
+	defer cache.Stop()
+
+	if _, ok := cache.GetEntry("foo"); ok {
+		t.Errorf("expected foo to be missing")
+	}
+
+	cache.Set("foo", 5)
+	cache.Get("foo")
+
+	entry, ok := cache.GetEntry("foo")
+	if !ok {
+		t.Fatalf("expected foo to exist")
+	}
+	if got, want := entry.Value, 5; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := entry.Hits, uint64(2); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if entry.InsertedAt.IsZero() || entry.LastAccessedAt.Before(entry.InsertedAt) {
+		t.Errorf("expected valid timestamps, got %s and %s", entry.InsertedAt, entry.LastAccessedAt)
+	}
+}
@@ -1,6 +1,9 @@
 package cache
 
 import (
+	"container/heap"
+	"context"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,10 +19,11 @@ var _ Cache[string, string] = (*TTL[string, string])(nil)
 // are best for performance.
 type TTL[K comparable, V any] struct {
 	// cache represents the internal cache storage.
-	cache map[K]*ttlListItem[K, V]
+	cache map[K]*ttlItem[K, V]
 
-	// head points to the head of the linked list and tail points to the tail.
-	head, tail *ttlListItem[K, V]
+	// expiry is a min-heap of the entries ordered by expiration, so the next
+	// entry to expire is always at the root.
+	expiry ttlHeap[K, V]
 
 	// ttl is the global TTL value.
 	ttl time.Duration
@@ -29,8 +33,185 @@ type TTL[K comparable, V any] struct {
 	stopped uint32
 	stopCh  chan struct{}
 
+	// done is closed when the cache's context is cancelled, or is nil if the
+	// cache was not created with a context.
+	done <-chan struct{}
+
 	// lock is the internal lock to allow for concurrent operations.
-	lock sync.RWMutex
+	lock waitMutex
+
+	// onSweep is the optional function invoked after each sweep.
+	onSweep func(SweepStats)
+
+	// sweepInterval is the time between background sweeps, and sweepPaused
+	// indicates whether they are paused. The interval is accessed atomically,
+	// since it changes with WithAdaptiveSweep.
+	sweepInterval time.Duration
+	sweepPaused   uint32
+
+	// sweepMin and sweepMax bound the sweep interval with WithAdaptiveSweep. They
+	// are 0 if the interval is fixed.
+	sweepMin, sweepMax time.Duration
+
+	// sweepBatch is the maximum number of entries removed while holding the
+	// write lock during a sweep, or 0 if it is unlimited.
+	sweepBatch int
+
+	// retention is how long expired entries are kept before they are swept.
+	retention time.Duration
+
+	// softTTL is how long entries are fresh, after which they are still served
+	// but refreshed by Fetch, or 0 if entries are fresh until they expire.
+	softTTL time.Duration
+
+	// refresher is the function which reloads entries past their soft TTL, or
+	// nil if they are not refreshed.
+	refresher func(K) (V, error)
+
+	// lastSweep holds the statistics for the most recent sweep. It is guarded by
+	// lastSweepLock, since sweeps happen in the background.
+	lastSweep     SweepStats
+	lastSweepLock sync.Mutex
+
+	// purgeTimers are the timers for scheduled purges. They are stopped when the
+	// cache is stopped.
+	purgeTimers []*time.Timer
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+
+	// expired receives the entries removed by the sweeper, or is nil if
+	// WithExpiredChannel was not given.
+	expired chan Entry[K, V]
+
+	// onLifetime are the functions to invoke with how long each entry removed by
+	// the sweeper was cached.
+	onLifetime []func(time.Duration)
+}
+
+// TTLOption is an option for configuring a TTL cache.
+type TTLOption func(*ttlOptions)
+
+// ttlOptions are the options for a TTL cache.
+type ttlOptions struct {
+	onSweep     func(SweepStats)
+	expiredSize int
+	sweepMin    time.Duration
+	sweepMax    time.Duration
+	sweepBatch  int
+	retention   time.Duration
+	softTTL     time.Duration
+}
+
+// WithSweepHook registers a function which is invoked after each background
+// sweep with statistics about the run. The function is called synchronously
+// from the sweeper goroutine, so it should return quickly.
+func WithSweepHook(fn func(SweepStats)) TTLOption {
+	return func(o *ttlOptions) {
+		o.onSweep = fn
+	}
+}
+
+// WithAdaptiveSweep adjusts the interval between background sweeps to the rate
+// at which entries expire, between min and max. After a sweep which removes
+// expired entries, the interval is halved, and after a sweep which removes none,
+// it is doubled, so the sweeper runs frequently during churn and backs off when
+// the cache is quiet. By default, the interval is fixed at a quarter of the TTL.
+func WithAdaptiveSweep(min, max time.Duration) TTLOption {
+	if min <= 0 {
+		panic("min must be greater than 0")
+	}
+	if max < min {
+		panic("max must not be less than min")
+	}
+
+	return func(o *ttlOptions) {
+		o.sweepMin = min
+		o.sweepMax = max
+	}
+}
+
+// WithSweepBatch limits each sweep to removing n expired entries at a time while
+// holding the write lock. After each batch, the lock is released so waiting
+// operations can proceed before the sweep continues, so a cache with many
+// entries expiring at once does not block writers for the whole sweep. By
+// default, a sweep removes all expired entries under a single lock.
+func WithSweepBatch(n int) TTLOption {
+	if n <= 0 {
+		panic("n must be greater than 0")
+	}
+
+	return func(o *ttlOptions) {
+		o.sweepBatch = n
+	}
+}
+
+// WithSoftTTL sets a second, shorter threshold after which entries are stale
+// but still served, for two-stage freshness as in CDN caching. Past the soft
+// TTL, GetFresh reports the value as stale, and Fetch returns it while reloading
+// it in the background with the function registered with RefreshWith. Past the
+// cache's TTL, the entry is expired and a true miss. The soft TTL must be less
+// than the cache's TTL.
+func WithSoftTTL(soft time.Duration) TTLOption {
+	if soft <= 0 {
+		panic("soft ttl must be greater than 0")
+	}
+
+	return func(o *ttlOptions) {
+		o.softTTL = soft
+	}
+}
+
+// WithExpiredRetention keeps expired entries for the given duration past their
+// expiration before the sweeper removes them. Expired entries are never
+// returned by Get, but during the retention window they can still be read with
+// GetStale, for example to serve stale data when reloading fails. By default,
+// expired entries are removed by the next sweep.
+func WithExpiredRetention(d time.Duration) TTLOption {
+	if d <= 0 {
+		panic("retention must be greater than 0")
+	}
+
+	return func(o *ttlOptions) {
+		o.retention = d
+	}
+}
+
+// WithExpiredChannel enables the channel returned by Expired, buffering up to
+// size entries. Entries expired while the buffer is full are dropped rather
+// than blocking the sweeper.
+func WithExpiredChannel(size int) TTLOption {
+	if size <= 0 {
+		panic("size must be greater than 0")
+	}
+
+	return func(o *ttlOptions) {
+		o.expiredSize = size
+	}
+}
+
+// SweepStats are statistics about a single run of the TTL cache's background
+// sweeper.
+type SweepStats struct {
+	// StartedAt is the time at which the sweep started and Duration is how long
+	// it took, including the time spent waiting for the lock.
+	StartedAt time.Time
+	Duration  time.Duration
+
+	// Examined is the number of entries the sweep inspected and Reaped is the
+	// number of expired entries it removed.
+	Examined int
+	Reaped   int
+
+	// Remaining is the number of entries in the cache after the sweep.
+	Remaining int
+
+	// Batches is the number of times the sweep acquired the write lock. It is
+	// only greater than 1 with WithSweepBatch.
+	Batches int
+
+	// NextRun is the time at which the next sweep is scheduled.
+	NextRun time.Time
 }
 
 // NewTTL creates a new TTL cache with the given of the given TTL. The TTL
@@ -38,118 +219,741 @@ type TTL[K comparable, V any] struct {
 // from the cache at their exact expiration time, but they are guaranteed to not
 // be returned past their expiration time. The sweeping operation runs on
 // quarterstep intervals of the provided TTL.
-func NewTTL[K comparable, V any](ttl time.Duration) *TTL[K, V] {
+//
+// Expiration is measured with the monotonic clock, so changes to the system's
+// wall clock do not cause entries to expire early or late.
+func NewTTL[K comparable, V any](ttl time.Duration, opts ...TTLOption) *TTL[K, V] {
+	return newTTL[K, V](nil, ttl, opts...)
+}
+
+// NewTTLContext creates a new TTL cache like NewTTL, which is stopped when the
+// given context is cancelled, so it can be tied to the lifetime of a service
+// like its other background goroutines. Stop may still be called to stop the
+// cache before the context is cancelled.
+func NewTTLContext[K comparable, V any](ctx context.Context, ttl time.Duration, opts ...TTLOption) *TTL[K, V] {
+	return newTTL[K, V](ctx.Done(), ttl, opts...)
+}
+
+// newTTL creates a new TTL cache which is stopped when done is closed.
+func newTTL[K comparable, V any](done <-chan struct{}, ttl time.Duration, opts ...TTLOption) *TTL[K, V] {
 	if ttl <= 0 {
 		panic("ttl must be greater than 0")
 	}
 
+	var o ttlOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.softTTL >= ttl {
+		panic("soft ttl must be less than ttl")
+	}
+
 	c := &TTL[K, V]{
-		cache:  make(map[K]*ttlListItem[K, V], 16),
-		ttl:    ttl,
-		stopCh: make(chan struct{}),
+		cache:      make(map[K]*ttlItem[K, V], 16),
+		ttl:        ttl,
+		stopCh:     make(chan struct{}),
+		done:       done,
+		onSweep:    o.onSweep,
+		sweepBatch: o.sweepBatch,
+		retention:  o.retention,
+		softTTL:    o.softTTL,
+	}
+	if o.expiredSize > 0 {
+		c.expired = make(chan Entry[K, V], o.expiredSize)
 	}
 
 	// Start the sweep!
-	sweep := ttl / 4
-	if min := 50 * time.Millisecond; sweep < min {
-		sweep = min
+	c.sweepInterval = ttl / 4
+	if min := 50 * time.Millisecond; c.sweepInterval < min {
+		c.sweepInterval = min
+	}
+	if o.sweepMax > 0 {
+		c.sweepMin, c.sweepMax = o.sweepMin, o.sweepMax
+		c.sweepInterval = c.clampSweep(c.sweepInterval)
 	}
-	go c.start(sweep)
+	go c.start(c.sweepInterval)
 
 	return c
 }
 
+// NewTTLFromMap creates a new TTL cache with the given TTL, populated with the
+// entries in m. It is considerably faster than calling Set for each entry. All
+// entries expire at the same time.
+func NewTTLFromMap[K comparable, V any](ttl time.Duration, m map[K]V, opts ...TTLOption) *TTL[K, V] {
+	c := NewTTL[K, V](ttl, opts...)
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k, v := range m {
+		c.set(k, v, now, monoTime(now)+int64(c.ttl))
+	}
+	return c
+}
+
+// LastSweep returns statistics about the most recent background sweep. If no
+// sweep has run yet, it returns the zero value.
+func (l *TTL[K, V]) LastSweep() SweepStats {
+	l.lastSweepLock.Lock()
+	defer l.lastSweepLock.Unlock()
+	return l.lastSweep
+}
+
 // Get fetches the cache item at the given key. If the value exists, it is
 // returned. If the value does not exist, it returns the zero value for the
 // object and the second parameter will be false.
 func (l *TTL[K, V]) Get(key K) (V, bool) {
-	now := time.Now().UTC()
+	now := time.Now()
 	l.lock.RLock()
 	defer l.lock.RUnlock()
 	return l.get(key, now)
 }
 
+// GetFresh fetches the cache item at the given key like Get, and also reports
+// whether it is fresh. With WithSoftTTL, a value past its soft TTL is returned
+// but is not fresh, and the caller should refresh it. Without a soft TTL, every
+// value which is returned is fresh.
+func (l *TTL[K, V]) GetFresh(key K) (V, bool, bool) {
+	now := time.Now()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.lookup(key, now)
+}
+
 // get is the internal implementation of Get. It does not lock.
 func (l *TTL[K, V]) get(key K, now time.Time) (V, bool) {
+	v, _, ok := l.lookup(key, now)
+	return v, ok
+}
+
+// lookup is the internal implementation of GetFresh. It does not lock.
+func (l *TTL[K, V]) lookup(key K, now time.Time) (V, bool, bool) {
 	if l.isStopped() {
-		panic("cache is stopped")
+		panic(ErrStopped)
 	}
 
 	v, ok := l.cache[key]
-	if !ok || v.expiresAt.Before(now) {
+	mono := monoTime(now)
+	if !ok || v.expiresAt < mono {
 		var zeroV V
-		return zeroV, false
+		return zeroV, false, false
 	}
-	return v.value, true
+
+	v.meta.recordAccess(now.UnixNano())
+	return v.value, v.refreshAt == 0 || v.refreshAt >= mono, true
 }
 
 // Set inserts the value in the cache. If an entry already exists at the given
 // key, it is overwritten. If an entry does not exist, a new entry is created.
 func (l *TTL[K, V]) Set(key K, val V) {
-	now := time.Now().UTC()
+	now := time.Now()
 	l.lock.Lock()
 	defer l.lock.Unlock()
-	l.set(key, val, now)
+	l.set(key, val, now, monoTime(now)+int64(l.ttl))
+}
+
+// SetWithExpireAt inserts the value in the cache with the given absolute
+// expiration instead of the global TTL. It is intended for values whose expiry
+// is dictated externally, such as the exp claim of a token. If an entry already
+// exists at the given key, it is overwritten. If t is in the past, the entry is
+// never returned and is removed on the next sweep. Since t is an absolute time,
+// it is interpreted against the wall clock.
+func (l *TTL[K, V]) SetWithExpireAt(key K, val V, t time.Time) {
+	now := time.Now()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.set(key, val, now, monoTime(t.Round(0)))
 }
 
 // set is the internal implementation for set. It does not lock.
-func (l *TTL[K, V]) set(key K, val V, now time.Time) {
+func (l *TTL[K, V]) set(key K, val V, now time.Time, expiresAt int64) {
 	if l.isStopped() {
-		panic("cache is stopped")
+		panic(ErrStopped)
 	}
 
 	node, ok := l.cache[key]
 	if !ok {
-		node = &ttlListItem[K, V]{
-			key: &key,
+		node = &ttlItem[K, V]{
+			key: key,
 		}
 		l.cache[key] = node
 	}
 	node.value = val
-	node.expiresAt = ptrTo(now.Add(l.ttl))
+	node.expiresAt = expiresAt
+	node.refreshAt = l.refreshAt(now)
+	node.meta.reset(now.UnixNano())
 
-	// If this is the first entry in the cache, update the head.
-	if l.head == nil {
-		l.head = node
+	if ok {
+		heap.Fix(&l.expiry, node.index)
+	} else {
+		heap.Push(&l.expiry, node)
 	}
+}
 
-	// This entry is new, so add it to the end of the list.
-	if l.tail != nil {
-		l.tail.next = node
+// SetIfVersion inserts the value in the cache only if the version of the entry
+// at the given key is the expected version, as with Set. An expected version
+// of 0 matches a missing or expired entry. It returns true if the value was
+// inserted.
+func (l *TTL[K, V]) SetIfVersion(key K, val V, version uint64) bool {
+	now := time.Now()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	var current uint64
+	if node, ok := l.cache[key]; ok && node.expiresAt >= monoTime(now) {
+		current = node.meta.version
+	}
+	if current != version {
+		return false
 	}
-	l.tail = node
+
+	l.set(key, val, now, monoTime(now)+int64(l.ttl))
+	return true
+}
+
+// Touch resets the expiration of the entry at the given key to the global TTL
+// from now, without changing its value. It does not count as an access. It
+// returns false if there is no unexpired entry at the given key.
+func (l *TTL[K, V]) Touch(key K) bool {
+	now := time.Now()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := l.cache[key]
+	if !ok || node.expiresAt < monoTime(now) {
+		return false
+	}
+
+	node.expiresAt = monoTime(now) + int64(l.ttl)
+	node.refreshAt = l.refreshAt(now)
+	heap.Fix(&l.expiry, node.index)
+	return true
+}
+
+// refreshAt returns the time at which an entry set at the given time stops
+// being fresh, or 0 if there is no soft TTL.
+func (l *TTL[K, V]) refreshAt(now time.Time) int64 {
+	if l.softTTL == 0 {
+		return 0
+	}
+	return monoTime(now) + int64(l.softTTL)
+}
+
+// Delete removes the entry at the given key from the cache, even if it has
+// expired. It returns false if there is no entry at the given key.
+func (l *TTL[K, V]) Delete(key K) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := l.cache[key]
+	if !ok {
+		return false
+	}
+	l.remove(node)
+	return true
+}
+
+// RemoveOldest removes the entry which expires soonest from the cache, which is
+// the entry that would be swept next, and returns it. The entry may already
+// have expired. If the cache is empty, the third return value is false.
+func (l *TTL[K, V]) RemoveOldest() (K, V, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	if len(l.expiry) == 0 {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+
+	node := l.expiry[0]
+	key, value := node.key, node.value
+	l.remove(node)
+	return key, value, true
+}
+
+// remove removes the node from the cache and the expiry index. It does not
+// lock.
+func (l *TTL[K, V]) remove(node *ttlItem[K, V]) {
+	delete(l.cache, node.key)
+	heap.Remove(&l.expiry, node.index)
+
+	var zeroK K
+	var zeroV V
+	node.key = zeroK
+	node.value = zeroV
 }
 
 // Fetch retrieves the cached value. If the value does not exist, the FetchFunc
 // is called and the result is stored. If the value does exist, the FetchFunc is
-// not invoked.
+// not invoked. The FetchFunc runs without holding the cache's lock, so other
+// operations proceed while it runs, and concurrent calls to Fetch for the same
+// key share a single invocation. The entry's expiration starts when the
+// FetchFunc returns.
+//
+// With WithSoftTTL, a value past its soft TTL is returned immediately, and is
+// reloaded in the background with the function registered with RefreshWith.
+// The FetchFunc is only ever called before Fetch returns.
 func (l *TTL[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
-	now := time.Now().UTC()
+	if v, fresh, ok := l.GetFresh(key); ok {
+		if !fresh {
+			l.refresh(key)
+		}
+		return v, nil
+	}
+
+	return l.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := l.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		l.Set(key, v)
+		return v, nil
+	})
+}
+
+// RefreshWith registers the function which reloads entries past their soft TTL
+// when they are fetched. It is called on a background goroutine, once at a time
+// for each key, while Fetch returns the stale value. On success the entry is
+// replaced; on failure the error is discarded, the stale value is kept, and the
+// next Fetch tries again. Without a refresh function, stale entries are served
+// until they expire.
+func (l *TTL[K, V]) RefreshWith(fn func(K) (V, error)) {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
 
 	l.lock.Lock()
 	defer l.lock.Unlock()
+	l.refresher = fn
+}
 
-	if l.isStopped() {
-		panic("cache is stopped")
+// refresh reloads the value at the given key in the background with the
+// refresh function, unless it is already being loaded.
+func (l *TTL[K, V]) refresh(key K) {
+	l.lock.RLock()
+	fn := l.refresher
+	l.lock.RUnlock()
+
+	if fn == nil {
+		return
 	}
 
-	if v, ok := l.get(key, now); ok {
+	l.loads.start(key, func() (V, error) {
+		v, err := fn(key)
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		l.Set(key, v)
+		return v, nil
+	})
+}
+
+// FetchTTLFunc is a function that is invoked when a cached value is not found,
+// which returns the value along with how long it should be cached.
+type FetchTTLFunc[V any] func() (V, time.Duration, error)
+
+// FetchWithTTL retrieves the cached value like Fetch, but the FetchTTLFunc
+// returns the lifetime of the value along with it, for values whose lifetime
+// comes from their source, such as DNS record TTLs, Cache-Control max-age, or
+// token expiry. The returned TTL is used instead of the cache's TTL, and its
+// expiration starts when the FetchTTLFunc returns. If the returned TTL is not
+// greater than 0, the value is returned but not cached.
+func (l *TTL[K, V]) FetchWithTTL(key K, fn FetchTTLFunc[V]) (V, error) {
+	if v, ok := l.Get(key); ok {
+		return v, nil
+	}
+
+	return l.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := l.Get(key); ok {
+			return v, nil
+		}
+
+		v, ttl, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		if ttl > 0 {
+			now := time.Now()
+			l.lock.Lock()
+			defer l.lock.Unlock()
+			l.set(key, v, now, monoTime(now)+int64(ttl))
+		}
 		return v, nil
+	})
+}
+
+// Purge removes all entries for which fn returns true, or all entries if fn is
+// nil, and returns the number of entries removed. The cache is locked for the
+// duration of the purge, so fn must not call methods on the cache.
+func (l *TTL[K, V]) Purge(fn func(K, V) bool) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+	return l.purge(fn)
+}
+
+// SchedulePurge purges the cache at the times given by the schedule, using the
+// cache's own background machinery. The fn function selects the entries to
+// remove as in Purge; if it is nil, all entries are removed. For example, to
+// clear the cache every day at midnight UTC:
+//
+//	c.SchedulePurge(cache.Daily(0, 0, time.UTC), nil)
+//
+// Scheduled purges run until the cache is stopped, or until the schedule
+// returns the zero time.
+func (l *TTL[K, V]) SchedulePurge(s Schedule, fn func(K, V) bool) {
+	if s == nil {
+		panic("schedule cannot be nil")
+	}
+
+	next := s.Next(time.Now())
+	if next.IsZero() {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	// The timer is assigned while holding the lock, and the callback acquires
+	// the lock before reading it, so the callback always sees the timer.
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(next), func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+
+		if l.isStopped() {
+			return
+		}
+		l.purge(fn)
+
+		if next := s.Next(time.Now()); !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
+	})
+	l.purgeTimers = append(l.purgeTimers, timer)
+}
+
+// purge is the internal implementation of Purge. It does not lock.
+func (l *TTL[K, V]) purge(fn func(K, V) bool) int {
+	var removed int
+	for _, node := range l.cache {
+		if fn != nil && !fn(node.key, node.value) {
+			continue
+		}
+
+		l.remove(node)
+		removed++
+	}
+	return removed
+}
+
+// GetEntry fetches the cache item at the given key along with its metadata.
+// It counts as an access, like Get, and the returned metadata includes it. If
+// the value does not exist, the second return value is false.
+func (l *TTL[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	now := time.Now()
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if _, ok := l.get(key, now); !ok {
+		return Entry[K, V]{}, false
+	}
+
+	node := l.cache[key]
+	entry := Entry[K, V]{
+		Key:       key,
+		Value:     node.value,
+		ExpiresAt: wallTime(node.expiresAt),
 	}
+	fillEntry(&entry, &node.meta)
+	return entry, true
+}
+
+// Entries returns a snapshot of the unexpired entries in the cache, including
+// their metadata, in eviction order: the first entry is the next to expire.
+func (l *TTL[K, V]) Entries() []Entry[K, V] {
+	now := nanotime()
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	entries := make([]Entry[K, V], 0, len(l.cache))
+	for key, node := range l.cache {
+		if node.expiresAt < now {
+			continue
+		}
+
+		entry := Entry[K, V]{
+			Key:       key,
+			Value:     node.value,
+			ExpiresAt: wallTime(node.expiresAt),
+		}
+		fillEntry(&entry, &node.meta)
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ExpiresAt.Before(entries[j].ExpiresAt)
+	})
+	return entries
+}
+
+// Snapshot returns an immutable view of the cache's unexpired entries as of the
+// call. The entries are copied while holding the shared lock, which takes time
+// linear in the size of the cache, and walking the snapshot does not hold the
+// lock. Entries in the snapshot do not expire. For constant-time snapshots, use
+// Snapshotting.
+func (l *TTL[K, V]) Snapshot() *Snapshot[K, V] {
+	now := nanotime()
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	snap := newSnapshot[K, V](len(l.cache))
+	for key, node := range l.cache {
+		if node.expiresAt >= now {
+			snap.add(key, node.value)
+		}
+	}
+	return snap
+}
+
+// Range calls fn for each unexpired entry in a snapshot of the cache, in no
+// particular order, until fn returns false. Since the cache is not locked while
+// fn runs, fn may call methods on the cache.
+func (l *TTL[K, V]) Range(fn func(K, V) bool) {
+	l.Snapshot().Range(fn)
+}
+
+// AscendEvictionOrder calls fn for each unexpired entry in the cache in
+// eviction order, starting with the next entry to expire. Iteration stops if fn
+// returns false. The cache is locked for the duration of the iteration, so fn
+// must not call methods on the cache.
+func (l *TTL[K, V]) AscendEvictionOrder(fn func(K, V) bool) {
+	now := nanotime()
 
-	v, err := fn()
-	if err != nil {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	nodes := make([]*ttlItem[K, V], 0, len(l.cache))
+	for _, node := range l.cache {
+		if node.expiresAt >= now {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].expiresAt < nodes[j].expiresAt
+	})
+
+	for _, node := range nodes {
+		if !fn(node.key, node.value) {
+			return
+		}
+	}
+}
+
+// Oldest returns the unexpired entry in the cache which was set the longest
+// time ago. With per-entry TTLs, it is not necessarily the next to expire; see
+// RemoveOldest. It does not count as an access. If the cache has no unexpired
+// entries, the third return value is false.
+func (l *TTL[K, V]) Oldest() (K, V, bool) {
+	return l.extreme(func(a, b *ttlItem[K, V]) bool { return setBefore(&a.meta, &b.meta) })
+}
+
+// Newest returns the unexpired entry in the cache which was set most recently.
+// It does not count as an access. If the cache has no unexpired entries, the
+// third return value is false.
+func (l *TTL[K, V]) Newest() (K, V, bool) {
+	return l.extreme(func(a, b *ttlItem[K, V]) bool { return setBefore(&b.meta, &a.meta) })
+}
+
+// setBefore reports whether the value with metadata a was set before the value
+// with metadata b. Values set within the same clock tick are ordered by their
+// versions, which increase with every set.
+func setBefore(a, b *entryMeta) bool {
+	if a.insertedAt != b.insertedAt {
+		return a.insertedAt < b.insertedAt
+	}
+	return a.version < b.version
+}
+
+// extreme returns the unexpired entry which sorts first according to the given
+// comparison.
+func (l *TTL[K, V]) extreme(less func(a, b *ttlItem[K, V]) bool) (K, V, bool) {
+	now := nanotime()
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	var found *ttlItem[K, V]
+	for _, node := range l.cache {
+		if node.expiresAt < now {
+			continue
+		}
+		if found == nil || less(node, found) {
+			found = node
+		}
+	}
+
+	if found == nil {
+		var zeroK K
 		var zeroV V
-		return zeroV, err
+		return zeroK, zeroV, false
 	}
+	return found.key, found.value, true
+}
+
+// GetStale fetches the cache item at the given key even if it has expired, as
+// long as it has not yet been swept, for serving slightly stale data when the
+// source of truth is unavailable. Use WithExpiredRetention to keep expired
+// entries available for longer. The age is how long ago the value expired, so
+// a positive age marks the value as stale; it is 0 if the value has not
+// expired. Unlike Get, it does not count as an access. If there is no value,
+// the third return value is false.
+func (l *TTL[K, V]) GetStale(key K) (V, time.Duration, bool) {
+	now := nanotime()
 
-	l.set(key, v, now)
-	return v, nil
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	if l.isStopped() {
+		panic(ErrStopped)
+	}
+
+	v, ok := l.cache[key]
+	if !ok {
+		var zeroV V
+		return zeroV, 0, false
+	}
+
+	var age time.Duration
+	if now > v.expiresAt {
+		age = time.Duration(now - v.expiresAt)
+	}
+	return v.value, age, true
+}
+
+// getStale returns the value at the given key even if it has expired, as long
+// as it has not yet been swept. It does not count as an access.
+func (l *TTL[K, V]) getStale(key K) (V, bool) {
+	v, _, ok := l.GetStale(key)
+	return v, ok
+}
+
+// Len returns the number of entries in the cache. This includes
+// expired entries which have not yet been swept.
+func (l *TTL[K, V]) Len() int {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return len(l.cache)
+}
+
+// LockWait returns the total time operations have spent waiting to acquire the
+// cache's internal lock. A steadily increasing value indicates contention.
+func (l *TTL[K, V]) LockWait() time.Duration {
+	return l.lock.waitTime()
+}
+
+// Expired returns a channel which receives each entry as the background sweeper
+// removes it, so expiration can drive downstream processing such as cleaning up
+// timed-out sessions. Entries removed by other means, such as RemoveOldest or
+// Purge, are not sent. The channel is closed when the cache is stopped.
+//
+// The channel is only enabled with WithExpiredChannel; otherwise Expired
+// returns nil.
+func (l *TTL[K, V]) Expired() <-chan Entry[K, V] {
+	return l.expired
+}
+
+// onEvictLifetime registers a function which is invoked with how long each
+// entry removed by the sweeper was cached.
+func (l *TTL[K, V]) onEvictLifetime(fn func(time.Duration)) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.onLifetime = appendHook(l.onLifetime, fn)
+}
+
+// PauseSweeper pauses the background sweeper, so it no longer takes the write
+// lock, for example during a latency-critical section. Expired entries are
+// still never returned, but they are not removed until the sweeper is resumed.
+func (l *TTL[K, V]) PauseSweeper() {
+	atomic.StoreUint32(&l.sweepPaused, 1)
+}
+
+// ResumeSweeper resumes the background sweeper after PauseSweeper, first
+// running a catch-up sweep to remove the entries which expired while it was
+// paused. The catch-up sweep runs synchronously and is reported like any other
+// sweep. If the sweeper is not paused, it does nothing.
+func (l *TTL[K, V]) ResumeSweeper() {
+	if !atomic.CompareAndSwapUint32(&l.sweepPaused, 1, 0) {
+		return
+	}
+	if l.isStopped() {
+		return
+	}
+	l.recordSweep(l.sweep())
 }
 
 // Stop clears the cache and prevents new entries from being added and
 // retrieved.
 func (l *TTL[K, V]) Stop() {
+	l.loads.close()
+
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
@@ -157,37 +961,38 @@ func (l *TTL[K, V]) Stop() {
 		return
 	}
 	close(l.stopCh)
+	if l.expired != nil {
+		close(l.expired)
+	}
 
-	for k, v := range l.cache {
-		var zeroV V
-		v.key = nil
-		v.value = zeroV
-		v.expiresAt = nil
-		delete(l.cache, k)
+	for _, timer := range l.purgeTimers {
+		timer.Stop()
 	}
-	l.cache = nil
+	l.purgeTimers = nil
 
-	var zeroK *K
+	var zeroK K
 	var zeroV V
 
-	node := l.head
-	for node != nil {
-		node.key = zeroK
-		node.value = zeroV
-		node, node.next = node.next, nil
+	for k, v := range l.cache {
+		v.key = zeroK
+		v.value = zeroV
+		delete(l.cache, k)
 	}
-
-	l.head = nil
-	l.tail = nil
+	l.cache = nil
+	l.expiry = nil
 }
 
+// readOnlyGet marks that Get does not modify the cache.
+func (l *TTL[K, V]) readOnlyGet() {}
+
 // isStopped is a helper for checking if the queue is stopped.
 func (l *TTL[K, V]) isStopped() bool {
 	return atomic.LoadUint32(&l.stopped) == 1
 }
 
 // start begins the background reaping process for expired entries. It runs
-// until stopped via Stop() and is intended to be called as a goroutine.
+// until stopped via Stop() or the cache's context is cancelled, and is intended
+// to be called as a goroutine.
 func (l *TTL[K, V]) start(sweep time.Duration) {
 	ticker := time.NewTicker(sweep)
 	defer ticker.Stop()
@@ -202,44 +1007,197 @@ func (l *TTL[K, V]) start(sweep time.Duration) {
 		select {
 		case <-l.stopCh:
 			return
+		case <-l.done:
+			l.Stop()
+			return
 		case <-ticker.C:
-			func() {
-				now := time.Now().UTC()
+			if atomic.LoadUint32(&l.sweepPaused) == 1 {
+				continue
+			}
+
+			stats := l.sweep()
+			if next, ok := l.adaptSweep(stats); ok {
+				ticker.Reset(next)
+			}
+			l.recordSweep(stats)
+		}
+	}
+}
+
+// adaptSweep computes the next sweep interval from the results of a sweep with
+// WithAdaptiveSweep. It returns false if the interval did not change.
+func (l *TTL[K, V]) adaptSweep(stats SweepStats) (time.Duration, bool) {
+	if l.sweepMax == 0 {
+		return 0, false
+	}
+
+	current := l.interval()
+	next := current * 2
+	if stats.Reaped > 0 {
+		next = current / 2
+	}
+	next = l.clampSweep(next)
+	if next == current {
+		return 0, false
+	}
+
+	atomic.StoreInt64((*int64)(&l.sweepInterval), int64(next))
+	return next, true
+}
+
+// clampSweep bounds the sweep interval to the adaptive range.
+func (l *TTL[K, V]) clampSweep(d time.Duration) time.Duration {
+	if d < l.sweepMin {
+		return l.sweepMin
+	}
+	if d > l.sweepMax {
+		return l.sweepMax
+	}
+	return d
+}
+
+// interval returns the current interval between background sweeps.
+func (l *TTL[K, V]) interval() time.Duration {
+	return time.Duration(atomic.LoadInt64((*int64)(&l.sweepInterval)))
+}
 
-				l.lock.Lock()
-				defer l.lock.Unlock()
+// recordSweep records the statistics for a completed sweep and invokes the
+// sweep hook.
+func (l *TTL[K, V]) recordSweep(stats SweepStats) {
+	stats.NextRun = stats.StartedAt.Add(l.interval())
+
+	l.lastSweepLock.Lock()
+	l.lastSweep = stats
+	l.lastSweepLock.Unlock()
+
+	if l.onSweep != nil {
+		l.onSweep(stats)
+	}
+}
+
+// sweep removes all expired entries, other than those within the retention
+// window, from the cache and returns statistics about the run. The NextRun field is not populated.
+func (l *TTL[K, V]) sweep() SweepStats {
+	now := time.Now()
+
+	var stats SweepStats
+	stats.StartedAt = now
+	cutoff := monoTime(now) - int64(l.retention)
+
+	// Entries which expire during the sweep are left for the next one, so the
+	// sweep always finishes even if entries expire faster than it removes them.
+	for l.sweepBatchAt(cutoff, &stats) {
+	}
+
+	stats.Duration = time.Since(now)
+	return stats
+}
+
+// sweepBatchAt removes up to sweepBatch entries which expired at or before
+// cutoff while holding the write lock, adding to the given statistics. It
+// returns true if there may be more expired entries to remove.
+func (l *TTL[K, V]) sweepBatchAt(cutoff int64, stats *SweepStats) bool {
+	var expired []victim[K, V]
+	defer func() {
+		for i := range expired {
+			expired[i].notify()
+		}
+	}()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
 
-				// Walk the LinkedList from the front, since those are the oldest items.
-				node := l.head
-				for node != nil {
-					// If this item isn't a candidate for expiration, then no future items
-					// will be a candidate either, since they are in increasing order.
-					if node.expiresAt.After(now) {
-						break
-					}
+	stats.Batches++
+	defer func() { stats.Remaining = len(l.cache) }()
 
-					delete(l.cache, *node.key)
+	// Pop from the root of the heap, since it is always the next to expire.
+	for n := 0; len(l.expiry) > 0; n++ {
+		if l.sweepBatch > 0 && n >= l.sweepBatch {
+			return true
+		}
+		stats.Examined++
 
-					var zeroV V
-					node.key = nil
-					node.value = zeroV
-					node.expiresAt = nil
-					node, node.next = node.next, nil
-				}
+		// If this item isn't a candidate for expiration, then no other items will
+		// be a candidate either, since it expires soonest.
+		node := l.expiry[0]
+		if node.expiresAt > cutoff {
+			return false
+		}
 
-				l.head = node
-				if node == nil {
-					l.tail = nil
-				}
-			}()
+		l.notifyExpired(node)
+		if len(l.onLifetime) > 0 {
+			expired = append(expired, newVictim(node.key, node.value, node.meta.insertedAt, true, nil, l.onLifetime))
 		}
+		l.remove(node)
+		stats.Reaped++
+	}
+	return false
+}
+
+// notifyExpired sends the node's entry on the expired channel, if it is enabled
+// and has room. It must be called while holding the write lock, which
+// guarantees the channel is not closed concurrently.
+func (l *TTL[K, V]) notifyExpired(node *ttlItem[K, V]) {
+	if l.expired == nil {
+		return
+	}
+
+	entry := Entry[K, V]{
+		Key:       node.key,
+		Value:     node.value,
+		ExpiresAt: wallTime(node.expiresAt),
+	}
+	fillEntry(&entry, &node.meta)
+
+	select {
+	case l.expired <- entry:
+	default:
 	}
 }
 
-// ttlListItem represents an entry in the linked list.
-type ttlListItem[K comparable, V any] struct {
-	next      *ttlListItem[K, V]
-	key       *K
+// ttlItem represents an entry in the cache.
+type ttlItem[K comparable, V any] struct {
+	meta      entryMeta
+	key       K
 	value     V
-	expiresAt *time.Time
+	expiresAt int64
+
+	// refreshAt is when the entry stops being fresh with WithSoftTTL, or 0 if
+	// it is fresh until it expires.
+	refreshAt int64
+
+	// index is the position of the item in the expiry heap.
+	index int
+}
+
+// ttlHeap is a min-heap of entries ordered by expiration. It implements
+// heap.Interface and keeps each item's index up to date.
+type ttlHeap[K comparable, V any] []*ttlItem[K, V]
+
+func (h ttlHeap[K, V]) Len() int { return len(h) }
+
+func (h ttlHeap[K, V]) Less(i, j int) bool {
+	return h[i].expiresAt < h[j].expiresAt
+}
+
+func (h ttlHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *ttlHeap[K, V]) Push(x any) {
+	item := x.(*ttlItem[K, V])
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *ttlHeap[K, V]) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
 }
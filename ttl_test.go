@@ -1,8 +1,13 @@
 package cache
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -19,7 +24,7 @@ func TestNewTTL(t *testing.T) {
 		if got, want := cache.ttl, 5*time.Minute; got != want {
 			t.Errorf("expected %d to be %d", got, want)
 		}
-		if got, want := cache.cache, make(map[string]*ttlListItem[string, string], 10); !reflect.DeepEqual(got, want) {
+		if got, want := cache.cache, make(map[string]*ttlItem[string, string], 10); !reflect.DeepEqual(got, want) {
 			t.Errorf("expected %#v to be %#v", got, want)
 		}
 	})
@@ -39,6 +44,30 @@ func TestNewTTL(t *testing.T) {
 	})
 }
 
+func TestNewTTLFromMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("populates", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTLFromMap(time.Minute, map[string]int{"a": 1, "b": 2, "c": 3})
+		defer cache.Stop()
+
+		if got, want := cache.Len(), 3; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		for k, want := range map[string]int{"a": 1, "b": 2, "c": 3} {
+			got, ok := cache.Get(k)
+			if !ok {
+				t.Errorf("expected %q to be in the cache", k)
+			}
+			if got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+		}
+	})
+}
+
 func TestTTL_Get(t *testing.T) {
 	t.Parallel()
 
@@ -124,6 +153,83 @@ func TestTTL_Set(t *testing.T) {
 	})
 }
 
+func TestTTL_SetWithExpireAt(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sets", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](time.Millisecond)
+		defer cache.Stop()
+
+		expiresAt := time.Now().Add(5 * time.Minute)
+		cache.SetWithExpireAt("foo", 5, expiresAt)
+
+		time.Sleep(5 * time.Millisecond)
+
+		if v, _ := cache.Get("foo"); v != 5 {
+			t.Errorf("expected %#v, got %#v", 5, v)
+		}
+
+		entries := cache.Entries()
+		if got, want := len(entries), 1; got != want {
+			t.Fatalf("expected %d to be %d", got, want)
+		}
+		if got, want := entries[0].ExpiresAt, expiresAt; !got.Equal(want) {
+			t.Errorf("expected %s to be %s", got, want)
+		}
+	})
+
+	t.Run("past", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.SetWithExpireAt("foo", 5, time.Now().Add(-time.Second))
+
+		if v, ok := cache.Get("foo"); ok {
+			t.Errorf("expected %#v to be expired", v)
+		}
+	})
+
+	t.Run("orders", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		now := time.Now()
+		cache.Set("a", 1)
+		cache.SetWithExpireAt("b", 2, now.Add(time.Minute))
+		cache.SetWithExpireAt("c", 3, now.Add(time.Hour))
+		cache.SetWithExpireAt("d", 4, now.Add(time.Second))
+		cache.Set("b", 5)
+
+		if got, want := expiryOrder(t, cache), []string{"d", "a", "b", "c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+
+	t.Run("sweeps", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.Set("foo", 1)
+		cache.SetWithExpireAt("bar", 2, time.Now().Add(-time.Second))
+
+		stats := cache.sweep()
+		if got, want := stats.Reaped, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if v, _ := cache.Get("foo"); v != 1 {
+			t.Errorf("expected %#v, got %#v", 1, v)
+		}
+	})
+}
+
 func TestTTL_Fetch(t *testing.T) {
 	t.Parallel()
 
@@ -178,6 +284,86 @@ func TestTTL_Fetch(t *testing.T) {
 			t.Error("expected error")
 		}
 	})
+
+	t.Run("does_not_block", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, string](5 * time.Minute)
+		defer cache.Stop()
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		doneCh := make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			cache.Fetch("foo", func() (string, error) {
+				close(started)
+				<-release
+				return "bar", nil
+			})
+		}()
+		<-started
+
+		// Other keys are usable while the load is in progress.
+		cache.Set("zip", "zap")
+		if v, _ := cache.Get("zip"); v != "zap" {
+			t.Errorf("expected %q to be %q", v, "zap")
+		}
+
+		close(release)
+		<-doneCh
+
+		if v, _ := cache.Get("foo"); v != "bar" {
+			t.Errorf("expected %q to be %q", v, "bar")
+		}
+	})
+
+	t.Run("deduplicates", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, string](5 * time.Minute)
+		defer cache.Stop()
+
+		var calls int32
+		release := make(chan struct{})
+		started := make(chan struct{})
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Fetch("foo", func() (string, error) {
+				atomic.AddInt32(&calls, 1)
+				close(started)
+				<-release
+				return "bar", nil
+			})
+		}()
+		<-started
+
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := cache.Fetch("foo", func() (string, error) {
+					atomic.AddInt32(&calls, 1)
+					return "baz", nil
+				})
+				if err != nil || v != "bar" {
+					t.Errorf("expected %q, %v to be %q, nil", v, err, "bar")
+				}
+			}()
+		}
+
+		// Give the other callers time to join the in-flight load.
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
 }
 
 func TestTTL_Stop(t *testing.T) {
@@ -228,3 +414,1021 @@ func TestTTL_Stop(t *testing.T) {
 		t.Errorf("did not panic")
 	})
 }
+
+func TestTTL_Touch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("extends", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.SetWithExpireAt("foo", 1, time.Now().Add(time.Second))
+		cache.SetWithExpireAt("bar", 2, time.Now().Add(time.Minute))
+
+		if !cache.Touch("foo") {
+			t.Errorf("expected foo to be touched")
+		}
+		if got, want := expiryOrder(t, cache), []string{"bar", "foo"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+		if got, want := cache.cache["foo"].meta.hits, uint64(0); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.SetWithExpireAt("foo", 1, time.Now().Add(-time.Second))
+
+		if cache.Touch("foo") {
+			t.Errorf("expected expired entry to not be touched")
+		}
+		if cache.Touch("bar") {
+			t.Errorf("expected missing entry to not be touched")
+		}
+	})
+}
+
+func TestTTL_RemoveOldest(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](5 * time.Minute)
+	defer cache.Stop()
+
+	if _, _, ok := cache.RemoveOldest(); ok {
+		t.Errorf("expected empty cache to return false")
+	}
+
+	now := time.Now()
+	cache.SetWithExpireAt("foo", 1, now.Add(time.Hour))
+	cache.SetWithExpireAt("bar", 2, now.Add(time.Minute))
+	cache.Set("baz", 3)
+
+	for _, want := range []string{"bar", "baz", "foo"} {
+		k, _, ok := cache.RemoveOldest()
+		if !ok {
+			t.Fatalf("expected %q to be removed", want)
+		}
+		if k != want {
+			t.Errorf("expected %q to be %q", k, want)
+		}
+	}
+	if got, want := cache.Len(), 0; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestTTL_overwrite(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[int, int](5 * time.Minute)
+	defer cache.Stop()
+
+	// Overwrite keys repeatedly with a mix of expirations, which corrupted the
+	// previous insertion-ordered list.
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		key := i % 10
+		if i%3 == 0 {
+			cache.SetWithExpireAt(key, i, now.Add(time.Duration(i%7)*time.Second))
+		} else {
+			cache.Set(key, i)
+		}
+	}
+
+	if got, want := len(expiryOrder(t, cache)), 10; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestTTL_Purge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("all", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+
+		if got, want := cache.Purge(nil), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.Len(), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := len(cache.expiry), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("predicate", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Set("c", 3)
+		cache.Set("d", 4)
+
+		if got, want := cache.Purge(func(k string, v int) bool { return v%2 == 0 }), 2; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		if got, want := expiryOrder(t, cache), []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	})
+}
+
+func TestTTL_SchedulePurge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("purges", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+
+		cache.SchedulePurge(Every(10*time.Millisecond), func(k string, v int) bool {
+			return k == "a"
+		})
+
+		deadline := time.Now().Add(time.Second)
+		for cache.Len() != 1 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for purge")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		if _, ok := cache.Get("a"); ok {
+			t.Errorf("expected a to be purged")
+		}
+
+		// The schedule recurs.
+		cache.Set("a", 1)
+		deadline = time.Now().Add(time.Second)
+		for cache.Len() != 1 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for purge")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("ends", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		calls := 0
+		cache.SchedulePurge(ScheduleFunc(func(after time.Time) time.Time {
+			calls++
+			return time.Time{}
+		}), nil)
+
+		if got, want := calls, 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := len(cache.purgeTimers), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("stops", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		cache.SchedulePurge(Every(time.Millisecond), nil)
+		cache.Stop()
+
+		if got, want := len(cache.purgeTimers), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	})
+}
+
+func TestTTL_Entries(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](5 * time.Minute)
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+	cache.Set("bar", 3)
+	cache.Get("foo")
+	cache.Get("foo")
+
+	entries := cache.Entries()
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	for _, entry := range entries {
+		if entry.InsertedAt.IsZero() {
+			t.Errorf("expected %q to have an insertion time", entry.Key)
+		}
+
+		switch entry.Key {
+		case "foo":
+			if got, want := entry.Value, 5; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if got, want := entry.Hits, uint64(2); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if entry.LastAccessedAt.IsZero() {
+				t.Errorf("expected %q to have an access time", entry.Key)
+			}
+		case "bar":
+			if got, want := entry.Hits, uint64(0); got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if !entry.LastAccessedAt.IsZero() {
+				t.Errorf("expected %q to not have an access time", entry.Key)
+			}
+		}
+
+		if entry.ExpiresAt.IsZero() {
+			t.Errorf("expected %q to expire", entry.Key)
+		}
+	}
+}
+
+func TestTTL_sweep(t *testing.T) {
+	t.Parallel()
+
+	statsCh := make(chan SweepStats, 10)
+
+	cache := NewTTL[string, int](10*time.Millisecond, WithSweepHook(func(s SweepStats) {
+		select {
+		case statsCh <- s:
+		default:
+		}
+	}))
+	defer cache.Stop()
+
+	if got, want := cache.LastSweep(), (SweepStats{}); got != want {
+		t.Errorf("expected %#v to be %#v", got, want)
+	}
+
+	cache.Set("foo", 5)
+	cache.Set("bar", 3)
+
+	var stats SweepStats
+	for stats.Reaped == 0 {
+		select {
+		case stats = <-statsCh:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for sweep")
+		}
+	}
+
+	if got, want := stats.Reaped, 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.Examined, 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.Remaining, 0; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if !stats.NextRun.After(stats.StartedAt) {
+		t.Errorf("expected %s to be after %s", stats.NextRun, stats.StartedAt)
+	}
+	if got := cache.LastSweep(); got.StartedAt.Before(stats.StartedAt) {
+		t.Errorf("expected %s to not be before %s", got.StartedAt, stats.StartedAt)
+	}
+}
+
+func TestTTL_AscendEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](5 * time.Minute)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	time.Sleep(time.Millisecond)
+	cache.Set("bar", 2)
+	time.Sleep(time.Millisecond)
+	cache.Set("baz", 3)
+
+	var keys []string
+	cache.AscendEvictionOrder(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if got, want := keys, []string{"foo", "bar", "baz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+
+	keys = nil
+	cache.AscendEvictionOrder(func(k string, v int) bool {
+		keys = append(keys, k)
+		return false
+	})
+	if got, want := len(keys), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestTTL_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](5 * time.Minute)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+	cache.SetWithExpireAt("baz", 3, time.Now().Add(-time.Minute))
+
+	snap := cache.Snapshot()
+
+	// Later changes do not affect the snapshot.
+	cache.Set("foo", 10)
+	cache.Delete("bar")
+
+	if got, want := snap.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if v, ok := snap.Get("foo"); !ok || v != 1 {
+		t.Errorf("expected %d to be %d", v, 1)
+	}
+	if v, ok := snap.Get("bar"); !ok || v != 2 {
+		t.Errorf("expected %d to be %d", v, 2)
+	}
+	if _, ok := snap.Get("baz"); ok {
+		t.Errorf("expected expired baz not to be in the snapshot")
+	}
+
+	// Range does not hold the lock, so fn can modify the cache.
+	got := make(map[string]int)
+	cache.Range(func(k string, v int) bool {
+		got[k] = v
+		cache.Delete(k)
+		return true
+	})
+	if want := map[string]int{"foo": 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+}
+
+func TestTTL_Oldest(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](5 * time.Minute)
+	defer cache.Stop()
+
+	if k, v, ok := cache.Oldest(); ok {
+		t.Errorf("expected empty, got %q=%d", k, v)
+	}
+	if k, v, ok := cache.Newest(); ok {
+		t.Errorf("expected empty, got %q=%d", k, v)
+	}
+
+	cache.Set("foo", 1)
+	time.Sleep(time.Millisecond)
+	cache.Set("bar", 2)
+	time.Sleep(time.Millisecond)
+	cache.Set("baz", 3)
+
+	if k, v, _ := cache.Oldest(); k != "foo" || v != 1 {
+		t.Errorf("unexpected oldest %q=%d", k, v)
+	}
+	if k, v, _ := cache.Newest(); k != "baz" || v != 3 {
+		t.Errorf("unexpected newest %q=%d", k, v)
+	}
+
+	t.Run("per_entry_ttl", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](5 * time.Minute)
+		defer cache.Stop()
+
+		// The oldest entry expires last and the newest expires first, so the
+		// order of setting differs from the order of expiring.
+		cache.SetWithExpireAt("foo", 1, time.Now().Add(time.Hour))
+		cache.Set("bar", 2)
+		if _, err := cache.FetchWithTTL("baz", func() (int, time.Duration, error) {
+			return 3, time.Minute, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if k, v, _ := cache.Oldest(); k != "foo" || v != 1 {
+			t.Errorf("unexpected oldest %q=%d", k, v)
+		}
+		if k, v, _ := cache.Newest(); k != "baz" || v != 3 {
+			t.Errorf("unexpected newest %q=%d", k, v)
+		}
+
+		// Expired entries are skipped.
+		cache.SetWithExpireAt("qux", 4, time.Now().Add(-time.Minute))
+		if k, v, _ := cache.Newest(); k != "baz" || v != 3 {
+			t.Errorf("unexpected newest %q=%d", k, v)
+		}
+	})
+}
+
+// expiryOrder verifies the cache's expiry index is consistent with its storage
+// and returns the keys in order of expiration.
+func expiryOrder[K comparable, V any](tb testing.TB, c *TTL[K, V]) []K {
+	tb.Helper()
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if got, want := len(c.expiry), len(c.cache); got != want {
+		tb.Fatalf("expected %d to be %d", got, want)
+	}
+
+	items := make([]*ttlItem[K, V], len(c.expiry))
+	for i, item := range c.expiry {
+		if got, want := item.index, i; got != want {
+			tb.Fatalf("expected %d to be %d", got, want)
+		}
+		if c.cache[item.key] != item {
+			tb.Fatalf("expected %v to be in the cache", item.key)
+		}
+		if i > 0 && c.expiry[i].expiresAt < c.expiry[(i-1)/2].expiresAt {
+			tb.Fatalf("heap invariant violated at %d", i)
+		}
+		items[i] = item
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].expiresAt < items[j].expiresAt
+	})
+
+	keys := make([]K, len(items))
+	for i, item := range items {
+		keys[i] = item.key
+	}
+	return keys
+}
+
+func TestTTL_GetEntry(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](5 * time.Minute)
+	defer cache.Stop()
+
+	if _, ok := cache.GetEntry("foo"); ok {
+		t.Errorf("expected foo to be missing")
+	}
+
+	cache.Set("foo", 5)
+	cache.Get("foo")
+
+	entry, ok := cache.GetEntry("foo")
+	if !ok {
+		t.Fatalf("expected foo to exist")
+	}
+	if got, want := entry.Value, 5; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := entry.Hits, uint64(2); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if entry.InsertedAt.IsZero() || entry.LastAccessedAt.Before(entry.InsertedAt) {
+		t.Errorf("expected valid timestamps, got %s and %s", entry.InsertedAt, entry.LastAccessedAt)
+	}
+	if got := time.Until(entry.ExpiresAt); got <= 4*time.Minute || got > 5*time.Minute {
+		t.Errorf("expected %s to be about 5m", got)
+	}
+}
+
+func TestTTL_PauseSweeper(t *testing.T) {
+	t.Parallel()
+
+	var sweeps int32
+	cache := NewTTL[string, int](10*time.Millisecond, WithSweepHook(func(SweepStats) {
+		atomic.AddInt32(&sweeps, 1)
+	}))
+	defer cache.Stop()
+
+	cache.PauseSweeper()
+	before := atomic.LoadInt32(&sweeps)
+
+	cache.Set("foo", 1)
+	time.Sleep(150 * time.Millisecond)
+
+	// The entry has expired, but it has not been swept.
+	if _, ok := cache.Get("foo"); ok {
+		t.Errorf("expected foo to be expired")
+	}
+	if got, want := cache.Len(), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got := atomic.LoadInt32(&sweeps); got > before+1 {
+		t.Errorf("expected at most one in-progress sweep, got %d", got-before)
+	}
+
+	cache.ResumeSweeper()
+	if got, want := cache.Len(), 0; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.LastSweep().Reaped, 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Resuming again does nothing.
+	cache.ResumeSweeper()
+}
+
+func TestTTL_Expired(t *testing.T) {
+	t.Parallel()
+
+	t.Run("receives", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](10*time.Millisecond, WithExpiredChannel(10))
+
+		cache.Set("foo", 5)
+
+		select {
+		case entry := <-cache.Expired():
+			if got, want := entry.Key, "foo"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+			if got, want := entry.Value, 5; got != want {
+				t.Errorf("expected %d to be %d", got, want)
+			}
+			if entry.ExpiresAt.IsZero() {
+				t.Errorf("expected expiration to be set")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for expiration")
+		}
+
+		cache.Stop()
+		if _, ok := <-cache.Expired(); ok {
+			t.Errorf("expected channel to be closed")
+		}
+	})
+
+	t.Run("drops_when_full", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](10*time.Millisecond, WithExpiredChannel(1))
+		defer cache.Stop()
+
+		cache.PauseSweeper()
+		cache.Set("foo", 1)
+		cache.Set("bar", 2)
+		time.Sleep(20 * time.Millisecond)
+		cache.ResumeSweeper()
+
+		if got, want := len(cache.Expired()), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+		if got, want := cache.Len(), 0; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](time.Minute)
+		defer cache.Stop()
+
+		if cache.Expired() != nil {
+			t.Errorf("expected nil channel")
+		}
+	})
+}
+
+func TestTTL_Delete(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](time.Minute)
+	defer cache.Stop()
+
+	cache.Set("foo", 1)
+	cache.Set("bar", 2)
+
+	if !cache.Delete("foo") {
+		t.Errorf("expected foo to be deleted")
+	}
+	if cache.Delete("foo") {
+		t.Errorf("expected foo to not exist")
+	}
+	if got, want := cache.Len(), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if k, _, _ := cache.RemoveOldest(); k != "bar" {
+		t.Errorf("expected %q to be %q", k, "bar")
+	}
+}
+
+func TestTTL_onEvictLifetime(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](time.Hour)
+	defer cache.Stop()
+
+	lifetimes := make(chan time.Duration, 1)
+	cache.onEvictLifetime(func(d time.Duration) { lifetimes <- d })
+
+	cache.SetWithExpireAt("foo", 5, time.Now().Add(5*time.Millisecond))
+	time.Sleep(10 * time.Millisecond)
+	cache.sweep()
+
+	select {
+	case d := <-lifetimes:
+		if want := 5 * time.Millisecond; d < want {
+			t.Errorf("expected %v to be at least %v", d, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for lifetime")
+	}
+}
+
+func TestNewTTLContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cache := NewTTLContext[string, int](ctx, time.Minute)
+	cache.Set("foo", 5)
+
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for !cache.isStopped() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for stop")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	defer func() {
+		if got, want := recover(), ErrStopped; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	}()
+	cache.Get("foo")
+	t.Errorf("did not panic")
+}
+
+func TestWithAdaptiveSweep(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "max must not be less than min"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		WithAdaptiveSweep(time.Second, time.Millisecond)
+		t.Errorf("did not panic")
+	})
+
+	t.Run("adapts", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](time.Hour, WithAdaptiveSweep(time.Second, 8*time.Second))
+		defer cache.Stop()
+
+		// A quarter of the TTL is clamped to the maximum.
+		if got, want := cache.interval(), 8*time.Second; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+
+		for _, want := range []time.Duration{4 * time.Second, 2 * time.Second, time.Second, time.Second} {
+			cache.adaptSweep(SweepStats{Reaped: 10})
+			if got := cache.interval(); got != want {
+				t.Errorf("expected %v to be %v", got, want)
+			}
+		}
+
+		for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second} {
+			cache.adaptSweep(SweepStats{})
+			if got := cache.interval(); got != want {
+				t.Errorf("expected %v to be %v", got, want)
+			}
+		}
+	})
+
+	t.Run("fixed", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](time.Hour)
+		defer cache.Stop()
+
+		if _, ok := cache.adaptSweep(SweepStats{Reaped: 10}); ok {
+			t.Errorf("expected fixed interval to not change")
+		}
+		if got, want := cache.interval(), 15*time.Minute; got != want {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+}
+
+func TestWithSweepBatch(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[int, int](time.Hour, WithSweepBatch(3))
+	defer cache.Stop()
+
+	past := time.Now().Add(-time.Second)
+	for i := 0; i < 10; i++ {
+		cache.SetWithExpireAt(i, i, past)
+	}
+	cache.Set(10, 10)
+
+	stats := cache.sweep()
+	if got, want := stats.Reaped, 10; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.Batches, 4; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := stats.Remaining, 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.Len(), 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestTTL_FetchWithTTL(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](time.Hour)
+	defer cache.Stop()
+
+	v, err := cache.FetchWithTTL("foo", func() (int, time.Duration, error) {
+		return 5, 10 * time.Millisecond, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 5 {
+		t.Errorf("expected %d to be %d", v, 5)
+	}
+
+	entry, ok := cache.GetEntry("foo")
+	if !ok {
+		t.Fatal("expected foo to exist")
+	}
+	if got := time.Until(entry.ExpiresAt); got > 10*time.Millisecond {
+		t.Errorf("expected %s to be at most %s", got, 10*time.Millisecond)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := cache.Get("foo"); ok {
+		t.Errorf("expected foo to be expired")
+	}
+
+	// A TTL of 0 is returned but not cached.
+	v, err = cache.FetchWithTTL("bar", func() (int, time.Duration, error) {
+		return 3, 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 3 {
+		t.Errorf("expected %d to be %d", v, 3)
+	}
+	if _, ok := cache.Get("bar"); ok {
+		t.Errorf("expected bar to not be cached")
+	}
+
+	_, err = cache.FetchWithTTL("baz", func() (int, time.Duration, error) {
+		return 0, time.Minute, fmt.Errorf("failed")
+	})
+	var lerr *LoaderError
+	if !errors.As(err, &lerr) {
+		t.Errorf("expected %v to be a loader error", err)
+	}
+}
+
+func TestTTL_GetStale(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](time.Hour)
+	defer cache.Stop()
+	cache.PauseSweeper()
+
+	cache.Set("foo", 5)
+	cache.SetWithExpireAt("bar", 3, time.Now().Add(-time.Minute))
+
+	v, age, ok := cache.GetStale("foo")
+	if !ok || v != 5 {
+		t.Errorf("expected %d to be %d", v, 5)
+	}
+	if age != 0 {
+		t.Errorf("expected %s to be 0", age)
+	}
+
+	if _, ok := cache.Get("bar"); ok {
+		t.Errorf("expected bar to be expired")
+	}
+	v, age, ok = cache.GetStale("bar")
+	if !ok || v != 3 {
+		t.Errorf("expected %d to be %d", v, 3)
+	}
+	if age < time.Minute {
+		t.Errorf("expected %s to be at least %s", age, time.Minute)
+	}
+
+	if _, _, ok := cache.GetStale("baz"); ok {
+		t.Errorf("expected baz to not exist")
+	}
+
+	// Once swept, stale values are gone.
+	cache.ResumeSweeper()
+	if _, _, ok := cache.GetStale("bar"); ok {
+		t.Errorf("expected bar to be swept")
+	}
+}
+
+func TestWithExpiredRetention(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTTL[string, int](time.Hour, WithExpiredRetention(time.Minute))
+	defer cache.Stop()
+
+	now := time.Now()
+	cache.SetWithExpireAt("foo", 5, now.Add(-time.Second))
+	cache.SetWithExpireAt("bar", 3, now.Add(-2*time.Minute))
+
+	stats := cache.sweep()
+	if got, want := stats.Reaped, 1; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Retained entries are hidden from Get, but available to GetStale.
+	if _, ok := cache.Get("foo"); ok {
+		t.Errorf("expected foo to be expired")
+	}
+	if v, _, ok := cache.GetStale("foo"); !ok || v != 5 {
+		t.Errorf("expected %d to be %d", v, 5)
+	}
+	if _, _, ok := cache.GetStale("bar"); ok {
+		t.Errorf("expected bar to be swept")
+	}
+}
+
+func TestWithSoftTTL(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get_fresh", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](time.Hour, WithSoftTTL(50*time.Millisecond))
+		defer cache.Stop()
+
+		cache.Set("foo", 5)
+		if v, fresh, ok := cache.GetFresh("foo"); !ok || !fresh || v != 5 {
+			t.Errorf("expected %d to be fresh", v)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		// Past the soft TTL, the value is still served.
+		if v, fresh, ok := cache.GetFresh("foo"); !ok || fresh || v != 5 {
+			t.Errorf("expected %d to be stale", v)
+		}
+		if v, ok := cache.Get("foo"); !ok || v != 5 {
+			t.Errorf("expected %d to be %d", v, 5)
+		}
+
+		cache.Set("foo", 6)
+		if v, fresh, ok := cache.GetFresh("foo"); !ok || !fresh || v != 6 {
+			t.Errorf("expected %d to be fresh", v)
+		}
+	})
+
+	t.Run("fetch_refreshes", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](time.Hour, WithSoftTTL(50*time.Millisecond))
+		defer cache.Stop()
+
+		refreshed := make(chan struct{})
+		cache.RefreshWith(func(k string) (int, error) {
+			defer close(refreshed)
+			if got, want := k, "foo"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+			return 6, nil
+		})
+
+		cache.Set("foo", 5)
+		time.Sleep(100 * time.Millisecond)
+
+		// The FetchFunc is not called for a stale value.
+		v, err := cache.Fetch("foo", func() (int, error) {
+			t.Errorf("expected FetchFunc not to be called")
+			return 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+
+		<-refreshed
+		cache.loads.close()
+		if v, fresh, ok := cache.GetFresh("foo"); !ok || !fresh || v != 6 {
+			t.Errorf("expected %d to be refreshed", v)
+		}
+	})
+
+	t.Run("refresh_error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](time.Hour, WithSoftTTL(50*time.Millisecond))
+		defer cache.Stop()
+
+		cache.RefreshWith(func(string) (int, error) {
+			return 0, fmt.Errorf("nope")
+		})
+
+		cache.Set("foo", 5)
+		time.Sleep(100 * time.Millisecond)
+
+		if _, err := cache.Fetch("foo", func() (int, error) {
+			return 6, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// The failed refresh keeps the stale value.
+		cache.loads.close()
+		if v, fresh, ok := cache.GetFresh("foo"); !ok || fresh || v != 5 {
+			t.Errorf("expected %d to be stale", v)
+		}
+	})
+
+	t.Run("no_refresher", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](time.Hour, WithSoftTTL(50*time.Millisecond))
+		defer cache.Stop()
+
+		cache.Set("foo", 5)
+		time.Sleep(100 * time.Millisecond)
+
+		v, err := cache.Fetch("foo", func() (int, error) {
+			t.Errorf("expected FetchFunc not to be called")
+			return 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, 5; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("hard_ttl", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewTTL[string, int](100*time.Millisecond, WithSoftTTL(50*time.Millisecond))
+		defer cache.Stop()
+
+		cache.Set("foo", 5)
+		time.Sleep(150 * time.Millisecond)
+
+		if _, _, ok := cache.GetFresh("foo"); ok {
+			t.Errorf("expected foo to be expired")
+		}
+	})
+
+	t.Run("panics", func(t *testing.T) {
+		t.Parallel()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected panic")
+			}
+		}()
+		NewTTL[string, int](time.Second, WithSoftTTL(time.Second))
+	})
+}
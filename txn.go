@@ -0,0 +1,153 @@
+package cache
+
+// Deleter is implemented by caches which can remove individual entries.
+type Deleter[K comparable] interface {
+	// Delete removes the entry at the given key. It returns false if there is
+	// no entry at the given key.
+	Delete(K) bool
+}
+
+// Ensure implements.
+var (
+	_ Deleter[string] = (*FIFO[string, string])(nil)
+	_ Deleter[string] = (*LIFO[string, string])(nil)
+	_ Deleter[string] = (*LRU[string, string])(nil)
+	_ Deleter[string] = (*Random[string, string])(nil)
+	_ Deleter[string] = (*TTL[string, string])(nil)
+	_ Deleter[string] = (*Sync[string, string])(nil)
+	_ Deleter[string] = (*Weighted[string, string])(nil)
+)
+
+// Txn is a transaction on a sync cache, passed to the function given to
+// Sync.Txn. It must not be used after that function returns.
+type Txn[K comparable, V any] interface {
+	// Get retrieves the given key, observing the transaction's own writes.
+	Get(K) (V, bool)
+
+	// Set inserts the given key when the transaction is committed.
+	Set(K, V)
+
+	// Delete removes the given key when the transaction is committed. It panics
+	// if the underlying cache does not implement Deleter.
+	Delete(K)
+}
+
+// syncTxn is the Txn passed to the function given to Sync.Txn. It buffers
+// writes until the function returns, so they can be discarded on error.
+type syncTxn[K comparable, V any] struct {
+	s *Sync[K, V]
+
+	// writes are the buffered writes by key, and order is the order in which
+	// keys were first written, so writes are applied in the order they were
+	// made.
+	writes map[K]txnWrite[V]
+	order  []K
+}
+
+// txnWrite is a buffered write. If deleted is true, the key is removed instead
+// of being set to value.
+type txnWrite[V any] struct {
+	value   V
+	deleted bool
+}
+
+// Txn calls fn with a transaction whose Gets, Sets, and Deletes are applied
+// atomically with respect to other users of the cache: the lock is held until
+// fn returns, so no other operation observes a partial set of writes. Writes
+// are buffered, and applied only if fn returns nil; otherwise they are
+// discarded and fn's error is returned.
+//
+// Since the lock is held while fn runs, fn should return quickly and must not
+// call methods on the cache other than through the transaction. If the cache is
+// sharded, the locks of all shards are held.
+func (s *Sync[K, V]) Txn(fn func(tx Txn[K, V]) error) error {
+	locked := s.shards
+	if locked == nil {
+		locked = []*Sync[K, V]{s}
+	}
+	for _, l := range locked {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.drain()
+	}
+
+	tx := &syncTxn[K, V]{s: s}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	tx.commit()
+	return nil
+}
+
+// Delete removes the entry at the given key from the underlying cache. It
+// panics if the underlying cache does not implement Deleter.
+func (s *Sync[K, V]) Delete(key K) bool {
+	if s.shards != nil {
+		return s.shardFor(key).Delete(key)
+	}
+
+	d := s.deleter()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.drain()
+	return d.Delete(key)
+}
+
+// deleter returns the underlying cache as a Deleter, panicking if it is not.
+func (s *Sync[K, V]) deleter() Deleter[K] {
+	d, ok := s.cache.(Deleter[K])
+	if !ok {
+		panic("cache does not support delete")
+	}
+	return d
+}
+
+// Get retrieves the given key, from the buffered writes if it has been written
+// in the transaction or from the underlying cache otherwise.
+func (t *syncTxn[K, V]) Get(key K) (V, bool) {
+	if w, ok := t.writes[key]; ok {
+		if w.deleted {
+			var zeroV V
+			return zeroV, false
+		}
+		return w.value, true
+	}
+	return t.s.owner(key).cache.Get(key)
+}
+
+// Set buffers the value to be inserted on commit.
+func (t *syncTxn[K, V]) Set(key K, val V) {
+	t.write(key, txnWrite[V]{value: val})
+}
+
+// Delete buffers the removal of the key on commit.
+func (t *syncTxn[K, V]) Delete(key K) {
+	t.s.owner(key).deleter()
+	t.write(key, txnWrite[V]{deleted: true})
+}
+
+// write buffers the given write, replacing any earlier write to the key.
+func (t *syncTxn[K, V]) write(key K, w txnWrite[V]) {
+	if t.writes == nil {
+		t.writes = make(map[K]txnWrite[V])
+	}
+	if _, ok := t.writes[key]; !ok {
+		t.order = append(t.order, key)
+	}
+	t.writes[key] = w
+}
+
+// commit applies the buffered writes to the underlying cache. It must be called
+// while holding the exclusive lock.
+func (t *syncTxn[K, V]) commit() {
+	for _, key := range t.order {
+		w := t.writes[key]
+		owner := t.s.owner(key)
+		if w.deleted {
+			owner.deleter().Delete(key)
+			continue
+		}
+		owner.cache.Set(key, w.value)
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSync_Txn(t *testing.T) {
+	t.Parallel()
+
+	t.Run("commits", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSyncLRU[string, int](10)
+		defer cache.Stop()
+
+		cache.Set("old", 1)
+
+		if err := cache.Txn(func(tx Txn[string, int]) error {
+			tx.Set("foo", 2)
+			if got, ok := tx.Get("foo"); !ok || got != 2 {
+				t.Errorf("expected %d to be %d", got, 2)
+			}
+
+			tx.Delete("old")
+			if _, ok := tx.Get("old"); ok {
+				t.Errorf("expected old to be deleted")
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, ok := cache.Get("foo"); !ok || got != 2 {
+			t.Errorf("expected %d to be %d", got, 2)
+		}
+		if _, ok := cache.Get("old"); ok {
+			t.Errorf("expected old to be deleted")
+		}
+	})
+
+	t.Run("discards_on_error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSyncTTL[string, int](time.Minute)
+		defer cache.Stop()
+
+		cache.Set("old", 1)
+
+		errAbort := errors.New("abort")
+		if err := cache.Txn(func(tx Txn[string, int]) error {
+			tx.Set("foo", 2)
+			tx.Delete("old")
+			return errAbort
+		}); err != errAbort {
+			t.Errorf("expected %v to be %v", err, errAbort)
+		}
+
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to not be set")
+		}
+		if got, ok := cache.Get("old"); !ok || got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSyncLRU[string, int](10)
+		defer cache.Stop()
+
+		cache.Set("index", 0)
+		cache.Set("value", 0)
+
+		var wg sync.WaitGroup
+		for i := 1; i <= 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				cache.Txn(func(tx Txn[string, int]) error {
+					tx.Set("index", i)
+					tx.Set("value", i)
+					return nil
+				})
+			}(i)
+		}
+
+		for i := 0; i < 50; i++ {
+			cache.Txn(func(tx Txn[string, int]) error {
+				index, _ := tx.Get("index")
+				value, _ := tx.Get("value")
+				if index != value {
+					t.Errorf("expected %d to be %d", index, value)
+				}
+				return nil
+			})
+		}
+		wg.Wait()
+	})
+
+	t.Run("panic_on_delete_unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewSync[string, int](NewFair[string, int](10, func(string) string { return "" }))
+		defer cache.Stop()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "cache does not support delete"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+		cache.Txn(func(tx Txn[string, int]) error {
+			tx.Delete("foo")
+			return nil
+		})
+		t.Errorf("did not panic")
+	})
+}
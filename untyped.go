@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrWrongType is returned by Untyped when a key or value is not of the type
+// of the underlying cache.
+var ErrWrongType = errors.New("wrong type")
+
+// Untyped wraps a cache with methods which accept and return keys and values
+// as any, for code which cannot name the cache's type parameters at compile
+// time, such as plugin systems and reflection-driven frameworks. Keys and
+// values are converted to the underlying cache's types with a type assertion,
+// so they must have exactly those types; a nil value is accepted for types
+// which can be nil.
+//
+// Untyped is as safe for concurrent use as the underlying cache.
+type Untyped struct {
+	// cache is the underlying cache, adapted to untyped keys and values.
+	cache untypedCache
+}
+
+// untypedCache is the typed adapter behind an Untyped cache.
+type untypedCache interface {
+	get(key any) (any, bool)
+	set(key, val any) error
+	fetch(key any, fn FetchFunc[any]) (any, error)
+	types() (reflect.Type, reflect.Type)
+	stop()
+}
+
+// NewUntyped wraps the given cache in a cache which accepts and returns keys
+// and values as any.
+func NewUntyped[K comparable, V any](c Cache[K, V]) *Untyped {
+	if c == nil {
+		panic("cache cannot be nil")
+	}
+
+	return &Untyped{
+		cache: &untypedAdapter[K, V]{cache: c},
+	}
+}
+
+// Get fetches the cache item at the given key. If the item does not exist, or
+// the key is not of the underlying cache's key type, the second argument will
+// be false.
+func (u *Untyped) Get(key any) (any, bool) {
+	return u.cache.get(key)
+}
+
+// Set inserts the value in the cache. It panics if the key or value is not of
+// the underlying cache's types. Use TrySet to observe the mismatch as an
+// error instead.
+func (u *Untyped) Set(key, val any) {
+	if err := u.cache.set(key, val); err != nil {
+		panic(err)
+	}
+}
+
+// TrySet inserts the value in the cache, returning an error wrapping
+// ErrWrongType if the key or value is not of the underlying cache's types.
+func (u *Untyped) TrySet(key, val any) error {
+	return u.cache.set(key, val)
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. It returns an error wrapping
+// ErrWrongType if the key or the FetchFunc's result is not of the underlying
+// cache's types.
+func (u *Untyped) Fetch(key any, fn FetchFunc[any]) (any, error) {
+	return u.cache.fetch(key, fn)
+}
+
+// KeyType returns the key type of the underlying cache.
+func (u *Untyped) KeyType() reflect.Type {
+	k, _ := u.cache.types()
+	return k
+}
+
+// ValueType returns the value type of the underlying cache.
+func (u *Untyped) ValueType() reflect.Type {
+	_, v := u.cache.types()
+	return v
+}
+
+// Stop stops the underlying cache.
+func (u *Untyped) Stop() {
+	u.cache.stop()
+}
+
+// untypedAdapter converts untyped keys and values for a typed cache.
+type untypedAdapter[K comparable, V any] struct {
+	cache Cache[K, V]
+}
+
+// get fetches the key from the underlying cache. A key of the wrong type is a
+// miss.
+func (a *untypedAdapter[K, V]) get(key any) (any, bool) {
+	k, ok := key.(K)
+	if !ok {
+		return nil, false
+	}
+	return a.cache.Get(k)
+}
+
+// set converts the key and value and inserts them in the underlying cache.
+func (a *untypedAdapter[K, V]) set(key, val any) error {
+	k, err := untypedConvert[K]("key", key)
+	if err != nil {
+		return err
+	}
+	v, err := untypedConvert[V]("value", val)
+	if err != nil {
+		return err
+	}
+
+	a.cache.Set(k, v)
+	return nil
+}
+
+// fetch converts the key and fetches it from the underlying cache, converting
+// the FetchFunc's result before it is stored.
+func (a *untypedAdapter[K, V]) fetch(key any, fn FetchFunc[any]) (any, error) {
+	k, err := untypedConvert[K]("key", key)
+	if err != nil {
+		return nil, err
+	}
+
+	var convertErr error
+	v, err := a.cache.Fetch(k, func() (V, error) {
+		val, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, err
+		}
+
+		v, err := untypedConvert[V]("value", val)
+		if err != nil {
+			convertErr = err
+			return v, err
+		}
+		return v, nil
+	})
+	if convertErr != nil {
+		return nil, convertErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// types returns the key and value types of the underlying cache.
+func (a *untypedAdapter[K, V]) types() (reflect.Type, reflect.Type) {
+	return reflect.TypeOf((*K)(nil)).Elem(), reflect.TypeOf((*V)(nil)).Elem()
+}
+
+// stop stops the underlying cache.
+func (a *untypedAdapter[K, V]) stop() {
+	a.cache.Stop()
+}
+
+// untypedConvert converts x to T. A nil x is converted to the zero value if T
+// can be nil.
+func untypedConvert[T any](what string, x any) (T, error) {
+	if t, ok := x.(T); ok {
+		return t, nil
+	}
+
+	var zeroT T
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if x == nil {
+		switch typ.Kind() {
+		case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+			return zeroT, nil
+		}
+	}
+	return zeroT, fmt.Errorf("%w: %s of type %T is not %s", ErrWrongType, what, x, typ)
+}
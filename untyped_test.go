@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNewUntyped(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "cache cannot be nil"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	NewUntyped[string, int](nil)
+	t.Errorf("did not panic")
+}
+
+func TestUntyped_Get(t *testing.T) {
+	t.Parallel()
+
+	cache := NewUntyped[string, int](NewLRU[string, int](10))
+	defer cache.Stop()
+
+	cache.Set("foo", 5)
+
+	if v, ok := cache.Get("foo"); !ok || v != 5 {
+		t.Errorf("expected %#v to be %#v", v, 5)
+	}
+	if v, ok := cache.Get("bar"); ok {
+		t.Errorf("expected not found, got %#v", v)
+	}
+	if v, ok := cache.Get(5); ok {
+		t.Errorf("expected not found, got %#v", v)
+	}
+}
+
+func TestUntyped_Set(t *testing.T) {
+	t.Parallel()
+
+	t.Run("wrong_type", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewUntyped[string, int](NewLRU[string, int](10))
+		defer cache.Stop()
+
+		if err := cache.TrySet("foo", "bar"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("expected %v to be %v", err, ErrWrongType)
+		}
+		if err := cache.TrySet(1, 1); !errors.Is(err, ErrWrongType) {
+			t.Errorf("expected %v to be %v", err, ErrWrongType)
+		}
+		if v, ok := cache.Get("foo"); ok {
+			t.Errorf("expected not found, got %#v", v)
+		}
+
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrWrongType) {
+				t.Errorf("expected %v to be %v", err, ErrWrongType)
+			}
+		}()
+		cache.Set("foo", "bar")
+		t.Errorf("did not panic")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewUntyped[string, *int](NewLRU[string, *int](10))
+		defer cache.Stop()
+
+		if err := cache.TrySet("foo", nil); err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := cache.Get("foo"); !ok || v != (*int)(nil) {
+			t.Errorf("expected %#v to be %#v", v, (*int)(nil))
+		}
+	})
+}
+
+func TestUntyped_Fetch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("saves", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewUntyped[string, int](NewLRU[string, int](10))
+		defer cache.Stop()
+
+		v, err := cache.Fetch("foo", func() (any, error) { return 5, nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != 5 {
+			t.Errorf("expected %#v to be %#v", v, 5)
+		}
+		if v, _ := cache.Get("foo"); v != 5 {
+			t.Errorf("expected %#v to be %#v", v, 5)
+		}
+	})
+
+	t.Run("wrong_type", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewUntyped[string, int](NewLRU[string, int](10))
+		defer cache.Stop()
+
+		if _, err := cache.Fetch("foo", func() (any, error) { return "bar", nil }); !errors.Is(err, ErrWrongType) {
+			t.Errorf("expected %v to be %v", err, ErrWrongType)
+		}
+		if _, err := cache.Fetch(1, func() (any, error) { return 5, nil }); !errors.Is(err, ErrWrongType) {
+			t.Errorf("expected %v to be %v", err, ErrWrongType)
+		}
+		if v, ok := cache.Get("foo"); ok {
+			t.Errorf("expected not found, got %#v", v)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewUntyped[string, int](NewLRU[string, int](10))
+		defer cache.Stop()
+
+		oops := errors.New("oops")
+		_, err := cache.Fetch("foo", func() (any, error) { return nil, oops })
+		var lerr *LoaderError
+		if !errors.As(err, &lerr) || !errors.Is(err, oops) {
+			t.Errorf("expected %v to be a loader error wrapping %v", err, oops)
+		}
+	})
+}
+
+func TestUntyped_Types(t *testing.T) {
+	t.Parallel()
+
+	cache := NewUntyped[string, *int](NewLRU[string, *int](10))
+	defer cache.Stop()
+
+	if got, want := cache.KeyType(), reflect.TypeOf(""); got != want {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+	if got, want := cache.ValueType(), reflect.TypeOf((*int)(nil)); got != want {
+		t.Errorf("expected %v to be %v", got, want)
+	}
+}
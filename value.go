@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Value holds a single value which is loaded on first use and reloaded after it
+// expires, such as configuration or an access token:
+//
+//	token := cache.NewValue[*oauth2.Token](time.Hour)
+//	defer token.Stop()
+//
+//	t, err := token.Get(fetchToken)
+//
+// Concurrent calls to Get while the value is missing or expired share a single
+// load. Value is safe for concurrent use.
+type Value[V any] struct {
+	// value is the current value, and expiresAt is the monotonic time at which
+	// it expires, or 0 if there is no value. They are guarded by lock.
+	value     V
+	expiresAt int64
+	lock      sync.RWMutex
+
+	// ttl is the lifetime of the value.
+	ttl time.Duration
+
+	// stopped indicates whether the value is stopped.
+	stopped uint32
+
+	// loads is the in-flight load.
+	loads flightGroup[struct{}, V]
+}
+
+// NewValue creates a new holder for a value which expires after the given TTL.
+func NewValue[V any](ttl time.Duration) *Value[V] {
+	if ttl <= 0 {
+		panic("ttl must be greater than 0")
+	}
+
+	return &Value[V]{
+		ttl: ttl,
+	}
+}
+
+// Get returns the value. If there is no value, or it has expired, the FetchFunc
+// is called and the result is stored. Concurrent calls to Get share a single
+// invocation of the FetchFunc, and all of them return its result. If the
+// FetchFunc returns an error, it is returned as-is and nothing is stored, so
+// the next call to Get tries again.
+func (v *Value[V]) Get(fn FetchFunc[V]) (V, error) {
+	if val, ok := v.Peek(); ok {
+		return val, nil
+	}
+
+	return v.loads.do(struct{}{}, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if val, ok := v.Peek(); ok {
+			return val, nil
+		}
+
+		val, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, err
+		}
+
+		v.Set(val)
+		return val, nil
+	})
+}
+
+// Peek returns the value without loading it. If there is no value, or it has
+// expired, it returns the zero value and the second parameter will be false.
+func (v *Value[V]) Peek() (V, bool) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if v.isStopped() {
+		panic(ErrStopped)
+	}
+
+	if v.expiresAt == 0 || v.expiresAt < nanotime() {
+		var zeroV V
+		return zeroV, false
+	}
+	return v.value, true
+}
+
+// Set replaces the value. Its expiration starts now.
+func (v *Value[V]) Set(val V) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.isStopped() {
+		panic(ErrStopped)
+	}
+
+	v.value = val
+	v.expiresAt = nanotime() + int64(v.ttl)
+}
+
+// Invalidate removes the value, so the next call to Get loads it again, for
+// example after the value has been rejected by the service it is used with.
+func (v *Value[V]) Invalidate() {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.isStopped() {
+		panic(ErrStopped)
+	}
+
+	var zeroV V
+	v.value = zeroV
+	v.expiresAt = 0
+}
+
+// Stop clears the value and prevents it from being loaded or retrieved. It
+// waits for an in-flight load to finish.
+func (v *Value[V]) Stop() {
+	v.loads.close()
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&v.stopped, 0, 1) {
+		return
+	}
+
+	var zeroV V
+	v.value = zeroV
+	v.expiresAt = 0
+}
+
+// isStopped is a helper for checking if the value is stopped.
+func (v *Value[V]) isStopped() bool {
+	return atomic.LoadUint32(&v.stopped) == 1
+}
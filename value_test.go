@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValue_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("loads_once", func(t *testing.T) {
+		t.Parallel()
+
+		v := NewValue[int](time.Hour)
+		defer v.Stop()
+
+		var calls int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := v.Get(func() (int, error) {
+					atomic.AddInt32(&calls, 1)
+					time.Sleep(20 * time.Millisecond)
+					return 5, nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+				if want := 5; got != want {
+					t.Errorf("expected %d to be %d", got, want)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("refreshes_after_ttl", func(t *testing.T) {
+		t.Parallel()
+
+		v := NewValue[int](50 * time.Millisecond)
+		defer v.Stop()
+
+		var calls int
+		fn := func() (int, error) {
+			calls++
+			return calls, nil
+		}
+
+		if got, _ := v.Get(fn); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+		if got, _ := v.Get(fn); got != 1 {
+			t.Errorf("expected %d to be %d", got, 1)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		if _, ok := v.Peek(); ok {
+			t.Errorf("expected value to be expired")
+		}
+		if got, _ := v.Get(fn); got != 2 {
+			t.Errorf("expected %d to be %d", got, 2)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		v := NewValue[int](time.Hour)
+		defer v.Stop()
+
+		errNope := errors.New("nope")
+		if _, err := v.Get(func() (int, error) {
+			return 0, errNope
+		}); !errors.Is(err, errNope) {
+			t.Errorf("expected %v to be %v", err, errNope)
+		}
+
+		// Nothing was stored, so the next call loads again.
+		if got, _ := v.Get(func() (int, error) {
+			return 5, nil
+		}); got != 5 {
+			t.Errorf("expected %d to be %d", got, 5)
+		}
+	})
+}
+
+func TestValue_Invalidate(t *testing.T) {
+	t.Parallel()
+
+	v := NewValue[int](time.Hour)
+	defer v.Stop()
+
+	v.Set(5)
+	if got, ok := v.Peek(); !ok || got != 5 {
+		t.Errorf("expected %d to be %d", got, 5)
+	}
+
+	v.Invalidate()
+	if _, ok := v.Peek(); ok {
+		t.Errorf("expected value to be invalidated")
+	}
+	if got, _ := v.Get(func() (int, error) {
+		return 6, nil
+	}); got != 6 {
+		t.Errorf("expected %d to be %d", got, 6)
+	}
+}
+
+func TestValue_Stop(t *testing.T) {
+	t.Parallel()
+
+	v := NewValue[int](time.Hour)
+	v.Set(5)
+	v.Stop()
+	v.Stop()
+
+	defer func() {
+		if r := recover(); r != ErrStopped {
+			t.Errorf("expected %v to be %v", r, ErrStopped)
+		}
+	}()
+	v.Peek()
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WarmKeys bulk-populates the cache by loading the given keys with up to
+// parallelism concurrent calls to loader. Keys should be ordered from most to
+// least important: once all loads complete, entries are inserted from least to
+// most important, so the most important entries are the most protected from
+// eviction in recency- and insertion-ordered caches.
+//
+// Keys whose loader fails are skipped. If any loads fail, the first error in
+// key order is returned after all other entries have been inserted.
+func WarmKeys[K comparable, V any](c Cache[K, V], keys []K, loader func(K) (V, error), parallelism int) error {
+	if parallelism <= 0 {
+		panic("parallelism must be greater than 0")
+	}
+
+	type result struct {
+		value V
+		err   error
+	}
+	results := make([]result, len(keys))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallelism)
+	for i, key := range keys {
+		i, key := i, key
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			v, err := loader(key)
+			results[i] = result{value: v, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var firstErr error
+	for i := len(keys) - 1; i >= 0; i-- {
+		if err := results[i].err; err != nil {
+			firstErr = fmt.Errorf("failed to load %v: %w", keys[i], err)
+			continue
+		}
+		c.Set(keys[i], results[i].value)
+	}
+	return firstErr
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWarmKeys(t *testing.T) {
+	t.Parallel()
+
+	t.Run("loads", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[int, string](3)
+		defer cache.Stop()
+
+		var inflight, maxInflight int32
+		err := WarmKeys[int, string](cache, []int{1, 2, 3, 4, 5}, func(k int) (string, error) {
+			n := atomic.AddInt32(&inflight, 1)
+			defer atomic.AddInt32(&inflight, -1)
+			for {
+				m := atomic.LoadInt32(&maxInflight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInflight, m, n) {
+					break
+				}
+			}
+			return fmt.Sprint(k), nil
+		}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := atomic.LoadInt32(&maxInflight), int32(2); got > want {
+			t.Errorf("expected %d to be at most %d", got, want)
+		}
+
+		// The most important keys are retained, with the first key being the most
+		// protected.
+		var keys []int
+		cache.AscendEvictionOrder(func(k int, _ string) bool {
+			keys = append(keys, k)
+			return true
+		})
+		if got, want := keys, []int{3, 2, 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v to be %v", got, want)
+		}
+	})
+
+	t.Run("returns_error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewLRU[int, string](10)
+		defer cache.Stop()
+
+		errBoom := errors.New("boom")
+		err := WarmKeys[int, string](cache, []int{1, 2, 3}, func(k int) (string, error) {
+			if k != 1 {
+				return "", errBoom
+			}
+			return "one", nil
+		}, 4)
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected %v to be %v", err, errBoom)
+		}
+		if got, want := err.Error(), "failed to load 2: boom"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+
+		if v, _ := cache.Get(1); v != "one" {
+			t.Errorf("expected %q, got %q", "one", v)
+		}
+		if got, want := cache.Len(), 1; got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+}
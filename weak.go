@@ -0,0 +1,154 @@
+//go:build go1.24
+
+package cache
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"weak"
+)
+
+// Ensure implements.
+var _ Cache[string, *string] = (*Weak[string, string])(nil)
+
+// Weak is a cache which holds its values through weak pointers, so an entry is
+// dropped automatically once its value is no longer reachable from anywhere
+// else. It is intended for canonicalizing or interning large objects without
+// pinning them in memory: the cache hands out the existing value while anyone
+// still uses it, but never keeps it alive by itself.
+//
+// Entries are removed by a cleanup which runs some time after the garbage
+// collector finds the value unreachable, so Len may briefly include entries
+// whose values have already been collected. Get never returns them. As with
+// runtime.AddCleanup, very small values without pointers may share an
+// allocation and so be collected late or never.
+//
+// K is the cache key and must be a comparable. The cache holds values of type
+// *V. Weak requires Go 1.24 or later.
+type Weak[K comparable, V any] struct {
+	// cache maps each key to a weak pointer to its value.
+	cache map[K]weak.Pointer[V]
+
+	// stopped indicates whether the cache is stopped.
+	stopped uint32
+
+	// lock is the internal lock for concurrency.
+	lock sync.RWMutex
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, *V]
+}
+
+// NewWeak creates a new weak-value cache.
+func NewWeak[K comparable, V any]() *Weak[K, V] {
+	return &Weak[K, V]{
+		cache: make(map[K]weak.Pointer[V]),
+	}
+}
+
+// Get fetches the value at the given key. If the value does not exist or has
+// been collected, the second return value is false.
+func (w *Weak[K, V]) Get(key K) (*V, bool) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if w.isStopped() {
+		panic(ErrStopped)
+	}
+
+	p, ok := w.cache[key]
+	if !ok {
+		return nil, false
+	}
+
+	v := p.Value()
+	return v, v != nil
+}
+
+// Set inserts the value in the cache without keeping it reachable. If an entry
+// already exists at the given key, it is overwritten. Setting a nil value
+// removes the entry.
+func (w *Weak[K, V]) Set(key K, val *V) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.isStopped() {
+		panic(ErrStopped)
+	}
+
+	if val == nil {
+		delete(w.cache, key)
+		return
+	}
+
+	w.cache[key] = weak.Make(val)
+	runtime.AddCleanup(val, w.cleanup, key)
+}
+
+// cleanup removes the entry at the given key if its value has been collected.
+// The entry may have been overwritten with a live value since the cleanup was
+// registered, in which case it is kept.
+func (w *Weak[K, V]) cleanup(key K) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.isStopped() {
+		return
+	}
+
+	if p, ok := w.cache[key]; ok && p.Value() == nil {
+		delete(w.cache, key)
+	}
+}
+
+// Fetch retrieves the cached value. If the value does not exist or has been
+// collected, the FetchFunc is called and the result is stored. Concurrent calls
+// to Fetch for the same key share a single invocation.
+func (w *Weak[K, V]) Fetch(key K, fn FetchFunc[*V]) (*V, error) {
+	if v, ok := w.Get(key); ok {
+		return v, nil
+	}
+
+	return w.loads.do(key, func() (*V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := w.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			return nil, newLoaderError(key, err)
+		}
+
+		w.Set(key, v)
+		return v, nil
+	})
+}
+
+// Len returns the number of entries in the cache. This includes entries whose
+// values have been collected but not yet cleaned up.
+func (w *Weak[K, V]) Len() int {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return len(w.cache)
+}
+
+// Stop clears the cache and prevents new entries from being added and
+// retrieved.
+func (w *Weak[K, V]) Stop() {
+	w.loads.close()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&w.stopped, 0, 1) {
+		return
+	}
+	w.cache = nil
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (w *Weak[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&w.stopped) == 1
+}
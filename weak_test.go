@@ -0,0 +1,82 @@
+//go:build go1.24
+
+package cache
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWeak(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get_set", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewWeak[string, string]()
+		defer cache.Stop()
+
+		v := new(string)
+		*v = "bar"
+		cache.Set("foo", v)
+
+		got, ok := cache.Get("foo")
+		if !ok || got != v {
+			t.Errorf("expected %p to be %p", got, v)
+		}
+		if _, ok := cache.Get("baz"); ok {
+			t.Errorf("expected baz to be missing")
+		}
+
+		cache.Set("foo", nil)
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to be removed")
+		}
+		runtime.KeepAlive(v)
+	})
+
+	t.Run("drops_unreachable", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewWeak[string, [64]byte]()
+		defer cache.Stop()
+
+		cache.Set("foo", new([64]byte))
+
+		deadline := time.Now().Add(5 * time.Second)
+		for cache.Len() > 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for cleanup")
+			}
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to be collected")
+		}
+	})
+
+	t.Run("keeps_overwritten", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewWeak[string, [64]byte]()
+		defer cache.Stop()
+
+		cache.Set("foo", new([64]byte))
+
+		v := new([64]byte)
+		v[0] = 1
+		cache.Set("foo", v)
+
+		// The cleanup for the first value must not remove the second.
+		for i := 0; i < 5; i++ {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+		if got, ok := cache.Get("foo"); !ok || got != v {
+			t.Errorf("expected %p to be %p", got, v)
+		}
+		runtime.KeepAlive(v)
+	})
+}
@@ -0,0 +1,412 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ensure implements.
+var _ Cache[string, string] = (*Weighted[string, string])(nil)
+
+// Weighted implements a least-recently-used cache whose capacity is a total
+// weight rather than a number of entries. The weight of each entry is returned
+// by a user-supplied function, typically the size of the value in bytes. When
+// inserting an entry would exceed the capacity, the least recently used entries
+// are evicted until it fits, so a single large entry may evict many small ones.
+// This cache is not safe for concurrent use.
+//
+// K is the cache key and must be a comparable. V can be any type, but pointers
+// are best for performance.
+type Weighted[K comparable, V any] struct {
+	// cache represents the internal cache storage. It has a comparable key and
+	// points to an entry in the doubly-linked list.
+	cache map[K]*weightedListItem[K, V]
+
+	// head points to the least recently used entry and tail points to the most
+	// recently used entry.
+	head, tail *weightedListItem[K, V]
+
+	// weigh returns the weight of an entry.
+	weigh func(K, V) int64
+
+	// capacity is the total weight the cache may hold, and weight is the total
+	// weight of the entries in the cache.
+	capacity int64
+	weight   int64
+
+	// stopped indicates whether the cache is stopped.
+	stopped uint32
+
+	// lock is the internal lock for concurrency.
+	lock waitMutex
+
+	// onEvict are the functions to invoke when an entry is evicted.
+	onEvict []func(K, V)
+
+	// onLifetime are the functions to invoke with how long each evicted entry
+	// was cached.
+	onLifetime []func(time.Duration)
+
+	// loads are the in-flight Fetch loads.
+	loads flightGroup[K, V]
+
+	// admission decides whether new entries are inserted, or is nil if all
+	// entries are.
+	admission Admission[K, V]
+}
+
+// weightedListItem represents an entry in the linked list.
+type weightedListItem[K comparable, V any] struct {
+	meta       entryMeta
+	prev, next *weightedListItem[K, V]
+	key        K
+	value      V
+	weight     int64
+}
+
+// NewWeighted creates a new weighted cache which holds entries up to the given
+// total weight, using weigh to determine the weight of each entry. The weight
+// of an entry must not be negative.
+//
+//	c := cache.NewWeighted[string, []byte](64<<20, func(_ string, v []byte) int64 {
+//		return int64(len(v))
+//	})
+func NewWeighted[K comparable, V any](capacity int64, weigh func(K, V) int64, opts ...Option[K, V]) *Weighted[K, V] {
+	if capacity <= 0 {
+		panic("capacity must be greater than 0")
+	}
+	if weigh == nil {
+		panic("weigh cannot be nil")
+	}
+
+	o := newOptions(opts)
+	if o.ttl != 0 {
+		panic("cache does not support expiration")
+	}
+
+	return &Weighted[K, V]{
+		cache:     make(map[K]*weightedListItem[K, V]),
+		weigh:     weigh,
+		capacity:  capacity,
+		admission: o.admission,
+	}
+}
+
+// Get fetches the cache item at the given key. If the value exists, it is
+// returned. If the value does not exist, it returns the zero value for the
+// object and the second parameter will be false.
+func (w *Weighted[K, V]) Get(key K) (V, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := w.cache[key]
+	if !ok {
+		var v V
+		return v, false
+	}
+
+	node.meta.recordAccess(time.Now().UnixNano())
+	w.moveToTail(node)
+	return node.value, true
+}
+
+// Set inserts the value in the cache. If an entry already exists at the given
+// key, it is overwritten. If the cache does not have room for the entry's
+// weight, the least recently used entries are evicted until it does. An entry
+// heavier than the capacity is never stored, and any existing entry at the key
+// is removed.
+func (w *Weighted[K, V]) Set(key K, val V) {
+	var evicted victims[K, V]
+	defer evicted.notify()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	evicted = w.set(key, val)
+}
+
+// InsertAll inserts the value in the cache, like Set, and returns all of the
+// entries which were evicted to make room for it, from least to most recently
+// used. If no entries were evicted, it returns nil. The evicted entries are also
+// reported to any OnEvict hooks.
+func (w *Weighted[K, V]) InsertAll(key K, val V) []Entry[K, V] {
+	var evicted victims[K, V]
+	defer evicted.notify()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	evicted = w.set(key, val)
+
+	if len(evicted) == 0 {
+		return nil
+	}
+
+	entries := make([]Entry[K, V], len(evicted))
+	for i, v := range evicted {
+		entries[i] = Entry[K, V]{
+			Key:        v.key,
+			Value:      v.value,
+			InsertedAt: time.Unix(0, v.insertedAt),
+		}
+	}
+	return entries
+}
+
+// set is the internal implementation for set. It does not lock. It returns the
+// entries which were evicted to make room, if any.
+func (w *Weighted[K, V]) set(key K, val V) victims[K, V] {
+	if w.isStopped() {
+		panic(ErrStopped)
+	}
+
+	weight := w.weigh(key, val)
+	if weight < 0 {
+		panic("weight must not be negative")
+	}
+
+	// The existing entry is replaced rather than evicted, so its weight is
+	// released before making room.
+	if node, ok := w.cache[key]; ok {
+		w.remove(node)
+	} else if w.admission != nil && !w.admission.Admit(key, val) {
+		return nil
+	}
+	if weight > w.capacity {
+		return nil
+	}
+
+	evicted := w.evict(w.capacity-weight, nil)
+
+	node := &weightedListItem[K, V]{key: key, value: val, weight: weight}
+	node.meta.reset(time.Now().UnixNano())
+	w.cache[key] = node
+	w.weight += weight
+	w.moveToTail(node)
+
+	return evicted
+}
+
+// Fetch retrieves the cached value. If the value does not exist, the FetchFunc
+// is called and the result is stored. If the value does exist, the FetchFunc is
+// not invoked. The FetchFunc runs without holding the cache's lock, so other
+// operations proceed while it runs, and concurrent calls to Fetch for the same
+// key share a single invocation.
+func (w *Weighted[K, V]) Fetch(key K, fn FetchFunc[V]) (V, error) {
+	if v, ok := w.Get(key); ok {
+		return v, nil
+	}
+
+	return w.loads.do(key, func() (V, error) {
+		// Another caller may have stored the value since the lookup above.
+		if v, ok := w.Get(key); ok {
+			return v, nil
+		}
+
+		v, err := fn()
+		if err != nil {
+			var zeroV V
+			return zeroV, newLoaderError(key, err)
+		}
+
+		w.Set(key, v)
+		return v, nil
+	})
+}
+
+// UpdateWeight changes the weight of the entry at the given key, for values
+// which grow or shrink after they are set, such as buffers which are appended
+// to. The weight is used until the value is next set, when it is recomputed. If
+// the new weight exceeds the capacity, the least recently used entries other
+// than the updated entry are evicted, and if the new weight is heavier than the
+// capacity, the entry itself is removed. The entry's recency is not changed. It
+// returns false if there is no entry at the given key.
+func (w *Weighted[K, V]) UpdateWeight(key K, weight int64) bool {
+	if weight < 0 {
+		panic("weight must not be negative")
+	}
+
+	var evicted victims[K, V]
+	defer evicted.notify()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := w.cache[key]
+	if !ok {
+		return false
+	}
+
+	if weight > w.capacity {
+		w.remove(node)
+		return true
+	}
+
+	w.weight += weight - node.weight
+	node.weight = weight
+	evicted = w.evict(w.capacity, node)
+	return true
+}
+
+// Delete removes the entry at the given key from the cache. It returns false if
+// there is no entry at the given key.
+func (w *Weighted[K, V]) Delete(key K) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := w.cache[key]
+	if ok {
+		w.remove(node)
+	}
+	return ok
+}
+
+// GetEntry fetches the cache item at the given key along with its metadata.
+// Unlike Get, it does not mark the entry as recently used.
+func (w *Weighted[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if w.isStopped() {
+		panic(ErrStopped)
+	}
+
+	node, ok := w.cache[key]
+	if !ok {
+		return Entry[K, V]{}, false
+	}
+
+	entry := Entry[K, V]{Key: node.key, Value: node.value}
+	fillEntry(&entry, &node.meta)
+	return entry, true
+}
+
+// OnEvict registers a function which is invoked with each entry that is
+// evicted to make room for a new entry. Functions are invoked in the order they
+// were registered, after the cache's lock is released, so they may call back
+// into the cache. Entries removed with Stop are not reported.
+func (w *Weighted[K, V]) OnEvict(fn func(K, V)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.onEvict = appendHook(w.onEvict, fn)
+}
+
+// onEvictLifetime registers a function which is invoked with how long each
+// evicted entry was cached, alongside the OnEvict functions.
+func (w *Weighted[K, V]) onEvictLifetime(fn func(time.Duration)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.onLifetime = appendHook(w.onLifetime, fn)
+}
+
+// Capacity returns the total weight the cache may hold.
+func (w *Weighted[K, V]) Capacity() int64 {
+	return w.capacity
+}
+
+// Weight returns the total weight of the entries in the cache.
+func (w *Weighted[K, V]) Weight() int64 {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.weight
+}
+
+// Len returns the number of entries in the cache.
+func (w *Weighted[K, V]) Len() int {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return len(w.cache)
+}
+
+// Stop clears the cache and prevents new entries from being added and
+// retrieved.
+func (w *Weighted[K, V]) Stop() {
+	w.loads.close()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !atomic.CompareAndSwapUint32(&w.stopped, 0, 1) {
+		return
+	}
+
+	w.cache = nil
+	w.head = nil
+	w.tail = nil
+	w.weight = 0
+}
+
+// evict removes the least recently used entries, other than keep, until the
+// total weight is at most limit, and returns them in the order they were
+// evicted. It does not lock.
+func (w *Weighted[K, V]) evict(limit int64, keep *weightedListItem[K, V]) victims[K, V] {
+	var evicted victims[K, V]
+	for node := w.head; w.weight > limit && node != nil; {
+		next := node.next
+		if node != keep {
+			w.remove(node)
+			evicted = append(evicted, newVictim(node.key, node.value, node.meta.insertedAt, true, w.onEvict, w.onLifetime))
+		}
+		node = next
+	}
+	return evicted
+}
+
+// remove removes the node from the cache and releases its weight. It does not
+// lock.
+func (w *Weighted[K, V]) remove(node *weightedListItem[K, V]) {
+	delete(w.cache, node.key)
+	w.weight -= node.weight
+	w.unlink(node)
+}
+
+// moveToTail marks the node as the most recently used, moving it to the end
+// (tail) of the list. It does not lock.
+func (w *Weighted[K, V]) moveToTail(node *weightedListItem[K, V]) {
+	if node == w.tail {
+		return
+	}
+	if node.prev != nil || node == w.head {
+		w.unlink(node)
+	}
+
+	node.prev = w.tail
+	if w.tail != nil {
+		w.tail.next = node
+	}
+	w.tail = node
+	if w.head == nil {
+		w.head = node
+	}
+}
+
+// unlink removes the node from the list. It does not lock.
+func (w *Weighted[K, V]) unlink(node *weightedListItem[K, V]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		w.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		w.tail = node.prev
+	}
+	node.prev = nil
+	node.next = nil
+}
+
+// isStopped is a helper for checking if the cache is stopped.
+func (w *Weighted[K, V]) isStopped() bool {
+	return atomic.LoadUint32(&w.stopped) == 1
+}
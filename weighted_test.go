@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// weightedTestWeigh weighs an entry by the length of its value.
+func weightedTestWeigh(_ string, v string) int64 {
+	return int64(len(v))
+}
+
+func TestNewWeighted(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if got, want := fmt.Sprintf("%s", recover()), "weigh cannot be nil"; got != want {
+			t.Errorf("expected %q to be %q", got, want)
+		}
+	}()
+
+	NewWeighted[string, string](10, nil)
+	t.Errorf("did not panic")
+}
+
+func TestWeighted_Get(t *testing.T) {
+	t.Parallel()
+
+	cache := NewWeighted[string, string](10, weightedTestWeigh)
+	defer cache.Stop()
+
+	cache.Set("foo", "abc")
+	cache.Set("bar", "de")
+	cache.Set("foo", "abcd")
+
+	if v, _ := cache.Get("foo"); v != "abcd" {
+		t.Errorf("expected %q to be %q", v, "abcd")
+	}
+	if v, ok := cache.Get("baz"); ok {
+		t.Errorf("expected not found, got %q", v)
+	}
+	if got, want := cache.Weight(), int64(6); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+	if got, want := cache.Len(), 2; got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
+
+func TestWeighted_InsertAll(t *testing.T) {
+	t.Parallel()
+
+	cache := NewWeighted[string, string](10, weightedTestWeigh)
+	defer cache.Stop()
+
+	var hooked []string
+	cache.OnEvict(func(k string, _ string) {
+		hooked = append(hooked, k)
+	})
+
+	cache.Set("a", "aaa")
+	cache.Set("b", "bbb")
+	cache.Set("c", "ccc")
+	cache.Get("a")
+
+	// Making room for 7 evicts the two least recently used entries in one pass.
+	evicted := cache.InsertAll("d", "ddddddd")
+	keys := make([]string, 0, len(evicted))
+	for _, e := range evicted {
+		keys = append(keys, e.Key)
+	}
+	if got, want := keys, []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := hooked, []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := cache.Weight(), int64(10); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Entries which fit do not evict anything.
+	cache.Delete("a")
+	if evicted := cache.InsertAll("e", "eee"); evicted != nil {
+		t.Errorf("expected %v to be nil", evicted)
+	}
+}
+
+func TestWeighted_Set(t *testing.T) {
+	t.Parallel()
+
+	t.Run("too_heavy", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewWeighted[string, string](4, weightedTestWeigh)
+		defer cache.Stop()
+
+		cache.Set("foo", "abc")
+		cache.Set("bar", "a")
+		cache.Set("foo", "abcde")
+
+		if _, ok := cache.Get("foo"); ok {
+			t.Errorf("expected foo to not be stored")
+		}
+		if _, ok := cache.Get("bar"); !ok {
+			t.Errorf("expected bar to not be evicted")
+		}
+		if got, want := cache.Weight(), int64(1); got != want {
+			t.Errorf("expected %d to be %d", got, want)
+		}
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewWeighted[string, string](4, func(string, string) int64 { return -1 })
+		defer cache.Stop()
+
+		defer func() {
+			if got, want := fmt.Sprintf("%s", recover()), "weight must not be negative"; got != want {
+				t.Errorf("expected %q to be %q", got, want)
+			}
+		}()
+
+		cache.Set("foo", "bar")
+		t.Errorf("did not panic")
+	})
+}
+
+func TestWeighted_UpdateWeight(t *testing.T) {
+	t.Parallel()
+
+	cache := NewWeighted[string, string](10, weightedTestWeigh)
+	defer cache.Stop()
+
+	var hooked []string
+	cache.OnEvict(func(k string, _ string) {
+		hooked = append(hooked, k)
+	})
+
+	cache.Set("a", "aaa")
+	cache.Set("b", "bbb")
+	cache.Set("c", "ccc")
+
+	if cache.UpdateWeight("d", 1) {
+		t.Errorf("expected missing key to not be updated")
+	}
+
+	// Growing the least recently used entry evicts the others, but not itself.
+	if !cache.UpdateWeight("a", 6) {
+		t.Errorf("expected a to be updated")
+	}
+	if got, want := hooked, []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q to be %q", got, want)
+	}
+	if got, want := cache.Weight(), int64(9); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// Shrinking an entry releases its weight.
+	cache.UpdateWeight("a", 1)
+	if got, want := cache.Weight(), int64(4); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+
+	// An entry heavier than the capacity is removed.
+	cache.UpdateWeight("c", 11)
+	if _, ok := cache.Get("c"); ok {
+		t.Errorf("expected c to be removed")
+	}
+	if got, want := cache.Weight(), int64(1); got != want {
+		t.Errorf("expected %d to be %d", got, want)
+	}
+}
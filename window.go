@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// windowSlots is the number of slots a windowCounter divides its window into.
+const windowSlots = 10
+
+// windowCounter counts hits and misses over a sliding window of time. The
+// window is divided into slots which are reused as the window advances, so it
+// uses constant memory. It is safe for concurrent use, and the counts are
+// approximate: a lookup recorded concurrently with a slot being reused may be
+// lost.
+type windowCounter struct {
+	// slots are the counts, indexed by epoch modulo the number of slots.
+	slots [windowSlots]windowSlot
+
+	// width is the duration of each slot, in nanoseconds.
+	width int64
+}
+
+// windowSlot is the counts for a single slot. The epoch identifies which
+// period of the window the counts belong to.
+type windowSlot struct {
+	epoch  int64
+	hits   uint64
+	misses uint64
+}
+
+// newWindowCounter returns a counter over the given window.
+func newWindowCounter(window time.Duration) windowCounter {
+	width := int64(window) / windowSlots
+	if width < 1 {
+		width = 1
+	}
+	return windowCounter{width: width}
+}
+
+// record counts a lookup at the given monotonic time.
+func (w *windowCounter) record(now int64, hit bool) {
+	epoch := now / w.width
+	slot := &w.slots[epoch%windowSlots]
+
+	// The first lookup in a new period claims the slot and clears the counts
+	// from the period it last held.
+	if old := atomic.LoadInt64(&slot.epoch); old != epoch && atomic.CompareAndSwapInt64(&slot.epoch, old, epoch) {
+		atomic.StoreUint64(&slot.hits, 0)
+		atomic.StoreUint64(&slot.misses, 0)
+	}
+
+	if hit {
+		atomic.AddUint64(&slot.hits, 1)
+	} else {
+		atomic.AddUint64(&slot.misses, 1)
+	}
+}
+
+// ratio returns the ratio of hits to total lookups within the window ending at
+// the given monotonic time, or 0 if there were no lookups.
+func (w *windowCounter) ratio(now int64) float64 {
+	epoch := now / w.width
+
+	var hits, total uint64
+	for i := range w.slots {
+		slot := &w.slots[i]
+		if epoch-atomic.LoadInt64(&slot.epoch) >= windowSlots {
+			continue
+		}
+
+		h := atomic.LoadUint64(&slot.hits)
+		hits += h
+		total += h + atomic.LoadUint64(&slot.misses)
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowCounter(t *testing.T) {
+	t.Parallel()
+
+	w := newWindowCounter(10 * time.Second)
+	second := int64(time.Second)
+
+	if got, want := w.ratio(0), 0.0; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+
+	// A period of misses followed by a period of hits.
+	for i := 0; i < 4; i++ {
+		w.record(100*second, false)
+	}
+	for i := 0; i < 4; i++ {
+		w.record(105*second, true)
+	}
+
+	if got, want := w.ratio(105*second), 0.5; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+
+	// Once the misses leave the window, only the hits remain.
+	if got, want := w.ratio(112*second), 1.0; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+
+	// Reusing a slot clears the counts from its previous period.
+	w.record(115*second, false)
+	if got, want := w.ratio(115*second), 0.0; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+
+	if got, want := w.ratio(200*second), 0.0; got != want {
+		t.Errorf("expected %f to be %f", got, want)
+	}
+}